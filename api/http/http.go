@@ -51,6 +51,7 @@ func Factory(logger hclog.Logger, m interface{}, config map[string]interface{})
 		router.GET("/debug/pprof/profile", fastAdapt(pprof.Profile))
 		router.GET("/debug/pprof/symbol", fastAdapt(pprof.Symbol))
 		router.GET("/debug/pprof/trace", fastAdapt(pprof.Trace))
+		router.GET("/v1/debug/profile", h.Profile)
 	}
 
 	lis, err := net.Listen("tcp", tcpAddr.String())
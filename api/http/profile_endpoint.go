@@ -0,0 +1,37 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Profile executes again the blocks in the range given by the from and to
+// query arguments and returns the gas consumed by the contracts in the
+// pprof format
+func (h *HTTP) Profile(ctx *fasthttp.RequestCtx) {
+	from, err := strconv.ParseUint(string(ctx.QueryArgs().Peek("from")), 10, 64)
+	if err != nil {
+		ctx.Error(fmt.Sprintf("could not parse from: %v", err), http.StatusBadRequest)
+		return
+	}
+	to, err := strconv.ParseUint(string(ctx.QueryArgs().Peek("to")), 10, 64)
+	if err != nil {
+		ctx.Error(fmt.Sprintf("could not parse to: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	p, err := h.m.ProfileBlocks(from, to)
+	if err != nil {
+		ctx.Error(err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ctx.Response.Header.Set("Content-Type", "application/octet-stream")
+	ctx.Response.Header.Set("Content-Disposition", `attachment; filename="profile"`)
+	if err := p.WritePprof(ctx); err != nil {
+		ctx.Error(err.Error(), http.StatusInternalServerError)
+	}
+}
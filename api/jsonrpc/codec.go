@@ -51,17 +51,22 @@ func stringToBlockNumber(str string) (BlockNumber, error) {
 		return 0, fmt.Errorf("value is empty")
 	}
 
-	if strings.HasPrefix(str, "\"") && strings.HasSuffix(str, "\"") {
-		switch str := str[1 : len(str)-1]; str {
-		case "pending":
-			return PendingBlockNumber, nil
-		case "latest":
-			return LatestBlockNumber, nil
-		case "earliest":
-			return EarliestBlockNumber, nil
-		default:
-			return 0, fmt.Errorf("blocknumber not found: %s", str)
-		}
+	// the tags are accepted with and without quotes since the
+	// parameters decoded by the dispatcher do not have them
+	quoted := strings.HasPrefix(str, "\"") && strings.HasSuffix(str, "\"")
+	if quoted {
+		str = str[1 : len(str)-1]
+	}
+	switch str {
+	case "pending":
+		return PendingBlockNumber, nil
+	case "latest":
+		return LatestBlockNumber, nil
+	case "earliest":
+		return EarliestBlockNumber, nil
+	}
+	if quoted {
+		return 0, fmt.Errorf("blocknumber not found: %s", str)
 	}
 
 	n, err := types.ParseUint64orHex(&str)
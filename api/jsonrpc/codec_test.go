@@ -0,0 +1,40 @@
+package jsonrpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringToBlockNumber(t *testing.T) {
+	cases := []struct {
+		str      string
+		expected BlockNumber
+		err      bool
+	}{
+		{`"latest"`, LatestBlockNumber, false},
+		{`"pending"`, PendingBlockNumber, false},
+		{`"earliest"`, EarliestBlockNumber, false},
+		{"latest", LatestBlockNumber, false},
+		{"pending", PendingBlockNumber, false},
+		{"earliest", EarliestBlockNumber, false},
+		{"0x10", BlockNumber(16), false},
+		{"10", BlockNumber(10), false},
+		{`"other"`, 0, true},
+		{`"0x10"`, 0, true},
+		{"other", 0, true},
+		{"", 0, true},
+	}
+	for _, c := range cases {
+		n, err := stringToBlockNumber(c.str)
+		if c.err {
+			assert.Error(t, err, c.str)
+			continue
+		}
+		assert.NoError(t, err, c.str)
+		assert.Equal(t, c.expected, n, c.str)
+	}
+
+	_, err := stringToBlockNumber(`"other"`)
+	assert.EqualError(t, err, "blocknumber not found: other")
+}
@@ -0,0 +1,45 @@
+package jsonrpc
+
+import (
+	"fmt"
+)
+
+// Debug is the debug jsonrpc endpoint
+type Debug struct {
+	d *Dispatcher
+}
+
+// ProfileBlocks executes again the blocks in the range and returns the gas
+// consumed by contract address, by opcode and by basic block
+func (d *Debug) ProfileBlocks(fromBlock string, toBlock string) (interface{}, error) {
+	from, err := stringToBlockNumber(fromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := stringToBlockNumber(toBlock)
+	if err != nil {
+		return nil, err
+	}
+	if from < 0 || to < 0 {
+		return nil, fmt.Errorf("this data cannot be provided yet")
+	}
+
+	p, err := d.d.minimal.ProfileBlocks(uint64(from), uint64(to))
+	if err != nil {
+		return nil, err
+	}
+	return p.Profile(), nil
+}
+
+// SetHead rewinds the chain to the given block and returns the new head. The head
+// can be an ancestor of the block if the state of the block is not available.
+func (d *Debug) SetHead(number string) (interface{}, error) {
+	block, err := stringToBlockNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	if block < 0 {
+		return nil, fmt.Errorf("this data cannot be provided yet")
+	}
+	return d.d.minimal.Blockchain.SetHead(uint64(block))
+}
@@ -36,9 +36,10 @@ type funcData struct {
 }
 
 type endpoints struct {
-	Eth  *Eth
-	Web3 *Web3
-	Net  *Net
+	Eth   *Eth
+	Web3  *Web3
+	Net   *Net
+	Debug *Debug
 }
 
 type enabledEndpoints map[string]struct{}
@@ -49,11 +50,20 @@ type Dispatcher struct {
 	serviceMap       map[string]*serviceData
 	endpoints        endpoints
 	enabledEndpoints map[serverType]enabledEndpoints
+	filters          *filterManager
+
+	// maxLogsRange is the maximum number of blocks of a query of logs
+	// and maxLogsResults the maximum number of logs it returns
+	maxLogsRange   uint64
+	maxLogsResults uint64
 }
 
 func newDispatcher() *Dispatcher {
 	d := &Dispatcher{
 		enabledEndpoints: map[serverType]enabledEndpoints{},
+		filters:          newFilterManager(),
+		maxLogsRange:     defaultMaxLogsRange,
+		maxLogsResults:   defaultMaxLogsResults,
 	}
 
 	d.enabledEndpoints[serverIPC] = enabledEndpoints{}
@@ -80,10 +90,12 @@ func (d *Dispatcher) registerEndpoints() {
 	d.endpoints.Eth = &Eth{d}
 	d.endpoints.Net = &Net{d}
 	d.endpoints.Web3 = &Web3{d}
+	d.endpoints.Debug = &Debug{d}
 
 	d.registerService("eth", d.endpoints.Eth)
 	d.registerService("net", d.endpoints.Net)
 	d.registerService("web3", d.endpoints.Web3)
+	d.registerService("debug", d.endpoints.Debug)
 }
 
 func (d *Dispatcher) getFnHandler(typ serverType, req Request, params int) (*serviceData, *funcData, error) {
@@ -2,7 +2,9 @@ package jsonrpc
 
 import (
 	"fmt"
+	"math/big"
 
+	"github.com/umbracle/minimal/crypto"
 	"github.com/umbracle/minimal/helper/hex"
 	"github.com/umbracle/minimal/types"
 )
@@ -60,3 +62,323 @@ func (e *Eth) SendTransaction(params map[string]interface{}) (interface{}, error
 
 	return nil, nil
 }
+
+type storageProof struct {
+	Key   types.Hash `json:"key"`
+	Value string     `json:"value"`
+	Proof []string   `json:"proof"`
+}
+
+type accountProof struct {
+	Address      types.Address   `json:"address"`
+	AccountProof []string        `json:"accountProof"`
+	Balance      string          `json:"balance"`
+	CodeHash     types.Hash      `json:"codeHash"`
+	Nonce        string          `json:"nonce"`
+	StorageHash  types.Hash      `json:"storageHash"`
+	StorageProof []*storageProof `json:"storageProof"`
+}
+
+// blockNumber returns the number of a block parameter
+func (e *Eth) blockNumber(str string) (uint64, error) {
+	block, err := stringToBlockNumber(str)
+	if err != nil {
+		return 0, err
+	}
+	switch block {
+	case LatestBlockNumber:
+		header, ok := e.d.minimal.Blockchain.Header()
+		if !ok {
+			return 0, fmt.Errorf("header not found")
+		}
+		return header.Number, nil
+	case EarliestBlockNumber:
+		return 0, nil
+	case PendingBlockNumber:
+		return 0, fmt.Errorf("this data cannot be provided yet")
+	}
+	return uint64(block), nil
+}
+
+func encodeProof(proof [][]byte) []string {
+	res := make([]string, len(proof))
+	for i, node := range proof {
+		res[i] = hex.EncodeToHex(node)
+	}
+	return res
+}
+
+// GetProof returns the merkle proof of the account and its storage slots
+func (e *Eth) GetProof(address string, storageKeys []interface{}, blockNumber string) (interface{}, error) {
+	block, err := e.blockNumber(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	slots := make([]types.Hash, len(storageKeys))
+	for i, key := range storageKeys {
+		str, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("storage key %d is not a string", i)
+		}
+		slots[i] = types.StringToHash(str)
+	}
+
+	proof, err := e.d.minimal.GetProof(types.StringToAddress(address), slots, block)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &accountProof{
+		Address:      proof.Address,
+		AccountProof: encodeProof(proof.Proof),
+		Balance:      "0x0",
+		CodeHash:     types.BytesToHash(crypto.Keccak256(nil)),
+		Nonce:        "0x0",
+		StorageHash:  types.EmptyRootHash,
+		StorageProof: []*storageProof{},
+	}
+	if account := proof.Account; account != nil {
+		res.Balance = "0x" + account.Balance.Text(16)
+		res.CodeHash = types.BytesToHash(account.CodeHash)
+		res.Nonce = fmt.Sprintf("0x%x", account.Nonce)
+		res.StorageHash = account.Root
+	}
+	for _, entry := range proof.Storage {
+		res.StorageProof = append(res.StorageProof, &storageProof{
+			Key:   entry.Key,
+			Value: "0x" + new(big.Int).SetBytes(entry.Value.Bytes()).Text(16),
+			Proof: encodeProof(entry.Proof),
+		})
+	}
+	return res, nil
+}
+
+type transaction struct {
+	BlockHash        types.Hash     `json:"blockHash"`
+	BlockNumber      string         `json:"blockNumber"`
+	From             types.Address  `json:"from"`
+	Gas              string         `json:"gas"`
+	GasPrice         string         `json:"gasPrice"`
+	Hash             types.Hash     `json:"hash"`
+	Input            string         `json:"input"`
+	Nonce            string         `json:"nonce"`
+	To               *types.Address `json:"to"`
+	TransactionIndex string         `json:"transactionIndex"`
+	Value            string         `json:"value"`
+	V                string         `json:"v"`
+	R                string         `json:"r"`
+	S                string         `json:"s"`
+}
+
+type receiptLog struct {
+	Address          types.Address `json:"address"`
+	Topics           []types.Hash  `json:"topics"`
+	Data             string        `json:"data"`
+	BlockNumber      string        `json:"blockNumber"`
+	BlockHash        types.Hash    `json:"blockHash"`
+	TransactionHash  types.Hash    `json:"transactionHash"`
+	TransactionIndex string        `json:"transactionIndex"`
+	LogIndex         string        `json:"logIndex"`
+	Removed          bool          `json:"removed"`
+}
+
+type receipt struct {
+	BlockHash         types.Hash     `json:"blockHash"`
+	BlockNumber       string         `json:"blockNumber"`
+	ContractAddress   *types.Address `json:"contractAddress"`
+	CumulativeGasUsed string         `json:"cumulativeGasUsed"`
+	From              types.Address  `json:"from"`
+	GasUsed           string         `json:"gasUsed"`
+	Logs              []*receiptLog  `json:"logs"`
+	LogsBloom         string         `json:"logsBloom"`
+	Root              *types.Hash    `json:"root,omitempty"`
+	Status            string         `json:"status,omitempty"`
+	To                *types.Address `json:"to"`
+	TransactionHash   types.Hash     `json:"transactionHash"`
+	TransactionIndex  string         `json:"transactionIndex"`
+}
+
+func encodeLog(log *types.Log) *receiptLog {
+	return &receiptLog{
+		Address:          log.Address,
+		Topics:           log.Topics,
+		Data:             hex.EncodeToHex(log.Data),
+		BlockNumber:      encodeUint(log.BlockNumber),
+		BlockHash:        log.BlockHash,
+		TransactionHash:  log.TxHash,
+		TransactionIndex: encodeUint(uint64(log.TxIndex)),
+		LogIndex:         encodeUint(uint64(log.LogIndex)),
+		Removed:          log.Removed,
+	}
+}
+
+func encodeUint(n uint64) string {
+	return fmt.Sprintf("0x%x", n)
+}
+
+func encodeBig(b []byte) string {
+	return "0x" + new(big.Int).SetBytes(b).Text(16)
+}
+
+// txSender recovers the sender of a transaction. The chain id of
+// the replay protected transactions is part of the signature.
+func txSender(txn *types.Transaction) (types.Address, error) {
+	if txn.From != (types.Address{}) {
+		return txn.From, nil
+	}
+	chainID := uint64(0)
+	if txn.V > 28 {
+		chainID = (uint64(txn.V) - 35) / 2
+	}
+	return crypto.NewEIP155Signer(chainID).Sender(txn)
+}
+
+// getTransaction returns the transaction at the given position of a canonical block
+func (e *Eth) getTransaction(header *types.Header, index uint64) (*transaction, error) {
+	body, ok := e.d.minimal.Blockchain.GetBodyByHash(header.Hash)
+	if !ok || index >= uint64(len(body.Transactions)) {
+		return nil, nil
+	}
+	txn := body.Transactions[index]
+
+	from, err := txSender(txn)
+	if err != nil {
+		return nil, err
+	}
+	res := &transaction{
+		BlockHash:        header.Hash,
+		BlockNumber:      encodeUint(header.Number),
+		From:             from,
+		Gas:              encodeUint(txn.Gas),
+		GasPrice:         encodeBig(txn.GasPrice),
+		Hash:             txn.Hash,
+		Input:            hex.EncodeToHex(txn.Input),
+		Nonce:            encodeUint(txn.Nonce),
+		To:               txn.To,
+		TransactionIndex: encodeUint(index),
+		Value:            encodeBig(txn.Value),
+		V:                encodeUint(uint64(txn.V)),
+		R:                encodeBig(txn.R),
+		S:                encodeBig(txn.S),
+	}
+	return res, nil
+}
+
+// GetTransactionByHash returns the information about a transaction by its hash
+func (e *Eth) GetTransactionByHash(hash string) (interface{}, error) {
+	blockHash, index, ok := e.d.minimal.Blockchain.GetTxLookup(types.StringToHash(hash))
+	if !ok {
+		return nil, nil
+	}
+	header, ok := e.d.minimal.Blockchain.GetHeaderByHash(blockHash)
+	if !ok {
+		return nil, nil
+	}
+	return e.getTransaction(header, index)
+}
+
+// GetTransactionByBlockHashAndIndex returns the information about a transaction by
+// the hash of the block and its position in the block
+func (e *Eth) GetTransactionByBlockHashAndIndex(hash string, index string) (interface{}, error) {
+	indx, err := types.ParseUint64orHex(&index)
+	if err != nil {
+		return nil, err
+	}
+	header, ok := e.d.minimal.Blockchain.GetHeaderByHash(types.StringToHash(hash))
+	if !ok {
+		return nil, nil
+	}
+	return e.getTransaction(header, indx)
+}
+
+// GetTransactionByBlockNumberAndIndex returns the information about a transaction by
+// the number of the block and its position in the block
+func (e *Eth) GetTransactionByBlockNumberAndIndex(blockNumber string, index string) (interface{}, error) {
+	block, err := e.blockNumber(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	indx, err := types.ParseUint64orHex(&index)
+	if err != nil {
+		return nil, err
+	}
+	header, ok := e.d.minimal.Blockchain.GetHeaderByNumber(block)
+	if !ok {
+		return nil, nil
+	}
+	return e.getTransaction(header, indx)
+}
+
+// GetTransactionReceipt returns the receipt of a transaction by its hash
+func (e *Eth) GetTransactionReceipt(hash string) (interface{}, error) {
+	b := e.d.minimal.Blockchain
+
+	blockHash, index, ok := b.GetTxLookup(types.StringToHash(hash))
+	if !ok {
+		return nil, nil
+	}
+	header, ok := b.GetHeaderByHash(blockHash)
+	if !ok {
+		return nil, nil
+	}
+	body, ok := b.GetBodyByHash(blockHash)
+	if !ok {
+		return nil, nil
+	}
+	receipts := b.GetReceiptsByHash(blockHash)
+	if index >= uint64(len(receipts)) || index >= uint64(len(body.Transactions)) {
+		return nil, nil
+	}
+	txn, raw := body.Transactions[index], receipts[index]
+
+	from, err := txSender(txn)
+	if err != nil {
+		return nil, err
+	}
+
+	// the gas used and the position of the logs are not stored
+	gasUsed := raw.CumulativeGasUsed
+	logIndex := uint64(0)
+	if index > 0 {
+		gasUsed -= receipts[index-1].CumulativeGasUsed
+	}
+	for _, r := range receipts[:index] {
+		logIndex += uint64(len(r.Logs))
+	}
+
+	res := &receipt{
+		BlockHash:         blockHash,
+		BlockNumber:       encodeUint(header.Number),
+		CumulativeGasUsed: encodeUint(raw.CumulativeGasUsed),
+		From:              from,
+		GasUsed:           encodeUint(gasUsed),
+		Logs:              []*receiptLog{},
+		LogsBloom:         raw.LogsBloom.String(),
+		To:                txn.To,
+		TransactionHash:   txn.Hash,
+		TransactionIndex:  encodeUint(index),
+	}
+	if raw.Status != nil {
+		res.Status = encodeUint(uint64(*raw.Status))
+	} else {
+		res.Root = &raw.Root
+	}
+	if txn.To == nil {
+		addr := crypto.CreateAddress(from, txn.Nonce)
+		res.ContractAddress = &addr
+	}
+	for i, log := range raw.Logs {
+		res.Logs = append(res.Logs, encodeLog(&types.Log{
+			Address:     log.Address,
+			Topics:      log.Topics,
+			Data:        log.Data,
+			BlockNumber: header.Number,
+			TxHash:      txn.Hash,
+			TxIndex:     uint(index),
+			BlockHash:   blockHash,
+			LogIndex:    uint(logIndex) + uint(i),
+		}))
+	}
+	return res, nil
+}
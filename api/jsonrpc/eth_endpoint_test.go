@@ -4,7 +4,9 @@ import (
 	"testing"
 
 	"github.com/umbracle/minimal/blockchain"
+	"github.com/umbracle/minimal/crypto"
 	"github.com/umbracle/minimal/minimal"
+	"github.com/umbracle/minimal/types"
 )
 
 func TestEthEndpointGetBlockByNumber(t *testing.T) {
@@ -35,3 +37,98 @@ func TestEthEndpointGetBlockByNumber(t *testing.T) {
 	}
 	expectEmptyResult(t, resp)
 }
+
+func TestEthEndpointGetTransaction(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubKeyToAddress(&key.PublicKey)
+	signer := &crypto.FrontierSigner{}
+
+	txn := func(nonce uint64, to *types.Address) *types.Transaction {
+		txn, err := signer.SignTx(&types.Transaction{
+			Nonce:    nonce,
+			To:       to,
+			Gas:      21000,
+			Value:    []byte{0x1},
+			GasPrice: []byte{0x1},
+			Input:    []byte{},
+		}, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return txn.ComputeHash()
+	}
+	to := types.StringToAddress("1")
+	t1, t2 := txn(0, &to), txn(1, nil)
+
+	genesis := &types.Header{Number: 0, ExtraData: []byte{}}
+	genesis.ComputeHash()
+
+	b := blockchain.NewTestBlockchain(t, []*types.Header{genesis})
+
+	header := &types.Header{ParentHash: genesis.Hash, Number: 1, Difficulty: 1, ExtraData: []byte{}}
+	header.ComputeHash()
+
+	status := types.ReceiptSuccess
+	receipts := []*types.Receipt{
+		{Status: &status, CumulativeGasUsed: 21000, Logs: []*types.Log{{Address: to, Topics: []types.Hash{}, Data: []byte{}}}},
+		{Status: &status, CumulativeGasUsed: 50000, Logs: []*types.Log{{Address: to, Topics: []types.Hash{}, Data: []byte{}}}},
+	}
+	if err := b.CommitBodies([]types.Hash{header.Hash}, []*types.Body{{Transactions: []*types.Transaction{t1, t2}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.CommitReceipts([]types.Hash{header.Hash}, [][]*types.Receipt{receipts}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.WriteHeader(header); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestDispatcher("eth")
+	s.minimal = &minimal.Minimal{
+		Blockchain: b,
+	}
+
+	call := func(method string, params string, v interface{}) {
+		resp, err := s.handle(serverHTTP, []byte(`{"method": "`+method+`", "params": [`+params+`]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v == nil {
+			expectEmptyResult(t, resp)
+			return
+		}
+		if err := expectJSONResult(resp, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var tx map[string]interface{}
+	call("eth_getTransactionByHash", `"`+t2.Hash.String()+`"`, &tx)
+	if tx["from"] != from.String() || tx["transactionIndex"] != "0x1" || tx["blockNumber"] != "0x1" || tx["to"] != nil {
+		t.Fatalf("bad transaction %v", tx)
+	}
+	call("eth_getTransactionByHash", `"`+types.StringToHash("1").String()+`"`, nil)
+
+	call("eth_getTransactionByBlockHashAndIndex", `"`+header.Hash.String()+`", "0x0"`, &tx)
+	if tx["hash"] != t1.Hash.String() || tx["to"] != to.String() {
+		t.Fatalf("bad transaction %v", tx)
+	}
+	call("eth_getTransactionByBlockNumberAndIndex", `"latest", "0x1"`, &tx)
+	if tx["hash"] != t2.Hash.String() {
+		t.Fatalf("bad transaction %v", tx)
+	}
+	call("eth_getTransactionByBlockNumberAndIndex", `"0x1", "0x2"`, nil)
+
+	var r map[string]interface{}
+	call("eth_getTransactionReceipt", `"`+t2.Hash.String()+`"`, &r)
+	if r["gasUsed"] != "0x7148" || r["status"] != "0x1" || r["from"] != from.String() {
+		t.Fatalf("bad receipt %v", r)
+	}
+	if r["contractAddress"] != crypto.CreateAddress(from, 1).String() {
+		t.Fatalf("bad contract address %v", r["contractAddress"])
+	}
+	logs := r["logs"].([]interface{})
+	if len(logs) != 1 || logs[0].(map[string]interface{})["logIndex"] != "0x1" {
+		t.Fatalf("bad logs %v", logs)
+	}
+}
@@ -0,0 +1,219 @@
+package jsonrpc
+
+import (
+	"fmt"
+
+	"github.com/umbracle/minimal/blockchain"
+	"github.com/umbracle/minimal/helper/hex"
+	"github.com/umbracle/minimal/types"
+)
+
+const (
+	// defaultMaxLogsRange is the maximum number of blocks of eth_getLogs
+	defaultMaxLogsRange = 10000
+
+	// defaultMaxLogsResults is the maximum number of logs of eth_getLogs
+	defaultMaxLogsResults = 10000
+)
+
+// logQuery is the criteria of eth_getLogs and eth_newFilter
+type logQuery struct {
+	fromBlock BlockNumber
+	toBlock   BlockNumber
+	blockHash *types.Hash
+
+	addresses []types.Address
+	topics    [][]types.Hash
+}
+
+func decodeLogQuery(criteria map[string]interface{}) (*logQuery, error) {
+	q := &logQuery{
+		fromBlock: LatestBlockNumber,
+		toBlock:   LatestBlockNumber,
+	}
+
+	var err error
+	if v, ok := criteria["fromBlock"]; ok && v != nil {
+		if q.fromBlock, err = decodeBlockNumber(v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := criteria["toBlock"]; ok && v != nil {
+		if q.toBlock, err = decodeBlockNumber(v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := criteria["blockHash"]; ok && v != nil {
+		if _, ok := criteria["fromBlock"]; ok {
+			return nil, fmt.Errorf("blockHash cannot be used with fromBlock")
+		}
+		if _, ok := criteria["toBlock"]; ok {
+			return nil, fmt.Errorf("blockHash cannot be used with toBlock")
+		}
+		hash, err := decodeHash(v)
+		if err != nil {
+			return nil, err
+		}
+		q.blockHash = &hash
+	}
+
+	// address is either a single address or a list of them
+	if v, ok := criteria["address"]; ok && v != nil {
+		values, ok := v.([]interface{})
+		if !ok {
+			values = []interface{}{v}
+		}
+		for _, value := range values {
+			str, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid address")
+			}
+			buf, err := hex.DecodeHex(str)
+			if err != nil || len(buf) != types.AddressLength {
+				return nil, fmt.Errorf("invalid address %s", str)
+			}
+			q.addresses = append(q.addresses, types.BytesToAddress(buf))
+		}
+	}
+
+	// every position of topics is null, a topic or a list of topics
+	if v, ok := criteria["topics"]; ok && v != nil {
+		positions, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid topics")
+		}
+		for _, position := range positions {
+			topics := []types.Hash{}
+			switch obj := position.(type) {
+			case nil:
+			case string:
+				hash, err := decodeHash(obj)
+				if err != nil {
+					return nil, err
+				}
+				topics = append(topics, hash)
+			case []interface{}:
+				for _, value := range obj {
+					if value == nil {
+						// a null alternative matches any topic
+						topics = []types.Hash{}
+						break
+					}
+					hash, err := decodeHash(value)
+					if err != nil {
+						return nil, err
+					}
+					topics = append(topics, hash)
+				}
+			default:
+				return nil, fmt.Errorf("invalid topics")
+			}
+			q.topics = append(q.topics, topics)
+		}
+	}
+	return q, nil
+}
+
+func decodeBlockNumber(v interface{}) (BlockNumber, error) {
+	str, ok := v.(string)
+	if !ok {
+		return 0, fmt.Errorf("invalid block number")
+	}
+	return stringToBlockNumber(str)
+}
+
+func decodeHash(v interface{}) (types.Hash, error) {
+	str, ok := v.(string)
+	if !ok {
+		return types.Hash{}, fmt.Errorf("invalid hash")
+	}
+	buf, err := hex.DecodeHex(str)
+	if err != nil || len(buf) != types.HashLength {
+		return types.Hash{}, fmt.Errorf("invalid hash %s", str)
+	}
+	return types.BytesToHash(buf), nil
+}
+
+// filter returns the log filter of the query for a range of blocks
+func (q *logQuery) filter(from, to uint64) *blockchain.LogFilter {
+	return &blockchain.LogFilter{
+		FromBlock: from,
+		ToBlock:   to,
+		Addresses: q.addresses,
+		Topics:    q.topics,
+	}
+}
+
+// resolveBlockNumber returns the number of a block of the query. The
+// pending block is the head since the logs of pending blocks are not known.
+func resolveBlockNumber(b *blockchain.Blockchain, n BlockNumber) (uint64, error) {
+	switch n {
+	case LatestBlockNumber, PendingBlockNumber:
+		header, ok := b.Header()
+		if !ok {
+			return 0, fmt.Errorf("header not found")
+		}
+		return header.Number, nil
+	case EarliestBlockNumber:
+		return 0, nil
+	}
+	return uint64(n), nil
+}
+
+func encodeLogs(logs []*types.Log) []*receiptLog {
+	res := []*receiptLog{}
+	for _, log := range logs {
+		res = append(res, encodeLog(log))
+	}
+	return res
+}
+
+// GetLogs returns the logs that match the criteria
+func (e *Eth) GetLogs(criteria map[string]interface{}) (interface{}, error) {
+	q, err := decodeLogQuery(criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	b := e.d.minimal.Blockchain
+	if q.blockHash != nil {
+		header, ok := b.GetHeaderByHash(*q.blockHash)
+		if !ok {
+			return nil, fmt.Errorf("block %s not found", q.blockHash.String())
+		}
+		return encodeLogs(b.GetBlockLogs(header, q.filter(header.Number, header.Number))), nil
+	}
+
+	from, err := resolveBlockNumber(b, q.fromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := resolveBlockNumber(b, q.toBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	// the blocks above the head are not known yet
+	head, ok := b.Header()
+	if !ok {
+		return nil, fmt.Errorf("header not found")
+	}
+	if to > head.Number {
+		to = head.Number
+	}
+	if from > to {
+		return []*receiptLog{}, nil
+	}
+	if to-from >= e.d.maxLogsRange {
+		return nil, fmt.Errorf("block range %d to %d is larger than %d blocks", from, to, e.d.maxLogsRange)
+	}
+
+	filter := q.filter(from, to)
+	filter.Limit = e.d.maxLogsResults
+
+	logs, err := b.GetLogs(filter)
+	if err != nil {
+		return nil, err
+	}
+	return encodeLogs(logs), nil
+}
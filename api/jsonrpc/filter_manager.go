@@ -0,0 +1,221 @@
+package jsonrpc
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/umbracle/minimal/blockchain"
+	"github.com/umbracle/minimal/helper/hex"
+	"github.com/umbracle/minimal/types"
+)
+
+// defaultFilterTimeout is the time a filter is kept without being polled
+const defaultFilterTimeout = 5 * time.Minute
+
+// filter is a log or block filter installed with eth_newFilter or eth_newBlockFilter
+type filter struct {
+	// query is nil for the block filters
+	query *logQuery
+
+	// last is the head of the chain the last time the filter was polled
+	last *types.Header
+
+	lastSeen time.Time
+}
+
+// filterManager keeps the filters installed. A filter is removed if it
+// is not polled within the timeout.
+type filterManager struct {
+	lock    sync.Mutex
+	timeout time.Duration
+	filters map[string]*filter
+}
+
+func newFilterManager() *filterManager {
+	return &filterManager{
+		timeout: defaultFilterTimeout,
+		filters: map[string]*filter{},
+	}
+}
+
+// expire removes the filters that were not polled within the timeout
+func (m *filterManager) expire(now time.Time) {
+	for id, f := range m.filters {
+		if now.Sub(f.lastSeen) > m.timeout {
+			delete(m.filters, id)
+		}
+	}
+}
+
+func (m *filterManager) add(f *filter) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToHex(buf)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := time.Now()
+	m.expire(now)
+
+	f.lastSeen = now
+	m.filters[id] = f
+	return id, nil
+}
+
+func (m *filterManager) remove(id string) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.expire(time.Now())
+
+	if _, ok := m.filters[id]; !ok {
+		return false
+	}
+	delete(m.filters, id)
+	return true
+}
+
+// changes returns the blocks or the logs of a filter since it was polled
+// the last time. The logs of the blocks that are not canonical anymore
+// are returned as removed.
+func (m *filterManager) changes(b *blockchain.Blockchain, id string) (interface{}, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := time.Now()
+	m.expire(now)
+
+	f, ok := m.filters[id]
+	if !ok {
+		return nil, fmt.Errorf("filter %s not found", id)
+	}
+	f.lastSeen = now
+
+	head, ok := b.Header()
+	if !ok {
+		return nil, fmt.Errorf("header not found")
+	}
+
+	// walk back to the last block still in the canonical chain
+	removed := []*types.Header{}
+	last := f.last
+	for {
+		if canonical, ok := b.GetHeaderByNumber(last.Number); ok && canonical.Hash == last.Hash {
+			break
+		}
+		removed = append(removed, last)
+
+		parent, ok := b.GetHeaderByHash(last.ParentHash)
+		if !ok {
+			return nil, fmt.Errorf("header '%s' not found", last.ParentHash.String())
+		}
+		last = parent
+	}
+
+	if f.query == nil {
+		hashes := []string{}
+		for n := last.Number + 1; n <= head.Number; n++ {
+			header, ok := b.GetHeaderByNumber(n)
+			if !ok {
+				return nil, fmt.Errorf("header %d not found", n)
+			}
+			hashes = append(hashes, header.Hash.String())
+		}
+		f.last = head
+		return hashes, nil
+	}
+
+	from, to, err := f.queryRange(b, last.Number+1, head.Number)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := []*types.Log{}
+	for i := len(removed) - 1; i >= 0; i-- {
+		h := removed[i]
+		if h.Number < from || h.Number > to {
+			continue
+		}
+		for _, log := range b.GetBlockLogs(h, f.query.filter(h.Number, h.Number)) {
+			log.Removed = true
+			logs = append(logs, log)
+		}
+	}
+	if from <= to {
+		found, err := b.GetLogs(f.query.filter(from, to))
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, found...)
+	}
+	f.last = head
+	return encodeLogs(logs), nil
+}
+
+// queryRange limits a range of blocks to the blocks of the query
+func (f *filter) queryRange(b *blockchain.Blockchain, from, to uint64) (uint64, uint64, error) {
+	if f.query.fromBlock != LatestBlockNumber && f.query.fromBlock != PendingBlockNumber {
+		n, err := resolveBlockNumber(b, f.query.fromBlock)
+		if err != nil {
+			return 0, 0, err
+		}
+		if n > from {
+			from = n
+		}
+	}
+	if f.query.toBlock != LatestBlockNumber && f.query.toBlock != PendingBlockNumber {
+		n, err := resolveBlockNumber(b, f.query.toBlock)
+		if err != nil {
+			return 0, 0, err
+		}
+		if n < to {
+			to = n
+		}
+	}
+	return from, to, nil
+}
+
+// NewFilter creates a filter for the logs that match the criteria. The changes
+// of the filter are the logs of the blocks added after it is created.
+func (e *Eth) NewFilter(criteria map[string]interface{}) (interface{}, error) {
+	q, err := decodeLogQuery(criteria)
+	if err != nil {
+		return nil, err
+	}
+	if q.blockHash != nil {
+		return nil, fmt.Errorf("blockHash is not supported in a filter")
+	}
+	return e.newFilter(q)
+}
+
+// NewBlockFilter creates a filter for the new blocks
+func (e *Eth) NewBlockFilter() (interface{}, error) {
+	return e.newFilter(nil)
+}
+
+func (e *Eth) newFilter(q *logQuery) (interface{}, error) {
+	head, ok := e.d.minimal.Blockchain.Header()
+	if !ok {
+		return nil, fmt.Errorf("header not found")
+	}
+	id, err := e.d.filters.add(&filter{query: q, last: head})
+	if err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// GetFilterChanges returns the changes of a filter since the last poll
+func (e *Eth) GetFilterChanges(id string) (interface{}, error) {
+	return e.d.filters.changes(e.d.minimal.Blockchain, id)
+}
+
+// UninstallFilter removes a filter
+func (e *Eth) UninstallFilter(id string) (interface{}, error) {
+	return e.d.filters.remove(id), nil
+}
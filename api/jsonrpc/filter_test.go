@@ -0,0 +1,222 @@
+package jsonrpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/umbracle/minimal/blockchain"
+	"github.com/umbracle/minimal/minimal"
+	"github.com/umbracle/minimal/types"
+)
+
+type testLogChain struct {
+	t    *testing.T
+	b    *blockchain.Blockchain
+	head *types.Header
+}
+
+func newTestLogChain(t *testing.T) *testLogChain {
+	genesis := &types.Header{Number: 0, ExtraData: []byte{}}
+	genesis.ComputeHash()
+
+	return &testLogChain{
+		t:    t,
+		b:    blockchain.NewTestBlockchain(t, []*types.Header{genesis}),
+		head: genesis,
+	}
+}
+
+// add writes a block on top of the given parent with a transaction that emits a log
+func (c *testLogChain) add(parent *types.Header, extra byte, addr types.Address) *types.Header {
+	number := parent.Number + 1
+
+	txn := (&types.Transaction{
+		Nonce:    number,
+		To:       &addr,
+		Value:    []byte{},
+		GasPrice: []byte{},
+		Input:    []byte{extra},
+		V:        0x1b,
+	}).ComputeHash()
+
+	status := types.ReceiptSuccess
+	receipts := []*types.Receipt{
+		{
+			Status: &status,
+			Logs:   []*types.Log{{Address: addr, Topics: []types.Hash{}, Data: []byte{}}},
+		},
+	}
+
+	header := &types.Header{
+		ParentHash: parent.Hash,
+		Number:     number,
+		Difficulty: 1,
+		ExtraData:  []byte{extra},
+		LogsBloom:  types.CreateBloom(receipts),
+	}
+	header.ComputeHash()
+
+	if err := c.b.CommitBodies([]types.Hash{header.Hash}, []*types.Body{{Transactions: []*types.Transaction{txn}}}); err != nil {
+		c.t.Fatal(err)
+	}
+	if err := c.b.CommitReceipts([]types.Hash{header.Hash}, [][]*types.Receipt{receipts}); err != nil {
+		c.t.Fatal(err)
+	}
+	if err := c.b.WriteHeader(header); err != nil {
+		c.t.Fatal(err)
+	}
+	return header
+}
+
+func (c *testLogChain) next(addr types.Address) *types.Header {
+	c.head = c.add(c.head, 0, addr)
+	return c.head
+}
+
+func TestEthEndpointGetLogs(t *testing.T) {
+	addr1, addr2 := types.StringToAddress("1"), types.StringToAddress("2")
+
+	c := newTestLogChain(t)
+	for i := 0; i < 10; i++ {
+		if i%2 == 0 {
+			c.next(addr1)
+		} else {
+			c.next(addr2)
+		}
+	}
+
+	s := newTestDispatcher("eth")
+	s.minimal = &minimal.Minimal{
+		Blockchain: c.b,
+	}
+
+	getLogs := func(criteria string) []map[string]interface{} {
+		resp, err := s.handle(serverHTTP, []byte(`{"method": "eth_getLogs", "params": [`+criteria+`]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var logs []map[string]interface{}
+		if err := expectJSONResult(resp, &logs); err != nil {
+			t.Fatal(err)
+		}
+		return logs
+	}
+
+	if logs := getLogs(`{"fromBlock": "0x0", "address": "` + addr1.String() + `"}`); len(logs) != 5 {
+		t.Fatalf("expected 5 logs but found %d", len(logs))
+	}
+	if logs := getLogs(`{"fromBlock": "0x3", "toBlock": "0x6", "address": ["` + addr1.String() + `", "` + addr2.String() + `"]}`); len(logs) != 4 {
+		t.Fatalf("expected 4 logs but found %d", len(logs))
+	}
+	if logs := getLogs(`{"fromBlock": "earliest", "topics": [["` + types.StringToHash("1").String() + `"]]}`); len(logs) != 0 {
+		t.Fatalf("expected no logs but found %d", len(logs))
+	}
+
+	// the queries above the limits fail
+	getLogsErr := func(criteria string) {
+		if _, err := s.handle(serverHTTP, []byte(`{"method": "eth_getLogs", "params": [`+criteria+`]}`)); err == nil {
+			t.Fatalf("expected an error for %s", criteria)
+		}
+	}
+
+	s.maxLogsRange = 4
+	getLogsErr(`{"fromBlock": "0x0", "toBlock": "0x4"}`)
+	if logs := getLogs(`{"fromBlock": "0x1", "toBlock": "0x4"}`); len(logs) != 4 {
+		t.Fatalf("expected 4 logs but found %d", len(logs))
+	}
+	s.maxLogsRange = defaultMaxLogsRange
+
+	s.maxLogsResults = 4
+	getLogsErr(`{"fromBlock": "0x0"}`)
+	if logs := getLogs(`{"fromBlock": "0x0", "address": "` + addr1.String() + `", "toBlock": "0x7"}`); len(logs) != 4 {
+		t.Fatalf("expected 4 logs but found %d", len(logs))
+	}
+	s.maxLogsResults = defaultMaxLogsResults
+
+	// the logs of a single block
+	header, _ := c.b.GetHeaderByNumber(4)
+	logs := getLogs(`{"blockHash": "` + header.Hash.String() + `"}`)
+	if len(logs) != 1 || logs[0]["blockNumber"] != "0x4" || logs[0]["address"] != addr2.String() {
+		t.Fatalf("bad logs %v", logs)
+	}
+}
+
+func TestEthEndpointFilters(t *testing.T) {
+	addr1, addr2 := types.StringToAddress("1"), types.StringToAddress("2")
+
+	c := newTestLogChain(t)
+	c.next(addr1)
+
+	s := newTestDispatcher("eth")
+	s.minimal = &minimal.Minimal{
+		Blockchain: c.b,
+	}
+
+	call := func(method string, params string, v interface{}) error {
+		resp, err := s.handle(serverHTTP, []byte(`{"method": "`+method+`", "params": [`+params+`]}`))
+		if err != nil {
+			return err
+		}
+		return expectJSONResult(resp, v)
+	}
+
+	var logFilter, blockFilter string
+	if err := call("eth_newFilter", `{"address": "`+addr1.String()+`"}`, &logFilter); err != nil {
+		t.Fatal(err)
+	}
+	if err := call("eth_newBlockFilter", ``, &blockFilter); err != nil {
+		t.Fatal(err)
+	}
+
+	expectChanges := func(id string, n int) []interface{} {
+		var changes []interface{}
+		if err := call("eth_getFilterChanges", `"`+id+`"`, &changes); err != nil {
+			t.Fatal(err)
+		}
+		if len(changes) != n {
+			t.Fatalf("expected %d changes but found %d", n, len(changes))
+		}
+		return changes
+	}
+
+	// the blocks before the filter are not included
+	expectChanges(logFilter, 0)
+	expectChanges(blockFilter, 0)
+
+	fork := c.head
+	c.next(addr1)
+	c.next(addr2)
+
+	expectChanges(logFilter, 1)
+	if changes := expectChanges(blockFilter, 2); changes[1] != c.head.Hash.String() {
+		t.Fatal("bad block hash")
+	}
+	expectChanges(logFilter, 0)
+
+	// a longer fork with only logs of the second address replaces
+	// the chain, the log of the old chain is removed
+	a := c.add(fork, 1, addr2)
+	b := c.add(a, 1, addr2)
+	c.add(b, 1, addr2)
+
+	changes := expectChanges(logFilter, 1)
+	if log := changes[0].(map[string]interface{}); log["removed"] != true {
+		t.Fatal("the log should be removed")
+	}
+	expectChanges(blockFilter, 3)
+
+	// uninstall
+	var ok bool
+	if err := call("eth_uninstallFilter", `"`+blockFilter+`"`, &ok); err != nil || !ok {
+		t.Fatal("failed to uninstall the filter")
+	}
+	if err := call("eth_uninstallFilter", `"`+blockFilter+`"`, &ok); err != nil || ok {
+		t.Fatal("the filter should not exist")
+	}
+
+	// the filters that are not polled expire
+	s.filters.filters[logFilter].lastSeen = time.Now().Add(-2 * defaultFilterTimeout)
+	if err := call("eth_getFilterChanges", `"`+logFilter+`"`, nil); err == nil {
+		t.Fatal("the filter should be expired")
+	}
+}
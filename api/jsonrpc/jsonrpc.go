@@ -125,8 +125,20 @@ func getServerConfig(config map[string]interface{}, field string) (map[string]in
 
 	endpointsRaw, ok := conf["endpoints"]
 	if ok {
-		endpoints, ok = endpointsRaw.([]string)
-		if !ok {
+		switch obj := endpointsRaw.(type) {
+		case []string:
+			endpoints = obj
+		case []interface{}:
+			// decoded from a configuration file
+			endpoints = []string{}
+			for _, i := range obj {
+				endpoint, ok := i.(string)
+				if !ok {
+					return nil, nil, true, fmt.Errorf("could not get the enabled endpoints")
+				}
+				endpoints = append(endpoints, endpoint)
+			}
+		default:
 			return nil, nil, true, fmt.Errorf("could not get the enabled endpoints")
 		}
 		delete(conf, "endpoints")
@@ -46,6 +46,13 @@ func TestGetServerConfig(t *testing.T) {
 			},
 			endpoints: []string{"a", "b", "c"},
 		},
+		{
+			config: map[string]interface{}{
+				"endpoints": []interface{}{"eth", "debug"},
+			},
+			result:    map[string]interface{}{},
+			endpoints: []string{"eth", "debug"},
+		},
 	}
 
 	field := "xx"
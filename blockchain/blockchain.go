@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sync"
 
+	"github.com/umbracle/minimal/blockchain/bloombits"
 	"github.com/umbracle/minimal/blockchain/storage"
 	"github.com/umbracle/minimal/chain"
 	"github.com/umbracle/minimal/consensus"
@@ -13,6 +15,7 @@ import (
 	"github.com/umbracle/minimal/types/buildroot"
 
 	mapset "github.com/deckarep/golang-set"
+	"github.com/hashicorp/go-hclog"
 	lru "github.com/hashicorp/golang-lru"
 )
 
@@ -36,9 +39,39 @@ type Blockchain struct {
 
 	genesis types.Hash
 
-	// TODO: Unify in a single event
-	sidechainCh chan *types.Header
-	listeners   []chan *types.Header
+	// bloomSectionSize is the number of blocks of a section in the bloom bits index
+	bloomSectionSize uint64
+
+	// freezerThreshold is the number of blocks below the head that are not
+	// moved to the ancient store and freezerBatch the number of blocks moved
+	// at once
+	freezerThreshold uint64
+	freezerBatch     uint64
+
+	// events are sent to the subscriptions once the writes are in the storage
+	events *eventBus
+
+	// writeLock serializes the writes of the chain. The rewinds are
+	// written in several batches and they cannot interleave with the
+	// commits of new blocks.
+	writeLock sync.Mutex
+
+	// stateLock is held (read) from the moment the state of a block is
+	// written until the block is committed
+	stateLock sync.RWMutex
+
+	// the bloom bits are indexed in the background once the head advances
+	bloomCh   chan struct{}
+	bloomOnce sync.Once
+
+	// the final blocks are moved to the ancient store in the background
+	freezerCh   chan struct{}
+	freezerOnce sync.Once
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+
+	logger hclog.Logger
 
 	headersCache    *lru.Cache
 	bodiesCache     *lru.Cache
@@ -52,11 +85,18 @@ var ripemdFailedTxn = types.StringToHash("0xcf416c536ec1a19ed1fb89e4ec7ffb3cf73a
 // NewBlockchain creates a new blockchain object
 func NewBlockchain(db storage.Storage, consensus consensus.Consensus, executor *state.Executor) *Blockchain {
 	b := &Blockchain{
-		db:          db,
-		consensus:   consensus,
-		sidechainCh: make(chan *types.Header, 10),
-		listeners:   []chan *types.Header{},
-		executor:    executor,
+		db:        db,
+		consensus: consensus,
+		events:    newEventBus(),
+		executor:  executor,
+		bloomCh:   make(chan struct{}, 1),
+		freezerCh: make(chan struct{}, 1),
+		closeCh:   make(chan struct{}),
+		logger:    hclog.NewNullLogger(),
+
+		bloomSectionSize: bloombits.SectionSize,
+		freezerThreshold: FreezerThreshold,
+		freezerBatch:     freezerBatch,
 	}
 
 	b.headersCache, _ = lru.New(100)
@@ -65,14 +105,19 @@ func NewBlockchain(db storage.Storage, consensus consensus.Consensus, executor *
 	return b
 }
 
+// SetLogger sets the logger of the blockchain, it has to be
+// set before the chain is written
+func (b *Blockchain) SetLogger(logger hclog.Logger) {
+	b.logger = logger
+}
+
 func (b *Blockchain) Executor() *state.Executor {
 	return b.executor
 }
 
-func (b *Blockchain) Subscribe() chan *types.Header {
-	ch := make(chan *types.Header, 5)
-	b.listeners = append(b.listeners, ch)
-	return ch
+// Consensus returns the consensus engine that verifies the headers
+func (b *Blockchain) Consensus() consensus.Consensus {
+	return b.consensus
 }
 
 // GetParent return the parent
@@ -105,15 +150,17 @@ func (b *Blockchain) WriteGenesis(genesis *chain.Genesis) error {
 	b.genesis = header.Hash
 
 	// add genesis block
-	if err := b.addHeader(header); err != nil {
-		return err
-	}
-	if err := b.advanceHead(header); err != nil {
-		return err
-	}
-
 	diff := new(big.Int).SetUint64(header.Difficulty)
-	if err := b.db.WriteDiff(header.Hash, diff); err != nil {
+	err := b.commit(func(db storage.Storage, ev *chainEvents) error {
+		if err := b.addHeader(db, header); err != nil {
+			return err
+		}
+		if err := b.advanceHead(db, header); err != nil {
+			return err
+		}
+		return db.WriteDiff(header.Hash, diff)
+	})
+	if err != nil {
 		return err
 	}
 	b.difficultyCache.Add(header.Hash, diff)
@@ -130,17 +177,20 @@ func (b *Blockchain) WriteHeaderGenesis(header *types.Header) error {
 	}
 
 	// add genesis block
-	if err := b.addHeader(header); err != nil {
-		return err
-	}
-	if err := b.advanceHead(header); err != nil {
+	err := b.commit(func(db storage.Storage, ev *chainEvents) error {
+		if err := b.addHeader(db, header); err != nil {
+			return err
+		}
+		if err := b.advanceHead(db, header); err != nil {
+			return err
+		}
+		return db.WriteDiff(header.Hash, big.NewInt(1))
+	})
+	if err != nil {
 		return err
 	}
 
 	b.genesis = header.Hash
-	if err := b.db.WriteDiff(header.Hash, big.NewInt(1)); err != nil {
-		return err
-	}
 	return nil
 }
 
@@ -162,36 +212,52 @@ func (b *Blockchain) GetTD(hash types.Hash) (*big.Int, bool) {
 	return b.readDiff(hash)
 }
 
-func (b *Blockchain) writeCanonicalHeader(h *types.Header) error {
-	td, ok := b.readDiff(h.ParentHash)
-	if !ok {
-		return fmt.Errorf("parent difficulty not found 2")
-	}
+// commit writes the changes of fn to the storage in a batch, a crash
+// cannot leave the chain with part of them. Once they are written, the
+// events are published and the indexer of the bloom bits and the
+// freezer are notified.
+func (b *Blockchain) commit(fn func(db storage.Storage, ev *chainEvents) error) error {
+	b.writeLock.Lock()
+	defer b.writeLock.Unlock()
 
-	diff := big.NewInt(1).Add(td, new(big.Int).SetUint64(h.Difficulty))
-	if err := b.db.WriteCanonicalHeader(h, diff); err != nil {
+	prev, _ := b.db.ReadHeadHash()
+
+	ev := &chainEvents{}
+	err := b.db.Batch(func(db storage.Storage) error {
+		return fn(db, ev)
+	})
+	if err != nil {
 		return err
 	}
+	b.publish(ev, prev)
 
-	// notify the listeners
-	for _, ch := range b.listeners {
-		select {
-		case ch <- h:
-		default:
-		}
+	head, ok := b.Header()
+	if !ok || head.Hash == prev {
+		return nil
 	}
-
+	b.notifyBloomIndexer()
+	b.notifyFreezer()
 	return nil
 }
 
-func (b *Blockchain) advanceHead(h *types.Header) error {
-	if err := b.db.WriteHeadHash(h.Hash); err != nil {
+func (b *Blockchain) writeCanonicalHeader(db storage.Storage, h *types.Header) error {
+	td, ok := b.readDiff(h.ParentHash)
+	if !ok {
+		return fmt.Errorf("parent difficulty not found 2")
+	}
+
+	diff := big.NewInt(1).Add(td, new(big.Int).SetUint64(h.Difficulty))
+	return db.WriteCanonicalHeader(h, diff)
+}
+
+func (b *Blockchain) advanceHead(db storage.Storage, h *types.Header) error {
+	if err := db.WriteHeadHash(h.Hash); err != nil {
 		return err
 	}
-	if err := b.db.WriteHeadNumber(h.Number); err != nil {
+	if err := db.WriteHeadNumber(h.Number); err != nil {
 		return err
 	}
-	if err := b.db.WriteCanonicalHash(h.Number, h.Hash); err != nil {
+	if err := db.WriteCanonicalHash(h.Number, h.Hash); err != nil {
 		return err
 	}
 
@@ -202,18 +268,10 @@ func (b *Blockchain) advanceHead(h *types.Header) error {
 			return fmt.Errorf("parent difficulty not found 1")
 		}
 
-		if err := b.db.WriteDiff(h.Hash, big.NewInt(1).Add(td, new(big.Int).SetUint64(h.Difficulty))); err != nil {
+		if err := db.WriteDiff(h.Hash, big.NewInt(1).Add(td, new(big.Int).SetUint64(h.Difficulty))); err != nil {
 			return err
 		}
 	}
-
-	for _, ch := range b.listeners {
-		select {
-		case ch <- h:
-		default:
-		}
-	}
-
 	return nil
 }
 
@@ -233,18 +291,31 @@ func (b *Blockchain) Header() (*types.Header, bool) {
 	return header, true
 }
 
-// CommitBodies writes the bodies
+// CommitBodies writes the bodies. The transactions of the bodies of
+// canonical blocks are indexed.
 func (b *Blockchain) CommitBodies(headers []types.Hash, bodies []*types.Body) error {
 	if len(headers) != len(bodies) {
 		return fmt.Errorf("lengths dont match %d and %d", len(headers), len(bodies))
 	}
 
-	for indx, hash := range headers {
-		if err := b.db.WriteBody(hash, bodies[indx]); err != nil {
-			return err
+	return b.commit(func(db storage.Storage, ev *chainEvents) error {
+		for indx, hash := range headers {
+			if err := db.WriteBody(hash, bodies[indx]); err != nil {
+				return err
+			}
+			header, ok := b.readHeader(hash)
+			if !ok {
+				continue
+			}
+			if canonical, ok := db.ReadCanonicalHash(header.Number); !ok || canonical != hash {
+				continue
+			}
+			if err := b.writeTxLookups(db, header, bodies[indx]); err != nil {
+				return err
+			}
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 // CommitReceipts writes the receipts
@@ -431,9 +502,6 @@ func (b *Blockchain) WriteBlocks(blocks []*types.Block) error {
 		header := block.Header
 
 		body := block.Body()
-		if err := b.db.WriteBody(block.Header.Hash, block.Body()); err != nil {
-			return err
-		}
 		b.bodiesCache.Add(block.Header.Hash, body)
 
 		// Verify uncles. It requires to have the bodies on memory
@@ -441,11 +509,25 @@ func (b *Blockchain) WriteBlocks(blocks []*types.Block) error {
 			return err
 		}
 		// Process and validate the block
-		if err := b.processBlock(blocks[indx]); err != nil {
+		b.stateLock.RLock()
+		receipts, err := b.processBlock(blocks[indx])
+		if err != nil {
+			b.stateLock.RUnlock()
 			return err
 		}
-		// Write the header to the chain
-		if err := b.WriteHeader(header); err != nil {
+
+		// Write the block and the header to the chain at once
+		err = b.commit(func(db storage.Storage, ev *chainEvents) error {
+			if err := db.WriteBody(header.Hash, body); err != nil {
+				return err
+			}
+			if err := db.WriteReceipts(header.Hash, receipts); err != nil {
+				return err
+			}
+			return b.writeHeader(db, ev, header, body)
+		})
+		b.stateLock.RUnlock()
+		if err != nil {
 			return err
 		}
 	}
@@ -453,35 +535,46 @@ func (b *Blockchain) WriteBlocks(blocks []*types.Block) error {
 	return nil
 }
 
-func (b *Blockchain) processBlock(block *types.Block) error {
+// WithStateLock runs fn once the blocks being processed are committed and
+// no other block is processed until it returns. While fn runs, the states
+// written to the storage belong to the blocks of the chain.
+func (b *Blockchain) WithStateLock(fn func() error) error {
+	b.stateLock.Lock()
+	defer b.stateLock.Unlock()
+
+	return fn()
+}
+
+// processBlock executes the block and returns its receipts
+func (b *Blockchain) processBlock(block *types.Block) ([]*types.Receipt, error) {
 	header := block.Header
 
 	// process the block
 	parent, ok := b.readHeader(header.ParentHash)
 	if !ok {
-		return fmt.Errorf("unknown ancestor 1")
+		return nil, fmt.Errorf("unknown ancestor 1")
 	}
 	transition, root, err := b.executor.ProcessBlock(parent.StateRoot, block)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// validate the fields
 	if root != header.StateRoot {
-		return fmt.Errorf("invalid merkle root")
+		return nil, fmt.Errorf("invalid merkle root")
 	}
 	if transition.TotalGas() != header.GasUsed {
-		return fmt.Errorf("gas used is different")
+		return nil, fmt.Errorf("gas used is different")
 	}
 	receiptSha := buildroot.CalculateReceiptsRoot(transition.Receipts())
 	if receiptSha != header.ReceiptsRoot {
-		return fmt.Errorf("invalid receipts root")
+		return nil, fmt.Errorf("invalid receipts root")
 	}
 	rbloom := types.CreateBloom(transition.Receipts())
 	if rbloom != header.LogsBloom {
-		return fmt.Errorf("invalid receipts bloom")
+		return nil, fmt.Errorf("invalid receipts bloom")
 	}
-	return nil
+	return transition.Receipts(), nil
 }
 
 var emptyFrom = types.Address{}
@@ -567,13 +660,13 @@ func (b *Blockchain) VerifyUncles(block *types.Block) error {
 	return nil
 }
 
-func (b *Blockchain) addHeader(header *types.Header) error {
+func (b *Blockchain) addHeader(db storage.Storage, header *types.Header) error {
 	b.headersCache.Add(header.Hash, header)
 
-	if err := b.db.WriteHeader(header); err != nil {
+	if err := db.WriteHeader(header); err != nil {
 		return err
 	}
-	if err := b.db.WriteCanonicalHash(header.Number, header.Hash); err != nil {
+	if err := db.WriteCanonicalHash(header.Number, header.Hash); err != nil {
 		return err
 	}
 	return nil
@@ -586,6 +679,14 @@ func (b *Blockchain) WriteBlock(block *types.Block) error {
 
 // WriteHeader writes a block and the data, assumes the genesis is already set
 func (b *Blockchain) WriteHeader(header *types.Header) error {
+	return b.commit(func(db storage.Storage, ev *chainEvents) error {
+		return b.writeHeader(db, ev, header, nil)
+	})
+}
+
+// writeHeader writes the header and updates the canonical chain. The body
+// is the one written with the header, nil if only the header is known.
+func (b *Blockchain) writeHeader(db storage.Storage, ev *chainEvents, header *types.Header, body *types.Body) error {
 	head, ok := b.Header()
 	if !ok {
 		return fmt.Errorf("header not found")
@@ -594,10 +695,13 @@ func (b *Blockchain) WriteHeader(header *types.Header) error {
 	// Write the data
 	if header.ParentHash == head.Hash {
 		// Fast path to save the new canonical header
-		return b.writeCanonicalHeader(header)
+		if err := b.writeCanonicalHeader(db, header); err != nil {
+			return err
+		}
+		return b.writeTxLookups(db, header, body)
 	}
 
-	if err := b.db.WriteHeader(header); err != nil {
+	if err := db.WriteHeader(header); err != nil {
 		return err
 	}
 
@@ -610,7 +714,7 @@ func (b *Blockchain) WriteHeader(header *types.Header) error {
 	if !ok {
 		return fmt.Errorf("parent of %s (%d) not found", header.Hash.String(), header.Number)
 	}
-	if err := b.db.WriteDiff(header.Hash, big.NewInt(1).Add(parentDiff, new(big.Int).SetUint64(header.Difficulty))); err != nil {
+	if err := db.WriteDiff(header.Hash, big.NewInt(1).Add(parentDiff, new(big.Int).SetUint64(header.Difficulty))); err != nil {
 		return err
 	}
 	b.headersCache.Add(header.Hash, header)
@@ -618,31 +722,22 @@ func (b *Blockchain) WriteHeader(header *types.Header) error {
 	incomingDiff := big.NewInt(1).Add(parentDiff, new(big.Int).SetUint64(header.Difficulty))
 	if incomingDiff.Cmp(headerDiff) > 0 {
 		// new block has higher difficulty than us, reorg the chain
-		if err := b.handleReorg(head, header); err != nil {
+		if err := b.handleReorg(db, ev, head, header, body); err != nil {
 			return err
 		}
 	} else {
 		// new block has lower difficulty than us, create a new fork
-		if err := b.writeFork(header); err != nil {
+		if err := b.writeFork(db, header); err != nil {
 			return err
 		}
+		ev.sideBlocks = append(ev.sideBlocks, header)
 	}
 
 	return nil
 }
 
-// SideChainCh returns the channel of headers
-func (b *Blockchain) SideChainCh() chan *types.Header {
-	return b.sidechainCh
-}
-
-func (b *Blockchain) writeFork(header *types.Header) error {
-	forks := b.db.ReadForks()
-
-	select {
-	case b.sidechainCh <- header:
-	default:
-	}
+func (b *Blockchain) writeFork(db storage.Storage, header *types.Header) error {
+	forks := db.ReadForks()
 
 	newForks := []types.Hash{}
 	for _, fork := range forks {
@@ -651,13 +746,13 @@ func (b *Blockchain) writeFork(header *types.Header) error {
 		}
 	}
 	newForks = append(newForks, header.Hash)
-	if err := b.db.WriteForks(newForks); err != nil {
+	if err := db.WriteForks(newForks); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (b *Blockchain) handleReorg(oldHeader *types.Header, newHeader *types.Header) error {
+func (b *Blockchain) handleReorg(db storage.Storage, ev *chainEvents, oldHeader *types.Header, newHeader *types.Header, body *types.Body) error {
 	newChainHead := newHeader
 	oldChainHead := oldHeader
 
@@ -693,30 +788,219 @@ func (b *Blockchain) handleReorg(oldHeader *types.Header, newHeader *types.Heade
 		}
 
 		oldChain = append(oldChain, oldHeader)
+		newChain = append(newChain, newHeader)
 	}
 
-	if err := b.writeFork(oldChainHead); err != nil {
+	// the common ancestor is the last header of both chains
+	ancestor := oldHeader
+
+	if err := b.writeFork(db, oldChainHead); err != nil {
 		return fmt.Errorf("failed to write the old header as fork: %v", err)
 	}
 
 	// Update canonical chain numbers
 	for _, h := range newChain {
-		if err := b.db.WriteCanonicalHash(h.Number, h.Hash); err != nil {
+		if err := db.WriteCanonicalHash(h.Number, h.Hash); err != nil {
+			return err
+		}
+	}
+
+	// Move the transaction lookups to the blocks of the new chain
+	ev.reorg = true
+	for _, h := range append([]*types.Header{oldChainHead}, oldChain...) {
+		if h.Hash == ancestor.Hash {
+			continue
+		}
+		if err := b.deleteTxLookups(db, h); err != nil {
+			return err
+		}
+		ev.oldChain = append(ev.oldChain, h)
+	}
+	if err := b.writeTxLookups(db, newChainHead, body); err != nil {
+		return err
+	}
+	ev.newChain = append(ev.newChain, newChainHead)
+	for _, h := range newChain {
+		if h.Hash == ancestor.Hash {
+			continue
+		}
+		if err := b.writeTxLookups(db, h, nil); err != nil {
+			return err
+		}
+		ev.newChain = append(ev.newChain, h)
+	}
+
+	// the sections of the index after the ancestor are built again
+	if err := b.truncateBloomBits(db, ancestor.Number); err != nil {
+		return err
+	}
+
+	return b.advanceHead(db, newChainHead)
+}
+
+// SetHead rewinds the canonical chain to the block with the given number. The
+// canonical hashes, bodies, receipts and difficulties of the blocks above it
+// are removed, the headers are kept. If the state of the block is not available
+// the chain is rewound to the closest ancestor with the state. It returns the
+// new head.
+func (b *Blockchain) SetHead(number uint64) (*types.Header, error) {
+	b.writeLock.Lock()
+	defer b.writeLock.Unlock()
+
+	head, ok := b.Header()
+	if !ok {
+		return nil, fmt.Errorf("header not found")
+	}
+	if number > head.Number {
+		return nil, fmt.Errorf("block %d is above the head %d", number, head.Number)
+	}
+	if b.executor == nil {
+		return nil, fmt.Errorf("the state is not available")
+	}
+
+	target, ok := b.GetHeaderByNumber(number)
+	if !ok {
+		return nil, fmt.Errorf("block %d not found", number)
+	}
+	for {
+		if _, err := b.executor.State().NewSnapshotAt(target.StateRoot); err == nil {
+			break
+		}
+		if target.Number == 0 {
+			return nil, fmt.Errorf("the state of the genesis is not available")
+		}
+		parent, ok := b.readHeader(target.ParentHash)
+		if !ok {
+			return nil, fmt.Errorf("header '%s' not found", target.ParentHash.String())
+		}
+		target = parent
+	}
+
+	if err := b.rewind(head.Number, target); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// rewind makes the target the head of the chain and removes the data
+// of the canonical blocks above it up to the block with the given number
+func (b *Blockchain) rewind(top uint64, target *types.Header) error {
+	// the events are built before the receipts of the blocks are removed
+	oldChain := []*types.Header{}
+	for n := top; n > target.Number; n-- {
+		if hash, ok := b.db.ReadCanonicalHash(n); ok {
+			if header, ok := b.readHeader(hash); ok {
+				oldChain = append(oldChain, header)
+			}
+		}
+	}
+	events := append(b.reorgEvents(oldChain, nil, b.events.hasLogSubs()), &Event{Type: NewHead, Header: target})
+
+	// write the new head first, if the rewind is interrupted
+	// the blocks above it are not part of the chain anymore
+	err := b.db.Batch(func(db storage.Storage) error {
+		if err := db.WriteHeadHash(target.Hash); err != nil {
 			return err
 		}
+		if err := db.WriteHeadNumber(target.Number); err != nil {
+			return err
+		}
+		if err := db.WriteCanonicalHash(target.Number, target.Hash); err != nil {
+			return err
+		}
+		// the blocks above the new head are executed again
+		if pivot, ok := db.ReadSyncPivot(); ok && pivot > target.Number {
+			if err := db.WriteSyncPivot(0); err != nil {
+				return err
+			}
+		}
+		return b.truncateBloomBits(db, target.Number)
+	})
+	if err != nil {
+		return err
 	}
+	b.events.publish(events...)
 
-	// oldChain headers can become now uncles
-	go func() {
-		for _, i := range oldChain {
-			select {
-			case b.sidechainCh <- i:
-			default:
+	for n := top; n > target.Number; n-- {
+		hash, ok := b.db.ReadCanonicalHash(n)
+		if !ok {
+			continue
+		}
+		// the data of each block is removed at once
+		err := b.db.Batch(func(db storage.Storage) error {
+			if header, ok := b.readHeader(hash); ok {
+				if err := b.deleteTxLookups(db, header); err != nil {
+					return err
+				}
+			}
+			// the receipts are removed before the body since
+			// they reference the transactions in some storages
+			if err := db.DeleteReceipts(hash); err != nil {
+				return err
 			}
+			if err := db.DeleteBody(hash); err != nil {
+				return err
+			}
+			if err := db.DeleteDiff(hash); err != nil {
+				return err
+			}
+			return db.DeleteCanonicalHash(n)
+		})
+		if err != nil {
+			return err
 		}
-	}()
+		b.bodiesCache.Remove(hash)
+		b.difficultyCache.Remove(hash)
+	}
+
+	// remove the forks above the new head since their ancestors were removed
+	forks := []types.Hash{}
+	for _, fork := range b.db.ReadForks() {
+		if header, ok := b.readHeader(fork); ok && header.Number <= target.Number {
+			forks = append(forks, fork)
+		}
+	}
+	return b.db.WriteForks(forks)
+}
+
+// writeTxLookups indexes the transactions of a canonical block. If the body
+// is not given it is read from the batch. The blocks without body (i.e.
+// headers written before the bodies during the fast sync) are skipped,
+// they are indexed once the body is committed.
+func (b *Blockchain) writeTxLookups(db storage.Storage, header *types.Header, body *types.Body) error {
+	if body == nil {
+		var ok bool
+		if body, ok = db.ReadBody(header.Hash); !ok {
+			return nil
+		}
+	}
+	for indx, txn := range body.Transactions {
+		if err := db.WriteTxLookup(txn.Hash, header.Hash, uint64(indx)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteTxLookups removes the index of the transactions of a block
+// that is not canonical anymore
+func (b *Blockchain) deleteTxLookups(db storage.Storage, header *types.Header) error {
+	body, ok := db.ReadBody(header.Hash)
+	if !ok {
+		return nil
+	}
+	for _, txn := range body.Transactions {
+		if err := db.DeleteTxLookup(txn.Hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	return b.advanceHead(newChainHead)
+// GetTxLookup returns the hash of the canonical block that includes
+// the transaction and the position of the transaction in the block
+func (b *Blockchain) GetTxLookup(hash types.Hash) (types.Hash, uint64, bool) {
+	return b.db.ReadTxLookup(hash)
 }
 
 // GetForks returns the forks
@@ -759,5 +1043,7 @@ func (b *Blockchain) GetBlockByNumber(n uint64, full bool) (*types.Block, bool)
 }
 
 func (b *Blockchain) Close() error {
+	close(b.closeCh)
+	b.wg.Wait()
 	return b.db.Close()
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/minimal/blockchain/storage"
 	"github.com/umbracle/minimal/chain"
 
 	"github.com/umbracle/minimal/types"
@@ -326,3 +327,286 @@ func TestCommitChain(t *testing.T) {
 		assert.Len(t, r, 1)
 	}
 }
+
+func TestSetHead(t *testing.T) {
+	// the state is only available for the even blocks
+	headers := []*types.Header{}
+	for i := 0; i < 10; i++ {
+		header := &types.Header{
+			Number:     uint64(i),
+			Difficulty: uint64(i),
+			ExtraData:  []byte{},
+			StateRoot:  types.EmptyRootHash,
+		}
+		if i != 0 {
+			header.ParentHash = headers[i-1].Hash
+		}
+		if i%2 != 0 {
+			header.StateRoot = types.StringToHash("1")
+		}
+		header.ComputeHash()
+		headers = append(headers, header)
+	}
+
+	b := NewTestBlockchain(t, headers)
+
+	hashes := []types.Hash{}
+	bodies := []*types.Body{}
+	receipts := [][]*types.Receipt{}
+	for _, header := range headers {
+		hashes = append(hashes, header.Hash)
+		bodies = append(bodies, &types.Body{})
+		receipts = append(receipts, []*types.Receipt{})
+	}
+	assert.NoError(t, b.CommitBodies(hashes, bodies))
+	assert.NoError(t, b.CommitReceipts(hashes, receipts))
+
+	_, err := b.SetHead(10)
+	assert.Error(t, err)
+
+	// the state of the block 5 is not available
+	head, err := b.SetHead(5)
+	assert.NoError(t, err)
+	assert.Equal(t, headers[4].Hash, head.Hash)
+
+	current, _ := b.Header()
+	assert.Equal(t, headers[4].Hash, current.Hash)
+
+	for _, header := range headers {
+		_, canonical := b.GetHeaderByNumber(header.Number)
+		_, body := b.GetBodyByHash(header.Hash)
+		_, diff := b.GetTD(header.Hash)
+
+		removed := header.Number > 4
+		assert.Equal(t, !removed, canonical)
+		assert.Equal(t, !removed, body)
+		assert.Equal(t, !removed, diff)
+
+		// the headers are kept
+		_, ok := b.GetHeaderByHash(header.Hash)
+		assert.True(t, ok)
+	}
+
+	// the chain grows again from the new head
+	assert.NoError(t, b.WriteHeaders(headers[5:]))
+
+	current, _ = b.Header()
+	assert.Equal(t, headers[9].Hash, current.Hash)
+}
+
+func TestSetHeadConcurrentWrites(t *testing.T) {
+	headers := NewTestHeaderChain(200)
+	for i, header := range headers {
+		header.StateRoot = types.EmptyRootHash
+		if i != 0 {
+			header.ParentHash = headers[i-1].Hash
+		}
+		header.ComputeHash()
+	}
+	b := NewTestBlockchain(t, headers[:10])
+
+	doneCh := make(chan struct{})
+	go func() {
+		for {
+			head, _ := b.Header()
+			if head.Number == 199 {
+				break
+			}
+			// the write fails if a rewind removes the parent
+			// in between, the chain is written again from the head
+			b.WriteHeader(headers[head.Number+1])
+		}
+		close(doneCh)
+	}()
+
+	for i := 0; i < 100; i++ {
+		_, err := b.SetHead(5)
+		assert.NoError(t, err)
+	}
+	<-doneCh
+
+	// the canonical chain is linked from the head to the genesis
+	head, _ := b.Header()
+	for n := head.Number; n > 0; n-- {
+		header, ok := b.GetHeaderByNumber(n)
+		assert.True(t, ok)
+
+		parent, ok := b.GetHeaderByNumber(n - 1)
+		assert.True(t, ok)
+		assert.Equal(t, parent.Hash, header.ParentHash)
+	}
+}
+
+func TestTxLookup(t *testing.T) {
+	txn := func(nonce uint64) *types.Transaction {
+		addr := types.StringToAddress("1")
+		return (&types.Transaction{
+			Nonce:    nonce,
+			To:       &addr,
+			Value:    []byte{},
+			GasPrice: []byte{},
+			Input:    []byte{},
+			V:        0x1b,
+		}).ComputeHash()
+	}
+	t1, t2, t3, t4 := txn(1), txn(2), txn(3), txn(4)
+
+	genesis := &types.Header{Number: 0, ExtraData: []byte{}, StateRoot: types.EmptyRootHash}
+	genesis.ComputeHash()
+
+	b := NewTestBlockchain(t, []*types.Header{genesis})
+
+	// write a block with its body
+	write := func(parent *types.Header, extra byte, txs ...*types.Transaction) *types.Header {
+		header := &types.Header{
+			ParentHash: parent.Hash,
+			Number:     parent.Number + 1,
+			Difficulty: 1,
+			ExtraData:  []byte{extra},
+			StateRoot:  types.EmptyRootHash,
+		}
+		header.ComputeHash()
+
+		assert.NoError(t, b.CommitBodies([]types.Hash{header.Hash}, []*types.Body{{Transactions: txs}}))
+		assert.NoError(t, b.WriteHeader(header))
+		return header
+	}
+
+	expectLookup := func(txn *types.Transaction, block *types.Header, index uint64) {
+		hash, indx, ok := b.GetTxLookup(txn.Hash)
+		if block == nil {
+			assert.False(t, ok)
+			return
+		}
+		assert.True(t, ok)
+		assert.Equal(t, block.Hash, hash)
+		assert.Equal(t, index, indx)
+	}
+
+	a1 := write(genesis, 0xa, t1)
+	a2 := write(a1, 0xa, t2, t3)
+
+	expectLookup(t1, a1, 0)
+	expectLookup(t2, a2, 0)
+	expectLookup(t3, a2, 1)
+
+	// a fork does not change the lookups
+	b2 := write(a1, 0xb, t3)
+	expectLookup(t3, a2, 1)
+
+	// the reorg moves the lookups to the new chain
+	b3 := write(b2, 0xb, t4)
+
+	head, _ := b.Header()
+	assert.Equal(t, b3.Hash, head.Hash)
+
+	canonical, _ := b.GetHeaderByNumber(2)
+	assert.Equal(t, b2.Hash, canonical.Hash)
+
+	expectLookup(t1, a1, 0)
+	expectLookup(t2, nil, 0)
+	expectLookup(t3, b2, 0)
+	expectLookup(t4, b3, 0)
+
+	// the rewind removes the lookups above the head
+	_, err := b.SetHead(1)
+	assert.NoError(t, err)
+
+	expectLookup(t1, a1, 0)
+	expectLookup(t3, nil, 0)
+	expectLookup(t4, nil, 0)
+
+	// the header written before the body is indexed with the body
+	c2 := &types.Header{
+		ParentHash: a1.Hash,
+		Number:     2,
+		Difficulty: 1,
+		ExtraData:  []byte{0xc},
+		StateRoot:  types.EmptyRootHash,
+	}
+	c2.ComputeHash()
+
+	assert.NoError(t, b.WriteHeader(c2))
+	expectLookup(t3, nil, 0)
+
+	assert.NoError(t, b.CommitBodies([]types.Hash{c2.Hash}, []*types.Body{{Transactions: []*types.Transaction{t3}}}))
+	expectLookup(t3, c2, 0)
+}
+
+// failingStorage fails to write the transaction lookups
+type failingStorage struct {
+	storage.Storage
+}
+
+func (f *failingStorage) WriteTxLookup(hash types.Hash, blockHash types.Hash, index uint64) error {
+	return fmt.Errorf("failed to write the lookup")
+}
+
+func (f *failingStorage) Batch(fn func(storage.Storage) error) error {
+	return f.Storage.Batch(func(db storage.Storage) error {
+		return fn(&failingStorage{db})
+	})
+}
+
+func TestWriteHeaderAtomic(t *testing.T) {
+	addr := types.StringToAddress("1")
+	txn := (&types.Transaction{
+		To:       &addr,
+		Value:    []byte{},
+		GasPrice: []byte{},
+		Input:    []byte{},
+		V:        0x1b,
+	}).ComputeHash()
+
+	genesis := &types.Header{Number: 0, ExtraData: []byte{}}
+	genesis.ComputeHash()
+
+	b := NewTestBlockchain(t, nil)
+	b.db = &failingStorage{b.db}
+	assert.NoError(t, b.WriteHeaderGenesis(genesis))
+
+	header := func(parent *types.Header, extra byte) *types.Header {
+		h := &types.Header{
+			ParentHash: parent.Hash,
+			Number:     parent.Number + 1,
+			Difficulty: 1,
+			ExtraData:  []byte{extra},
+		}
+		return h.ComputeHash()
+	}
+
+	a1 := header(genesis, 0xa)
+	assert.NoError(t, b.WriteHeader(a1))
+
+	expectHead := func() {
+		head, _ := b.Header()
+		assert.Equal(t, a1.Hash, head.Hash)
+
+		hash, _ := b.db.ReadCanonicalHash(1)
+		assert.Equal(t, a1.Hash, hash)
+
+		_, ok := b.db.ReadCanonicalHash(2)
+		assert.False(t, ok)
+	}
+
+	// the canonical header is not written if the lookups fail
+	a2 := header(a1, 0xa)
+	assert.NoError(t, b.CommitBodies([]types.Hash{a2.Hash}, []*types.Body{{Transactions: []*types.Transaction{txn}}}))
+	assert.Error(t, b.WriteHeader(a2))
+
+	expectHead()
+	_, ok := b.GetTD(a2.Hash)
+	assert.False(t, ok)
+
+	// the reorg is not written if the lookups fail
+	b1 := header(genesis, 0xb)
+	assert.NoError(t, b.WriteHeader(b1))
+	assert.Equal(t, []types.Hash{b1.Hash}, b.GetForks())
+
+	b2 := header(b1, 0xb)
+	assert.NoError(t, b.CommitBodies([]types.Hash{b2.Hash}, []*types.Body{{Transactions: []*types.Transaction{txn}}}))
+	assert.Error(t, b.WriteHeader(b2))
+
+	expectHead()
+	assert.Equal(t, []types.Hash{b1.Hash}, b.GetForks())
+}
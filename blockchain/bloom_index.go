@@ -0,0 +1,142 @@
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/umbracle/minimal/blockchain/bloombits"
+	"github.com/umbracle/minimal/blockchain/storage"
+	"github.com/umbracle/minimal/types"
+)
+
+// BloomSectionSize returns the number of blocks of a section in the bloom bits index
+func (b *Blockchain) BloomSectionSize() uint64 {
+	return b.bloomSectionSize
+}
+
+// BloomSections returns the number of sections in the bloom bits index
+func (b *Blockchain) BloomSections() uint64 {
+	n, _ := b.db.ReadBloomSections()
+	return n
+}
+
+// GetBloomBits returns the vector of a bloom bit in an indexed section
+func (b *Blockchain) GetBloomBits(bit uint, section uint64) ([]byte, error) {
+	data, ok := b.db.ReadBloomBits(bit, section)
+	if !ok {
+		return nil, fmt.Errorf("bloom bit %d of section %d not found", bit, section)
+	}
+	return bloombits.DecompressBytes(data, int(b.bloomSectionSize/8))
+}
+
+// notifyBloomIndexer wakes up the indexer of the bloom bits after the head
+// advances. The indexer is started with the first write of the chain.
+func (b *Blockchain) notifyBloomIndexer() {
+	b.bloomOnce.Do(func() {
+		b.wg.Add(1)
+		go b.runBloomIndexer()
+	})
+	select {
+	case b.bloomCh <- struct{}{}:
+	default:
+	}
+}
+
+// runBloomIndexer indexes the complete sections of the chain in the
+// background until the blockchain is closed
+func (b *Blockchain) runBloomIndexer() {
+	defer b.wg.Done()
+
+	for {
+		select {
+		case <-b.bloomCh:
+		case <-b.closeCh:
+			return
+		}
+		if err := b.indexBloomBits(); err != nil {
+			b.logger.Error("failed to index the bloom bits", "err", err)
+		}
+	}
+}
+
+// indexBloomBits adds to the index the sections of the canonical chain that
+// are complete with the current head. A section is built without the write
+// lock and it is only written if the chain did not change in between.
+func (b *Blockchain) indexBloomBits() error {
+	size := b.bloomSectionSize
+
+	for {
+		select {
+		case <-b.closeCh:
+			return nil
+		default:
+		}
+
+		head, ok := b.Header()
+		if !ok {
+			return nil
+		}
+		section := b.BloomSections()
+		if (section+1)*size > head.Number+1 {
+			return nil
+		}
+
+		gen, err := bloombits.NewGenerator(uint(size))
+		if err != nil {
+			return err
+		}
+		var last types.Hash
+		for j := uint64(0); j < size; j++ {
+			header, ok := b.GetHeaderByNumber(section*size + j)
+			if !ok {
+				return fmt.Errorf("header %d not found", section*size+j)
+			}
+			if err := gen.AddBloom(uint(j), header.LogsBloom); err != nil {
+				return err
+			}
+			last = header.Hash
+		}
+		vectors := make([][]byte, bloombits.BloomBitLength)
+		for bit := range vectors {
+			bits, err := gen.Bitset(uint(bit))
+			if err != nil {
+				return err
+			}
+			vectors[bit] = bloombits.CompressBytes(bits)
+		}
+		if err := b.writeBloomSection(section, last, vectors); err != nil {
+			return err
+		}
+	}
+}
+
+// writeBloomSection writes the bit vectors of a section unless the section
+// was indexed or its last block is not canonical anymore
+func (b *Blockchain) writeBloomSection(section uint64, last types.Hash, vectors [][]byte) error {
+	b.writeLock.Lock()
+	defer b.writeLock.Unlock()
+
+	if b.BloomSections() != section {
+		return nil
+	}
+	if hash, ok := b.db.ReadCanonicalHash((section+1)*b.bloomSectionSize - 1); !ok || hash != last {
+		return nil
+	}
+	return b.db.Batch(func(db storage.Storage) error {
+		for bit, vector := range vectors {
+			if err := db.WriteBloomBits(uint(bit), section, vector); err != nil {
+				return err
+			}
+		}
+		return db.WriteBloomSections(section + 1)
+	})
+}
+
+// truncateBloomBits removes from the index the sections with blocks
+// above the given number after they are not canonical anymore
+func (b *Blockchain) truncateBloomBits(db storage.Storage, number uint64) error {
+	sections := (number + 1) / b.bloomSectionSize
+	if n, _ := db.ReadBloomSections(); sections >= n {
+		return nil
+	}
+	return db.WriteBloomSections(sections)
+}
@@ -0,0 +1,118 @@
+package bloombits
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/umbracle/minimal/types"
+)
+
+func TestCompress(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{0, 1, 8, 100, 512} {
+		for _, fill := range []int{0, 1, n / 4, n} {
+			data := make([]byte, n)
+			for i := 0; i < fill && n > 0; i++ {
+				data[r.Intn(n)] = byte(1 + r.Intn(255))
+			}
+
+			comp := CompressBytes(data)
+			if len(comp) > len(data) {
+				t.Fatalf("compressed %d bytes into %d", len(data), len(comp))
+			}
+			res, err := DecompressBytes(comp, n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(res, data) {
+				t.Fatalf("bad decompression of %d bytes", n)
+			}
+		}
+	}
+
+	// an empty vector is compressed to nothing
+	if comp := CompressBytes(make([]byte, 512)); len(comp) != 0 {
+		t.Fatal("expected an empty output")
+	}
+	if _, err := DecompressBytes([]byte{0x1, 0x2}, 1); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func bloomOf(values ...[]byte) (b types.Bloom) {
+	for _, v := range values {
+		for _, bit := range calcBloomIndexes(v) {
+			b[types.BloomByteLength-1-bit/8] |= 1 << (bit % 8)
+		}
+	}
+	return
+}
+
+func TestMatcher(t *testing.T) {
+	addr1, addr2 := []byte{0x1}, []byte{0x2}
+	topic1, topic2 := []byte{0x3}, []byte{0x4}
+
+	blooms := []types.Bloom{
+		bloomOf(),
+		bloomOf(addr1),
+		bloomOf(addr1, topic1),
+		bloomOf(addr2, topic2),
+		bloomOf(addr2, topic1),
+		bloomOf(),
+		bloomOf(addr1, topic2),
+		bloomOf(addr2),
+	}
+
+	gen, err := NewGenerator(uint(len(blooms)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, bloom := range blooms {
+		if err := gen.AddBloom(uint(i), bloom); err != nil {
+			t.Fatal(err)
+		}
+	}
+	retrieve := func(bit uint, section uint64) ([]byte, error) {
+		return gen.Bitset(bit)
+	}
+
+	cases := []struct {
+		filters [][][]byte
+		blocks  []int
+	}{
+		{nil, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{[][][]byte{{addr1}}, []int{1, 2, 6}},
+		{[][][]byte{{addr1, addr2}}, []int{1, 2, 3, 4, 6, 7}},
+		{[][][]byte{{addr2}, {topic1}}, []int{4}},
+		{[][][]byte{{}, {topic2}}, []int{3, 6}},
+		{[][][]byte{{addr1, addr2}, {topic1, topic2}}, []int{2, 3, 4, 6}},
+	}
+	for _, c := range cases {
+		m := NewMatcher(uint64(len(blooms)), c.filters)
+
+		res, err := m.Match(0, retrieve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := []int{}
+		for i := range blooms {
+			match := res[i/8]&(1<<byte(7-i%8)) != 0
+			if match != m.MatchBloom(blooms[i]) {
+				t.Fatalf("the vector and the bloom of block %d do not match", i)
+			}
+			if match {
+				found = append(found, i)
+			}
+		}
+		if len(found) != len(c.blocks) {
+			t.Fatalf("expected blocks %v but found %v", c.blocks, found)
+		}
+		for i := range found {
+			if found[i] != c.blocks[i] {
+				t.Fatalf("expected blocks %v but found %v", c.blocks, found)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package bloombits
+
+import (
+	"fmt"
+)
+
+var (
+	errMissingData   = fmt.Errorf("missing bytes on input")
+	errUnreferenced  = fmt.Errorf("extra bytes on input")
+	errExceededSize  = fmt.Errorf("target size exceeded")
+	errZeroContent   = fmt.Errorf("zero byte in input content")
+	errCorruptedData = fmt.Errorf("input is larger than the target")
+)
+
+// CompressBytes compresses a sparse vector. The non zero bytes are
+// stored after a bitset of their positions, which is compressed too.
+// The input is returned unchanged if the compression does not help.
+func CompressBytes(data []byte) []byte {
+	if out := bitsetEncodeBytes(data); len(out) < len(data) {
+		return out
+	}
+	cpy := make([]byte, len(data))
+	copy(cpy, data)
+	return cpy
+}
+
+func bitsetEncodeBytes(data []byte) []byte {
+	if len(data) == 0 {
+		return nil
+	}
+	if len(data) == 1 {
+		if data[0] == 0 {
+			return nil
+		}
+		return data
+	}
+
+	nonZeroBitset := make([]byte, (len(data)+7)/8)
+	nonZeroBytes := make([]byte, 0, len(data))
+
+	for i, b := range data {
+		if b != 0 {
+			nonZeroBytes = append(nonZeroBytes, b)
+			nonZeroBitset[i/8] |= 1 << byte(7-i%8)
+		}
+	}
+	if len(nonZeroBytes) == 0 {
+		return nil
+	}
+	return append(bitsetEncodeBytes(nonZeroBitset), nonZeroBytes...)
+}
+
+// DecompressBytes decompresses a vector of the given size
+func DecompressBytes(data []byte, target int) ([]byte, error) {
+	if len(data) > target {
+		return nil, errCorruptedData
+	}
+	if len(data) == target {
+		cpy := make([]byte, len(data))
+		copy(cpy, data)
+		return cpy, nil
+	}
+
+	out, size, err := bitsetDecodePartialBytes(data, target)
+	if err != nil {
+		return nil, err
+	}
+	if size != len(data) {
+		return nil, errUnreferenced
+	}
+	return out, nil
+}
+
+// bitsetDecodePartialBytes decodes a vector and returns the
+// number of bytes of the input used
+func bitsetDecodePartialBytes(data []byte, target int) ([]byte, int, error) {
+	if target == 0 {
+		return nil, 0, nil
+	}
+
+	decomp := make([]byte, target)
+	if len(data) == 0 {
+		return decomp, 0, nil
+	}
+	if target == 1 {
+		decomp[0] = data[0]
+		if data[0] != 0 {
+			return decomp, 1, nil
+		}
+		return decomp, 0, nil
+	}
+
+	nonZeroBitset, ptr, err := bitsetDecodePartialBytes(data, (target+7)/8)
+	if err != nil {
+		return nil, ptr, err
+	}
+	for i := 0; i < 8*len(nonZeroBitset); i++ {
+		if nonZeroBitset[i/8]&(1<<byte(7-i%8)) != 0 {
+			if ptr >= len(data) {
+				return nil, 0, errMissingData
+			}
+			if i >= len(decomp) {
+				return nil, 0, errExceededSize
+			}
+			if data[ptr] == 0 {
+				return nil, 0, errZeroContent
+			}
+			decomp[i] = data[ptr]
+			ptr++
+		}
+	}
+	return decomp, ptr, nil
+}
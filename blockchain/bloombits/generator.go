@@ -0,0 +1,70 @@
+package bloombits
+
+import (
+	"fmt"
+
+	"github.com/umbracle/minimal/types"
+)
+
+// SectionSize is the default number of blocks in a section of the index
+const SectionSize = 4096
+
+// BloomBitLength is the number of bits in a header bloom
+const BloomBitLength = 8 * types.BloomByteLength
+
+// Generator rotates the blooms of the blocks of a section into
+// one vector per bloom bit. The n-th bit of the vector of a bloom
+// bit is set if the bloom of the n-th block of the section has it.
+type Generator struct {
+	blooms   [BloomBitLength][]byte
+	sections uint
+	next     uint
+}
+
+// NewGenerator creates a generator for a section of the given number of blocks
+func NewGenerator(sections uint) (*Generator, error) {
+	if sections%8 != 0 {
+		return nil, fmt.Errorf("section size %d is not a multiple of 8", sections)
+	}
+	g := &Generator{sections: sections}
+	for i := range g.blooms {
+		g.blooms[i] = make([]byte, sections/8)
+	}
+	return g, nil
+}
+
+// AddBloom adds the bloom of the block with the given position in the section.
+// The blooms have to be added in order.
+func (g *Generator) AddBloom(index uint, bloom types.Bloom) error {
+	if g.next >= g.sections {
+		return fmt.Errorf("section is full")
+	}
+	if g.next != index {
+		return fmt.Errorf("expected bloom %d but found %d", g.next, index)
+	}
+
+	byteIndex := g.next / 8
+	bitMask := byte(1) << byte(7-g.next%8)
+
+	for i := 0; i < BloomBitLength; i++ {
+		bloomByteIndex := types.BloomByteLength - 1 - i/8
+		bloomBitMask := byte(1) << byte(i%8)
+
+		if bloom[bloomByteIndex]&bloomBitMask != 0 {
+			g.blooms[i][byteIndex] |= bitMask
+		}
+	}
+	g.next++
+	return nil
+}
+
+// Bitset returns the vector of a bloom bit once all the blooms are added
+func (g *Generator) Bitset(bit uint) ([]byte, error) {
+	if g.next != g.sections {
+		return nil, fmt.Errorf("section is not complete")
+	}
+	if bit >= BloomBitLength {
+		return nil, fmt.Errorf("bloom bit %d out of bounds", bit)
+	}
+	return g.blooms[bit], nil
+}
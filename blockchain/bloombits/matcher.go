@@ -0,0 +1,127 @@
+package bloombits
+
+import (
+	"github.com/umbracle/minimal/helper/keccak"
+	"github.com/umbracle/minimal/types"
+)
+
+// bloomIndexes are the three bloom bits set by a value
+type bloomIndexes [3]uint
+
+func calcBloomIndexes(b []byte) bloomIndexes {
+	buf := keccak.Keccak256(nil, b)
+
+	var idxs bloomIndexes
+	for i := 0; i < len(idxs); i++ {
+		idxs[i] = (uint(buf[2*i])<<8 | uint(buf[2*i+1])) & (BloomBitLength - 1)
+	}
+	return idxs
+}
+
+// Retriever returns the vector of a bloom bit in a section
+type Retriever func(bit uint, section uint64) ([]byte, error)
+
+// Matcher finds the blocks whose bloom may include the logs of a filter.
+// The filter is a list of groups, a block matches if for every group its
+// bloom includes any of the values of the group. An empty group matches
+// all the blocks.
+type Matcher struct {
+	sectionSize uint64
+	filters     [][]bloomIndexes
+}
+
+// NewMatcher creates a matcher for sections of the given size
+func NewMatcher(sectionSize uint64, filters [][][]byte) *Matcher {
+	m := &Matcher{
+		sectionSize: sectionSize,
+		filters:     [][]bloomIndexes{},
+	}
+	for _, group := range filters {
+		if len(group) == 0 {
+			continue
+		}
+		idxs := []bloomIndexes{}
+		for _, value := range group {
+			idxs = append(idxs, calcBloomIndexes(value))
+		}
+		m.filters = append(m.filters, idxs)
+	}
+	return m
+}
+
+// MatchBloom checks if a single bloom matches the filter
+func (m *Matcher) MatchBloom(bloom types.Bloom) bool {
+	for _, group := range m.filters {
+		found := false
+		for _, idxs := range group {
+			if bloomHasIndexes(bloom, idxs) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func bloomHasIndexes(bloom types.Bloom, idxs bloomIndexes) bool {
+	for _, bit := range idxs {
+		if bloom[types.BloomByteLength-1-bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Match returns the vector of the blocks of a section that match the filter
+func (m *Matcher) Match(section uint64, retrieve Retriever) ([]byte, error) {
+	// the same bit can be part of more than one value
+	vectors := map[uint][]byte{}
+	get := func(bit uint) ([]byte, error) {
+		if v, ok := vectors[bit]; ok {
+			return v, nil
+		}
+		v, err := retrieve(bit, section)
+		if err != nil {
+			return nil, err
+		}
+		vectors[bit] = v
+		return v, nil
+	}
+
+	res := make([]byte, m.sectionSize/8)
+	for i := range res {
+		res[i] = 0xff
+	}
+	for _, group := range m.filters {
+		groupRes := make([]byte, len(res))
+		for _, idxs := range group {
+			valueRes := make([]byte, len(res))
+			copy(valueRes, res)
+			for _, bit := range idxs {
+				v, err := get(bit)
+				if err != nil {
+					return nil, err
+				}
+				andBytes(valueRes, v)
+			}
+			orBytes(groupRes, valueRes)
+		}
+		res = groupRes
+	}
+	return res, nil
+}
+
+func andBytes(dst, src []byte) {
+	for i := range dst {
+		dst[i] &= src[i]
+	}
+}
+
+func orBytes(dst, src []byte) {
+	for i := range dst {
+		dst[i] |= src[i]
+	}
+}
@@ -0,0 +1,260 @@
+package blockchain
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/umbracle/minimal/types"
+)
+
+// eventBufferSize is the number of events a subscription keeps
+// before it starts to drop them
+const eventBufferSize = 128
+
+// EventType is the type of a chain event
+type EventType int
+
+const (
+	// NewHead is the new head of the chain
+	NewHead EventType = iota
+
+	// ChainReorg replaces a segment of the canonical chain with another
+	ChainReorg
+
+	// SideBlock is a new block that is not part of the canonical chain
+	SideBlock
+
+	// LogsAdded are the logs of the blocks added to the canonical chain
+	LogsAdded
+
+	// LogsRemoved are the logs of the blocks removed from the canonical chain
+	LogsRemoved
+)
+
+func (t EventType) String() string {
+	switch t {
+	case NewHead:
+		return "new head"
+	case ChainReorg:
+		return "chain reorg"
+	case SideBlock:
+		return "side block"
+	case LogsAdded:
+		return "logs added"
+	case LogsRemoved:
+		return "logs removed"
+	default:
+		panic(fmt.Sprintf("unknown event type: %d", t))
+	}
+}
+
+// Event is a change of the chain. The events are shared between the
+// subscriptions and must not be modified.
+type Event struct {
+	Type EventType
+
+	// Header is the head of NewHead and the block of SideBlock
+	Header *types.Header
+
+	// OldChain and NewChain are the blocks removed and added by ChainReorg
+	// from the newest to the oldest, the common ancestor is not included.
+	// NewChain is empty if the chain is rewound.
+	OldChain []*types.Header
+	NewChain []*types.Header
+
+	// Logs are the logs of LogsAdded and LogsRemoved, the removed
+	// logs have the Removed flag set
+	Logs []*types.Log
+}
+
+func (e *Event) isLog() bool {
+	return e.Type == LogsAdded || e.Type == LogsRemoved
+}
+
+// Subscription receives the events of the chain. The writes of the chain
+// do not wait for the subscriptions, the events are dropped if the buffer
+// of the subscription is full.
+type Subscription struct {
+	bus     *eventBus
+	eventCh chan *Event
+	dropped uint64
+
+	// logs is set if the subscription receives the log events
+	logs bool
+}
+
+// EventCh returns the channel of the events, it is closed once
+// the subscription is removed
+func (s *Subscription) EventCh() <-chan *Event {
+	return s.eventCh
+}
+
+// Dropped returns the number of events dropped because the buffer was full
+func (s *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Unsubscribe removes the subscription
+func (s *Subscription) Unsubscribe() {
+	s.bus.unsubscribe(s)
+}
+
+type eventBus struct {
+	lock sync.Mutex
+	subs map[*Subscription]struct{}
+
+	// logSubs is the number of subscriptions to the log events
+	logSubs int
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{
+		subs: map[*Subscription]struct{}{},
+	}
+}
+
+func (e *eventBus) subscribe(size int, logs bool) *Subscription {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	sub := &Subscription{
+		bus:     e,
+		eventCh: make(chan *Event, size),
+		logs:    logs,
+	}
+	e.subs[sub] = struct{}{}
+	if logs {
+		e.logSubs++
+	}
+	return sub
+}
+
+func (e *eventBus) unsubscribe(sub *Subscription) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if _, ok := e.subs[sub]; ok {
+		delete(e.subs, sub)
+		close(sub.eventCh)
+		if sub.logs {
+			e.logSubs--
+		}
+	}
+}
+
+// hasLogSubs returns whether any subscription receives the log events
+func (e *eventBus) hasLogSubs() bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	return e.logSubs != 0
+}
+
+func (e *eventBus) publish(events ...*Event) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	for sub := range e.subs {
+		for _, ev := range events {
+			if ev.isLog() && !sub.logs {
+				continue
+			}
+			select {
+			case sub.eventCh <- ev:
+			default:
+				atomic.AddUint64(&sub.dropped, 1)
+			}
+		}
+	}
+}
+
+// Subscribe returns a subscription to the events of the chain,
+// the log events are not included
+func (b *Blockchain) Subscribe() *Subscription {
+	return b.events.subscribe(eventBufferSize, false)
+}
+
+// SubscribeLogs returns a subscription to the events of the chain
+// along with the log events
+func (b *Blockchain) SubscribeLogs() *Subscription {
+	return b.events.subscribe(eventBufferSize, true)
+}
+
+// chainEvents are the changes of a write, the events are
+// published once the write is in the storage
+type chainEvents struct {
+	sideBlocks []*types.Header
+
+	// reorg is set if the write replaces blocks of the canonical chain
+	reorg    bool
+	oldChain []*types.Header
+	newChain []*types.Header
+}
+
+// publish sends the events of a write that moved the head from prev. The
+// log events read the receipts, they are only built if someone receives them.
+func (b *Blockchain) publish(c *chainEvents, prev types.Hash) {
+	logs := b.events.hasLogSubs()
+
+	events := []*Event{}
+	for _, header := range c.sideBlocks {
+		events = append(events, &Event{Type: SideBlock, Header: header})
+	}
+
+	if head, ok := b.Header(); ok && head.Hash != prev {
+		if c.reorg {
+			events = append(events, b.reorgEvents(c.oldChain, c.newChain, logs)...)
+		} else if logs {
+			if added := b.blockLogs(head, false); len(added) != 0 {
+				events = append(events, &Event{Type: LogsAdded, Logs: added})
+			}
+		}
+		events = append(events, &Event{Type: NewHead, Header: head})
+	}
+
+	if len(events) != 0 {
+		b.events.publish(events...)
+	}
+}
+
+// reorgEvents returns the events of a reorg, the log events are included
+// if logs is set. The logs of the old chain have to be read before the
+// receipts are removed.
+func (b *Blockchain) reorgEvents(oldChain, newChain []*types.Header, logs bool) []*Event {
+	events := []*Event{
+		{Type: ChainReorg, OldChain: oldChain, NewChain: newChain},
+	}
+	if !logs {
+		return events
+	}
+
+	removed := []*types.Log{}
+	for _, header := range oldChain {
+		removed = append(removed, b.blockLogs(header, true)...)
+	}
+	if len(removed) != 0 {
+		events = append(events, &Event{Type: LogsRemoved, Logs: removed})
+	}
+
+	// the logs are added from the oldest block
+	added := []*types.Log{}
+	for i := len(newChain) - 1; i >= 0; i-- {
+		added = append(added, b.blockLogs(newChain[i], false)...)
+	}
+	if len(added) != 0 {
+		events = append(events, &Event{Type: LogsAdded, Logs: added})
+	}
+	return events
+}
+
+func (b *Blockchain) blockLogs(header *types.Header, removed bool) []*types.Log {
+	if header.ReceiptsRoot == types.EmptyRootHash {
+		return nil
+	}
+	logs := b.GetBlockLogs(header, &LogFilter{})
+	for _, log := range logs {
+		log.Removed = removed
+	}
+	return logs
+}
@@ -0,0 +1,175 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/minimal/types"
+)
+
+func TestEvents(t *testing.T) {
+	genesis := &types.Header{Number: 0, ExtraData: []byte{}, StateRoot: types.EmptyRootHash}
+	genesis.ComputeHash()
+
+	b := NewTestBlockchain(t, []*types.Header{genesis})
+
+	// write a block with a transaction that emits a log
+	write := func(parent *types.Header, extra byte) *types.Header {
+		number := parent.Number + 1
+
+		addr := types.StringToAddress("1")
+		txn := (&types.Transaction{
+			Nonce:    number,
+			To:       &addr,
+			Value:    []byte{},
+			GasPrice: []byte{},
+			Input:    []byte{extra},
+			V:        0x1b,
+		}).ComputeHash()
+
+		status := types.ReceiptSuccess
+		receipts := []*types.Receipt{
+			{
+				Status: &status,
+				Logs: []*types.Log{
+					{Address: addr, Topics: []types.Hash{}, Data: []byte{extra}},
+				},
+			},
+		}
+
+		header := &types.Header{
+			ParentHash: parent.Hash,
+			Number:     number,
+			Difficulty: 1,
+			ExtraData:  []byte{extra},
+			StateRoot:  types.EmptyRootHash,
+		}
+		header.ComputeHash()
+
+		assert.NoError(t, b.CommitBodies([]types.Hash{header.Hash}, []*types.Body{{Transactions: []*types.Transaction{txn}}}))
+		assert.NoError(t, b.CommitReceipts([]types.Hash{header.Hash}, [][]*types.Receipt{receipts}))
+		assert.NoError(t, b.WriteHeader(header))
+		return header
+	}
+
+	sub := b.SubscribeLogs()
+
+	// events returns the events received so far
+	events := func() []*Event {
+		res := []*Event{}
+		for {
+			select {
+			case ev := <-sub.EventCh():
+				res = append(res, ev)
+			default:
+				return res
+			}
+		}
+	}
+
+	// logBlocks returns the blocks of the logs and checks the removed flag
+	logBlocks := func(ev *Event, removed bool) []types.Hash {
+		hashes := []types.Hash{}
+		for _, log := range ev.Logs {
+			assert.Equal(t, removed, log.Removed)
+			hashes = append(hashes, log.BlockHash)
+		}
+		return hashes
+	}
+
+	// the canonical chain advances
+	a1 := write(genesis, 0xa)
+	a2 := write(a1, 0xa)
+
+	evs := events()
+	assert.Len(t, evs, 4)
+	assert.Equal(t, LogsAdded, evs[0].Type)
+	assert.Equal(t, []types.Hash{a1.Hash}, logBlocks(evs[0], false))
+	assert.Equal(t, NewHead, evs[1].Type)
+	assert.Equal(t, a1.Hash, evs[1].Header.Hash)
+	assert.Equal(t, LogsAdded, evs[2].Type)
+	assert.Equal(t, NewHead, evs[3].Type)
+	assert.Equal(t, a2.Hash, evs[3].Header.Hash)
+
+	// the blocks of a fork with the same difficulty are side blocks
+	b1 := write(genesis, 0xb)
+	b2 := write(b1, 0xb)
+
+	evs = events()
+	assert.Len(t, evs, 2)
+	for i, header := range []*types.Header{b1, b2} {
+		assert.Equal(t, SideBlock, evs[i].Type)
+		assert.Equal(t, header.Hash, evs[i].Header.Hash)
+	}
+
+	// the fork becomes the canonical chain
+	b3 := write(b2, 0xb)
+
+	evs = events()
+	assert.Len(t, evs, 4)
+	assert.Equal(t, ChainReorg, evs[0].Type)
+	assert.Equal(t, []*types.Header{a2, a1}, evs[0].OldChain)
+	assert.Equal(t, []*types.Header{b3, b2, b1}, evs[0].NewChain)
+	assert.Equal(t, LogsRemoved, evs[1].Type)
+	assert.Equal(t, []types.Hash{a2.Hash, a1.Hash}, logBlocks(evs[1], true))
+	assert.Equal(t, LogsAdded, evs[2].Type)
+	assert.Equal(t, []types.Hash{b1.Hash, b2.Hash, b3.Hash}, logBlocks(evs[2], false))
+	assert.Equal(t, NewHead, evs[3].Type)
+	assert.Equal(t, b3.Hash, evs[3].Header.Hash)
+
+	// the rewind of the chain removes the blocks
+	_, err := b.SetHead(1)
+	assert.NoError(t, err)
+
+	evs = events()
+	assert.Len(t, evs, 3)
+	assert.Equal(t, ChainReorg, evs[0].Type)
+	assert.Equal(t, []*types.Header{b3, b2}, evs[0].OldChain)
+	assert.Empty(t, evs[0].NewChain)
+	assert.Equal(t, LogsRemoved, evs[1].Type)
+	assert.Equal(t, []types.Hash{b3.Hash, b2.Hash}, logBlocks(evs[1], true))
+	assert.Equal(t, NewHead, evs[2].Type)
+	assert.Equal(t, b1.Hash, evs[2].Header.Hash)
+
+	// the channel is closed once the subscription is removed
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+
+	write(b1, 0xb)
+	_, ok := <-sub.EventCh()
+	assert.False(t, ok)
+}
+
+func TestEventsDropped(t *testing.T) {
+	bus := newEventBus()
+
+	full := bus.subscribe(1, false)
+	sub := bus.subscribe(5, false)
+
+	// the events of the full subscription are dropped
+	bus.publish(&Event{Type: NewHead}, &Event{Type: NewHead}, &Event{Type: NewHead})
+
+	assert.Equal(t, uint64(2), full.Dropped())
+	assert.Equal(t, uint64(0), sub.Dropped())
+	assert.Len(t, full.EventCh(), 1)
+	assert.Len(t, sub.EventCh(), 3)
+}
+
+func TestEventsLogs(t *testing.T) {
+	bus := newEventBus()
+
+	sub := bus.subscribe(5, false)
+	assert.False(t, bus.hasLogSubs())
+
+	logSub := bus.subscribe(5, true)
+	assert.True(t, bus.hasLogSubs())
+
+	// only the subscriptions to the logs receive the log events
+	bus.publish(&Event{Type: LogsRemoved}, &Event{Type: LogsAdded}, &Event{Type: NewHead})
+
+	assert.Len(t, sub.EventCh(), 1)
+	assert.Len(t, logSub.EventCh(), 3)
+
+	logSub.Unsubscribe()
+	assert.False(t, bus.hasLogSubs())
+}
@@ -0,0 +1,184 @@
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/umbracle/minimal/blockchain/bloombits"
+	"github.com/umbracle/minimal/types"
+)
+
+// LogFilter is a query for the logs of the canonical chain
+type LogFilter struct {
+	// FromBlock and ToBlock are the range of blocks, both included
+	FromBlock uint64
+	ToBlock   uint64
+
+	// Addresses are the contracts of the logs, any of them matches.
+	// The logs of any contract match if it is empty.
+	Addresses []types.Address
+
+	// Topics are the topics of the logs by position, a log matches if
+	// it has any of the topics in each position. An empty position
+	// matches any topic.
+	Topics [][]types.Hash
+
+	// Limit is the maximum number of logs of the query, it fails if
+	// there are more of them. There is no limit if it is zero.
+	Limit uint64
+}
+
+// Match checks if a log matches the filter
+func (f *LogFilter) Match(log *types.Log) bool {
+	if len(f.Addresses) != 0 {
+		found := false
+		for _, addr := range f.Addresses {
+			if addr == log.Address {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.Topics) > len(log.Topics) {
+		return false
+	}
+	for i, topics := range f.Topics {
+		if len(topics) == 0 {
+			continue
+		}
+		found := false
+		for _, topic := range topics {
+			if topic == log.Topics[i] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// checkLimit returns an error if the number of logs is above the limit
+func (f *LogFilter) checkLimit(n int) error {
+	if f.Limit != 0 && uint64(n) > f.Limit {
+		return fmt.Errorf("query returned more than %d logs", f.Limit)
+	}
+	return nil
+}
+
+func (f *LogFilter) matcher(sectionSize uint64) *bloombits.Matcher {
+	filters := [][][]byte{}
+
+	addrs := [][]byte{}
+	for _, addr := range f.Addresses {
+		addrs = append(addrs, addr.Bytes())
+	}
+	filters = append(filters, addrs)
+
+	for _, topics := range f.Topics {
+		group := [][]byte{}
+		for _, topic := range topics {
+			group = append(group, topic.Bytes())
+		}
+		filters = append(filters, group)
+	}
+	return bloombits.NewMatcher(sectionSize, filters)
+}
+
+// GetLogs returns the logs of the canonical chain that match the filter. The
+// blocks in the indexed sections are found with the bloom bits index and the
+// rest with the bloom of their headers.
+func (b *Blockchain) GetLogs(filter *LogFilter) ([]*types.Log, error) {
+	if filter.FromBlock > filter.ToBlock {
+		return nil, fmt.Errorf("block %d is after block %d", filter.FromBlock, filter.ToBlock)
+	}
+
+	size := b.bloomSectionSize
+	indexed := b.BloomSections() * size
+	matcher := filter.matcher(size)
+
+	logs := []*types.Log{}
+	addBlock := func(number uint64) error {
+		header, ok := b.GetHeaderByNumber(number)
+		if !ok {
+			return fmt.Errorf("header %d not found", number)
+		}
+		logs = append(logs, b.GetBlockLogs(header, filter)...)
+		return filter.checkLimit(len(logs))
+	}
+
+	number := filter.FromBlock
+	for number <= filter.ToBlock && number < indexed {
+		section := number / size
+		bits, err := matcher.Match(section, b.GetBloomBits)
+		if err != nil {
+			return nil, err
+		}
+
+		end := (section+1)*size - 1
+		if end > filter.ToBlock {
+			end = filter.ToBlock
+		}
+		for ; number <= end; number++ {
+			i := number - section*size
+			if bits[i/8]&(1<<byte(7-i%8)) == 0 {
+				continue
+			}
+			if err := addBlock(number); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for ; number <= filter.ToBlock; number++ {
+		header, ok := b.GetHeaderByNumber(number)
+		if !ok {
+			return nil, fmt.Errorf("header %d not found", number)
+		}
+		if matcher.MatchBloom(header.LogsBloom) {
+			logs = append(logs, b.GetBlockLogs(header, filter)...)
+			if err := filter.checkLimit(len(logs)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return logs, nil
+}
+
+// GetBlockLogs returns the logs of a block that match the filter with
+// their position in the block. The range of the filter is not checked.
+func (b *Blockchain) GetBlockLogs(header *types.Header, filter *LogFilter) []*types.Log {
+	receipts := b.GetReceiptsByHash(header.Hash)
+	if len(receipts) == 0 {
+		return nil
+	}
+	body, ok := b.readBody(header.Hash)
+	if !ok || len(body.Transactions) != len(receipts) {
+		return nil
+	}
+
+	logs := []*types.Log{}
+	logIndex := uint(0)
+	for i, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			if filter.Match(log) {
+				logs = append(logs, &types.Log{
+					Address:     log.Address,
+					Topics:      log.Topics,
+					Data:        log.Data,
+					BlockNumber: header.Number,
+					TxHash:      body.Transactions[i].Hash,
+					TxIndex:     uint(i),
+					BlockHash:   header.Hash,
+					LogIndex:    logIndex,
+				})
+			}
+			logIndex++
+		}
+	}
+	return logs
+}
@@ -0,0 +1,123 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/minimal/types"
+)
+
+func TestGetLogs(t *testing.T) {
+	addr1, addr2 := types.StringToAddress("1"), types.StringToAddress("2")
+
+	// the topic of the log of a block depends on its number
+	topic := func(number uint64) types.Hash {
+		return types.BytesToHash([]byte{byte(number%3) + 1})
+	}
+
+	genesis := &types.Header{Number: 0, ExtraData: []byte{}, StateRoot: types.EmptyRootHash}
+	genesis.ComputeHash()
+
+	b := NewTestBlockchain(t, nil)
+	b.bloomSectionSize = 8
+	assert.NoError(t, b.WriteHeaderGenesis(genesis))
+
+	// write a block with a transaction that emits a log
+	write := func(parent *types.Header, extra byte, addr types.Address) *types.Header {
+		number := parent.Number + 1
+
+		txn := (&types.Transaction{
+			Nonce:    number,
+			To:       &addr,
+			Value:    []byte{},
+			GasPrice: []byte{},
+			Input:    []byte{extra},
+			V:        0x1b,
+		}).ComputeHash()
+
+		status := types.ReceiptSuccess
+		receipts := []*types.Receipt{
+			{
+				Status: &status,
+				Logs: []*types.Log{
+					{Address: addr, Topics: []types.Hash{topic(number)}, Data: []byte{}},
+				},
+			},
+		}
+
+		header := &types.Header{
+			ParentHash: parent.Hash,
+			Number:     number,
+			Difficulty: 1,
+			ExtraData:  []byte{extra},
+			StateRoot:  types.EmptyRootHash,
+			LogsBloom:  types.CreateBloom(receipts),
+		}
+		header.ComputeHash()
+
+		assert.NoError(t, b.CommitBodies([]types.Hash{header.Hash}, []*types.Body{{Transactions: []*types.Transaction{txn}}}))
+		assert.NoError(t, b.CommitReceipts([]types.Hash{header.Hash}, [][]*types.Receipt{receipts}))
+		assert.NoError(t, b.WriteHeader(header))
+		return header
+	}
+
+	headers := []*types.Header{genesis}
+	for i := 1; i <= 20; i++ {
+		addr := addr2
+		if i%2 == 0 {
+			addr = addr1
+		}
+		headers = append(headers, write(headers[i-1], 0xa, addr))
+	}
+
+	// the first two sections are complete
+	assert.NoError(t, b.indexBloomBits())
+	assert.Equal(t, uint64(2), b.BloomSections())
+
+	expectLogs := func(filter *LogFilter, blocks ...uint64) {
+		logs, err := b.GetLogs(filter)
+		assert.NoError(t, err)
+
+		found := []uint64{}
+		for _, log := range logs {
+			found = append(found, log.BlockNumber)
+
+			header, _ := b.GetHeaderByNumber(log.BlockNumber)
+			assert.Equal(t, header.Hash, log.BlockHash)
+		}
+		if len(blocks) == 0 {
+			blocks = []uint64{}
+		}
+		assert.Equal(t, blocks, found)
+	}
+
+	expectLogs(&LogFilter{FromBlock: 0, ToBlock: 20, Addresses: []types.Address{addr1}}, 2, 4, 6, 8, 10, 12, 14, 16, 18, 20)
+	expectLogs(&LogFilter{FromBlock: 5, ToBlock: 17, Addresses: []types.Address{addr1}}, 6, 8, 10, 12, 14, 16)
+	expectLogs(&LogFilter{FromBlock: 0, ToBlock: 20, Addresses: []types.Address{addr1}, Topics: [][]types.Hash{{topic(0)}}}, 6, 12, 18)
+	expectLogs(&LogFilter{FromBlock: 0, ToBlock: 20, Topics: [][]types.Hash{{}, {topic(0)}}})
+	expectLogs(&LogFilter{FromBlock: 17, ToBlock: 20}, 17, 18, 19, 20)
+
+	_, err := b.GetLogs(&LogFilter{FromBlock: 10, ToBlock: 5})
+	assert.Error(t, err)
+
+	// a fork from block 10 with only logs of the second address
+	// replaces the chain once it has more difficulty
+	parent := headers[10]
+	for i := 11; i <= 21; i++ {
+		parent = write(parent, 0xb, addr2)
+	}
+	head, _ := b.Header()
+	assert.Equal(t, parent.Hash, head.Hash)
+
+	// the second section is indexed again
+	assert.NoError(t, b.indexBloomBits())
+	assert.Equal(t, uint64(2), b.BloomSections())
+	expectLogs(&LogFilter{FromBlock: 0, ToBlock: 21, Addresses: []types.Address{addr1}}, 2, 4, 6, 8, 10)
+
+	// the rewind removes the sections above the head
+	_, err = b.SetHead(5)
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint64(0), b.BloomSections())
+	expectLogs(&LogFilter{FromBlock: 0, ToBlock: 5, Addresses: []types.Address{addr1}}, 2, 4)
+}
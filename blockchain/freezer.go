@@ -0,0 +1,119 @@
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/umbracle/minimal/blockchain/storage"
+)
+
+// FreezerThreshold is the number of blocks below the head after which the
+// blocks are final and they are moved to the ancient store of the storage
+const FreezerThreshold = 90000
+
+// freezerBatch is the number of blocks moved to the ancient store at once
+const freezerBatch = 2048
+
+// notifyFreezer wakes up the freezer after the head advances. The freezer
+// is started with the first write of a chain with an ancient store.
+func (b *Blockchain) notifyFreezer() {
+	if _, ok := b.Ancients(); !ok {
+		return
+	}
+	b.freezerOnce.Do(func() {
+		b.wg.Add(1)
+		go b.runFreezer()
+	})
+	select {
+	case b.freezerCh <- struct{}{}:
+	default:
+	}
+}
+
+// runFreezer moves the final blocks to the ancient store in the
+// background until the blockchain is closed
+func (b *Blockchain) runFreezer() {
+	defer b.wg.Done()
+
+	for {
+		select {
+		case <-b.freezerCh:
+		case <-b.closeCh:
+			return
+		}
+		if err := b.freeze(); err != nil {
+			b.logger.Error("failed to freeze the blocks", "err", err)
+		}
+	}
+}
+
+// freeze moves the final blocks to the ancient store in batches while
+// there are enough of them. It does nothing if the storage has no ancient store.
+func (b *Blockchain) freeze() error {
+	st, ok := b.db.(storage.AncientStorage)
+	if !ok {
+		return nil
+	}
+	for {
+		select {
+		case <-b.closeCh:
+			return nil
+		default:
+		}
+
+		done, err := b.freezeBatch(st)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// freezeBatch moves the next batch of final blocks to the ancient store.
+// It returns true if there are not enough blocks for a batch.
+func (b *Blockchain) freezeBatch(st storage.AncientStorage) (bool, error) {
+	b.writeLock.Lock()
+	defer b.writeLock.Unlock()
+
+	head, ok := b.Header()
+	if !ok || head.Number < b.freezerThreshold {
+		return true, nil
+	}
+	ancients, ok := st.Ancients()
+	if !ok {
+		return true, nil
+	}
+	if head.Number-b.freezerThreshold < ancients+b.freezerBatch {
+		return true, nil
+	}
+	return false, st.Freeze(ancients + b.freezerBatch)
+}
+
+// Ancients returns the number of blocks in the ancient store and
+// false if the storage does not have an ancient store
+func (b *Blockchain) Ancients() (uint64, bool) {
+	st, ok := b.db.(storage.AncientStorage)
+	if !ok {
+		return 0, false
+	}
+	return st.Ancients()
+}
+
+// Freeze moves the canonical blocks below the given number to the ancient
+// store. The number has to be below the head minus the freezer threshold.
+func (b *Blockchain) Freeze(number uint64) error {
+	st, ok := b.db.(storage.AncientStorage)
+	if !ok {
+		return fmt.Errorf("the storage does not have an ancient store")
+	}
+
+	b.writeLock.Lock()
+	defer b.writeLock.Unlock()
+
+	head, ok := b.Header()
+	if !ok {
+		return fmt.Errorf("header not found")
+	}
+	if head.Number < b.freezerThreshold || number > head.Number-b.freezerThreshold {
+		return fmt.Errorf("block %d is not final", number)
+	}
+	return st.Freeze(number)
+}
@@ -0,0 +1,112 @@
+package blockchain
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/minimal/blockchain/storage"
+	"github.com/umbracle/minimal/types"
+)
+
+func TestFreezer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ancient")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	addr := types.StringToAddress("1")
+
+	genesis := &types.Header{Number: 0, ExtraData: []byte{}, StateRoot: types.EmptyRootHash}
+	genesis.ComputeHash()
+
+	b := NewTestBlockchain(t, nil)
+	assert.NoError(t, b.db.(*storage.KeyValueStorage).OpenAncients(dir))
+
+	b.freezerThreshold = 4
+	b.freezerBatch = 2
+	assert.NoError(t, b.WriteHeaderGenesis(genesis))
+
+	txns := []*types.Transaction{nil}
+	headers := []*types.Header{genesis}
+	for i := uint64(1); i <= 12; i++ {
+		txn := (&types.Transaction{
+			Nonce:    i,
+			To:       &addr,
+			Value:    []byte{},
+			GasPrice: []byte{},
+			Input:    []byte{},
+			V:        0x1b,
+		}).ComputeHash()
+
+		status := types.ReceiptSuccess
+		receipts := []*types.Receipt{{Status: &status, CumulativeGasUsed: i, Logs: []*types.Log{}}}
+
+		header := &types.Header{
+			ParentHash: headers[i-1].Hash,
+			Number:     i,
+			Difficulty: 1,
+			ExtraData:  []byte{},
+			StateRoot:  types.EmptyRootHash,
+		}
+		header.ComputeHash()
+
+		assert.NoError(t, b.CommitBodies([]types.Hash{header.Hash}, []*types.Body{{Transactions: []*types.Transaction{txn}}}))
+		assert.NoError(t, b.CommitReceipts([]types.Hash{header.Hash}, [][]*types.Receipt{receipts}))
+		assert.NoError(t, b.WriteHeader(header))
+
+		txns = append(txns, txn)
+		headers = append(headers, header)
+	}
+
+	// the blocks are frozen in batches below the threshold
+	assert.NoError(t, b.freeze())
+
+	ancients, ok := b.Ancients()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(8), ancients)
+
+	// the frozen blocks are read from the ancient store
+	for i := uint64(1); i <= 12; i++ {
+		block, ok := b.GetBlockByNumber(i, true)
+		assert.True(t, ok)
+		assert.Equal(t, headers[i].Hash, block.Hash())
+		assert.Len(t, block.Transactions, 1)
+
+		receipts := b.GetReceiptsByHash(headers[i].Hash)
+		assert.Len(t, receipts, 1)
+		assert.Equal(t, i, receipts[0].CumulativeGasUsed)
+
+		td, ok := b.GetTD(headers[i].Hash)
+		assert.True(t, ok)
+		assert.Equal(t, i+1, td.Uint64())
+
+		hash, index, ok := b.GetTxLookup(txns[i].Hash)
+		assert.True(t, ok)
+		assert.Equal(t, headers[i].Hash, hash)
+		assert.Equal(t, uint64(0), index)
+	}
+
+	// the head has to be above the threshold
+	assert.Error(t, b.Freeze(9))
+	assert.NoError(t, b.Freeze(8))
+
+	// the rewind removes the frozen blocks above the head
+	head, err := b.SetHead(5)
+	assert.NoError(t, err)
+	assert.Equal(t, headers[5].Hash, head.Hash)
+
+	ancients, _ = b.Ancients()
+	assert.Equal(t, uint64(6), ancients)
+
+	_, ok = b.GetBlockByNumber(6, false)
+	assert.False(t, ok)
+	_, ok = b.GetHeaderByHash(headers[6].Hash)
+	assert.True(t, ok)
+	_, _, ok = b.GetTxLookup(txns[6].Hash)
+	assert.False(t, ok)
+
+	block, ok := b.GetBlockByNumber(5, true)
+	assert.True(t, ok)
+	assert.Len(t, block.Transactions, 1)
+}
@@ -0,0 +1,152 @@
+package blockchain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/umbracle/minimal/types"
+)
+
+// RepairResult describes the blocks removed from the chain by the repair
+type RepairResult struct {
+	// OldHead is the number of the head before the repair
+	OldHead uint64
+
+	// Head is the head after the repair
+	Head *types.Header
+
+	// Stale is the number of canonical blocks found above the old head,
+	// they are left behind if a rewind of the chain is interrupted
+	Stale uint64
+
+	// Blocks are the blocks from the old head that had missing data
+	Blocks []*InconsistentBlock
+}
+
+// InconsistentBlock is a block of the chain with missing data
+type InconsistentBlock struct {
+	Number  uint64
+	Hash    types.Hash
+	Missing []string
+}
+
+// Repair checks that the head block has all its data, a crash while the
+// chain is written could leave part of it behind. The data of a block is
+// the header, the total difficulty, the body, the receipts and the state.
+// If anything is missing, the chain is rewound to the newest block that
+// has all the data. The blocks below the pivot of the fast sync were never
+// executed, they do not have state and the chain is rewound to the genesis
+// if the pivot is not consistent. It returns nil if the chain is consistent.
+func (b *Blockchain) Repair() (*RepairResult, error) {
+	if b.executor == nil {
+		return nil, fmt.Errorf("the state is not available")
+	}
+
+	b.writeLock.Lock()
+	defer b.writeLock.Unlock()
+
+	hash, ok := b.db.ReadHeadHash()
+	if !ok {
+		return nil, fmt.Errorf("head not found")
+	}
+	number, ok := b.db.ReadHeadNumber()
+	if !ok {
+		header, ok := b.readHeader(hash)
+		if !ok {
+			return nil, fmt.Errorf("head block %s not found", hash.String())
+		}
+		number = header.Number
+	}
+
+	res := &RepairResult{
+		OldHead: number,
+	}
+
+	top := number
+	for {
+		if _, ok := b.db.ReadCanonicalHash(top + 1); !ok {
+			break
+		}
+		top++
+	}
+	res.Stale = top - number
+
+	pivot, _ := b.db.ReadSyncPivot()
+	for {
+		header, missing := b.checkBlock(number, hash, number >= pivot)
+		if len(missing) == 0 {
+			res.Head = header
+			break
+		}
+		res.Blocks = append(res.Blocks, &InconsistentBlock{Number: number, Hash: hash, Missing: missing})
+
+		if number == 0 {
+			return nil, fmt.Errorf("the genesis is missing the %s", strings.Join(missing, ", "))
+		}
+		number--
+
+		if number < pivot {
+			// the blocks below the pivot do not have state
+			number = 0
+			header = nil
+		}
+
+		// follow the parent if the header is known since the
+		// canonical hash could be missing too
+		if header != nil {
+			hash = header.ParentHash
+		} else if hash, ok = b.db.ReadCanonicalHash(number); !ok {
+			return nil, fmt.Errorf("canonical hash of block %d not found", number)
+		}
+	}
+
+	if top == res.Head.Number {
+		return nil, nil
+	}
+	if err := b.rewind(top, res.Head); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// checkBlock returns the header of a block and the data the block is missing.
+// The body and the receipts are only required if the block has any and the
+// receipts only if the block was executed.
+func (b *Blockchain) checkBlock(number uint64, hash types.Hash, executed bool) (*types.Header, []string) {
+	header, ok := b.readHeader(hash)
+	if !ok {
+		return nil, []string{"header"}
+	}
+
+	missing := []string{}
+	if _, ok := b.readDiff(hash); !ok {
+		missing = append(missing, "total difficulty")
+	}
+	if number != 0 {
+		if header.TxRoot != types.EmptyRootHash || header.Sha3Uncles != types.EmptyUncleHash {
+			if _, ok := b.readBody(hash); !ok {
+				missing = append(missing, "body")
+			}
+		}
+		if executed && header.ReceiptsRoot != types.EmptyRootHash {
+			if _, ok := b.db.ReadReceipts(hash); !ok {
+				missing = append(missing, "receipts")
+			}
+		}
+	}
+	if _, err := b.executor.State().NewSnapshotAt(header.StateRoot); err != nil {
+		missing = append(missing, "state")
+	}
+	return header, missing
+}
+
+// WriteSyncPivot records the pivot block of the fast sync. The blocks
+// below it were never executed and they do not have receipts nor state.
+func (b *Blockchain) WriteSyncPivot(header *types.Header) error {
+	return b.db.WriteSyncPivot(header.Number)
+}
+
+// SyncPivot returns the number of the pivot block of the fast sync
+func (b *Blockchain) SyncPivot() (uint64, bool) {
+	return b.db.ReadSyncPivot()
+}
@@ -0,0 +1,161 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/minimal/types"
+)
+
+func newRepairChain(t *testing.T, n int, edit func(i int, h *types.Header)) (*Blockchain, []*types.Header) {
+	headers := []*types.Header{}
+	for i := 0; i < n; i++ {
+		header := &types.Header{
+			Number:       uint64(i),
+			Difficulty:   1,
+			ExtraData:    []byte{},
+			StateRoot:    types.EmptyRootHash,
+			TxRoot:       types.EmptyRootHash,
+			ReceiptsRoot: types.EmptyRootHash,
+			Sha3Uncles:   types.EmptyUncleHash,
+		}
+		if i != 0 {
+			header.ParentHash = headers[i-1].Hash
+		}
+		if edit != nil {
+			edit(i, header)
+		}
+		header.ComputeHash()
+		headers = append(headers, header)
+	}
+	return NewTestBlockchain(t, headers), headers
+}
+
+func TestRepairConsistent(t *testing.T) {
+	b, _ := newRepairChain(t, 5, nil)
+
+	res, err := b.Repair()
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+}
+
+func TestRepairMissingData(t *testing.T) {
+	b, headers := newRepairChain(t, 8, func(i int, h *types.Header) {
+		switch i {
+		case 7:
+			// the state is not available
+			h.StateRoot = types.StringToHash("1")
+		case 6:
+			// the receipts are not available
+			h.ReceiptsRoot = types.StringToHash("1")
+		case 5:
+			h.TxRoot = types.StringToHash("1")
+			h.ReceiptsRoot = types.StringToHash("1")
+		}
+	})
+
+	// the block 5 has all the data
+	assert.NoError(t, b.CommitBodies([]types.Hash{headers[5].Hash}, []*types.Body{{}}))
+	assert.NoError(t, b.CommitReceipts([]types.Hash{headers[5].Hash}, [][]*types.Receipt{{}}))
+
+	res, err := b.Repair()
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint64(7), res.OldHead)
+	assert.Equal(t, headers[5].Hash, res.Head.Hash)
+	assert.Equal(t, uint64(0), res.Stale)
+	assert.Equal(t, []*InconsistentBlock{
+		{Number: 7, Hash: headers[7].Hash, Missing: []string{"state"}},
+		{Number: 6, Hash: headers[6].Hash, Missing: []string{"receipts"}},
+	}, res.Blocks)
+
+	head, _ := b.Header()
+	assert.Equal(t, headers[5].Hash, head.Hash)
+	_, ok := b.GetHeaderByNumber(6)
+	assert.False(t, ok)
+
+	// the chain is consistent after the repair
+	res, err = b.Repair()
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+}
+
+func TestRepairStaleBlocks(t *testing.T) {
+	b, headers := newRepairChain(t, 6, nil)
+
+	// an interrupted rewind leaves the canonical blocks above the head
+	assert.NoError(t, b.db.WriteHeadHash(headers[3].Hash))
+	assert.NoError(t, b.db.WriteHeadNumber(3))
+
+	res, err := b.Repair()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), res.Stale)
+	assert.Empty(t, res.Blocks)
+	assert.Equal(t, headers[3].Hash, res.Head.Hash)
+
+	for i := 4; i < 6; i++ {
+		_, ok := b.GetHeaderByNumber(uint64(i))
+		assert.False(t, ok)
+		_, ok = b.GetTD(headers[i].Hash)
+		assert.False(t, ok)
+	}
+}
+
+func TestRepairMissingHeader(t *testing.T) {
+	b, headers := newRepairChain(t, 4, nil)
+
+	// the head points to a header that was not written
+	missing := types.StringToHash("1")
+	assert.NoError(t, b.db.WriteHeadHash(missing))
+
+	res, err := b.Repair()
+	assert.NoError(t, err)
+	assert.Equal(t, []*InconsistentBlock{
+		{Number: 3, Hash: missing, Missing: []string{"header"}},
+	}, res.Blocks)
+	assert.Equal(t, headers[2].Hash, res.Head.Hash)
+}
+
+func TestRepairFastSync(t *testing.T) {
+	// the blocks below the pivot 5 do not have receipts nor state
+	b, headers := newRepairChain(t, 8, func(i int, h *types.Header) {
+		if i == 0 {
+			return
+		}
+		if i < 5 {
+			h.ReceiptsRoot = types.StringToHash("1")
+		}
+		if i != 5 {
+			h.StateRoot = types.StringToHash("1")
+		}
+	})
+	assert.NoError(t, b.WriteSyncPivot(headers[5]))
+
+	// the pivot is the newest block with all the data
+	res, err := b.Repair()
+	assert.NoError(t, err)
+	assert.Equal(t, headers[5].Hash, res.Head.Hash)
+	assert.Len(t, res.Blocks, 2)
+
+	// the chain is rewound to the genesis without checking the
+	// blocks below the pivot if the state of the pivot is missing
+	b, headers = newRepairChain(t, 8, func(i int, h *types.Header) {
+		if i != 0 {
+			h.StateRoot = types.StringToHash("1")
+		}
+	})
+	assert.NoError(t, b.WriteSyncPivot(headers[5]))
+
+	res, err = b.Repair()
+	assert.NoError(t, err)
+	assert.Equal(t, headers[0].Hash, res.Head.Hash)
+	assert.Equal(t, []*InconsistentBlock{
+		{Number: 7, Hash: headers[7].Hash, Missing: []string{"state"}},
+		{Number: 6, Hash: headers[6].Hash, Missing: []string{"state"}},
+		{Number: 5, Hash: headers[5].Hash, Missing: []string{"state"}},
+	}, res.Blocks)
+
+	// the pivot is removed by the rewind
+	pivot, _ := b.db.ReadSyncPivot()
+	assert.Equal(t, uint64(0), pivot)
+}
@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/umbracle/minimal/blockchain/storage/freezer"
+	"github.com/umbracle/minimal/types"
+)
+
+// AncientStorage is a storage that moves the old blocks of the canonical
+// chain to an ancient store. The blocks in the ancient store are read like
+// the rest.
+type AncientStorage interface {
+	// Ancients returns the number of blocks in the ancient store and
+	// false if the storage does not have an ancient store
+	Ancients() (uint64, bool)
+
+	// Freeze moves the canonical blocks below the given number to the ancient store
+	Freeze(number uint64) error
+}
+
+// OpenAncients opens the ancient store of a key value storage in the path
+// of the 'ancient' field of the config. The storage is returned unchanged
+// if the field is not set and it is closed if the ancient store fails to open.
+func OpenAncients(s Storage, config map[string]interface{}) (Storage, error) {
+	path, ok := config["ancient"]
+	if !ok {
+		return s, nil
+	}
+	pathStr, ok := path.(string)
+	if !ok {
+		s.Close()
+		return nil, fmt.Errorf("ancient is not a string")
+	}
+	kv, ok := s.(*KeyValueStorage)
+	if !ok {
+		s.Close()
+		return nil, fmt.Errorf("the storage does not support an ancient store")
+	}
+	if err := kv.OpenAncients(pathStr); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return kv, nil
+}
+
+// ancientTables are the tables of the freezer for the prefixes of the storage
+var ancientTables = map[string]string{
+	string(CANONICAL):  freezer.Hashes,
+	string(HEADER):     freezer.Headers,
+	string(BODY):       freezer.Bodies,
+	string(RECEIPTS):   freezer.Receipts,
+	string(DIFFICULTY): freezer.Diffs,
+}
+
+// OpenAncients opens the freezer in the given path as the ancient store
+func (s *KeyValueStorage) OpenAncients(path string) error {
+	if s.ancients != nil {
+		return fmt.Errorf("the ancient store is already open")
+	}
+	f, err := freezer.Open(path)
+	if err != nil {
+		return err
+	}
+	s.ancients = f
+	return nil
+}
+
+// Ancients implements the AncientStorage interface
+func (s *KeyValueStorage) Ancients() (uint64, bool) {
+	if s.ancients == nil {
+		return 0, false
+	}
+	if s.batch != nil {
+		return s.batch.ancients, true
+	}
+	return s.ancients.Items(), true
+}
+
+// frozen returns the number of blocks in the ancient store
+func (s *KeyValueStorage) frozen() uint64 {
+	n, _ := s.Ancients()
+	return n
+}
+
+// Freeze implements the AncientStorage interface. The number of the frozen
+// blocks is written before the data is appended to the freezer, and the data
+// in the key value storage is removed after the freezer is synced, the reads
+// find the blocks if the process is interrupted at any point.
+func (s *KeyValueStorage) Freeze(number uint64) error {
+	if s.ancients == nil {
+		return fmt.Errorf("the ancient store is not open")
+	}
+	if s.batch != nil {
+		return fmt.Errorf("the blocks cannot be frozen in a batch")
+	}
+
+	start := s.ancients.Items()
+
+	frozen := []types.Hash{}
+	for n := start; n < number; n++ {
+		hash, ok := s.ReadCanonicalHash(n)
+		if !ok {
+			return fmt.Errorf("canonical hash %d not found", n)
+		}
+		header, ok := s.get(HEADER, hash.Bytes())
+		if !ok {
+			return fmt.Errorf("header %d not found", n)
+		}
+		diff, ok := s.get(DIFFICULTY, hash.Bytes())
+		if !ok {
+			return fmt.Errorf("difficulty %d not found", n)
+		}
+
+		// the body and the receipts are not known if only the headers are synced
+		body, _ := s.get(BODY, hash.Bytes())
+		receipts, _ := s.get(RECEIPTS, hash.Bytes())
+
+		if err := s.set(ANCIENT, hash.Bytes(), s.encodeUint(n)); err != nil {
+			return err
+		}
+		if err := s.ancients.Append(n, hash.Bytes(), header, body, receipts, diff); err != nil {
+			return err
+		}
+		frozen = append(frozen, hash)
+	}
+	if err := s.ancients.Sync(); err != nil {
+		return err
+	}
+
+	for i, hash := range frozen {
+		for _, p := range [][]byte{HEADER, BODY, RECEIPTS, DIFFICULTY} {
+			if err := s.delete(p, hash.Bytes()); err != nil {
+				return err
+			}
+		}
+		if err := s.delete(CANONICAL, s.encodeUint(start+uint64(i))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getAncient returns the data of a frozen block for a key of the storage
+func (s *KeyValueStorage) getAncient(p []byte, k []byte) ([]byte, bool) {
+	table, ok := ancientTables[string(p)]
+	if !ok {
+		return nil, false
+	}
+
+	var number uint64
+	if bytes.Equal(p, CANONICAL) {
+		number = s.decodeUint(k)
+	} else {
+		data, ok, err := s.getKey(append(append([]byte{}, ANCIENT...), k...))
+		if err != nil || !ok {
+			return nil, false
+		}
+		number = s.decodeUint(data)
+	}
+	if number >= s.frozen() {
+		return nil, false
+	}
+
+	data, err := s.ancients.Retrieve(table, number)
+	if err != nil {
+		return nil, false
+	}
+	// the body and the receipts are empty if they were not known
+	if len(data) == 0 && (table == freezer.Bodies || table == freezer.Receipts) {
+		return nil, false
+	}
+	return data, true
+}
+
+// truncateAncients removes the blocks from the given number in the
+// ancient store. Their headers are moved back to the key value storage
+// since the headers of the blocks that are not canonical are kept. In
+// a batch, the ancient store is truncated after the batch is written.
+func (s *KeyValueStorage) truncateAncients(number uint64) error {
+	for n := number; n < s.frozen(); n++ {
+		hash, err := s.ancients.Retrieve(freezer.Hashes, n)
+		if err != nil {
+			return err
+		}
+		header, err := s.ancients.Retrieve(freezer.Headers, n)
+		if err != nil {
+			return err
+		}
+		if err := s.set(HEADER, hash, header); err != nil {
+			return err
+		}
+		if err := s.delete(ANCIENT, hash); err != nil {
+			return err
+		}
+	}
+	if s.batch != nil {
+		s.batch.ancients = number
+		return nil
+	}
+	return s.ancients.Truncate(number)
+}
@@ -59,6 +59,54 @@ func (b *badgerDBKV) Get(p []byte) ([]byte, bool, error) {
 	return val, true, err
 }
 
+func (b *badgerDBKV) Delete(p []byte) error {
+	return b.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(p)
+	})
+}
+
+func (b *badgerDBKV) NewBatch() storage.KVBatch {
+	return &badgerDBBatch{db: b.db}
+}
+
 func (b *badgerDBKV) Close() error {
 	return b.db.Close()
 }
+
+// badgerDBBatch is a batch of writes of badgerdb, the writes
+// are applied in a single transaction
+type badgerDBBatch struct {
+	db  *badger.DB
+	ops []badgerDBOp
+}
+
+type badgerDBOp struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+func (b *badgerDBBatch) Set(p []byte, v []byte) {
+	b.ops = append(b.ops, badgerDBOp{key: p, value: v})
+}
+
+func (b *badgerDBBatch) Delete(p []byte) {
+	b.ops = append(b.ops, badgerDBOp{key: p, delete: true})
+}
+
+func (b *badgerDBBatch) Write() error {
+	return b.db.Update(func(txn *badger.Txn) error {
+		for _, op := range b.ops {
+			var err error
+			if op.delete {
+				err = txn.Delete(op.key)
+			} else {
+				err = txn.Set(op.key, op.value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,68 @@
+package storage
+
+// kvBatch keeps the writes of a batch of a kv storage. The reads of
+// the batch find the pending writes before the data in the db.
+type kvBatch struct {
+	batch KVBatch
+
+	// pending are the values written in the batch, nil if deleted
+	pending map[string][]byte
+
+	// ancients is the number of blocks in the ancient store after the batch,
+	// the ancient store is truncated once the batch is written
+	ancients uint64
+}
+
+func (b *kvBatch) set(p []byte, v []byte) {
+	// the keys share the array of the prefixes
+	p = append([]byte{}, p...)
+	v = append([]byte{}, v...)
+
+	b.pending[string(p)] = v
+	b.batch.Set(p, v)
+}
+
+func (b *kvBatch) get(p []byte) ([]byte, bool) {
+	v, ok := b.pending[string(p)]
+	return v, ok
+}
+
+func (b *kvBatch) delete(p []byte) {
+	p = append([]byte{}, p...)
+
+	b.pending[string(p)] = nil
+	b.batch.Delete(p)
+}
+
+// Batch implements the storage interface. A batch inside another
+// batch is part of it. The blocks removed from the ancient store
+// are truncated after the writes of the batch.
+func (s *KeyValueStorage) Batch(fn func(Storage) error) error {
+	if s.batch != nil {
+		return fn(s)
+	}
+
+	batch := &kvBatch{
+		batch:   s.db.NewBatch(),
+		pending: map[string][]byte{},
+	}
+	if s.ancients != nil {
+		batch.ancients = s.ancients.Items()
+	}
+	bs := &KeyValueStorage{
+		logger:   s.logger,
+		db:       s.db,
+		ancients: s.ancients,
+		batch:    batch,
+	}
+	if err := fn(bs); err != nil {
+		return err
+	}
+	if err := batch.batch.Write(); err != nil {
+		return err
+	}
+	if s.ancients != nil && batch.ancients < s.ancients.Items() {
+		return s.ancients.Truncate(batch.ancients)
+	}
+	return nil
+}
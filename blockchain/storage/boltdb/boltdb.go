@@ -19,7 +19,11 @@ func Factory(config map[string]interface{}, logger hclog.Logger) (storage.Storag
 	if !ok {
 		return nil, fmt.Errorf("path is not a string")
 	}
-	return NewBoltDBStorage(filepath.Join(pathStr, "db"), logger)
+	s, err := NewBoltDBStorage(filepath.Join(pathStr, "db"), logger)
+	if err != nil {
+		return nil, err
+	}
+	return storage.OpenAncients(s, config)
 }
 
 // NewBoltDBStorage creates the new storage reference with boltdb
@@ -68,6 +72,61 @@ func (l *boltDBKV) Get(p []byte) ([]byte, bool, error) {
 	return data, found, err
 }
 
+func (l *boltDBKV) Delete(p []byte) error {
+	return l.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return nil
+		}
+		return b.Delete(p)
+	})
+}
+
+func (l *boltDBKV) NewBatch() storage.KVBatch {
+	return &boltDBBatch{db: l.db}
+}
+
 func (l *boltDBKV) Close() error {
 	return l.db.Close()
 }
+
+// boltDBBatch is a batch of writes of boltdb, the writes
+// are applied in a single transaction
+type boltDBBatch struct {
+	db  *bolt.DB
+	ops []boltDBOp
+}
+
+type boltDBOp struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+func (b *boltDBBatch) Set(p []byte, v []byte) {
+	b.ops = append(b.ops, boltDBOp{key: p, value: v})
+}
+
+func (b *boltDBBatch) Delete(p []byte) {
+	b.ops = append(b.ops, boltDBOp{key: p, delete: true})
+}
+
+func (b *boltDBBatch) Write() error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bkt, err := tx.CreateBucketIfNotExists(bucket)
+		if err != nil {
+			return err
+		}
+		for _, op := range b.ops {
+			if op.delete {
+				err = bkt.Delete(op.key)
+			} else {
+				err = bkt.Put(op.key, op.value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,158 @@
+package freezer
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// The tables of the freezer, every table has an item for each frozen block
+const (
+	Hashes   = "hashes"
+	Headers  = "headers"
+	Bodies   = "bodies"
+	Receipts = "receipts"
+	Diffs    = "diffs"
+)
+
+var tableNames = []string{Hashes, Headers, Bodies, Receipts, Diffs}
+
+// Freezer is an append only store for the data of the old blocks of
+// the canonical chain, which is not going to change anymore. The blocks
+// are stored by number in a set of flat files, one per kind of data.
+type Freezer struct {
+	lock   sync.RWMutex
+	tables map[string]*table
+	items  uint64
+}
+
+// Open opens the freezer in the given directory
+func Open(path string) (*Freezer, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+
+	f := &Freezer{
+		tables: map[string]*table{},
+	}
+	for _, name := range tableNames {
+		t, err := newTable(path, name)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		f.tables[name] = t
+	}
+
+	// the tables could have a different number of items if the
+	// append of a block was interrupted
+	f.items = f.tables[Hashes].items
+	for _, t := range f.tables {
+		if t.items < f.items {
+			f.items = t.items
+		}
+	}
+	for _, t := range f.tables {
+		if err := t.truncate(f.items); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Items returns the number of blocks in the freezer
+func (f *Freezer) Items() uint64 {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.items
+}
+
+// Append adds the data of the next block. The body and the receipts are empty
+// if they are not known. The data is not persisted until Sync is called.
+func (f *Freezer) Append(number uint64, hash, header, body, receipts, diff []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if number != f.items {
+		return fmt.Errorf("expected block %d but found %d", f.items, number)
+	}
+	items := map[string][]byte{
+		Hashes:   hash,
+		Headers:  header,
+		Bodies:   body,
+		Receipts: receipts,
+		Diffs:    diff,
+	}
+	for _, name := range tableNames {
+		if err := f.tables[name].append(number, items[name]); err != nil {
+			// remove the data written of the block
+			for _, t := range f.tables {
+				t.truncate(f.items)
+			}
+			return err
+		}
+	}
+	f.items++
+	return nil
+}
+
+// Retrieve returns the data of a kind of a block
+func (f *Freezer) Retrieve(kind string, number uint64) ([]byte, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	t, ok := f.tables[kind]
+	if !ok {
+		return nil, fmt.Errorf("table %s not found", kind)
+	}
+	if number >= f.items {
+		return nil, fmt.Errorf("block %d not found", number)
+	}
+	return t.retrieve(number)
+}
+
+// Truncate removes the blocks after the given number of blocks
+func (f *Freezer) Truncate(items uint64) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if items >= f.items {
+		return nil
+	}
+	for _, t := range f.tables {
+		if err := t.truncate(items); err != nil {
+			return err
+		}
+	}
+	f.items = items
+	return nil
+}
+
+// Sync flushes the tables to disk
+func (f *Freezer) Sync() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, t := range f.tables {
+		if err := t.sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the tables
+func (f *Freezer) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	var err error
+	for _, t := range f.tables {
+		if tErr := t.close(); tErr != nil {
+			err = tErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+package freezer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func blockItem(kind string, number uint64) []byte {
+	return bytes.Repeat([]byte(kind), int(number)+1)
+}
+
+func appendBlocks(t *testing.T, f *Freezer, from, to uint64) {
+	for i := from; i < to; i++ {
+		if err := f.Append(i, blockItem(Hashes, i), blockItem(Headers, i), blockItem(Bodies, i), []byte{}, blockItem(Diffs, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func expectBlocks(t *testing.T, f *Freezer, n uint64) {
+	if f.Items() != n {
+		t.Fatalf("expected %d blocks but found %d", n, f.Items())
+	}
+	for i := uint64(0); i < n; i++ {
+		for _, kind := range []string{Hashes, Headers, Bodies, Diffs} {
+			data, err := f.Retrieve(kind, i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, blockItem(kind, i)) {
+				t.Fatalf("bad %s of block %d", kind, i)
+			}
+		}
+		if data, err := f.Retrieve(Receipts, i); err != nil || len(data) != 0 {
+			t.Fatal("expected empty receipts")
+		}
+	}
+	if _, err := f.Retrieve(Headers, n); err == nil {
+		t.Fatal("the block should not exist")
+	}
+}
+
+func TestFreezer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendBlocks(t, f, 0, 10)
+	expectBlocks(t, f, 10)
+
+	// the blocks are appended in order
+	if err := f.Append(20, nil, nil, nil, nil, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	// the blocks are read back after the freezer is opened again
+	if err := f.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if f, err = Open(dir); err != nil {
+		t.Fatal(err)
+	}
+	expectBlocks(t, f, 10)
+
+	// truncate
+	if err := f.Truncate(4); err != nil {
+		t.Fatal(err)
+	}
+	expectBlocks(t, f, 4)
+
+	appendBlocks(t, f, 4, 8)
+	expectBlocks(t, f, 8)
+	f.Close()
+
+	// an interrupted append leaves partial data in some tables
+	data, err := os.OpenFile(filepath.Join(dir, Headers+".dat"), os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data.Write([]byte{0x1, 0x2, 0x3})
+	data.Close()
+
+	index, err := os.OpenFile(filepath.Join(dir, Bodies+".idx"), os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index.Write([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x1})
+	index.Close()
+
+	if f, err = Open(dir); err != nil {
+		t.Fatal(err)
+	}
+	expectBlocks(t, f, 8)
+
+	appendBlocks(t, f, 8, 12)
+	expectBlocks(t, f, 12)
+	f.Close()
+}
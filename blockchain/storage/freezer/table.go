@@ -0,0 +1,178 @@
+package freezer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/golang/snappy"
+)
+
+// indexEntrySize is the size of an entry in the index file, the
+// offset in the data file where the item ends
+const indexEntrySize = 8
+
+// table is an append only list of items. The items are compressed
+// with snappy and written one after the other in the data file, the
+// index file has the offset of the end of every item.
+type table struct {
+	data  *os.File
+	index *os.File
+
+	// items is the number of items in the table
+	items uint64
+
+	// size is the size of the data file
+	size uint64
+}
+
+func newTable(path string, name string) (*table, error) {
+	data, err := os.OpenFile(filepath.Join(path, name+".dat"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	index, err := os.OpenFile(filepath.Join(path, name+".idx"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+	t := &table{
+		data:  data,
+		index: index,
+	}
+	if err := t.repair(); err != nil {
+		t.close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// repair truncates the files to the last complete item, the
+// write of the last item could be interrupted
+func (t *table) repair() error {
+	stat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	t.items = uint64(stat.Size()) / indexEntrySize
+
+	stat, err = t.data.Stat()
+	if err != nil {
+		return err
+	}
+	dataSize := uint64(stat.Size())
+
+	// remove the items whose data is not complete
+	for t.items > 0 {
+		end, err := t.offset(t.items)
+		if err != nil {
+			return err
+		}
+		if end <= dataSize {
+			t.size = end
+			break
+		}
+		t.items--
+	}
+	if t.items == 0 {
+		t.size = 0
+	}
+	if err := t.index.Truncate(int64(t.items * indexEntrySize)); err != nil {
+		return err
+	}
+	return t.data.Truncate(int64(t.size))
+}
+
+// offset returns the offset where the item before the given one ends
+func (t *table) offset(item uint64) (uint64, error) {
+	if item == 0 {
+		return 0, nil
+	}
+	buf := make([]byte, indexEntrySize)
+	if _, err := t.index.ReadAt(buf, int64((item-1)*indexEntrySize)); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf), nil
+}
+
+// append adds an item at the end of the table
+func (t *table) append(item uint64, blob []byte) error {
+	if item != t.items {
+		return fmt.Errorf("expected item %d but found %d", t.items, item)
+	}
+	blob = snappy.Encode(nil, blob)
+	if _, err := t.data.WriteAt(blob, int64(t.size)); err != nil {
+		return err
+	}
+
+	buf := make([]byte, indexEntrySize)
+	binary.BigEndian.PutUint64(buf, t.size+uint64(len(blob)))
+	if _, err := t.index.WriteAt(buf, int64(t.items*indexEntrySize)); err != nil {
+		return err
+	}
+	t.size += uint64(len(blob))
+	t.items++
+	return nil
+}
+
+// retrieve returns an item of the table
+func (t *table) retrieve(item uint64) ([]byte, error) {
+	if item >= t.items {
+		return nil, fmt.Errorf("item %d out of bounds", item)
+	}
+	start, err := t.offset(item)
+	if err != nil {
+		return nil, err
+	}
+	end, err := t.offset(item + 1)
+	if err != nil {
+		return nil, err
+	}
+	if start > end || end > t.size {
+		return nil, fmt.Errorf("bad offsets of item %d", item)
+	}
+
+	blob := make([]byte, end-start)
+	if _, err := t.data.ReadAt(blob, int64(start)); err != nil {
+		return nil, err
+	}
+	return snappy.Decode(nil, blob)
+}
+
+// truncate removes the items after the given number of items
+func (t *table) truncate(items uint64) error {
+	if items >= t.items {
+		return nil
+	}
+	size, err := t.offset(items)
+	if err != nil {
+		return err
+	}
+	if err := t.index.Truncate(int64(items * indexEntrySize)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(size)); err != nil {
+		return err
+	}
+	t.items = items
+	t.size = size
+	return nil
+}
+
+func (t *table) sync() error {
+	if err := t.data.Sync(); err != nil {
+		return err
+	}
+	return t.index.Sync()
+}
+
+// close closes the files of the table
+func (t *table) close() error {
+	err1 := t.data.Close()
+	err2 := t.index.Close()
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
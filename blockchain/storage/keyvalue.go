@@ -2,10 +2,12 @@ package storage
 
 import (
 	"encoding/binary"
+	"fmt"
 	"math/big"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/umbracle/fastrlp"
+	"github.com/umbracle/minimal/blockchain/storage/freezer"
 	"github.com/umbracle/minimal/types"
 )
 
@@ -32,6 +34,15 @@ var (
 
 	// RECEIPTS is the prefix for receipts
 	RECEIPTS = []byte("r")
+
+	// TX_LOOKUP is the prefix for the block of the transactions
+	TX_LOOKUP = []byte("l")
+
+	// BLOOM_BITS is the prefix for the bloom bits index
+	BLOOM_BITS = []byte("m")
+
+	// ANCIENT is the prefix for the number of the blocks in the ancient store
+	ANCIENT = []byte("a")
 )
 
 // sub-prefix
@@ -40,6 +51,9 @@ var (
 	HASH   = []byte("hash")
 	NUMBER = []byte("number")
 	EMPTY  = []byte("empty")
+
+	SECTIONS = []byte("sections")
+	PIVOT    = []byte("pivot")
 )
 
 // KV is a key value storage interface
@@ -47,6 +61,15 @@ type KV interface {
 	Close() error
 	Set(p []byte, v []byte) error
 	Get(p []byte) ([]byte, bool, error)
+	Delete(p []byte) error
+	NewBatch() KVBatch
+}
+
+// KVBatch is a set of writes to a kv storage that are applied atomically
+type KVBatch interface {
+	Set(p []byte, v []byte)
+	Delete(p []byte)
+	Write() error
 }
 
 // KeyValueStorage is a generic storage for kv databases
@@ -54,6 +77,13 @@ type KeyValueStorage struct {
 	logger hclog.Logger
 	db     KV
 	Db     KV
+
+	// ancients is the store of the old blocks of the canonical chain,
+	// the keys not found in the db are read from it
+	ancients *freezer.Freezer
+
+	// batch has the pending writes if the storage is a batch
+	batch *kvBatch
 }
 
 func NewKeyValueStorage(logger hclog.Logger, db KV) Storage {
@@ -83,9 +113,27 @@ func (s *KeyValueStorage) ReadCanonicalHash(n uint64) (types.Hash, bool) {
 
 // WriteCanonicalHash writes a hash for a number block in the canonical chain
 func (s *KeyValueStorage) WriteCanonicalHash(n uint64, hash types.Hash) error {
+	if n < s.frozen() {
+		// the blocks in the ancient store cannot change
+		if current, ok := s.ReadCanonicalHash(n); ok && current == hash {
+			return nil
+		}
+		return fmt.Errorf("block %d is in the ancient store", n)
+	}
 	return s.set(CANONICAL, s.encodeUint(n), hash.Bytes())
 }
 
+// DeleteCanonicalHash removes the hash of a number in the canonical chain.
+// The blocks from the number are removed if they are in the ancient store.
+func (s *KeyValueStorage) DeleteCanonicalHash(n uint64) error {
+	if n < s.frozen() {
+		if err := s.truncateAncients(n); err != nil {
+			return err
+		}
+	}
+	return s.delete(CANONICAL, s.encodeUint(n))
+}
+
 // -- head --
 
 // ReadHeadHash returns the hash of the head
@@ -176,6 +224,11 @@ func (s *KeyValueStorage) ReadDiff(hash types.Hash) (*big.Int, bool) {
 	return big.NewInt(0).SetBytes(v), true
 }
 
+// DeleteDiff deletes the difficulty
+func (s *KeyValueStorage) DeleteDiff(hash types.Hash) error {
+	return s.delete(DIFFICULTY, hash.Bytes())
+}
+
 // -- header --
 
 // WriteHeader writes the header
@@ -248,6 +301,11 @@ func (s *KeyValueStorage) ReadBody(hash types.Hash) (*types.Body, bool) {
 	return body2, true
 }
 
+// DeleteBody deletes the body
+func (s *KeyValueStorage) DeleteBody(hash types.Hash) error {
+	return s.delete(BODY, hash.Bytes())
+}
+
 // -- receipts --
 
 // WriteReceipts writes the receipts
@@ -291,6 +349,80 @@ func (s *KeyValueStorage) ReadReceipts(hash types.Hash) ([]*types.Receipt, bool)
 	return receipts2, true
 }
 
+// DeleteReceipts deletes the receipts
+func (s *KeyValueStorage) DeleteReceipts(hash types.Hash) error {
+	return s.delete(RECEIPTS, hash.Bytes())
+}
+
+// -- tx lookup --
+
+// WriteTxLookup writes the block and the position of a transaction
+func (s *KeyValueStorage) WriteTxLookup(hash types.Hash, blockHash types.Hash, index uint64) error {
+	v := append(blockHash.Bytes(), s.encodeUint(index)...)
+	return s.set(TX_LOOKUP, hash.Bytes(), v)
+}
+
+// ReadTxLookup reads the block and the position of a transaction
+func (s *KeyValueStorage) ReadTxLookup(hash types.Hash) (types.Hash, uint64, bool) {
+	v, ok := s.get(TX_LOOKUP, hash.Bytes())
+	if !ok || len(v) != types.HashLength+8 {
+		return types.Hash{}, 0, false
+	}
+	return types.BytesToHash(v[:types.HashLength]), s.decodeUint(v[types.HashLength:]), true
+}
+
+// DeleteTxLookup deletes the lookup entry of a transaction
+func (s *KeyValueStorage) DeleteTxLookup(hash types.Hash) error {
+	return s.delete(TX_LOOKUP, hash.Bytes())
+}
+
+// -- bloom bits --
+
+// WriteBloomBits writes the vector of a bloom bit in a section
+func (s *KeyValueStorage) WriteBloomBits(bit uint, section uint64, bits []byte) error {
+	return s.set(BLOOM_BITS, s.bloomBitsKey(bit, section), bits)
+}
+
+// ReadBloomBits reads the vector of a bloom bit in a section
+func (s *KeyValueStorage) ReadBloomBits(bit uint, section uint64) ([]byte, bool) {
+	return s.get(BLOOM_BITS, s.bloomBitsKey(bit, section))
+}
+
+// WriteBloomSections writes the number of sections in the bloom bits index
+func (s *KeyValueStorage) WriteBloomSections(n uint64) error {
+	return s.set(BLOOM_BITS, SECTIONS, s.encodeUint(n))
+}
+
+// ReadBloomSections reads the number of sections in the bloom bits index
+func (s *KeyValueStorage) ReadBloomSections() (uint64, bool) {
+	data, ok := s.get(BLOOM_BITS, SECTIONS)
+	if !ok || len(data) != 8 {
+		return 0, false
+	}
+	return s.decodeUint(data), true
+}
+
+// WriteSyncPivot writes the number of the pivot block of the fast sync
+func (s *KeyValueStorage) WriteSyncPivot(n uint64) error {
+	return s.set(HEAD, PIVOT, s.encodeUint(n))
+}
+
+// ReadSyncPivot reads the number of the pivot block of the fast sync
+func (s *KeyValueStorage) ReadSyncPivot() (uint64, bool) {
+	data, ok := s.get(HEAD, PIVOT)
+	if !ok || len(data) != 8 {
+		return 0, false
+	}
+	return s.decodeUint(data), true
+}
+
+func (s *KeyValueStorage) bloomBitsKey(bit uint, section uint64) []byte {
+	k := make([]byte, 10)
+	binary.BigEndian.PutUint16(k[0:2], uint16(bit))
+	binary.BigEndian.PutUint64(k[2:], section)
+	return k
+}
+
 // -- write ops --
 
 func (s *KeyValueStorage) read2(p, k []byte, parser *fastrlp.Parser) *fastrlp.Value {
@@ -314,19 +446,53 @@ func (s *KeyValueStorage) write2(p, k []byte, v *fastrlp.Value) error {
 
 func (s *KeyValueStorage) set(p []byte, k []byte, v []byte) error {
 	p = append(p, k...)
+	if s.batch != nil {
+		s.batch.set(p, v)
+		return nil
+	}
 	return s.db.Set(p, v)
 }
 
 func (s *KeyValueStorage) get(p []byte, k []byte) ([]byte, bool) {
-	p = append(p, k...)
-	data, ok, err := s.db.Get(p)
+	data, ok, err := s.getKey(append(p, k...))
 	if err != nil {
 		return nil, false
 	}
+	if !ok && s.ancients != nil {
+		return s.getAncient(p, k)
+	}
 	return data, ok
 }
 
+// getKey reads a key from the pending writes of the batch or the db
+func (s *KeyValueStorage) getKey(p []byte) ([]byte, bool, error) {
+	if s.batch != nil {
+		if v, ok := s.batch.get(p); ok {
+			return v, v != nil, nil
+		}
+	}
+	return s.db.Get(p)
+}
+
+func (s *KeyValueStorage) delete(p []byte, k []byte) error {
+	p = append(p, k...)
+	if s.batch != nil {
+		s.batch.delete(p)
+		return nil
+	}
+	return s.db.Delete(p)
+}
+
 // Close closes the connection with the db
 func (s *KeyValueStorage) Close() error {
+	if s.batch != nil {
+		return fmt.Errorf("a batch cannot be closed")
+	}
+	if s.ancients != nil {
+		if err := s.ancients.Close(); err != nil {
+			s.db.Close()
+			return err
+		}
+	}
 	return s.db.Close()
 }
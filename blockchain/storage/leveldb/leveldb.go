@@ -18,7 +18,11 @@ func Factory(config map[string]interface{}, logger hclog.Logger) (storage.Storag
 	if !ok {
 		return nil, fmt.Errorf("path is not a string")
 	}
-	return NewLevelDBStorage(pathStr, logger)
+	s, err := NewLevelDBStorage(pathStr, logger)
+	if err != nil {
+		return nil, err
+	}
+	return storage.OpenAncients(s, config)
 }
 
 // NewLevelDBStorage creates the new storage reference with leveldb
@@ -52,6 +56,32 @@ func (l *levelDBKV) Get(p []byte) ([]byte, bool, error) {
 	return data, true, nil
 }
 
+func (l *levelDBKV) Delete(p []byte) error {
+	return l.db.Delete(p, nil)
+}
+
+func (l *levelDBKV) NewBatch() storage.KVBatch {
+	return &levelDBBatch{db: l.db, batch: new(leveldb.Batch)}
+}
+
 func (l *levelDBKV) Close() error {
 	return l.db.Close()
 }
+
+// levelDBBatch is a batch of writes of leveldb
+type levelDBBatch struct {
+	db    *leveldb.DB
+	batch *leveldb.Batch
+}
+
+func (b *levelDBBatch) Set(p []byte, v []byte) {
+	b.batch.Put(p, v)
+}
+
+func (b *levelDBBatch) Delete(p []byte) {
+	b.batch.Delete(p)
+}
+
+func (b *levelDBBatch) Write() error {
+	return b.db.Write(b.batch, nil)
+}
@@ -28,3 +28,7 @@ func newStorage(t *testing.T) (storage.Storage, func()) {
 func TestStorage(t *testing.T) {
 	storage.TestStorage(t, newStorage)
 }
+
+func TestAncientStorage(t *testing.T) {
+	storage.TestAncientStorage(t, newStorage)
+}
@@ -1,6 +1,8 @@
 package memory
 
 import (
+	"sync"
+
 	"github.com/hashicorp/go-hclog"
 	"github.com/umbracle/minimal/blockchain/storage"
 	"github.com/umbracle/minimal/helper/hex"
@@ -8,21 +10,28 @@ import (
 
 // NewMemoryStorage creates the new storage reference with inmemory
 func NewMemoryStorage(logger hclog.Logger) (storage.Storage, error) {
-	db := &memoryKV{map[string][]byte{}}
+	db := &memoryKV{db: map[string][]byte{}}
 	return storage.NewKeyValueStorage(logger, db), nil
 }
 
 // memoryKV is an in memory implementation of the kv storage
 type memoryKV struct {
-	db map[string][]byte
+	lock sync.RWMutex
+	db   map[string][]byte
 }
 
 func (m *memoryKV) Set(p []byte, v []byte) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	m.db[hex.EncodeToHex(p)] = v
 	return nil
 }
 
 func (m *memoryKV) Get(p []byte) ([]byte, bool, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
 	v, ok := m.db[hex.EncodeToHex(p)]
 	if !ok {
 		return nil, false, nil
@@ -30,6 +39,51 @@ func (m *memoryKV) Get(p []byte) ([]byte, bool, error) {
 	return v, true, nil
 }
 
+func (m *memoryKV) Delete(p []byte) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.db, hex.EncodeToHex(p))
+	return nil
+}
+
+func (m *memoryKV) NewBatch() storage.KVBatch {
+	return &memoryBatch{m: m, writes: map[string][]byte{}}
+}
+
 func (m *memoryKV) Close() error {
 	return nil
 }
+
+// memoryBatch is a batch of writes of the in memory kv storage
+type memoryBatch struct {
+	m *memoryKV
+
+	// writes are the values of the batch, nil if deleted
+	writes map[string][]byte
+}
+
+func (b *memoryBatch) Set(p []byte, v []byte) {
+	if v == nil {
+		v = []byte{}
+	}
+	b.writes[hex.EncodeToHex(p)] = v
+}
+
+func (b *memoryBatch) Delete(p []byte) {
+	b.writes[hex.EncodeToHex(p)] = nil
+}
+
+func (b *memoryBatch) Write() error {
+	b.m.lock.Lock()
+	defer b.m.lock.Unlock()
+
+	for k, v := range b.writes {
+		if v == nil {
+			delete(b.m.db, k)
+		} else {
+			b.m.db[k] = v
+		}
+	}
+	return nil
+}
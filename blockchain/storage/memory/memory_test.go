@@ -12,4 +12,5 @@ func TestStorage(t *testing.T) {
 		return s, func() {}
 	}
 	storage.TestStorage(t, f)
+	storage.TestAncientStorage(t, f)
 }
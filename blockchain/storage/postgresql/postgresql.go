@@ -18,7 +18,43 @@ import (
 
 // Backend is the postgresql backend
 type Backend struct {
-	db *sqlx.DB
+	conn *sqlx.DB
+
+	// db runs the queries in the connection or in the transaction of the batch
+	db queryer
+
+	// tx is the transaction of the batch
+	tx *sqlx.Tx
+}
+
+// queryer is the common interface of the connection and a transaction
+type queryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+}
+
+// txn is a transaction of the backend. The transaction of the
+// batch is committed or rolled back when the batch finishes.
+type txn struct {
+	*sqlx.Tx
+	batch bool
+}
+
+// Commit commits the transaction
+func (t *txn) Commit() error {
+	if t.batch {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+// Rollback aborts the transaction
+func (t *txn) Rollback() error {
+	if t.batch {
+		return nil
+	}
+	return t.Tx.Rollback()
 }
 
 var _ storage.Storage = &Backend{}
@@ -58,14 +94,47 @@ func Factory(config map[string]interface{}, logger hclog.Logger) (*Backend, erro
 	}
 
 	b := &Backend{
-		db: db,
+		conn: db,
+		db:   db,
 	}
 	return b, nil
 }
 
+// begin starts a transaction or returns the transaction of the batch
+func (b *Backend) begin() (*txn, error) {
+	if b.tx != nil {
+		return &txn{Tx: b.tx, batch: true}, nil
+	}
+	tx, err := b.conn.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	return &txn{Tx: tx}, nil
+}
+
+// Batch implements the storage interface. The writes of the batch
+// are done in a transaction.
+func (b *Backend) Batch(fn func(storage.Storage) error) error {
+	if b.tx != nil {
+		return fn(b)
+	}
+	tx, err := b.conn.Beginx()
+	if err != nil {
+		return err
+	}
+	if err := fn(&Backend{conn: b.conn, db: tx, tx: tx}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
 // Close implements the storage interface
 func (b *Backend) Close() error {
-	return b.db.Close()
+	if b.tx != nil {
+		return fmt.Errorf("a batch cannot be closed")
+	}
+	return b.conn.Close()
 }
 
 // WriteReceipts implements the storage interface
@@ -73,7 +142,7 @@ func (b *Backend) WriteReceipts(hash types.Hash, receipts []*types.Receipt) erro
 	// TODO, it does not store logs
 	query := "INSERT INTO receipts (hash, txhash, root, cumulative_gas_used, gas_used, bloom, contract_address) VALUES ($1, $2, $3, $4, $5, $6, $7)"
 
-	tx, err := b.db.Begin()
+	tx, err := b.begin()
 	if err != nil {
 		return err
 	}
@@ -100,8 +169,16 @@ func (b *Backend) ReadReceipts(hash types.Hash) ([]*types.Receipt, bool) {
 	return receipts, true
 }
 
+// DeleteReceipts implements the storage interface
+func (b *Backend) DeleteReceipts(hash types.Hash) error {
+	if _, err := b.db.Exec("DELETE FROM receipts WHERE hash=$1", hash.String()); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (b *Backend) WriteTransaction(hash types.Hash, t *types.Transaction) error {
-	tx, err := b.db.Begin()
+	tx, err := b.begin()
 	if err != nil {
 		return err
 	}
@@ -111,10 +188,10 @@ func (b *Backend) WriteTransaction(hash types.Hash, t *types.Transaction) error
 	return tx.Commit()
 }
 
-func (b *Backend) writeTransactionImpl(tx *sql.Tx, hash types.Hash, t *types.Transaction) error {
+func (b *Backend) writeTransactionImpl(tx *txn, hash types.Hash, t *types.Transaction) error {
 	query := "INSERT INTO transactions (hash, txhash, nonce, gas_price, gas, dst, value, input, v, r, s) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)"
 
-	if _, err := b.db.Exec(query, hash, t.Hash, t.Nonce, t.GasPrice, t.Gas, t.To, t.Value.String(), t.Input.String(), int(t.V), t.R.String(), t.S.String()); err != nil {
+	if _, err := tx.Exec(query, hash, t.Hash, t.Nonce, t.GasPrice, t.Gas, t.To, t.Value.String(), t.Input.String(), int(t.V), t.R.String(), t.S.String()); err != nil {
 		return err
 	}
 	return nil
@@ -136,6 +213,93 @@ func (b *Backend) ReadTransaction(hash types.Hash) (*types.Transaction, bool) {
 	return &txn, true
 }
 
+// WriteTxLookup implements the storage backend
+func (b *Backend) WriteTxLookup(hash types.Hash, blockHash types.Hash, index uint64) error {
+	query := "INSERT INTO tx_lookup (txhash, hash, txindex) VALUES ($1, $2, $3) ON CONFLICT (txhash) DO UPDATE SET hash = $2, txindex = $3"
+
+	if _, err := b.db.Exec(query, hash.String(), blockHash.String(), index); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReadTxLookup implements the storage backend
+func (b *Backend) ReadTxLookup(hash types.Hash) (types.Hash, uint64, bool) {
+	query := "SELECT hash, txindex FROM tx_lookup WHERE txhash=$1"
+
+	var entry struct {
+		Hash  string `db:"hash"`
+		Index uint64 `db:"txindex"`
+	}
+	if err := b.db.Get(&entry, query, hash.String()); err != nil {
+		return types.Hash{}, 0, false
+	}
+	return types.StringToHash(entry.Hash), entry.Index, true
+}
+
+// DeleteTxLookup implements the storage backend
+func (b *Backend) DeleteTxLookup(hash types.Hash) error {
+	if _, err := b.db.Exec("DELETE FROM tx_lookup WHERE txhash=$1", hash.String()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// WriteBloomBits implements the storage backend
+func (b *Backend) WriteBloomBits(bit uint, section uint64, bits []byte) error {
+	query := "INSERT INTO bloom_bits (bit, section, bits) VALUES ($1, $2, $3) ON CONFLICT (bit, section) DO UPDATE SET bits = $3"
+
+	if _, err := b.db.Exec(query, bit, section, bits); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReadBloomBits implements the storage backend
+func (b *Backend) ReadBloomBits(bit uint, section uint64) ([]byte, bool) {
+	query := "SELECT bits FROM bloom_bits WHERE bit=$1 AND section=$2"
+
+	var bits []byte
+	if err := b.db.Get(&bits, query, bit, section); err != nil {
+		return nil, false
+	}
+	return bits, true
+}
+
+// WriteBloomSections implements the storage backend
+func (b *Backend) WriteBloomSections(n uint64) error {
+	if _, err := b.db.Exec("UPDATE header SET bloom_sections=$1", n); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReadBloomSections implements the storage backend
+func (b *Backend) ReadBloomSections() (uint64, bool) {
+	var n uint64
+	if err := b.db.Get(&n, "SELECT bloom_sections FROM header"); err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// WriteSyncPivot implements the storage backend
+func (b *Backend) WriteSyncPivot(n uint64) error {
+	if _, err := b.db.Exec("UPDATE header SET sync_pivot=$1", n); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReadSyncPivot implements the storage backend
+func (b *Backend) ReadSyncPivot() (uint64, bool) {
+	var n uint64
+	if err := b.db.Get(&n, "SELECT sync_pivot FROM header"); err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
 // ReadHeader implements the storage backend
 func (b *Backend) ReadHeader(hash types.Hash) (*types.Header, bool) {
 	query := "SELECT parent_hash, sha3_uncles, miner, state_root, transactions_root, receipts_root, logs_bloom, difficulty, number, gas_limit, gas_used, timestamp, extradata, mixhash, nonce FROM headers where hash=$1"
@@ -155,7 +319,7 @@ func (b *Backend) ReadHeader(hash types.Hash) (*types.Header, bool) {
 
 // WriteHeader implements the storage backend
 func (b *Backend) WriteHeader(h *types.Header) error {
-	tx, err := b.db.Begin()
+	tx, err := b.begin()
 	if err != nil {
 		return err
 	}
@@ -168,7 +332,7 @@ func (b *Backend) WriteHeader(h *types.Header) error {
 	return nil
 }
 
-func (b *Backend) writeHeaderImpl(tx *sql.Tx, h *types.Header) error {
+func (b *Backend) writeHeaderImpl(tx *txn, h *types.Header) error {
 	query := `INSERT INTO headers (hash, parent_hash, sha3_uncles, miner, state_root, transactions_root, receipts_root, logs_bloom, difficulty, number, gas_limit, gas_used, timestamp, extradata, mixhash, nonce) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`
 
 	if _, err := tx.Exec(query, h.Hash, h.ParentHash, h.Sha3Uncles, h.Miner, h.StateRoot, h.TxRoot, h.ReceiptsRoot, h.LogsBloom, h.Difficulty, h.Number, h.GasLimit, h.GasUsed, h.Timestamp, h.ExtraData, h.MixHash, hex.EncodeToHex(h.Nonce[:])); err != nil {
@@ -200,6 +364,14 @@ func (b *Backend) WriteDiff(hash types.Hash, diff *big.Int) error {
 	return nil
 }
 
+// DeleteDiff implements the storage backend
+func (b *Backend) DeleteDiff(hash types.Hash) error {
+	if _, err := b.db.Exec("DELETE FROM difficulty WHERE hash=$1", hash.String()); err != nil {
+		return err
+	}
+	return nil
+}
+
 // ReadCanonicalHash implements the storage backend
 func (b *Backend) ReadCanonicalHash(n uint64) (types.Hash, bool) {
 	query := "SELECT hash FROM canonical WHERE number=$1"
@@ -213,7 +385,7 @@ func (b *Backend) ReadCanonicalHash(n uint64) (types.Hash, bool) {
 
 // WriteCanonicalHash implements the storage backend
 func (b *Backend) WriteCanonicalHash(n uint64, hash types.Hash) error {
-	tx, err := b.db.Begin()
+	tx, err := b.begin()
 	if err != nil {
 		return err
 	}
@@ -226,7 +398,7 @@ func (b *Backend) WriteCanonicalHash(n uint64, hash types.Hash) error {
 	return nil
 }
 
-func (b *Backend) writeCanonicalHashImpl(tx *sql.Tx, n uint64, hash types.Hash) error {
+func (b *Backend) writeCanonicalHashImpl(tx *txn, n uint64, hash types.Hash) error {
 	query := "INSERT INTO canonical (hash, number) VALUES ($1, $2) ON CONFLICT (number) DO UPDATE SET hash = $1"
 
 	if _, err := tx.Exec(query, hash.String(), n); err != nil {
@@ -235,6 +407,14 @@ func (b *Backend) writeCanonicalHashImpl(tx *sql.Tx, n uint64, hash types.Hash)
 	return nil
 }
 
+// DeleteCanonicalHash implements the storage backend
+func (b *Backend) DeleteCanonicalHash(n uint64) error {
+	if _, err := b.db.Exec("DELETE FROM canonical WHERE number=$1", n); err != nil {
+		return err
+	}
+	return nil
+}
+
 // WriteForks implements the storage backend
 func (b *Backend) WriteForks(forks []types.Hash) error {
 	query := "UPDATE header SET forks=$1"
@@ -319,7 +499,7 @@ func (b *Backend) WriteBody(hash types.Hash, body *types.Body) error {
 		return nil
 	}
 
-	tx, err := b.db.Begin()
+	tx, err := b.begin()
 	if err != nil {
 		return err
 	}
@@ -370,9 +550,27 @@ func (b *Backend) ReadBody(hash types.Hash) (*types.Body, bool) {
 	return body, true
 }
 
+// DeleteBody implements the storage backend. The receipts of the
+// block have to be deleted first since they reference the transactions.
+func (b *Backend) DeleteBody(hash types.Hash) error {
+	tx, err := b.begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM uncles WHERE hash=$1", hash.String()); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM transactions WHERE hash=$1", hash.String()); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
 // WriteCanonicalHeader implements the storage backend
 func (b *Backend) WriteCanonicalHeader(h *types.Header, diff *big.Int) error {
-	tx, err := b.db.Begin()
+	tx, err := b.begin()
 	if err != nil {
 		return err
 	}
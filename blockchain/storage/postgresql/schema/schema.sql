@@ -24,9 +24,11 @@ CREATE TABLE uncles (
 );
 
 CREATE TABLE header (
-    hash    char(66) REFERENCES headers(hash),
-    number  int,
-    forks   text
+    hash            char(66) REFERENCES headers(hash),
+    number          int,
+    forks           text,
+    bloom_sections  int,
+    sync_pivot      int
 );
 
 CREATE TABLE transactions (
@@ -63,6 +65,19 @@ CREATE TABLE difficulty (
     difficulty  numeric
 );
 
+CREATE TABLE tx_lookup (
+    txhash  char(66) PRIMARY KEY,
+    hash    char(66) REFERENCES headers(hash),
+    txindex int
+);
+
+CREATE TABLE bloom_bits (
+    bit     int,
+    section int,
+    bits    bytea,
+    PRIMARY KEY (bit, section)
+);
+
 CREATE TABLE canonical (
     hash    char(66) REFERENCES headers(hash),
     number  int UNIQUE
@@ -11,6 +11,7 @@ import (
 type Storage interface {
 	ReadCanonicalHash(n uint64) (types.Hash, bool)
 	WriteCanonicalHash(n uint64, hash types.Hash) error
+	DeleteCanonicalHash(n uint64) error
 
 	ReadHeadHash() (types.Hash, bool)
 	ReadHeadNumber() (uint64, bool)
@@ -22,6 +23,7 @@ type Storage interface {
 
 	WriteDiff(hash types.Hash, diff *big.Int) error
 	ReadDiff(hash types.Hash) (*big.Int, bool)
+	DeleteDiff(hash types.Hash) error
 
 	WriteHeader(h *types.Header) error
 	ReadHeader(hash types.Hash) (*types.Header, bool)
@@ -30,9 +32,28 @@ type Storage interface {
 
 	WriteBody(hash types.Hash, body *types.Body) error
 	ReadBody(hash types.Hash) (*types.Body, bool)
+	DeleteBody(hash types.Hash) error
 
 	WriteReceipts(hash types.Hash, receipts []*types.Receipt) error
 	ReadReceipts(hash types.Hash) ([]*types.Receipt, bool)
+	DeleteReceipts(hash types.Hash) error
+
+	WriteTxLookup(hash types.Hash, blockHash types.Hash, index uint64) error
+	ReadTxLookup(hash types.Hash) (types.Hash, uint64, bool)
+	DeleteTxLookup(hash types.Hash) error
+
+	WriteBloomBits(bit uint, section uint64, bits []byte) error
+	ReadBloomBits(bit uint, section uint64) ([]byte, bool)
+	WriteBloomSections(n uint64) error
+	ReadBloomSections() (uint64, bool)
+
+	// the pivot block of the fast sync, the blocks below it were not executed
+	WriteSyncPivot(n uint64) error
+	ReadSyncPivot() (uint64, bool)
+
+	// Batch runs fn with a storage whose writes are applied atomically
+	// once fn returns without error. The writes are discarded otherwise.
+	Batch(fn func(Storage) error) error
 
 	Close() error
 }
@@ -3,7 +3,9 @@ package storage
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"math/big"
+	"os"
 	"reflect"
 	"testing"
 
@@ -49,6 +51,21 @@ func TestStorage(t *testing.T, m MockStorage) {
 	t.Run("", func(t *testing.T) {
 		testWriteCanonicalHeader(t, m)
 	})
+	t.Run("", func(t *testing.T) {
+		testDelete(t, m)
+	})
+	t.Run("", func(t *testing.T) {
+		testTxLookup(t, m)
+	})
+	t.Run("", func(t *testing.T) {
+		testBloomBits(t, m)
+	})
+	t.Run("", func(t *testing.T) {
+		testSyncPivot(t, m)
+	})
+	t.Run("", func(t *testing.T) {
+		testBatch(t, m)
+	})
 }
 
 func testCanonicalChain(t *testing.T, m MockStorage) {
@@ -418,3 +435,354 @@ func testWriteCanonicalHeader(t *testing.T, m MockStorage) {
 		t.Fatal("canonical hash not correct")
 	}
 }
+
+func testDelete(t *testing.T, m MockStorage) {
+	s, close := m(t)
+	defer close()
+
+	h := &types.Header{
+		Number:    10,
+		ExtraData: []byte{0x1},
+	}
+	h.ComputeHash()
+
+	if err := s.WriteCanonicalHeader(h, big.NewInt(10)); err != nil {
+		t.Fatal(err)
+	}
+
+	addr := types.StringToAddress("11")
+	txn := &types.Transaction{
+		To:       &addr,
+		Value:    big.NewInt(1).Bytes(),
+		GasPrice: big.NewInt(1).Bytes(),
+		Input:    []byte{},
+	}
+	txn.ComputeHash()
+
+	if err := s.WriteBody(h.Hash, &types.Body{Transactions: []*types.Transaction{txn}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteReceipts(h.Hash, []*types.Receipt{{TxHash: txn.Hash}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the receipts reference the transactions in some backends
+	if err := s.DeleteReceipts(h.Hash); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteBody(h.Hash); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteDiff(h.Hash); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteCanonicalHash(h.Number); err != nil {
+		t.Fatal(err)
+	}
+
+	if receipts, ok := s.ReadReceipts(h.Hash); ok && len(receipts) != 0 {
+		t.Fatal("the receipts should be deleted")
+	}
+	if body, ok := s.ReadBody(h.Hash); ok && len(body.Transactions) != 0 {
+		t.Fatal("the body should be deleted")
+	}
+	if _, ok := s.ReadDiff(h.Hash); ok {
+		t.Fatal("the difficulty should be deleted")
+	}
+	if _, ok := s.ReadCanonicalHash(h.Number); ok {
+		t.Fatal("the canonical hash should be deleted")
+	}
+
+	// the header is kept
+	if _, ok := s.ReadHeader(h.Hash); !ok {
+		t.Fatal("the header should not be deleted")
+	}
+
+	// deleting a missing entry is not an error
+	if err := s.DeleteCanonicalHash(h.Number + 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testTxLookup(t *testing.T, m MockStorage) {
+	s, close := m(t)
+	defer close()
+
+	// the block of the lookup has to exist in some backends
+	h1 := &types.Header{Number: 1, ExtraData: []byte{}}
+	h1.ComputeHash()
+	h2 := &types.Header{Number: 2, ExtraData: []byte{}}
+	h2.ComputeHash()
+
+	for _, h := range []*types.Header{h1, h2} {
+		if err := s.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, _, ok := s.ReadTxLookup(hash1); ok {
+		t.Fatal("the lookup should not exist")
+	}
+	if err := s.WriteTxLookup(hash1, h1.Hash, 3); err != nil {
+		t.Fatal(err)
+	}
+	if block, index, ok := s.ReadTxLookup(hash1); !ok || block != h1.Hash || index != 3 {
+		t.Fatal("bad lookup")
+	}
+
+	// the lookup is replaced if the transaction is included in another block
+	if err := s.WriteTxLookup(hash1, h2.Hash, 0); err != nil {
+		t.Fatal(err)
+	}
+	if block, index, ok := s.ReadTxLookup(hash1); !ok || block != h2.Hash || index != 0 {
+		t.Fatal("bad lookup")
+	}
+
+	if err := s.DeleteTxLookup(hash1); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := s.ReadTxLookup(hash1); ok {
+		t.Fatal("the lookup should be deleted")
+	}
+}
+
+func testBloomBits(t *testing.T, m MockStorage) {
+	s, close := m(t)
+	defer close()
+
+	if _, ok := s.ReadBloomBits(1, 2); ok {
+		t.Fatal("the bits should not exist")
+	}
+	if err := s.WriteBloomBits(1, 2, []byte{0x1, 0x2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteBloomBits(2, 1, []byte{0x3}); err != nil {
+		t.Fatal(err)
+	}
+	if bits, ok := s.ReadBloomBits(1, 2); !ok || !bytes.Equal(bits, []byte{0x1, 0x2}) {
+		t.Fatal("bad bits")
+	}
+
+	// the section is indexed again after a reorg
+	if err := s.WriteBloomBits(1, 2, []byte{0x4}); err != nil {
+		t.Fatal(err)
+	}
+	if bits, ok := s.ReadBloomBits(1, 2); !ok || !bytes.Equal(bits, []byte{0x4}) {
+		t.Fatal("bad bits")
+	}
+
+	if err := s.WriteBloomSections(10); err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := s.ReadBloomSections(); !ok || n != 10 {
+		t.Fatal("bad sections")
+	}
+}
+
+func testSyncPivot(t *testing.T, m MockStorage) {
+	s, close := m(t)
+	defer close()
+
+	if _, ok := s.ReadSyncPivot(); ok {
+		t.Fatal("expected no pivot")
+	}
+	if err := s.WriteSyncPivot(100); err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := s.ReadSyncPivot(); !ok || n != 100 {
+		t.Fatal("bad pivot")
+	}
+}
+
+func testBatch(t *testing.T, m MockStorage) {
+	s, close := m(t)
+	defer close()
+
+	h1 := &types.Header{Number: 1, ExtraData: []byte{}}
+	h1.ComputeHash()
+
+	// the writes are discarded if the batch fails
+	errBatch := fmt.Errorf("batch failed")
+	err := s.Batch(func(s Storage) error {
+		if err := s.WriteCanonicalHeader(h1, big.NewInt(1)); err != nil {
+			return err
+		}
+		// the batch reads its own writes
+		if hash, ok := s.ReadHeadHash(); !ok || hash != h1.Hash {
+			return fmt.Errorf("bad head in the batch")
+		}
+		return errBatch
+	})
+	if err != errBatch {
+		t.Fatalf("expected the error of the batch but found %v", err)
+	}
+	if _, ok := s.ReadHeadHash(); ok {
+		t.Fatal("the head should not exist")
+	}
+	if _, ok := s.ReadHeader(h1.Hash); ok {
+		t.Fatal("the header should not exist")
+	}
+
+	err = s.Batch(func(s Storage) error {
+		if err := s.WriteCanonicalHeader(h1, big.NewInt(1)); err != nil {
+			return err
+		}
+		if err := s.WriteTxLookup(hash1, h1.Hash, 0); err != nil {
+			return err
+		}
+		// a batch inside a batch is part of it
+		return s.Batch(func(s Storage) error {
+			return s.DeleteTxLookup(hash1)
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash, ok := s.ReadHeadHash(); !ok || hash != h1.Hash {
+		t.Fatal("bad head")
+	}
+	if hash, ok := s.ReadCanonicalHash(1); !ok || hash != h1.Hash {
+		t.Fatal("bad canonical hash")
+	}
+	if _, ok := s.ReadHeader(h1.Hash); !ok {
+		t.Fatal("the header should exist")
+	}
+	if _, _, ok := s.ReadTxLookup(hash1); ok {
+		t.Fatal("the lookup should be deleted")
+	}
+}
+
+// TestAncientStorage tests the ancient store of a key value storage
+func TestAncientStorage(t *testing.T, m MockStorage) {
+	t.Helper()
+
+	s, close := m(t)
+	defer close()
+
+	dir, err := ioutil.TempDir("", "ancient")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	kv := s.(*KeyValueStorage)
+	if err := kv.OpenAncients(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	headers := []*types.Header{}
+	for i := 0; i < 10; i++ {
+		h := &types.Header{Number: uint64(i), ExtraData: []byte{}}
+		if i != 0 {
+			h.ParentHash = headers[i-1].Hash
+		}
+		h.ComputeHash()
+		headers = append(headers, h)
+
+		if err := s.WriteCanonicalHeader(h, big.NewInt(int64(i))); err != nil {
+			t.Fatal(err)
+		}
+		// only the even blocks have a body
+		if i%2 == 0 {
+			txn := &types.Transaction{Nonce: uint64(i), Value: []byte{}, GasPrice: []byte{}, Input: []byte{}, V: 0x1b}
+			txn.ComputeHash()
+
+			if err := s.WriteBody(h.Hash, &types.Body{Transactions: []*types.Transaction{txn}}); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.WriteReceipts(h.Hash, []*types.Receipt{{TxHash: txn.Hash, Logs: []*types.Log{}}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	expectBlock := func(i int) {
+		h := headers[i]
+		if hash, ok := s.ReadCanonicalHash(uint64(i)); !ok || hash != h.Hash {
+			t.Fatalf("bad canonical hash %d", i)
+		}
+		if header, ok := s.ReadHeader(h.Hash); !ok || header.Hash != h.Hash {
+			t.Fatalf("bad header %d", i)
+		}
+		if diff, ok := s.ReadDiff(h.Hash); !ok || diff.Uint64() != uint64(i) {
+			t.Fatalf("bad difficulty %d", i)
+		}
+		body, ok := s.ReadBody(h.Hash)
+		receipts, _ := s.ReadReceipts(h.Hash)
+		if i%2 == 0 && (!ok || len(body.Transactions) != 1 || len(receipts) != 1) {
+			t.Fatalf("bad body %d", i)
+		}
+		if i%2 != 0 && ok {
+			t.Fatalf("the body %d should not exist", i)
+		}
+	}
+
+	if err := kv.Freeze(6); err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := kv.Ancients(); !ok || n != 6 {
+		t.Fatalf("expected 6 ancient blocks but found %d", n)
+	}
+	for i := range headers {
+		expectBlock(i)
+	}
+
+	// the frozen blocks are removed from the db
+	if _, ok, _ := kv.db.Get(append(append([]byte{}, HEADER...), headers[3].Hash.Bytes()...)); ok {
+		t.Fatal("the header should be removed from the db")
+	}
+
+	// the frozen blocks cannot change
+	if err := s.WriteCanonicalHash(3, hash1); err == nil {
+		t.Fatal("expected an error")
+	}
+	if err := s.WriteCanonicalHash(3, headers[3].Hash); err != nil {
+		t.Fatal(err)
+	}
+
+	// the frozen blocks are removed once the batch is written
+	errBatch := fmt.Errorf("batch failed")
+	err = s.Batch(func(s Storage) error {
+		if err := s.DeleteCanonicalHash(5); err != nil {
+			return err
+		}
+		if n, _ := s.(AncientStorage).Ancients(); n != 5 {
+			return fmt.Errorf("expected 5 ancient blocks in the batch but found %d", n)
+		}
+		if _, ok := s.ReadCanonicalHash(5); ok {
+			return fmt.Errorf("the canonical hash should be deleted in the batch")
+		}
+		return errBatch
+	})
+	if err != errBatch {
+		t.Fatalf("expected the error of the batch but found %v", err)
+	}
+	if n, _ := kv.Ancients(); n != 6 {
+		t.Fatalf("expected 6 ancient blocks but found %d", n)
+	}
+	for i := range headers {
+		expectBlock(i)
+	}
+
+	// removing a canonical hash removes the frozen blocks from its number
+	if err := s.DeleteCanonicalHash(4); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := kv.Ancients(); n != 4 {
+		t.Fatalf("expected 4 ancient blocks but found %d", n)
+	}
+	for i := 0; i < 4; i++ {
+		expectBlock(i)
+	}
+	if _, ok := s.ReadCanonicalHash(4); ok {
+		t.Fatal("the canonical hash should be deleted")
+	}
+	if _, ok := s.ReadBody(headers[4].Hash); ok {
+		t.Fatal("the body should be deleted")
+	}
+	for i := 4; i < 6; i++ {
+		if _, ok := s.ReadHeader(headers[i].Hash); !ok {
+			t.Fatalf("the header %d should be kept", i)
+		}
+	}
+}
@@ -239,6 +239,36 @@ type GenesisAccount struct {
 // GenesisAlloc specifies the initial state that is part of the genesis block.
 type GenesisAlloc map[types.Address]GenesisAccount
 
+// Encoding
+
+// MarshalJSON implements the json interface
+func (g GenesisAccount) MarshalJSON() ([]byte, error) {
+	type GenesisAccount struct {
+		Code       *string           `json:"code,omitempty"`
+		Storage    map[string]string `json:"storage,omitempty"`
+		Balance    *string           `json:"balance"`
+		Nonce      *string           `json:"nonce,omitempty"`
+		PrivateKey *string           `json:"secretKey,omitempty"`
+	}
+
+	var enc GenesisAccount
+	enc.Code = encodeBytes(g.Code)
+	if len(g.Storage) != 0 {
+		enc.Storage = make(map[string]string, len(g.Storage))
+		for k, v := range g.Storage {
+			enc.Storage[k.String()] = v.String()
+		}
+	}
+	if g.Balance != nil {
+		balance := "0x" + g.Balance.Text(16)
+		enc.Balance = &balance
+	}
+	enc.Nonce = encodeUint64(g.Nonce)
+	enc.PrivateKey = encodeBytes(g.PrivateKey)
+
+	return json.Marshal(&enc)
+}
+
 // Decoding
 
 func (g *GenesisAccount) UnmarshalJSON(data []byte) error {
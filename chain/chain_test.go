@@ -94,6 +94,37 @@ func TestGenesisAlloc(t *testing.T) {
 	}
 }
 
+func TestGenesisAccountEncoding(t *testing.T) {
+	cases := []GenesisAccount{
+		{
+			Balance: big.NewInt(17),
+		},
+		{
+			Balance: big.NewInt(17),
+			Nonce:   256,
+			Code:    []byte{0x1, 0x2},
+			Storage: map[types.Hash]types.Hash{
+				hash("1"): hash("3"),
+				hash("2"): hash("4"),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		data, err := json.Marshal(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var dec GenesisAccount
+		if err := json.Unmarshal(data, &dec); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(dec, c) {
+			t.Fatal("bad")
+		}
+	}
+}
+
 func TestGenesis(t *testing.T) {
 	cases := []struct {
 		input  string
@@ -2,13 +2,16 @@ package chain
 
 import (
 	"math/big"
+
+	"github.com/umbracle/minimal/types"
 )
 
 // Params are all the set of params for the chain
 type Params struct {
-	Forks   *Forks                 `json:"forks"`
-	ChainID int                    `json:"chainID"`
-	Engine  map[string]interface{} `json:"engine"`
+	Forks       *Forks                 `json:"forks"`
+	ChainID     int                    `json:"chainID"`
+	Engine      map[string]interface{} `json:"engine"`
+	Precompiles []*Precompile          `json:"precompiles,omitempty"`
 }
 
 func (p *Params) GetEngine() string {
@@ -19,6 +22,21 @@ func (p *Params) GetEngine() string {
 	return ""
 }
 
+// Precompile enables a custom precompiled contract at an address
+type Precompile struct {
+	// Name of the precompiled contract implementation
+	Name string `json:"name"`
+
+	// Address where the contract is available
+	Address types.Address `json:"address"`
+
+	// Block from which the contract is available
+	Block Fork `json:"block"`
+
+	// Config is the specific configuration of the contract
+	Config map[string]interface{} `json:"config,omitempty"`
+}
+
 // Forks specifies when each fork is activated
 type Forks struct {
 	Homestead      *Fork `json:"homestead,omitempty"`
@@ -28,6 +46,8 @@ type Forks struct {
 	EIP150         *Fork `json:"EIP150,omitempty"`
 	EIP158         *Fork `json:"EIP158,omitempty"`
 	EIP155         *Fork `json:"EIP155,omitempty"`
+	EWASM          *Fork `json:"ewasm,omitempty"`
+	EIP2537        *Fork `json:"EIP2537,omitempty"`
 }
 
 func (f *Forks) active(ff *Fork, block uint64) bool {
@@ -65,6 +85,14 @@ func (f *Forks) IsEIP155(block uint64) bool {
 	return f.active(f.EIP155, block)
 }
 
+func (f *Forks) IsEWASM(block uint64) bool {
+	return f.active(f.EWASM, block)
+}
+
+func (f *Forks) IsEIP2537(block uint64) bool {
+	return f.active(f.EIP2537, block)
+}
+
 func (f *Forks) At(block uint64) ForksInTime {
 	return ForksInTime{
 		Homestead:      f.active(f.Homestead, block),
@@ -74,6 +102,8 @@ func (f *Forks) At(block uint64) ForksInTime {
 		EIP150:         f.active(f.EIP150, block),
 		EIP158:         f.active(f.EIP158, block),
 		EIP155:         f.active(f.EIP155, block),
+		EWASM:          f.active(f.EWASM, block),
+		EIP2537:        f.active(f.EIP2537, block),
 	}
 }
 
@@ -93,5 +123,5 @@ func (f Fork) Int() *big.Int {
 }
 
 type ForksInTime struct {
-	Homestead, Byzantium, Constantinople, Petersburg, EIP150, EIP158, EIP155 bool
+	Homestead, Byzantium, Constantinople, Petersburg, EIP150, EIP158, EIP155, EWASM, EIP2537 bool
 }
@@ -5,6 +5,8 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/umbracle/minimal/types"
 )
 
 func TestValidateChainID(t *testing.T) {
@@ -45,6 +47,40 @@ func TestParamsForks(t *testing.T) {
 	}
 }
 
+func TestParamsPrecompiles(t *testing.T) {
+	input := `{
+		"precompiles": [
+			{
+				"name": "a",
+				"address": "0x0000000000000000000000000000000000000100",
+				"block": 10,
+				"config": {
+					"b": "c"
+				}
+			}
+		]
+	}`
+
+	var params *Params
+	if err := json.Unmarshal([]byte(input), &params); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*Precompile{
+		{
+			Name:    "a",
+			Address: types.StringToAddress("0x0000000000000000000000000000000000000100"),
+			Block:   Fork(10),
+			Config: map[string]interface{}{
+				"b": "c",
+			},
+		},
+	}
+	if !reflect.DeepEqual(params.Precompiles, expected) {
+		t.Fatal("bad")
+	}
+}
+
 func TestParamsForksInTime(t *testing.T) {
 	f := Forks{
 		Homestead:      NewFork(0),
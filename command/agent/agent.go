@@ -4,47 +4,30 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"runtime"
 
 	"github.com/google/gops/agent"
 	"github.com/hashicorp/go-hclog"
 	"github.com/umbracle/minimal/api"
-	"github.com/umbracle/minimal/blockchain/storage"
-	"github.com/umbracle/minimal/consensus"
+	"github.com/umbracle/minimal/command"
 	"github.com/umbracle/minimal/minimal/keystore"
 	"github.com/umbracle/minimal/network/discovery"
+	"github.com/umbracle/minimal/state/runtime/evm"
 
 	"github.com/umbracle/minimal/protocol"
 
 	"github.com/umbracle/minimal/chain"
 	"github.com/umbracle/minimal/minimal"
 
-	consensusClique "github.com/umbracle/minimal/consensus/clique"
-	consensusEthash "github.com/umbracle/minimal/consensus/ethash"
-	consensusPOW "github.com/umbracle/minimal/consensus/pow"
-
 	discoveryConsul "github.com/umbracle/minimal/network/discovery/consul"
 	discoveryDevP2P "github.com/umbracle/minimal/network/discovery/devp2p"
 
 	protocolEthereum "github.com/umbracle/minimal/protocol/ethereum"
 
-	storageBoltDB "github.com/umbracle/minimal/blockchain/storage/boltdb"
-	storageLevelDB "github.com/umbracle/minimal/blockchain/storage/leveldb"
-
 	apiHTTP "github.com/umbracle/minimal/api/http"
 	apiJsonRPC "github.com/umbracle/minimal/api/jsonrpc"
 )
 
-var blockchainBackends = map[string]storage.Factory{
-	"leveldb": storageLevelDB.Factory,
-	"boltdb":  storageBoltDB.Factory,
-}
-
-var consensusBackends = map[string]consensus.Factory{
-	"clique": consensusClique.Factory,
-	"ethash": consensusEthash.Factory,
-	"pow":    consensusPOW.Factory,
-}
-
 var discoveryBackends = map[string]discovery.Factory{
 	"consul": discoveryConsul.Factory,
 	"devp2p": discoveryDevP2P.Factory,
@@ -174,16 +157,38 @@ func (a *Agent) Start() error {
 		DiscoveryBackends: discoveryBackends,
 		DiscoveryEntries:  discoveryEntries,
 
-		BlockchainBackends: blockchainBackends,
+		BlockchainBackends: command.BlockchainBackends,
 		BlockchainEntries:  blockchainEntry,
 
-		ConsensusBackends: consensusBackends,
+		ConsensusBackends: command.ConsensusBackends,
 		ConsensusEntry:    consensusEntry,
 
 		APIBackends: apiBackends,
 		APIEntries:  apiEntries,
 
-		StateStorage: a.config.StateStorage,
+		PrecompiledBackends: command.PrecompiledBackends,
+
+		StateBackends: command.StateBackends,
+		StateStorage:  a.config.StateStorage,
+		StateCache:    a.config.StateCache,
+		Preimages:     a.config.Preimages,
+	}
+	if a.config.EVM != nil {
+		config.EVM = &evm.Config{
+			JumpdestCacheSize: a.config.EVM.JumpdestCacheSize,
+		}
+	}
+	if a.config.Executor != nil && a.config.Executor.Parallel {
+		config.ParallelWorkers = a.config.Executor.Workers
+		if config.ParallelWorkers == 0 {
+			config.ParallelWorkers = runtime.NumCPU()
+		}
+	}
+	if a.config.Prune != nil && a.config.Prune.Enabled {
+		config.Prune = &minimal.PruneConfig{
+			Retain:     a.config.Prune.Retain,
+			Checkpoint: a.config.Prune.Checkpoint,
+		}
 	}
 
 	logger := hclog.New(&hclog.LoggerOptions{
@@ -27,12 +27,38 @@ type Config struct {
 	API        map[string]BackendConfig `json:"api"`
 
 	StateStorage string `json:"state_storage"`
+	StateCache   int    `json:"state_cache"`
+	Preimages    bool   `json:"preimages"`
+
+	EVM *EVMConfig `json:"evm"`
+
+	Executor *ExecutorConfig `json:"executor"`
+
+	Prune *PruneConfig `json:"prune"`
 }
 
 type Telemetry struct {
 	PrometheusPort int `json:"prometheus_port"`
 }
 
+// EVMConfig is the configuration of the evm runtime
+type EVMConfig struct {
+	JumpdestCacheSize int `json:"jumpdest_cache_size"`
+}
+
+// ExecutorConfig is the configuration of the block executor
+type ExecutorConfig struct {
+	Parallel bool `json:"parallel"`
+	Workers  int  `json:"workers"`
+}
+
+// PruneConfig is the configuration of the state pruning
+type PruneConfig struct {
+	Enabled    bool   `json:"enabled"`
+	Retain     uint64 `json:"retain"`
+	Checkpoint uint64 `json:"checkpoint"`
+}
+
 func DefaultConfig() *Config {
 	return &Config{
 		Chain:       "foundation",
@@ -53,6 +79,17 @@ func DefaultConfig() *Config {
 		Consensus:    BackendConfig{},
 		API:          map[string]BackendConfig{},
 		StateStorage: "leveldb",
+		StateCache:   65536,
+		EVM: &EVMConfig{
+			JumpdestCacheSize: 4096,
+		},
+		Executor: &ExecutorConfig{
+			Parallel: false,
+		},
+		Prune: &PruneConfig{
+			Enabled: false,
+			Retain:  128,
+		},
 	}
 }
 
@@ -86,6 +123,45 @@ func (c *Config) merge(c1 *Config) error {
 	if c1.StateStorage != "" {
 		c.StateStorage = c1.StateStorage
 	}
+	if c1.StateCache != 0 {
+		c.StateCache = c1.StateCache
+	}
+	if c1.Preimages {
+		c.Preimages = true
+	}
+	if c1.EVM != nil {
+		if c.EVM == nil {
+			c.EVM = &EVMConfig{}
+		}
+		if c1.EVM.JumpdestCacheSize != 0 {
+			c.EVM.JumpdestCacheSize = c1.EVM.JumpdestCacheSize
+		}
+	}
+	if c1.Executor != nil {
+		if c.Executor == nil {
+			c.Executor = &ExecutorConfig{}
+		}
+		if c1.Executor.Parallel {
+			c.Executor.Parallel = true
+		}
+		if c1.Executor.Workers != 0 {
+			c.Executor.Workers = c1.Executor.Workers
+		}
+	}
+	if c1.Prune != nil {
+		if c.Prune == nil {
+			c.Prune = &PruneConfig{}
+		}
+		if c1.Prune.Enabled {
+			c.Prune.Enabled = true
+		}
+		if c1.Prune.Retain != 0 {
+			c.Prune.Retain = c1.Prune.Retain
+		}
+		if c1.Prune.Checkpoint != 0 {
+			c.Prune.Checkpoint = c1.Prune.Checkpoint
+		}
+	}
 	if err := mergo.Merge(&c.Protocols, c1.Protocols, mergo.WithOverride); err != nil {
 		return err
 	}
@@ -186,6 +186,60 @@ func TestConfigFromFile(t *testing.T) {
 				},
 			},
 		},
+		{
+			`{
+				"evm": {
+					"jumpdest_cache_size": 100
+				}
+			}`,
+			&Config{
+				EVM: &EVMConfig{
+					JumpdestCacheSize: 100,
+				},
+			},
+		},
+		{
+			`{
+				"executor": {
+					"parallel": true,
+					"workers": 4
+				}
+			}`,
+			&Config{
+				Executor: &ExecutorConfig{
+					Parallel: true,
+					Workers:  4,
+				},
+			},
+		},
+		{
+			`{
+				"state_storage": "badgerdb",
+				"state_cache": 1024,
+				"preimages": true
+			}`,
+			&Config{
+				StateStorage: "badgerdb",
+				StateCache:   1024,
+				Preimages:    true,
+			},
+		},
+		{
+			`{
+				"prune": {
+					"enabled": true,
+					"retain": 64,
+					"checkpoint": 10000
+				}
+			}`,
+			&Config{
+				Prune: &PruneConfig{
+					Enabled:    true,
+					Retain:     64,
+					Checkpoint: 10000,
+				},
+			},
+		},
 	}
 
 	for _, c := range cases {
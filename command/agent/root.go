@@ -40,6 +40,8 @@ func init() {
 	agentCmd.Flags().Bool("seal", false, "Seal ...")
 	agentCmd.Flags().String("log-level", "", "Log-level ...")
 	agentCmd.Flags().String("state-storage", "", "State-storage ...")
+	agentCmd.Flags().Int("state-cache", 0, "State-cache ...")
+	agentCmd.Flags().Bool("preimages", false, "Preimages ...")
 	agentCmd.Flags().StringSlice("config", nil, "Config ...")
 
 	command.RegisterCmd(agentCmd)
@@ -69,6 +71,8 @@ func readConfig(cmd *cobra.Command, args []string) (*Config, error) {
 		cliConfig.Seal, _ = cmd.Flags().GetBool("seal")
 		cliConfig.LogLevel, _ = cmd.Flags().GetString("log-level")
 		cliConfig.StateStorage, _ = cmd.Flags().GetString("state-storage")
+		cliConfig.StateCache, _ = cmd.Flags().GetInt("state-cache")
+		cliConfig.Preimages, _ = cmd.Flags().GetBool("preimages")
 
 		// config file
 		if len(configFilePaths) != 0 {
@@ -0,0 +1,52 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/umbracle/minimal/blockchain/storage"
+	"github.com/umbracle/minimal/consensus"
+	"github.com/umbracle/minimal/state/runtime/precompiled"
+
+	consensusClique "github.com/umbracle/minimal/consensus/clique"
+	consensusEthash "github.com/umbracle/minimal/consensus/ethash"
+	consensusPOW "github.com/umbracle/minimal/consensus/pow"
+
+	storageBoltDB "github.com/umbracle/minimal/blockchain/storage/boltdb"
+	storageLevelDB "github.com/umbracle/minimal/blockchain/storage/leveldb"
+
+	itrie "github.com/umbracle/minimal/state/immutable-trie"
+)
+
+// BlockchainBackends are the storages of the blockchain by name
+var BlockchainBackends = map[string]storage.Factory{
+	"leveldb": storageLevelDB.Factory,
+	"boltdb":  storageBoltDB.Factory,
+}
+
+// StateBackends are the storages of the state by name
+var StateBackends = map[string]itrie.StorageFactory{
+	"leveldb":  itrie.LevelDBFactory,
+	"badgerdb": itrie.BadgerDBFactory,
+	"boltdb":   itrie.BoltDBFactory,
+}
+
+// ConsensusBackends are the consensus engines by name
+var ConsensusBackends = map[string]consensus.Factory{
+	"clique": consensusClique.Factory,
+	"ethash": consensusEthash.Factory,
+	"pow":    consensusPOW.Factory,
+}
+
+// PrecompiledBackends are the custom precompiled contracts that
+// can be enabled in the chain params
+var PrecompiledBackends = map[string]precompiled.Factory{}
+
+// RegisterPrecompile registers a custom precompiled contract that can be
+// enabled by name in the chain params. It has to be called before the
+// commands run, usually from an init function of the package of the contract.
+func RegisterPrecompile(name string, factory precompiled.Factory) {
+	if _, ok := PrecompiledBackends[name]; ok {
+		panic(fmt.Sprintf("precompiled contract %s already registered", name))
+	}
+	PrecompiledBackends[name] = factory
+}
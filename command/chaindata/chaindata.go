@@ -0,0 +1,185 @@
+package chaindata
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/umbracle/fastrlp"
+	"github.com/umbracle/minimal/chain"
+	"github.com/umbracle/minimal/command"
+	"github.com/umbracle/minimal/minimal"
+	"github.com/umbracle/minimal/types"
+)
+
+// maxBlockSize is the maximum size of an encoded block in the file
+const maxBlockSize = 32 * 1024 * 1024
+
+// loadChain loads the chain from a file or by name
+func loadChain(name string) (*chain.Chain, error) {
+	if _, err := os.Stat(name); err == nil {
+		return chain.ImportFromFile(name)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to stat (%s): %v", name, err)
+	}
+	return chain.ImportFromName(name)
+}
+
+// openChain opens the blockchain in the data directory with the consensus
+// and the executor of the chain to process blocks, like the agent. The data
+// directory is created with the genesis of the chain if it does not exist.
+func openChain(dataDir, storageName, stateStorage string, c *chain.Chain) (*minimal.ChainDB, error) {
+	config := &minimal.Config{
+		Chain:   c,
+		DataDir: dataDir,
+
+		BlockchainBackends: command.BlockchainBackends,
+		BlockchainEntries: map[string]*minimal.Entry{
+			storageName: {},
+		},
+
+		ConsensusBackends: command.ConsensusBackends,
+		ConsensusEntry:    &minimal.Entry{},
+
+		PrecompiledBackends: command.PrecompiledBackends,
+
+		StateBackends: command.StateBackends,
+		StateStorage:  stateStorage,
+	}
+	return minimal.OpenChainDB(hclog.NewNullLogger(), config)
+}
+
+// createFile creates the file for the export, the blocks are
+// compressed with gzip if the name of the file ends with .gz
+func createFile(path string) (io.WriteCloser, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+	return &gzipWriter{Writer: gzip.NewWriter(f), f: f}, nil
+}
+
+type gzipWriter struct {
+	*gzip.Writer
+	f *os.File
+}
+
+func (g *gzipWriter) Close() error {
+	if err := g.Writer.Close(); err != nil {
+		g.f.Close()
+		return err
+	}
+	return g.f.Close()
+}
+
+// openFile opens the file for the import, the blocks are
+// decompressed with gzip if the name of the file ends with .gz
+func openFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &gzipReader{Reader: zr, f: f}, nil
+}
+
+type gzipReader struct {
+	*gzip.Reader
+	f *os.File
+}
+
+func (g *gzipReader) Close() error {
+	g.Reader.Close()
+	return g.f.Close()
+}
+
+// blockWriter writes the blocks as concatenated RLP values
+type blockWriter struct {
+	w   io.Writer
+	ar  fastrlp.Arena
+	buf []byte
+}
+
+func newBlockWriter(w io.Writer) *blockWriter {
+	return &blockWriter{w: w}
+}
+
+func (b *blockWriter) write(block *types.Block) error {
+	b.buf = block.MarshalWith(&b.ar).MarshalTo(b.buf[:0])
+	b.ar.Reset()
+
+	_, err := b.w.Write(b.buf)
+	return err
+}
+
+// blockReader reads the blocks of a stream of concatenated RLP values
+type blockReader struct {
+	r *bufio.Reader
+}
+
+func newBlockReader(r io.Reader) *blockReader {
+	return &blockReader{r: bufio.NewReader(r)}
+}
+
+// next returns the next block of the stream or io.EOF at the end of the stream
+func (b *blockReader) next() (*types.Block, error) {
+	prefix, err := b.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if prefix < 0xc0 {
+		return nil, fmt.Errorf("expected a list but found prefix 0x%x", prefix)
+	}
+
+	head := []byte{prefix}
+	size := uint64(prefix - 0xc0)
+	if prefix > 0xf7 {
+		// long list, the prefix has the size of the length
+		n := prefix - 0xf7
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(b.r, buf); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		size = 0
+		for _, c := range buf {
+			size = size<<8 | uint64(c)
+		}
+		head = append(head, buf...)
+	}
+	if size > maxBlockSize {
+		return nil, fmt.Errorf("block of %d bytes is too large", size)
+	}
+
+	buf := make([]byte, uint64(len(head))+size)
+	copy(buf, head)
+	if _, err := io.ReadFull(b.r, buf[len(head):]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	block := &types.Block{}
+	if err := block.UnmarshalRLP(buf); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
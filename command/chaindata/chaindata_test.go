@@ -0,0 +1,209 @@
+package chaindata
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/umbracle/minimal/blockchain"
+	"github.com/umbracle/minimal/blockchain/storage/memory"
+	"github.com/umbracle/minimal/chain"
+	"github.com/umbracle/minimal/command"
+	"github.com/umbracle/minimal/consensus"
+	"github.com/umbracle/minimal/state"
+	itrie "github.com/umbracle/minimal/state/immutable-trie"
+	"github.com/umbracle/minimal/state/runtime"
+	"github.com/umbracle/minimal/state/runtime/precompiled"
+	"github.com/umbracle/minimal/types"
+)
+
+func newTestChain(t *testing.T, genesis *chain.Genesis) *blockchain.Blockchain {
+	s, err := memory.NewMemoryStorage(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := &chain.Params{
+		Forks: &chain.Forks{
+			Homestead: chain.NewFork(0),
+		},
+	}
+	executor := state.NewExecutor(params, itrie.NewState(itrie.NewMemoryStorage()))
+
+	b := blockchain.NewBlockchain(s, &consensus.NoProof{}, executor)
+	if err := b.WriteGenesis(genesis); err != nil {
+		t.Fatal(err)
+	}
+	executor.GetHash = b.GetHashHelper
+	return b
+}
+
+func writeTestBlocks(t *testing.T, b *blockchain.Blockchain, n int) {
+	parent, _ := b.Header()
+
+	blocks := []*types.Block{}
+	for i := 0; i < n; i++ {
+		header := &types.Header{
+			ParentHash:   parent.Hash,
+			Number:       parent.Number + 1,
+			Difficulty:   1,
+			GasLimit:     parent.GasLimit,
+			Timestamp:    parent.Timestamp + 1,
+			ExtraData:    []byte{},
+			StateRoot:    parent.StateRoot,
+			TxRoot:       types.EmptyRootHash,
+			ReceiptsRoot: types.EmptyRootHash,
+			Sha3Uncles:   types.EmptyUncleHash,
+		}
+		header.ComputeHash()
+
+		blocks = append(blocks, &types.Block{Header: header})
+		parent = header
+	}
+	if err := b.WriteBlocks(blocks); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chaindata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	genesis := &chain.Genesis{GasLimit: 5000, Difficulty: 1}
+
+	src := newTestChain(t, genesis)
+	writeTestBlocks(t, src, 10)
+	head, _ := src.Header()
+
+	for _, name := range []string{"chain.rlp", "chain.rlp.gz"} {
+		path := filepath.Join(dir, name)
+
+		f, err := createFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := exportBlocks(f, src, 0, head.Number); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		importFile := func(b *blockchain.Blockchain) (int, int, error) {
+			r, err := openFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			return importBlocks(r, b, 3, nil)
+		}
+
+		// the genesis is skipped since it is already in the chain
+		dst := newTestChain(t, genesis)
+		imported, known, err := importFile(dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if imported != 10 || known != 1 {
+			t.Fatalf("expected 10 imported and 1 known but found %d and %d", imported, known)
+		}
+		if dstHead, _ := dst.Header(); dstHead.Hash != head.Hash {
+			t.Fatal("the head of the chains is different")
+		}
+
+		// the blocks are already known
+		if imported, known, err = importFile(dst); err != nil {
+			t.Fatal(err)
+		}
+		if imported != 0 || known != 11 {
+			t.Fatalf("expected 0 imported and 11 known but found %d and %d", imported, known)
+		}
+
+		// the blocks belong to another chain
+		other := newTestChain(t, &chain.Genesis{GasLimit: 6000, Difficulty: 1})
+		if _, _, err := importFile(other); err == nil {
+			t.Fatal("expected a genesis mismatch")
+		}
+	}
+}
+
+func TestBlockReaderTruncated(t *testing.T) {
+	src := newTestChain(t, &chain.Genesis{GasLimit: 5000, Difficulty: 1})
+	writeTestBlocks(t, src, 2)
+
+	var buf bytes.Buffer
+	if err := exportBlocks(&buf, src, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	br := newBlockReader(bytes.NewReader(data[:len(data)-1]))
+	if block, err := br.next(); err != nil || block.Number() != 1 {
+		t.Fatal("expected the first block")
+	}
+	if _, err := br.next(); err == nil {
+		t.Fatal("expected an error for the truncated block")
+	}
+}
+
+// echoContract is a custom precompiled contract that returns its input
+type echoContract struct{}
+
+func (e *echoContract) Gas(input []byte, config *chain.ForksInTime) uint64 {
+	return 1
+}
+
+func (e *echoContract) Run(c *runtime.Contract, host runtime.Host, config *chain.ForksInTime) ([]byte, error) {
+	return c.Input, nil
+}
+
+func TestOpenChain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chaindata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	command.RegisterPrecompile("chaindata-echo", func(config map[string]interface{}) (precompiled.Contract, error) {
+		return &echoContract{}, nil
+	})
+
+	c := &chain.Chain{
+		Genesis: &chain.Genesis{GasLimit: 5000, Difficulty: 1},
+		Params: &chain.Params{
+			Forks:  &chain.Forks{Homestead: chain.NewFork(0)},
+			Engine: map[string]interface{}{"pow": map[string]interface{}{}},
+			Precompiles: []*chain.Precompile{
+				{Name: "chaindata-echo", Address: types.StringToAddress("100")},
+			},
+		},
+	}
+
+	// the chain is opened with the storages and the precompiles of the agent
+	for i := 0; i < 2; i++ {
+		db, err := openChain(dir, "boltdb", "badgerdb", c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		head, ok := db.Blockchain.Header()
+		if !ok || head.Number != 0 {
+			t.Fatal("expected the genesis as head")
+		}
+		if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := openChain(dir, "unknown", "badgerdb", c); err == nil {
+		t.Fatal("expected an error for an unknown storage")
+	}
+
+	c.Params.Precompiles[0].Name = "unknown"
+	if _, err := openChain(dir, "boltdb", "badgerdb", c); err == nil {
+		t.Fatal("expected an error for an unknown precompiled contract")
+	}
+}
@@ -0,0 +1,108 @@
+package chaindata
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/umbracle/minimal/blockchain"
+	"github.com/umbracle/minimal/command"
+	"github.com/umbracle/minimal/types"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <file> [from] [to]",
+	Short: "Export the canonical blocks as concatenated RLP, compressed with gzip if the file ends with .gz",
+	Run:   exportRun,
+	RunE:  exportRunE,
+}
+
+func init() {
+	exportCmd.Flags().String("data-dir", "./test-chain", "Data directory of the client")
+	command.AddDBFlags(exportCmd)
+
+	command.RegisterCmd(exportCmd)
+}
+
+func exportRun(cmd *cobra.Command, args []string) {
+	command.RunCmd(cmd, args, exportRunE)
+}
+
+func exportRunE(cmd *cobra.Command, args []string) error {
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	storageName, _ := cmd.Flags().GetString("storage")
+	stateStorage, _ := cmd.Flags().GetString("state-storage")
+
+	if len(args) < 1 || len(args) > 3 {
+		return fmt.Errorf("expected the file and optionally the first and the last block")
+	}
+
+	b, st, err := command.OpenDB(dataDir, storageName, stateStorage)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	defer b.Close()
+
+	head, ok := b.Header()
+	if !ok {
+		return fmt.Errorf("head block not found")
+	}
+
+	from, to := uint64(0), head.Number
+	if len(args) > 1 {
+		if from, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+			return fmt.Errorf("failed to parse the first block: %v", err)
+		}
+	}
+	if len(args) > 2 {
+		if to, err = strconv.ParseUint(args[2], 10, 64); err != nil {
+			return fmt.Errorf("failed to parse the last block: %v", err)
+		}
+	}
+	if from > to {
+		return fmt.Errorf("the first block %d is after the last block %d", from, to)
+	}
+	if to > head.Number {
+		return fmt.Errorf("the last block %d is after the head block %d", to, head.Number)
+	}
+
+	f, err := createFile(args[0])
+	if err != nil {
+		return err
+	}
+	if err := exportBlocks(f, b, from, to); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Exported blocks %d-%d to %s\n", from, to, args[0])
+	return nil
+}
+
+// exportBlocks writes the canonical blocks between from and to, both included
+func exportBlocks(w io.Writer, b *blockchain.Blockchain, from, to uint64) error {
+	bw := newBlockWriter(w)
+	for n := from; n <= to; n++ {
+		header, ok := b.GetHeaderByNumber(n)
+		if !ok {
+			return fmt.Errorf("block %d not found", n)
+		}
+		block := &types.Block{Header: header}
+		if header.TxRoot != types.EmptyRootHash || header.Sha3Uncles != types.EmptyUncleHash {
+			body, ok := b.GetBodyByHash(header.Hash)
+			if !ok {
+				return fmt.Errorf("body of block %d not found", n)
+			}
+			block.Transactions = body.Transactions
+			block.Uncles = body.Uncles
+		}
+		if err := bw.write(block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
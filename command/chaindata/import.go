@@ -0,0 +1,134 @@
+package chaindata
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/umbracle/minimal/blockchain"
+	"github.com/umbracle/minimal/command"
+	"github.com/umbracle/minimal/types"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import the blocks of a file exported as concatenated RLP, decompressed with gzip if the file ends with .gz",
+	Run:   importRun,
+	RunE:  importRunE,
+}
+
+func init() {
+	importCmd.Flags().String("data-dir", "./test-chain", "Data directory of the client")
+	command.AddDBFlags(importCmd)
+	importCmd.Flags().String("chain", "foundation", "Chain of the blocks, a file or the name of a known chain")
+	importCmd.Flags().Int("batch", 2500, "Number of blocks written at once")
+
+	command.RegisterCmd(importCmd)
+}
+
+func importRun(cmd *cobra.Command, args []string) {
+	command.RunCmd(cmd, args, importRunE)
+}
+
+func importRunE(cmd *cobra.Command, args []string) error {
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	storageName, _ := cmd.Flags().GetString("storage")
+	stateStorage, _ := cmd.Flags().GetString("state-storage")
+	chainName, _ := cmd.Flags().GetString("chain")
+	batch, _ := cmd.Flags().GetInt("batch")
+
+	if len(args) != 1 {
+		return fmt.Errorf("expected the file to import")
+	}
+	if batch <= 0 {
+		return fmt.Errorf("the batch has to be greater than zero")
+	}
+
+	c, err := loadChain(chainName)
+	if err != nil {
+		return fmt.Errorf("failed to load chain %s: %v", chainName, err)
+	}
+
+	f, err := openFile(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	db, err := openChain(dataDir, storageName, stateStorage, c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	b := db.Blockchain
+
+	progress := func(first, last *types.Block) {
+		fmt.Printf("Imported blocks %d-%d\n", first.Number(), last.Number())
+	}
+	imported, known, err := importBlocks(f, b, batch, progress)
+	if err != nil {
+		return err
+	}
+
+	head, _ := b.Header()
+	fmt.Printf("Imported %d blocks (%d already known), head block %d (%s)\n", imported, known, head.Number, head.Hash.String())
+	return nil
+}
+
+// importBlocks writes the blocks of the stream in batches and returns the number
+// of blocks imported and the number of blocks skipped because they were already
+// in the canonical chain. The progress is called after every batch.
+func importBlocks(r io.Reader, b *blockchain.Blockchain, batch int, progress func(first, last *types.Block)) (int, int, error) {
+	br := newBlockReader(r)
+
+	imported, known := 0, 0
+	blocks := make([]*types.Block, 0, batch)
+
+	write := func() error {
+		if len(blocks) == 0 {
+			return nil
+		}
+		if err := b.WriteBlocks(blocks); err != nil {
+			return fmt.Errorf("failed to import blocks %d-%d: %v", blocks[0].Number(), blocks[len(blocks)-1].Number(), err)
+		}
+		imported += len(blocks)
+		if progress != nil {
+			progress(blocks[0], blocks[len(blocks)-1])
+		}
+		blocks = blocks[:0]
+		return nil
+	}
+
+	for {
+		block, err := br.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return imported, known, fmt.Errorf("failed to read block %d: %v", imported+known+len(blocks), err)
+		}
+
+		// skip the blocks of the canonical chain, like the genesis
+		if len(blocks) == 0 {
+			if header, ok := b.GetHeaderByNumber(block.Number()); ok && header.Hash == block.Hash() {
+				known++
+				continue
+			}
+			if block.Number() == 0 {
+				return imported, known, fmt.Errorf("genesis mismatch: have %s, want %s", b.Genesis().String(), block.Hash().String())
+			}
+		}
+
+		blocks = append(blocks, block)
+		if len(blocks) == batch {
+			if err := write(); err != nil {
+				return imported, known, err
+			}
+		}
+	}
+	if err := write(); err != nil {
+		return imported, known, err
+	}
+	return imported, known, nil
+}
@@ -0,0 +1,54 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/umbracle/minimal/blockchain"
+	"github.com/umbracle/minimal/state"
+	itrie "github.com/umbracle/minimal/state/immutable-trie"
+)
+
+// AddDBFlags adds to the command the flags with the storages of the
+// blockchain and the state, they must match the ones of the client
+func AddDBFlags(cmd *cobra.Command) {
+	cmd.Flags().String("storage", "leveldb", "Storage of the blockchain (leveldb, boltdb)")
+	cmd.Flags().String("state-storage", "leveldb", "Storage of the state (leveldb, badgerdb, boltdb)")
+}
+
+// OpenDB opens the blockchain and the trie storage in the data directory
+// of the client with the storages of the given names. The client must not
+// be running.
+func OpenDB(dataDir, storageName, stateStorage string) (*blockchain.Blockchain, itrie.Storage, error) {
+	if _, err := os.Stat(dataDir); err != nil {
+		return nil, nil, fmt.Errorf("data dir %s not found: %v", dataDir, err)
+	}
+
+	storageFunc, ok := BlockchainBackends[storageName]
+	if !ok {
+		return nil, nil, fmt.Errorf("storage '%s' not found", storageName)
+	}
+	stateFunc, ok := StateBackends[stateStorage]
+	if !ok {
+		return nil, nil, fmt.Errorf("state storage '%s' not found", stateStorage)
+	}
+
+	config := map[string]interface{}{
+		"path":    filepath.Join(dataDir, "blockchain"),
+		"ancient": filepath.Join(dataDir, "ancient"),
+	}
+	db, err := storageFunc(config, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	st, err := stateFunc(map[string]interface{}{"path": filepath.Join(dataDir, "trie")}, nil)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	// the executor only gives access to the state, it cannot process blocks
+	executor := state.NewExecutor(nil, itrie.NewState(st))
+	return blockchain.NewBlockchain(db, nil, executor), st, nil
+}
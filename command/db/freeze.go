@@ -0,0 +1,75 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/umbracle/minimal/blockchain"
+	"github.com/umbracle/minimal/command"
+)
+
+var freezeCmd = &cobra.Command{
+	Use:   "freeze",
+	Short: "Move the final blocks of a database to the ancient store",
+	Run:   freezeRun,
+	RunE:  freezeRunE,
+}
+
+func init() {
+	freezeCmd.Flags().String("data-dir", "./test-chain", "Data directory of the client")
+	command.AddDBFlags(freezeCmd)
+	freezeCmd.Flags().Uint64("threshold", blockchain.FreezerThreshold, "Number of blocks below the head that are kept in the database")
+	freezeCmd.Flags().Uint64("batch", 10000, "Number of blocks moved at once")
+
+	dbCmd.AddCommand(freezeCmd)
+}
+
+func freezeRun(cmd *cobra.Command, args []string) {
+	command.RunCmd(cmd, args, freezeRunE)
+}
+
+func freezeRunE(cmd *cobra.Command, args []string) error {
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	storageName, _ := cmd.Flags().GetString("storage")
+	stateStorage, _ := cmd.Flags().GetString("state-storage")
+	threshold, _ := cmd.Flags().GetUint64("threshold")
+	batch, _ := cmd.Flags().GetUint64("batch")
+
+	if batch == 0 {
+		return fmt.Errorf("the batch cannot be zero")
+	}
+	if threshold < blockchain.FreezerThreshold {
+		return fmt.Errorf("the threshold cannot be lower than %d", blockchain.FreezerThreshold)
+	}
+
+	b, st, err := command.OpenDB(dataDir, storageName, stateStorage)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	defer b.Close()
+
+	head, ok := b.Header()
+	if !ok {
+		return fmt.Errorf("head block not found")
+	}
+	ancients, _ := b.Ancients()
+	if head.Number < threshold || head.Number-threshold <= ancients {
+		fmt.Printf("No blocks to freeze, %d blocks in the ancient store\n", ancients)
+		return nil
+	}
+
+	limit := head.Number - threshold
+	for ancients < limit {
+		next := ancients + batch
+		if next > limit {
+			next = limit
+		}
+		if err := b.Freeze(next); err != nil {
+			return fmt.Errorf("failed to freeze the blocks below %d: %v", next, err)
+		}
+		ancients = next
+		fmt.Printf("Moved the blocks below %d to the ancient store\n", ancients)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/umbracle/minimal/command"
+	"github.com/umbracle/minimal/minimal"
+	itrie "github.com/umbracle/minimal/state/immutable-trie"
+)
+
+var pruneStateCmd = &cobra.Command{
+	Use:   "prune-state",
+	Short: "Remove the state of the old blocks",
+	Run:   pruneStateRun,
+	RunE:  pruneStateRunE,
+}
+
+func init() {
+	pruneStateCmd.Flags().String("data-dir", "./test-chain", "Data directory of the client")
+	command.AddDBFlags(pruneStateCmd)
+	pruneStateCmd.Flags().Uint64("retain", 128, "Number of recent states to keep")
+	pruneStateCmd.Flags().Uint64("checkpoint", 0, "Keep the states of the blocks multiple of this number")
+
+	dbCmd.AddCommand(pruneStateCmd)
+}
+
+func pruneStateRun(cmd *cobra.Command, args []string) {
+	command.RunCmd(cmd, args, pruneStateRunE)
+}
+
+func pruneStateRunE(cmd *cobra.Command, args []string) error {
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	storageName, _ := cmd.Flags().GetString("storage")
+	stateStorage, _ := cmd.Flags().GetString("state-storage")
+	retain, _ := cmd.Flags().GetUint64("retain")
+	checkpoint, _ := cmd.Flags().GetUint64("checkpoint")
+
+	if retain == 0 {
+		return fmt.Errorf("at least one state must be retained")
+	}
+
+	b, st, err := command.OpenDB(dataDir, storageName, stateStorage)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	defer b.Close()
+
+	prunable, ok := st.(itrie.PrunableStorage)
+	if !ok {
+		return fmt.Errorf("state storage '%s' cannot be pruned", stateStorage)
+	}
+	deleted, err := minimal.PruneState(b, prunable, retain, checkpoint)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Pruned %d trie nodes\n", deleted)
+	return nil
+}
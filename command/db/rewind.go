@@ -0,0 +1,55 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/umbracle/minimal/command"
+)
+
+var rewindCmd = &cobra.Command{
+	Use:   "rewind",
+	Short: "Rewind the chain to a previous block",
+	Run:   rewindRun,
+	RunE:  rewindRunE,
+}
+
+func init() {
+	rewindCmd.Flags().String("data-dir", "./test-chain", "Data directory of the client")
+	command.AddDBFlags(rewindCmd)
+	rewindCmd.Flags().Int64("block", -1, "Number of the new head block")
+
+	dbCmd.AddCommand(rewindCmd)
+}
+
+func rewindRun(cmd *cobra.Command, args []string) {
+	command.RunCmd(cmd, args, rewindRunE)
+}
+
+func rewindRunE(cmd *cobra.Command, args []string) error {
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	storageName, _ := cmd.Flags().GetString("storage")
+	stateStorage, _ := cmd.Flags().GetString("state-storage")
+	block, _ := cmd.Flags().GetInt64("block")
+
+	if block < 0 {
+		return fmt.Errorf("the number of the block is required")
+	}
+
+	b, st, err := command.OpenDB(dataDir, storageName, stateStorage)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	defer b.Close()
+
+	head, err := b.SetHead(uint64(block))
+	if err != nil {
+		return err
+	}
+	if head.Number != uint64(block) {
+		fmt.Printf("The state of block %d is not available\n", block)
+	}
+	fmt.Printf("Rewound the chain to block %d (%s)\n", head.Number, head.Hash.String())
+	return nil
+}
@@ -0,0 +1,25 @@
+package db
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/umbracle/minimal/command"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Offline tools to manage the database of the client",
+	Run:   dbRun,
+	RunE:  dbRunE,
+}
+
+func init() {
+	command.RegisterCmd(dbCmd)
+}
+
+func dbRun(cmd *cobra.Command, args []string) {
+	command.RunCmd(cmd, args, dbRunE)
+}
+
+func dbRunE(cmd *cobra.Command, args []string) error {
+	return cmd.Help()
+}
@@ -0,0 +1,186 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+	"github.com/umbracle/minimal/blockchain"
+	"github.com/umbracle/minimal/chain"
+	"github.com/umbracle/minimal/command"
+	"github.com/umbracle/minimal/crypto"
+	"github.com/umbracle/minimal/helper/hex"
+	"github.com/umbracle/minimal/network"
+	"github.com/umbracle/minimal/network/discovery"
+	discoveryDevP2P "github.com/umbracle/minimal/network/discovery/devp2p"
+	"github.com/umbracle/minimal/network/transport/rlpx"
+	"github.com/umbracle/minimal/protocol/ethereum"
+	itrie "github.com/umbracle/minimal/state/immutable-trie"
+	"github.com/umbracle/minimal/types"
+)
+
+var verifyStateCmd = &cobra.Command{
+	Use:   "verify-state",
+	Short: "Check that the state of a root is complete and not corrupted",
+	Run:   verifyStateRun,
+	RunE:  verifyStateRunE,
+}
+
+func init() {
+	verifyStateCmd.Flags().String("data-dir", "./test-chain", "Data directory of the client")
+	command.AddDBFlags(verifyStateCmd)
+	verifyStateCmd.Flags().String("root", "", "State root to verify, the root of the head block if not set")
+	verifyStateCmd.Flags().Bool("repair", false, "Download the missing and corrupted items from the peers")
+	verifyStateCmd.Flags().String("chain", "foundation", "Chain of the data directory, used to find peers during the repair")
+	verifyStateCmd.Flags().Int("port", 30304, "Port to connect with the peers during the repair")
+	verifyStateCmd.Flags().Duration("timeout", time.Minute, "Time to wait for the first peer during the repair")
+
+	dbCmd.AddCommand(verifyStateCmd)
+}
+
+func verifyStateRun(cmd *cobra.Command, args []string) {
+	command.RunCmd(cmd, args, verifyStateRunE)
+}
+
+func verifyStateRunE(cmd *cobra.Command, args []string) error {
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	storageName, _ := cmd.Flags().GetString("storage")
+	stateStorage, _ := cmd.Flags().GetString("state-storage")
+	rootStr, _ := cmd.Flags().GetString("root")
+	repair, _ := cmd.Flags().GetBool("repair")
+
+	b, st, err := command.OpenDB(dataDir, storageName, stateStorage)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	defer b.Close()
+
+	var root types.Hash
+	if rootStr == "" {
+		header, ok := b.Header()
+		if !ok {
+			return fmt.Errorf("head block not found")
+		}
+		root = header.StateRoot
+	} else {
+		buf, err := hex.DecodeHex(rootStr)
+		if err != nil || len(buf) != types.HashLength {
+			return fmt.Errorf("invalid state root %s", rootStr)
+		}
+		root = types.BytesToHash(buf)
+	}
+
+	s := itrie.NewState(st)
+	if verifyState(s, root) == 0 {
+		return nil
+	}
+	if !repair {
+		return fmt.Errorf("the state is not complete")
+	}
+
+	chainName, _ := cmd.Flags().GetString("chain")
+	port, _ := cmd.Flags().GetInt("port")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	fmt.Println("Downloading the missing items from the peers")
+	if err := repairState(dataDir, chainName, port, timeout, b, s, root); err != nil {
+		return fmt.Errorf("failed to repair the state: %v", err)
+	}
+	if verifyState(s, root) != 0 {
+		return fmt.Errorf("the state is not complete after the repair")
+	}
+	return nil
+}
+
+// verifyState prints the issues found in the state and returns the number of them
+func verifyState(s *itrie.State, root types.Hash) int {
+	fmt.Printf("Verifying state %s\n", root.String())
+
+	issues := 0
+	stats := s.Verify(root, func(issue *itrie.VerifyIssue) {
+		fmt.Println(issue.String())
+		issues++
+	})
+	fmt.Printf("Checked %d nodes, %d accounts, %d storage slots and %d codes, found %d issues\n", stats.Nodes, stats.Accounts, stats.Slots, stats.Codes, issues)
+	return issues
+}
+
+// repairState connects to the peers of the chain with an ethereum backend
+// that does not sync the blocks and downloads the missing state items
+func repairState(dataDir, chainName string, port int, timeout time.Duration, b *blockchain.Blockchain, s *itrie.State, root types.Hash) error {
+	var c *chain.Chain
+	var err error
+	if _, err = os.Stat(chainName); err == nil {
+		c, err = chain.ImportFromFile(chainName)
+	} else {
+		c, err = chain.ImportFromName(chainName)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load chain %s: %v", chainName, err)
+	}
+
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:  "repair",
+		Level: hclog.LevelFromString("INFO"),
+	})
+
+	// the node uses a new key since the agent is not running
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return err
+	}
+
+	serverConfig := network.DefaultConfig()
+	serverConfig.BindAddress = "0.0.0.0"
+	serverConfig.BindPort = port
+	serverConfig.Bootnodes = c.Bootnodes
+	serverConfig.DataDir = filepath.Join(dataDir, "network")
+
+	transport := &rlpx.Rlpx{
+		Logger: logger.Named("Rlpx"),
+	}
+	server := network.NewServer("minimal", key, serverConfig, logger.Named("server"), transport)
+
+	// dial the peers known by the agent first
+	peerstore, err := network.NewBoltDBPeerStore(serverConfig.DataDir)
+	if err != nil {
+		return err
+	}
+	server.SetPeerStore(peerstore)
+
+	discoveryConfig := &discovery.BackendConfig{
+		Logger: logger.Named("devp2p"),
+		Key:    key,
+		Enode:  server.Enode,
+		Config: map[string]interface{}{"bootnodes": c.Bootnodes},
+	}
+	if server.Discovery, err = discoveryDevP2P.Factory(context.Background(), discoveryConfig); err != nil {
+		peerstore.Close()
+		return err
+	}
+	defer server.Discovery.Close()
+
+	backend, err := ethereum.NewBackend(nil, logger.Named("ethereum"), b)
+	if err != nil {
+		peerstore.Close()
+		return err
+	}
+	backend.NetworkID = uint64(c.Params.ChainID)
+
+	if err := server.RegisterProtocol(backend.Protocols()); err != nil {
+		peerstore.Close()
+		return err
+	}
+	if err := server.Schedule(); err != nil {
+		peerstore.Close()
+		return err
+	}
+	defer server.Close()
+
+	return backend.RepairState(s, root, timeout)
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/umbracle/minimal/state"
 	itrie "github.com/umbracle/minimal/state/immutable-trie"
 	"github.com/umbracle/minimal/state/runtime/evm"
+	"github.com/umbracle/minimal/state/runtime/ewasm"
 	"github.com/umbracle/minimal/state/runtime/precompiled"
 	"github.com/umbracle/minimal/types"
 )
@@ -69,6 +70,7 @@ func versionRunE(cmd *cobra.Command, args []string) error {
 			EIP150:         chain.NewFork(0),
 			EIP155:         chain.NewFork(0),
 			EIP158:         chain.NewFork(0),
+			EWASM:          chain.NewFork(0),
 		},
 		ChainID: 1234,
 	}
@@ -86,6 +88,7 @@ func versionRunE(cmd *cobra.Command, args []string) error {
 
 	executor := state.NewExecutor(params, stateI)
 	executor.SetRuntime(precompiled.NewPrecompiled())
+	executor.SetRuntime(ewasm.NewEWASM())
 	executor.SetRuntime(evm.NewEVM())
 
 	bChain := blockchain.NewBlockchain(inmemBlockchainStorage, engine, executor)
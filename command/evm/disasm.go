@@ -0,0 +1,67 @@
+package evm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/umbracle/minimal/command"
+	"github.com/umbracle/minimal/helper/hex"
+	"github.com/umbracle/minimal/state/runtime/evm"
+	"github.com/umbracle/minimal/types"
+)
+
+var evmDisasmCmd = &cobra.Command{
+	Use:   "disasm [code]",
+	Short: "Disassemble EVM bytecode",
+	Run:   evmDisasmRun,
+	RunE:  evmDisasmRunE,
+}
+
+func init() {
+	evmCmd.AddCommand(evmDisasmCmd)
+}
+
+func evmDisasmRun(cmd *cobra.Command, args []string) {
+	command.RunCmd(cmd, args, evmDisasmRunE)
+}
+
+func evmDisasmRunE(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected one argument")
+	}
+	code, err := types.ParseBytes(&args[0])
+	if err != nil {
+		return fmt.Errorf("failed to decode code: %v", err)
+	}
+	fmt.Print(disassemble(code))
+	return nil
+}
+
+// disassemble returns one line per instruction with its offset, name
+// and, for the PUSH instructions, the immediate value
+func disassemble(code []byte) string {
+	var b strings.Builder
+	for i := 0; i < len(code); i++ {
+		op := evm.OpCode(code[i])
+
+		name := op.String()
+		if name == "" {
+			name = fmt.Sprintf("INVALID (0x%02x)", code[i])
+		}
+		fmt.Fprintf(&b, "%05d: %s", i, name)
+
+		if op >= evm.PUSH1 && op <= evm.PUSH32 {
+			size := int(op-evm.PUSH1) + 1
+			end := i + 1 + size
+			if end > len(code) {
+				// truncated push at the end of the code
+				end = len(code)
+			}
+			fmt.Fprintf(&b, " %s", hex.EncodeToHex(code[i+1:end]))
+			i = end - 1
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
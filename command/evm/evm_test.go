@@ -0,0 +1,49 @@
+package evm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/minimal/helper/hex"
+)
+
+func TestDisassemble(t *testing.T) {
+	cases := []struct {
+		code string
+		asm  string
+	}{
+		{
+			"0x6001600201",
+			"00000: PUSH1 0x01\n00002: PUSH1 0x02\n00004: ADD\n",
+		},
+		{
+			// truncated push
+			"0x61aa",
+			"00000: PUSH2 0xaa\n",
+		},
+		{
+			"0xfe",
+			"00000: INVALID (0xfe)\n",
+		},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.asm, disassemble(hex.MustDecodeHex(c.code)))
+	}
+}
+
+func TestEVMRunner(t *testing.T) {
+	// stores 1 + 2 in the slot 0 and returns 1
+	r := &evmRunner{
+		code:  hex.MustDecodeHex("0x6001600201600055600160005260206000f3"),
+		gas:   100000,
+		value: big.NewInt(0),
+	}
+
+	res, err := r.run()
+	assert.NoError(t, err)
+	assert.NoError(t, res.err)
+	assert.Equal(t, uint64(20030), res.gasUsed)
+	assert.Equal(t, big.NewInt(1), new(big.Int).SetBytes(res.ret))
+}
@@ -0,0 +1,25 @@
+package evm
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/umbracle/minimal/command"
+)
+
+var evmCmd = &cobra.Command{
+	Use:   "evm",
+	Short: "Run and debug EVM bytecode without a chain",
+	Run:   evmRun,
+	RunE:  evmRunE,
+}
+
+func init() {
+	command.RegisterCmd(evmCmd)
+}
+
+func evmRun(cmd *cobra.Command, args []string) {
+	command.RunCmd(cmd, args, evmRunE)
+}
+
+func evmRunE(cmd *cobra.Command, args []string) error {
+	return cmd.Usage()
+}
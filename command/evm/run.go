@@ -0,0 +1,203 @@
+package evm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/umbracle/minimal/chain"
+	"github.com/umbracle/minimal/command"
+	"github.com/umbracle/minimal/helper/hex"
+	"github.com/umbracle/minimal/state"
+	itrie "github.com/umbracle/minimal/state/immutable-trie"
+	"github.com/umbracle/minimal/state/runtime/evm"
+	"github.com/umbracle/minimal/state/runtime/precompiled"
+	"github.com/umbracle/minimal/types"
+)
+
+var evmRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run EVM bytecode on an in-memory state",
+	Run:   evmRunRun,
+	RunE:  evmRunRunE,
+}
+
+var defaultReceiver = "0x0000000000000000000000000000000000001000"
+
+func init() {
+	evmRunCmd.Flags().String("code", "", "Bytecode to execute")
+	evmRunCmd.Flags().String("input", "", "Input of the call")
+	evmRunCmd.Flags().Uint64("gas", 10000000, "Gas limit of the call")
+	evmRunCmd.Flags().String("sender", "0x0000000000000000000000000000000000000000", "Address of the caller")
+	evmRunCmd.Flags().String("receiver", defaultReceiver, "Address of the contract")
+	evmRunCmd.Flags().String("value", "0", "Value sent with the call")
+	evmRunCmd.Flags().String("genesis", "", "Genesis file with the initial state")
+	evmRunCmd.Flags().Bool("trace", false, "Print the trace of the execution")
+
+	evmCmd.AddCommand(evmRunCmd)
+}
+
+func evmRunRun(cmd *cobra.Command, args []string) {
+	command.RunCmd(cmd, args, evmRunRunE)
+}
+
+func evmRunRunE(cmd *cobra.Command, args []string) error {
+	flags := cmd.Flags()
+
+	codeStr, _ := flags.GetString("code")
+	inputStr, _ := flags.GetString("input")
+	valueStr, _ := flags.GetString("value")
+	senderStr, _ := flags.GetString("sender")
+	receiverStr, _ := flags.GetString("receiver")
+	genesisPath, _ := flags.GetString("genesis")
+
+	r := &evmRunner{}
+	r.gas, _ = flags.GetUint64("gas")
+	r.sender = types.StringToAddress(senderStr)
+	r.receiver = types.StringToAddress(receiverStr)
+
+	var err error
+	if r.code, err = types.ParseBytes(&codeStr); err != nil {
+		return fmt.Errorf("failed to decode code: %v", err)
+	}
+	if r.input, err = types.ParseBytes(&inputStr); err != nil {
+		return fmt.Errorf("failed to decode input: %v", err)
+	}
+	if r.value, err = types.ParseUint256orHex(&valueStr); err != nil {
+		return fmt.Errorf("failed to decode value: %v", err)
+	}
+
+	if genesisPath != "" {
+		data, err := ioutil.ReadFile(genesisPath)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &r.genesis); err != nil {
+			return fmt.Errorf("failed to decode genesis: %v", err)
+		}
+	}
+
+	if trace, _ := flags.GetBool("trace"); trace {
+		r.tracer = &printTracer{out: os.Stdout}
+	}
+
+	res, err := r.run()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Return:   %s\n", hex.EncodeToHex(res.ret))
+	fmt.Printf("Gas used: %d\n", res.gasUsed)
+	if res.err != nil {
+		fmt.Printf("Error:    %v\n", res.err)
+	}
+	return nil
+}
+
+// evmRunner executes a single call against an in-memory state
+type evmRunner struct {
+	code     []byte
+	input    []byte
+	gas      uint64
+	value    *big.Int
+	sender   types.Address
+	receiver types.Address
+	genesis  *chain.Genesis
+	tracer   evm.Tracer
+}
+
+type evmResult struct {
+	ret     []byte
+	gasUsed uint64
+	err     error
+}
+
+func (r *evmRunner) run() (*evmResult, error) {
+	genesis := r.genesis
+	if genesis == nil {
+		genesis = &chain.Genesis{
+			GasLimit: r.gas,
+		}
+	}
+
+	alloc := chain.GenesisAlloc{}
+	for addr, account := range genesis.Alloc {
+		alloc[addr] = account
+	}
+	if len(r.code) != 0 {
+		account := alloc[r.receiver]
+		account.Code = r.code
+		alloc[r.receiver] = account
+	}
+
+	// everything enabled
+	params := &chain.Params{
+		Forks: &chain.Forks{
+			Homestead:      chain.NewFork(0),
+			Byzantium:      chain.NewFork(0),
+			Constantinople: chain.NewFork(0),
+			Petersburg:     chain.NewFork(0),
+			EIP150:         chain.NewFork(0),
+			EIP155:         chain.NewFork(0),
+			EIP158:         chain.NewFork(0),
+		},
+	}
+
+	executor := state.NewExecutor(params, itrie.NewState(itrie.NewMemoryStorage()))
+	executor.GetHash = func(*types.Header) state.GetHashByNumber {
+		return func(i uint64) types.Hash {
+			return types.Hash{}
+		}
+	}
+	executor.SetRuntime(precompiled.NewPrecompiled())
+
+	evmConfig := evm.DefaultConfig()
+	evmConfig.Tracer = r.tracer
+	executor.SetRuntime(evm.NewEVMWithConfig(evmConfig))
+
+	root := executor.WriteGenesis(alloc)
+
+	header := genesis.ToBlock()
+	if header.GasLimit < r.gas {
+		header.GasLimit = r.gas
+	}
+	transition, err := executor.BeginTxn(root, header)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, gasLeft, vmErr := transition.Call2(r.sender, r.receiver, r.input, r.value, r.gas)
+	res := &evmResult{
+		ret:     ret,
+		gasUsed: r.gas - gasLeft,
+		err:     vmErr,
+	}
+	return res, nil
+}
+
+// printTracer writes one line per executed instruction
+type printTracer struct {
+	out io.Writer
+}
+
+// TraceStack implements the evm.StackTracer interface
+func (p *printTracer) TraceStack() bool {
+	return true
+}
+
+func (p *printTracer) CaptureState(depth int, pc uint64, op evm.OpCode, gas, cost uint64, stack []*big.Int, memory []byte, err error) {
+	items := make([]string, len(stack))
+	for i, v := range stack {
+		items[i] = "0x" + v.Text(16)
+	}
+	fmt.Fprintf(p.out, "depth=%d pc=%05d op=%-14s gas=%d cost=%d stack=[%s]", depth, pc, op.String(), gas, cost, strings.Join(items, " "))
+	if err != nil {
+		fmt.Fprintf(p.out, " err=%v", err)
+	}
+	fmt.Fprintln(p.out)
+}
@@ -0,0 +1,200 @@
+package state
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/umbracle/minimal/chain"
+	"github.com/umbracle/minimal/command"
+	"github.com/umbracle/minimal/helper/hex"
+	itrie "github.com/umbracle/minimal/state/immutable-trie"
+	"github.com/umbracle/minimal/types"
+)
+
+var dumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Dump the accounts and the storage of the state of a block",
+	Long:  "Dump the accounts and the storage of the state of a block. The addresses and the storage keys are only known if the client runs with the preimages enabled.",
+	Run:   dumpRun,
+	RunE:  dumpRunE,
+}
+
+func init() {
+	dumpCmd.Flags().String("data-dir", "./test-chain", "Data directory of the client")
+	command.AddDBFlags(dumpCmd)
+	dumpCmd.Flags().Int64("block", -1, "Number of the block, the head block if not set")
+	dumpCmd.Flags().String("format", "json", "Output format (json, genesis)")
+
+	stateCmd.AddCommand(dumpCmd)
+}
+
+func dumpRun(cmd *cobra.Command, args []string) {
+	command.RunCmd(cmd, args, dumpRunE)
+}
+
+func dumpRunE(cmd *cobra.Command, args []string) error {
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	storageName, _ := cmd.Flags().GetString("storage")
+	stateStorage, _ := cmd.Flags().GetString("state-storage")
+	block, _ := cmd.Flags().GetInt64("block")
+	format, _ := cmd.Flags().GetString("format")
+
+	var dumper stateDumper
+	switch format {
+	case "json":
+		dumper = &jsonDumper{}
+	case "genesis":
+		dumper = &genesisDumper{}
+	default:
+		return fmt.Errorf("format %s not supported", format)
+	}
+
+	b, st, err := command.OpenDB(dataDir, storageName, stateStorage)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	defer b.Close()
+
+	var header *types.Header
+	var ok bool
+	if block < 0 {
+		header, ok = b.Header()
+	} else {
+		header, ok = b.GetHeaderByNumber(uint64(block))
+	}
+	if !ok {
+		return fmt.Errorf("block not found")
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	return dumpState(out, itrie.NewState(st), header.StateRoot, dumper)
+}
+
+// stateDumper encodes the accounts of the dump
+type stateDumper interface {
+	header(root types.Hash) string
+	account(account *itrie.DumpAccount) (string, interface{}, error)
+	footer() string
+}
+
+// dumpState writes the dump as a json object with one key per account
+func dumpState(w io.Writer, s *itrie.State, root types.Hash, dumper stateDumper) error {
+	if _, err := io.WriteString(w, dumper.header(root)); err != nil {
+		return err
+	}
+
+	first := true
+	err := s.Dump(root, nil, nil, func(account *itrie.DumpAccount) error {
+		key, obj, err := dumper.account(account)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(obj, "    ", "  ")
+		if err != nil {
+			return err
+		}
+		sep := ",\n"
+		if first {
+			sep = "\n"
+			first = false
+		}
+		_, err = fmt.Fprintf(w, "%s    %q: %s", sep, key, data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, dumper.footer())
+	return err
+}
+
+// jsonDumper dumps all the information of the accounts. The accounts and
+// slots are referenced by their hash if the preimage is not known.
+type jsonDumper struct{}
+
+type jsonAccount struct {
+	AddressHash string            `json:"addressHash"`
+	Balance     string            `json:"balance"`
+	Nonce       uint64            `json:"nonce"`
+	Root        string            `json:"root"`
+	CodeHash    string            `json:"codeHash"`
+	Code        string            `json:"code,omitempty"`
+	Storage     map[string]string `json:"storage,omitempty"`
+}
+
+func (j *jsonDumper) header(root types.Hash) string {
+	return fmt.Sprintf("{\n  \"root\": %q,\n  \"accounts\": {", root.String())
+}
+
+func (j *jsonDumper) account(account *itrie.DumpAccount) (string, interface{}, error) {
+	obj := &jsonAccount{
+		AddressHash: account.Hash.String(),
+		Balance:     account.Account.Balance.String(),
+		Nonce:       account.Account.Nonce,
+		Root:        account.Account.Root.String(),
+		CodeHash:    hex.EncodeToHex(account.Account.CodeHash),
+	}
+	if len(account.Code) != 0 {
+		obj.Code = hex.EncodeToHex(account.Code)
+	}
+	if len(account.Storage) != 0 {
+		obj.Storage = map[string]string{}
+		for _, slot := range account.Storage {
+			key := slot.Hash
+			if slot.Key != nil {
+				key = *slot.Key
+			}
+			obj.Storage[key.String()] = slot.Value.String()
+		}
+	}
+
+	key := account.Hash.String()
+	if account.Address != nil {
+		key = account.Address.String()
+	}
+	return key, obj, nil
+}
+
+func (j *jsonDumper) footer() string {
+	return "\n  }\n}\n"
+}
+
+// genesisDumper dumps the accounts as the alloc of a genesis file
+type genesisDumper struct{}
+
+func (g *genesisDumper) header(root types.Hash) string {
+	return "{"
+}
+
+func (g *genesisDumper) account(account *itrie.DumpAccount) (string, interface{}, error) {
+	if account.Address == nil {
+		return "", nil, fmt.Errorf("preimage of the account %s not found", account.Hash)
+	}
+	obj := chain.GenesisAccount{
+		Balance: account.Account.Balance,
+		Nonce:   account.Account.Nonce,
+		Code:    account.Code,
+	}
+	if len(account.Storage) != 0 {
+		obj.Storage = map[types.Hash]types.Hash{}
+		for _, slot := range account.Storage {
+			if slot.Key == nil {
+				return "", nil, fmt.Errorf("preimage of the slot %s of the account %s not found", slot.Hash, account.Address)
+			}
+			obj.Storage[*slot.Key] = slot.Value
+		}
+	}
+	return account.Address.String(), obj, nil
+}
+
+func (g *genesisDumper) footer() string {
+	return "\n}\n"
+}
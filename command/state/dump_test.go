@@ -0,0 +1,99 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/umbracle/minimal/chain"
+	"github.com/umbracle/minimal/crypto"
+	minimalstate "github.com/umbracle/minimal/state"
+	itrie "github.com/umbracle/minimal/state/immutable-trie"
+	"github.com/umbracle/minimal/types"
+)
+
+func testDumpState(t *testing.T) (*itrie.State, types.Hash) {
+	s := itrie.NewState(itrie.NewMemoryStorage())
+	s.EnablePreimages()
+	_, root := s.NewSnapshot().Commit([]*minimalstate.Object{
+		{
+			Address:   types.StringToAddress("1"),
+			Balance:   big.NewInt(100),
+			Nonce:     5,
+			CodeHash:  types.BytesToHash(crypto.Keccak256([]byte{0x1})),
+			Root:      types.EmptyRootHash,
+			DirtyCode: true,
+			Code:      []byte{0x1},
+			Storage: []*minimalstate.StorageObject{
+				{Key: types.StringToHash("2").Bytes(), Val: types.StringToHash("3").Bytes()},
+			},
+		},
+		{
+			Address:  types.StringToAddress("4"),
+			Balance:  big.NewInt(1),
+			CodeHash: types.BytesToHash(crypto.Keccak256(nil)),
+			Root:     types.EmptyRootHash,
+		},
+	})
+	return s, types.BytesToHash(root)
+}
+
+func TestDumpJSON(t *testing.T) {
+	s, root := testDumpState(t)
+
+	var buf bytes.Buffer
+	if err := dumpState(&buf, s, root, &jsonDumper{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var dump struct {
+		Root     string
+		Accounts map[string]*jsonAccount
+	}
+	if err := json.Unmarshal(buf.Bytes(), &dump); err != nil {
+		t.Fatal(err)
+	}
+	if dump.Root != root.String() {
+		t.Fatal("bad root")
+	}
+	account, ok := dump.Accounts[types.StringToAddress("1").String()]
+	if !ok {
+		t.Fatal("account not found")
+	}
+	if account.Balance != "100" || account.Nonce != 5 || account.Code != "0x01" {
+		t.Fatal("bad account")
+	}
+	if account.Storage[types.StringToHash("2").String()] != types.StringToHash("3").String() {
+		t.Fatal("bad storage")
+	}
+}
+
+func TestDumpGenesis(t *testing.T) {
+	s, root := testDumpState(t)
+
+	var buf bytes.Buffer
+	if err := dumpState(&buf, s, root, &genesisDumper{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var alloc map[string]chain.GenesisAccount
+	if err := json.Unmarshal(buf.Bytes(), &alloc); err != nil {
+		t.Fatal(err)
+	}
+	if len(alloc) != 2 {
+		t.Fatalf("expected two accounts but found %d", len(alloc))
+	}
+
+	// the alloc builds the same state
+	s2 := itrie.NewState(itrie.NewMemoryStorage())
+	executor := minimalstate.NewExecutor(&chain.Params{Forks: &chain.Forks{}}, s2)
+
+	genesis := chain.GenesisAlloc{}
+	for addr, account := range alloc {
+		genesis[types.StringToAddress(addr)] = account
+	}
+	if executor.WriteGenesis(genesis) != root {
+		t.Fatal("the alloc does not produce the same state")
+	}
+}
@@ -0,0 +1,25 @@
+package state
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/umbracle/minimal/command"
+)
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Offline tools to inspect the state of the client",
+	Run:   stateRun,
+	RunE:  stateRunE,
+}
+
+func init() {
+	command.RegisterCmd(stateCmd)
+}
+
+func stateRun(cmd *cobra.Command, args []string) {
+	command.RunCmd(cmd, args, stateRunE)
+}
+
+func stateRunE(cmd *cobra.Command, args []string) error {
+	return cmd.Help()
+}
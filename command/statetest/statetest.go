@@ -0,0 +1,157 @@
+package statetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/umbracle/minimal/command"
+	"github.com/umbracle/minimal/state/runtime/evm"
+	"github.com/umbracle/minimal/tests/statetest"
+)
+
+var statetestCmd = &cobra.Command{
+	Use:   "statetest [file|dir]",
+	Short: "Run GeneralStateTests fixtures",
+	Run:   statetestRun,
+	RunE:  statetestRunE,
+}
+
+func init() {
+	statetestCmd.Flags().String("fork", "", "Only run the post states of this fork")
+	statetestCmd.Flags().Bool("json", false, "Output the results in json")
+	statetestCmd.Flags().Bool("trace", false, "Print the trace of the execution to stderr")
+
+	command.RegisterCmd(statetestCmd)
+}
+
+func statetestRun(cmd *cobra.Command, args []string) {
+	command.RunCmd(cmd, args, statetestRunE)
+}
+
+func statetestRunE(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected one argument")
+	}
+
+	fork, _ := cmd.Flags().GetString("fork")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	trace, _ := cmd.Flags().GetBool("trace")
+
+	if fork != "" {
+		if _, ok := statetest.Forks[fork]; !ok {
+			return fmt.Errorf("fork %s not supported", fork)
+		}
+	}
+
+	var tracer evm.Tracer
+	if trace {
+		tracer = &stderrTracer{out: os.Stderr}
+	}
+
+	files, err := statetest.ListFiles(args[0])
+	if err != nil {
+		return err
+	}
+
+	results := []*statetest.Result{}
+	for _, file := range files {
+		res, err := statetest.RunFile(file, fork, tracer)
+		if err != nil {
+			return err
+		}
+		results = append(results, res...)
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(results, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Print(formatResults(results))
+	}
+
+	failed := 0
+	for _, res := range results {
+		if !res.Pass {
+			failed++
+		}
+	}
+	if failed != 0 {
+		return fmt.Errorf("%d/%d tests failed", failed, len(results))
+	}
+	return nil
+}
+
+func formatResults(results []*statetest.Result) string {
+	var b strings.Builder
+
+	passed := 0
+	for _, res := range results {
+		if res.Pass {
+			passed++
+			fmt.Fprintf(&b, "PASS %s %s %d\n", res.Name, res.Fork, res.Index)
+			continue
+		}
+
+		fmt.Fprintf(&b, "FAIL %s %s %d (%s)\n", res.Name, res.Fork, res.Index, res.File)
+		if res.Error != "" {
+			fmt.Fprintf(&b, "  error: %s\n", res.Error)
+			continue
+		}
+		if res.Root != res.ExpectedRoot {
+			fmt.Fprintf(&b, "  state root: expected %s but found %s\n", res.ExpectedRoot.String(), res.Root.String())
+		}
+		if res.Logs != res.ExpectedLogs {
+			fmt.Fprintf(&b, "  logs hash: expected %s but found %s\n", res.ExpectedLogs.String(), res.Logs.String())
+		}
+	}
+
+	fmt.Fprintf(&b, "%d/%d passed\n", passed, len(results))
+	return b.String()
+}
+
+// stderrTracer writes one json line per executed instruction
+type stderrTracer struct {
+	out io.Writer
+}
+
+type traceLine struct {
+	Depth int      `json:"depth"`
+	Pc    uint64   `json:"pc"`
+	Op    string   `json:"op"`
+	Gas   uint64   `json:"gas"`
+	Cost  uint64   `json:"gasCost"`
+	Stack []string `json:"stack"`
+	Error string   `json:"error,omitempty"`
+}
+
+// TraceStack implements the evm.StackTracer interface
+func (s *stderrTracer) TraceStack() bool {
+	return true
+}
+
+func (s *stderrTracer) CaptureState(depth int, pc uint64, op evm.OpCode, gas, cost uint64, stack []*big.Int, memory []byte, err error) {
+	line := &traceLine{
+		Depth: depth,
+		Pc:    pc,
+		Op:    op.String(),
+		Gas:   gas,
+		Cost:  cost,
+		Stack: make([]string, len(stack)),
+	}
+	for i, v := range stack {
+		line.Stack[i] = "0x" + v.Text(16)
+	}
+	if err != nil {
+		line.Error = err.Error()
+	}
+	data, _ := json.Marshal(line)
+	fmt.Fprintln(s.out, string(data))
+}
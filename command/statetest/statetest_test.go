@@ -0,0 +1,84 @@
+package statetest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/minimal/tests/statetest"
+)
+
+// transfer sends 10 wei to 0x1000, the expected state root is wrong
+var transfer = `{
+	"transfer": {
+		"env": {
+			"currentCoinbase": "0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba",
+			"currentDifficulty": "0x020000",
+			"currentGasLimit": "0x7fffffffffffffff",
+			"currentNumber": "0x01",
+			"currentTimestamp": "0x03e8"
+		},
+		"pre": {
+			"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b": {
+				"balance": "0x0de0b6b3a7640000",
+				"code": "0x",
+				"nonce": "0x00",
+				"storage": {}
+			}
+		},
+		"post": {
+			"Byzantium": [
+				{
+					"hash": "0x0000000000000000000000000000000000000000000000000000000000000001",
+					"indexes": {"data": 0, "gas": 0, "value": 0},
+					"logs": "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347"
+				}
+			]
+		},
+		"transaction": {
+			"data": ["0x"],
+			"gasLimit": ["0x5208"],
+			"gasPrice": "0x01",
+			"nonce": "0x00",
+			"secretKey": "0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8",
+			"to": "0x0000000000000000000000000000000000001000",
+			"value": ["0x0a"]
+		}
+	}
+}`
+
+func TestStatetestFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "statetest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "transfer.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(transfer), 0644))
+
+	// the failed tests are an error of the command
+	err = statetestRunE(statetestCmd, []string{path})
+	assert.EqualError(t, err, "1/1 tests failed")
+}
+
+func TestFormatResults(t *testing.T) {
+	dir, err := ioutil.TempDir("", "statetest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "transfer.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(transfer), 0644))
+
+	results, err := statetest.RunFile(path, "", nil)
+	assert.NoError(t, err)
+
+	// only the roots are printed on a mismatch
+	out := formatResults(results)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	assert.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "FAIL transfer Byzantium 0"))
+	assert.True(t, strings.HasPrefix(lines[1], "  state root: expected 0x0000000000000000000000000000000000000000000000000000000000000001 but found"))
+	assert.Equal(t, "0/1 passed", lines[2])
+}
@@ -0,0 +1,293 @@
+package bls12381
+
+import (
+	"errors"
+	"math/big"
+	"math/bits"
+)
+
+// fe is an element of the base field Fp in Montgomery form.
+// The limbs are in little endian order.
+type fe [6]uint64
+
+var (
+	// pBig is the modulus of the base field
+	pBig, _ = new(big.Int).SetString("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab", 16)
+
+	// modulus is the modulus as limbs (not in Montgomery form)
+	modulus = limbsFromBig(pBig)
+
+	// inp is -p^-1 mod 2^64
+	inp = negInverse64(modulus[0])
+
+	// r1 is R mod p, the one in Montgomery form
+	r1 = limbsFromBig(new(big.Int).Mod(new(big.Int).Lsh(big.NewInt(1), 384), pBig))
+
+	// r2 is R^2 mod p, used to convert to Montgomery form
+	r2 = limbsFromBig(new(big.Int).Mod(new(big.Int).Lsh(big.NewInt(1), 768), pBig))
+
+	// exponents used for the inversion, the square root and the legendre symbol
+	pMinus2     = new(big.Int).Sub(pBig, big.NewInt(2))
+	pPlus1Div4  = new(big.Int).Rsh(new(big.Int).Add(pBig, big.NewInt(1)), 2)
+	pMinus1Div2 = new(big.Int).Rsh(new(big.Int).Sub(pBig, big.NewInt(1)), 1)
+	pMinus3Div4 = new(big.Int).Rsh(new(big.Int).Sub(pBig, big.NewInt(3)), 2)
+)
+
+var errInvalidFieldElement = errors.New("invalid field element")
+
+// negInverse64 returns -x^-1 mod 2^64 for an odd x
+func negInverse64(x uint64) uint64 {
+	// Newton iteration for x^-1 mod 2^64
+	inv := uint64(1)
+	for i := 0; i < 6; i++ {
+		inv *= 2 - x*inv
+	}
+	return -inv
+}
+
+func limbsFromBig(b *big.Int) fe {
+	var z fe
+	words := padBytes(b.Bytes(), 48)
+	for i := 0; i < 6; i++ {
+		for j := 0; j < 8; j++ {
+			z[i] |= uint64(words[47-i*8-j]) << (8 * uint(j))
+		}
+	}
+	return z
+}
+
+func limbsToBig(z *fe) *big.Int {
+	buf := make([]byte, 48)
+	for i := 0; i < 6; i++ {
+		for j := 0; j < 8; j++ {
+			buf[47-i*8-j] = byte(z[i] >> (8 * uint(j)))
+		}
+	}
+	return new(big.Int).SetBytes(buf)
+}
+
+// newFe returns the field element of a canonical integer
+func newFe(b *big.Int) *fe {
+	z := limbsFromBig(new(big.Int).Mod(b, pBig))
+	mul(&z, &z, &r2)
+	return &z
+}
+
+// feFromHex returns the field element of a hex string, it panics
+// if the string is not valid
+func feFromHex(str string) *fe {
+	b, ok := new(big.Int).SetString(str, 16)
+	if !ok {
+		panic("invalid hex field element " + str)
+	}
+	return newFe(b)
+}
+
+// feFromBytes decodes a 48 bytes big endian field element
+func feFromBytes(buf []byte) (*fe, error) {
+	if len(buf) != 48 {
+		return nil, errInvalidFieldElement
+	}
+	b := new(big.Int).SetBytes(buf)
+	if b.Cmp(pBig) >= 0 {
+		return nil, errInvalidFieldElement
+	}
+	return newFe(b), nil
+}
+
+// big returns the canonical integer of the field element
+func (z *fe) big() *big.Int {
+	var c fe
+	mul(&c, z, &fe{1})
+	return limbsToBig(&c)
+}
+
+// bytes encodes the field element as 48 bytes in big endian
+func (z *fe) bytes() []byte {
+	return padBytes(z.big().Bytes(), 48)
+}
+
+// padBytes left pads the buffer with zeros up to size bytes
+func padBytes(buf []byte, size int) []byte {
+	if len(buf) >= size {
+		return buf
+	}
+	res := make([]byte, size)
+	copy(res[size-len(buf):], buf)
+	return res
+}
+
+func (z *fe) set(x *fe) *fe {
+	*z = *x
+	return z
+}
+
+func (z *fe) zero() *fe {
+	*z = fe{}
+	return z
+}
+
+func (z *fe) one() *fe {
+	*z = r1
+	return z
+}
+
+func (z *fe) isZero() bool {
+	return *z == fe{}
+}
+
+func (z *fe) isOne() bool {
+	return *z == r1
+}
+
+func (z *fe) equal(x *fe) bool {
+	return *z == *x
+}
+
+// sign0 returns the parity of the canonical integer of the field element
+func (z *fe) sign0() bool {
+	var c fe
+	mul(&c, z, &fe{1})
+	return c[0]&1 == 1
+}
+
+// gte returns true if x >= y as integers
+func gte(x, y *fe) bool {
+	for i := 5; i >= 0; i-- {
+		if x[i] != y[i] {
+			return x[i] > y[i]
+		}
+	}
+	return true
+}
+
+func add(z, x, y *fe) {
+	var carry uint64
+	for i := 0; i < 6; i++ {
+		z[i], carry = bits.Add64(x[i], y[i], carry)
+	}
+	// the modulus is lower than 2^382 so the sum does not overflow
+	if gte(z, &modulus) {
+		subNoReduce(z, z, &modulus)
+	}
+}
+
+func double(z, x *fe) {
+	add(z, x, x)
+}
+
+func subNoReduce(z, x, y *fe) uint64 {
+	var borrow uint64
+	for i := 0; i < 6; i++ {
+		z[i], borrow = bits.Sub64(x[i], y[i], borrow)
+	}
+	return borrow
+}
+
+func sub(z, x, y *fe) {
+	if subNoReduce(z, x, y) != 0 {
+		var carry uint64
+		for i := 0; i < 6; i++ {
+			z[i], carry = bits.Add64(z[i], modulus[i], carry)
+		}
+	}
+}
+
+func neg(z, x *fe) {
+	if x.isZero() {
+		z.zero()
+		return
+	}
+	subNoReduce(z, &modulus, x)
+}
+
+// mul computes the Montgomery multiplication z = x * y * R^-1 mod p
+func mul(z, x, y *fe) {
+	var t [8]uint64
+	var c, carry, hi, lo uint64
+
+	for i := 0; i < 6; i++ {
+		// t += x * y[i]
+		c = 0
+		for j := 0; j < 6; j++ {
+			hi, lo = bits.Mul64(x[j], y[i])
+			lo, carry = bits.Add64(lo, t[j], 0)
+			hi += carry
+			lo, carry = bits.Add64(lo, c, 0)
+			hi += carry
+			t[j] = lo
+			c = hi
+		}
+		t[6], carry = bits.Add64(t[6], c, 0)
+		t[7] = carry
+
+		// t = (t + m * p) / 2^64
+		m := t[0] * inp
+		hi, lo = bits.Mul64(m, modulus[0])
+		_, carry = bits.Add64(lo, t[0], 0)
+		c = hi + carry
+		for j := 1; j < 6; j++ {
+			hi, lo = bits.Mul64(m, modulus[j])
+			lo, carry = bits.Add64(lo, t[j], 0)
+			hi += carry
+			lo, carry = bits.Add64(lo, c, 0)
+			hi += carry
+			t[j-1] = lo
+			c = hi
+		}
+		t[5], carry = bits.Add64(t[6], c, 0)
+		t[6] = t[7] + carry
+		t[7] = 0
+	}
+
+	res := fe{t[0], t[1], t[2], t[3], t[4], t[5]}
+	if gte(&res, &modulus) {
+		subNoReduce(&res, &res, &modulus)
+	}
+	*z = res
+}
+
+func square(z, x *fe) {
+	mul(z, x, x)
+}
+
+// exp computes z = x^e
+func exp(z, x *fe, e *big.Int) {
+	res := r1
+	base := *x
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		square(&res, &res)
+		if e.Bit(i) == 1 {
+			mul(&res, &res, &base)
+		}
+	}
+	*z = res
+}
+
+// inverse computes z = x^-1, the inverse of zero is zero
+func inverse(z, x *fe) {
+	exp(z, x, pMinus2)
+}
+
+// sqrt computes the square root of x and returns false
+// if x is not a quadratic residue
+func sqrt(z, x *fe) bool {
+	var s, c fe
+	exp(&s, x, pPlus1Div4)
+	square(&c, &s)
+	if !c.equal(x) {
+		return false
+	}
+	*z = s
+	return true
+}
+
+// isSquare returns true if x is a quadratic residue or zero
+func isSquare(x *fe) bool {
+	if x.isZero() {
+		return true
+	}
+	var c fe
+	exp(&c, x, pMinus1Div2)
+	return c.isOne()
+}
@@ -0,0 +1,107 @@
+package bls12381
+
+import "math/big"
+
+// fe12 is an element of the quadratic extension Fp12 = Fp6[w] / (w^2 - v)
+type fe12 [2]fe6
+
+// frobeniusCoeffs are the powers gamma^k for k in [0, 6) with
+// gamma = (1 + u)^((p - 1) / 6). The Frobenius map of w^k is gamma^k w^k.
+var frobeniusCoeffs [6]fe2
+
+func init() {
+	e := new(big.Int).Sub(pBig, big.NewInt(1))
+	e.Div(e, big.NewInt(6))
+
+	var xi, gamma fe2
+	xi[0].one()
+	xi[1].one()
+	exp2(&gamma, &xi, e)
+
+	frobeniusCoeffs[0].one()
+	for i := 1; i < 6; i++ {
+		mul2(&frobeniusCoeffs[i], &frobeniusCoeffs[i-1], &gamma)
+	}
+}
+
+func (z *fe12) one() *fe12 {
+	z[0].one()
+	z[1].zero()
+	return z
+}
+
+func (z *fe12) isOne() bool {
+	var one fe12
+	one.one()
+	return *z == one
+}
+
+func (z *fe12) equal(x *fe12) bool {
+	return *z == *x
+}
+
+func mul12(z, x, y *fe12) {
+	var t0, t1, t2, t3 fe6
+	mul6(&t0, &x[0], &y[0])
+	mul6(&t1, &x[1], &y[1])
+
+	// c1 = (x0 + x1)(y0 + y1) - t0 - t1
+	add6(&t2, &x[0], &x[1])
+	add6(&t3, &y[0], &y[1])
+	mul6(&t2, &t2, &t3)
+	sub6(&t2, &t2, &t0)
+	sub6(&z[1], &t2, &t1)
+
+	// c0 = t0 + t1 v
+	mulByV(&t1, &t1)
+	add6(&z[0], &t0, &t1)
+}
+
+func square12(z, x *fe12) {
+	mul12(z, x, x)
+}
+
+// conjugate12 computes the conjugate of the element which is
+// also its power to p^6
+func conjugate12(z, x *fe12) {
+	z[0] = x[0]
+	neg6(&z[1], &x[1])
+}
+
+func inverse12(z, x *fe12) {
+	// 1 / (x0 + x1 w) = (x0 - x1 w) / (x0^2 - x1^2 v)
+	var t0, t1 fe6
+	square6(&t0, &x[0])
+	square6(&t1, &x[1])
+	mulByV(&t1, &t1)
+	sub6(&t0, &t0, &t1)
+	inverse6(&t0, &t0)
+
+	mul6(&z[0], &x[0], &t0)
+	mul6(&t1, &x[1], &t0)
+	neg6(&z[1], &t1)
+}
+
+func exp12(z, x *fe12, e *big.Int) {
+	var res fe12
+	res.one()
+	base := *x
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		square12(&res, &res)
+		if e.Bit(i) == 1 {
+			mul12(&res, &res, &base)
+		}
+	}
+	*z = res
+}
+
+// frobenius12 computes z = x^p. The coefficient j of the part i
+// multiplies w^(2j + i).
+func frobenius12(z, x *fe12) {
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 3; j++ {
+			conjugate2(&z[i][j], &x[i][j])
+			mul2(&z[i][j], &z[i][j], &frobeniusCoeffs[2*j+i])
+		}
+	}
+}
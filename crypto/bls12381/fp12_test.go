@@ -0,0 +1,75 @@
+package bls12381
+
+import (
+	"testing"
+)
+
+func randFe12(t *testing.T) *fe12 {
+	var z fe12
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 2; k++ {
+				x, _ := randFe(t)
+				z[i][j][k] = *x
+			}
+		}
+	}
+	return &z
+}
+
+func TestFp2Sqrt(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		x, _ := randFe(t)
+		y, _ := randFe(t)
+		a := &fe2{*x, *y}
+
+		var a2, s, c fe2
+		square2(&a2, a)
+		if !isSquare2(&a2) {
+			t.Fatal("square not detected")
+		}
+		if !sqrt2(&s, &a2) {
+			t.Fatal("square root not found")
+		}
+		square2(&c, &s)
+		if !c.equal(&a2) {
+			t.Fatal("bad square root")
+		}
+	}
+}
+
+func TestFp12Inverse(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		x := randFe12(t)
+
+		var y fe12
+		inverse12(&y, x)
+		mul12(&y, &y, x)
+		if !y.isOne() {
+			t.Fatal("bad inverse")
+		}
+	}
+}
+
+func TestFp12Frobenius(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		x := randFe12(t)
+
+		var y, z fe12
+		frobenius12(&y, x)
+		exp12(&z, x, pBig)
+		if !y.equal(&z) {
+			t.Fatal("bad frobenius")
+		}
+
+		// the sixth power of the frobenius is the conjugate
+		y = *x
+		for j := 0; j < 6; j++ {
+			frobenius12(&y, &y)
+		}
+		conjugate12(&z, x)
+		if !y.equal(&z) {
+			t.Fatal("bad conjugate")
+		}
+	}
+}
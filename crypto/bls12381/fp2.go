@@ -0,0 +1,208 @@
+package bls12381
+
+import "math/big"
+
+// fe2 is an element of the quadratic extension Fp2 = Fp[u] / (u^2 + 1)
+type fe2 [2]fe
+
+func fe2FromHex(c0, c1 string) *fe2 {
+	return &fe2{*feFromHex(c0), *feFromHex(c1)}
+}
+
+// fe2FromBytes decodes the two coefficients of the element each one
+// as a 48 bytes big endian field element
+func fe2FromBytes(buf []byte) (*fe2, error) {
+	if len(buf) != 96 {
+		return nil, errInvalidFieldElement
+	}
+	c0, err := feFromBytes(buf[:48])
+	if err != nil {
+		return nil, err
+	}
+	c1, err := feFromBytes(buf[48:])
+	if err != nil {
+		return nil, err
+	}
+	return &fe2{*c0, *c1}, nil
+}
+
+func (z *fe2) bytes() []byte {
+	return append(z[0].bytes(), z[1].bytes()...)
+}
+
+func (z *fe2) set(x *fe2) *fe2 {
+	*z = *x
+	return z
+}
+
+func (z *fe2) zero() *fe2 {
+	*z = fe2{}
+	return z
+}
+
+func (z *fe2) one() *fe2 {
+	z[0].one()
+	z[1].zero()
+	return z
+}
+
+func (z *fe2) isZero() bool {
+	return z[0].isZero() && z[1].isZero()
+}
+
+func (z *fe2) isOne() bool {
+	return z[0].isOne() && z[1].isZero()
+}
+
+func (z *fe2) equal(x *fe2) bool {
+	return *z == *x
+}
+
+// sign0 returns the sign of the element as defined in the hash to curve
+// specification, the sign of the first coefficient unless it is zero
+func (z *fe2) sign0() bool {
+	if z[0].isZero() {
+		return z[1].sign0()
+	}
+	return z[0].sign0()
+}
+
+func add2(z, x, y *fe2) {
+	add(&z[0], &x[0], &y[0])
+	add(&z[1], &x[1], &y[1])
+}
+
+func double2(z, x *fe2) {
+	double(&z[0], &x[0])
+	double(&z[1], &x[1])
+}
+
+func sub2(z, x, y *fe2) {
+	sub(&z[0], &x[0], &y[0])
+	sub(&z[1], &x[1], &y[1])
+}
+
+func neg2(z, x *fe2) {
+	neg(&z[0], &x[0])
+	neg(&z[1], &x[1])
+}
+
+func conjugate2(z, x *fe2) {
+	z[0] = x[0]
+	neg(&z[1], &x[1])
+}
+
+func mul2(z, x, y *fe2) {
+	var t0, t1, t2, t3 fe
+	mul(&t0, &x[0], &y[0])
+	mul(&t1, &x[1], &y[1])
+
+	// (x0 + x1)(y0 + y1) - x0y0 - x1y1
+	add(&t2, &x[0], &x[1])
+	add(&t3, &y[0], &y[1])
+	mul(&t2, &t2, &t3)
+	sub(&t2, &t2, &t0)
+	sub(&z[1], &t2, &t1)
+	sub(&z[0], &t0, &t1)
+}
+
+func square2(z, x *fe2) {
+	var t0, t1, t2 fe
+	// (x0 + x1)(x0 - x1) + 2x0x1 u
+	add(&t0, &x[0], &x[1])
+	sub(&t1, &x[0], &x[1])
+	mul(&t2, &x[0], &x[1])
+	mul(&z[0], &t0, &t1)
+	double(&z[1], &t2)
+}
+
+// mulByFe multiplies the element by an element of the base field
+func mulByFe(z *fe2, x *fe2, y *fe) {
+	mul(&z[0], &x[0], y)
+	mul(&z[1], &x[1], y)
+}
+
+// mulByNonResidue multiplies the element by the non residue 1 + u
+// used to build the sextic extension
+func mulByNonResidue(z, x *fe2) {
+	var t fe
+	sub(&t, &x[0], &x[1])
+	add(&z[1], &x[0], &x[1])
+	z[0] = t
+}
+
+func inverse2(z, x *fe2) {
+	// 1 / (x0 + x1 u) = (x0 - x1 u) / (x0^2 + x1^2)
+	var t0, t1 fe
+	square(&t0, &x[0])
+	square(&t1, &x[1])
+	add(&t0, &t0, &t1)
+	inverse(&t0, &t0)
+	mul(&z[0], &x[0], &t0)
+	mul(&t1, &x[1], &t0)
+	neg(&z[1], &t1)
+}
+
+func exp2(z, x *fe2, e *big.Int) {
+	var res fe2
+	res.one()
+	base := *x
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		square2(&res, &res)
+		if e.Bit(i) == 1 {
+			mul2(&res, &res, &base)
+		}
+	}
+	*z = res
+}
+
+// isSquare2 returns true if x is a quadratic residue or zero
+func isSquare2(x *fe2) bool {
+	// x is a square in Fp2 if its norm is a square in Fp
+	var t0, t1 fe
+	square(&t0, &x[0])
+	square(&t1, &x[1])
+	add(&t0, &t0, &t1)
+	return isSquare(&t0)
+}
+
+// sqrt2 computes the square root of x with the algorithm 9 of
+// https://eprint.iacr.org/2012/685.pdf and returns false if x
+// is not a quadratic residue
+func sqrt2(z, x *fe2) bool {
+	if x.isZero() {
+		z.zero()
+		return true
+	}
+
+	var a1, alpha, x0, c fe2
+	exp2(&a1, x, pMinus3Div4)
+	square2(&alpha, &a1)
+	mul2(&alpha, &alpha, x)
+	mul2(&x0, &a1, x)
+
+	var minusOne fe2
+	minusOne.one()
+	neg2(&minusOne, &minusOne)
+
+	if alpha.equal(&minusOne) {
+		// multiply by u
+		var t fe
+		neg(&t, &x0[1])
+		x0[1] = x0[0]
+		x0[0] = t
+	} else {
+		var b fe2
+		b.one()
+		add2(&b, &b, &alpha)
+		exp2(&b, &b, pMinus1Div2)
+		mul2(&x0, &b, &x0)
+	}
+
+	square2(&c, &x0)
+	if !c.equal(x) {
+		return false
+	}
+	*z = x0
+	return true
+}
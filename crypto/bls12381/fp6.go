@@ -0,0 +1,119 @@
+package bls12381
+
+// fe6 is an element of the cubic extension Fp6 = Fp2[v] / (v^3 - (1 + u))
+type fe6 [3]fe2
+
+func (z *fe6) zero() *fe6 {
+	*z = fe6{}
+	return z
+}
+
+func (z *fe6) one() *fe6 {
+	z.zero()
+	z[0].one()
+	return z
+}
+
+func (z *fe6) isZero() bool {
+	return z[0].isZero() && z[1].isZero() && z[2].isZero()
+}
+
+func add6(z, x, y *fe6) {
+	add2(&z[0], &x[0], &y[0])
+	add2(&z[1], &x[1], &y[1])
+	add2(&z[2], &x[2], &y[2])
+}
+
+func sub6(z, x, y *fe6) {
+	sub2(&z[0], &x[0], &y[0])
+	sub2(&z[1], &x[1], &y[1])
+	sub2(&z[2], &x[2], &y[2])
+}
+
+func neg6(z, x *fe6) {
+	neg2(&z[0], &x[0])
+	neg2(&z[1], &x[1])
+	neg2(&z[2], &x[2])
+}
+
+func mul6(z, x, y *fe6) {
+	var t0, t1, t2, s0, s1, c0, c1, c2 fe2
+	mul2(&t0, &x[0], &y[0])
+	mul2(&t1, &x[1], &y[1])
+	mul2(&t2, &x[2], &y[2])
+
+	// c0 = t0 + ((x1 + x2)(y1 + y2) - t1 - t2) * (1 + u)
+	add2(&s0, &x[1], &x[2])
+	add2(&s1, &y[1], &y[2])
+	mul2(&c0, &s0, &s1)
+	sub2(&c0, &c0, &t1)
+	sub2(&c0, &c0, &t2)
+	mulByNonResidue(&c0, &c0)
+	add2(&c0, &c0, &t0)
+
+	// c1 = (x0 + x1)(y0 + y1) - t0 - t1 + t2 * (1 + u)
+	add2(&s0, &x[0], &x[1])
+	add2(&s1, &y[0], &y[1])
+	mul2(&c1, &s0, &s1)
+	sub2(&c1, &c1, &t0)
+	sub2(&c1, &c1, &t1)
+	mulByNonResidue(&s0, &t2)
+	add2(&c1, &c1, &s0)
+
+	// c2 = (x0 + x2)(y0 + y2) - t0 - t2 + t1
+	add2(&s0, &x[0], &x[2])
+	add2(&s1, &y[0], &y[2])
+	mul2(&c2, &s0, &s1)
+	sub2(&c2, &c2, &t0)
+	sub2(&c2, &c2, &t2)
+	add2(&c2, &c2, &t1)
+
+	z[0], z[1], z[2] = c0, c1, c2
+}
+
+func square6(z, x *fe6) {
+	mul6(z, x, x)
+}
+
+// mulByV multiplies the element by v
+func mulByV(z, x *fe6) {
+	var t fe2
+	mulByNonResidue(&t, &x[2])
+	z[2] = x[1]
+	z[1] = x[0]
+	z[0] = t
+}
+
+func inverse6(z, x *fe6) {
+	var c0, c1, c2, t0, t1 fe2
+
+	// c0 = x0^2 - (1 + u) x1 x2
+	square2(&c0, &x[0])
+	mul2(&t0, &x[1], &x[2])
+	mulByNonResidue(&t0, &t0)
+	sub2(&c0, &c0, &t0)
+
+	// c1 = (1 + u) x2^2 - x0 x1
+	square2(&c1, &x[2])
+	mulByNonResidue(&c1, &c1)
+	mul2(&t0, &x[0], &x[1])
+	sub2(&c1, &c1, &t0)
+
+	// c2 = x1^2 - x0 x2
+	square2(&c2, &x[1])
+	mul2(&t0, &x[0], &x[2])
+	sub2(&c2, &c2, &t0)
+
+	// t = x0 c0 + (1 + u)(x2 c1 + x1 c2)
+	mul2(&t0, &x[2], &c1)
+	mul2(&t1, &x[1], &c2)
+	add2(&t0, &t0, &t1)
+	mulByNonResidue(&t0, &t0)
+	mul2(&t1, &x[0], &c0)
+	add2(&t0, &t0, &t1)
+	inverse2(&t0, &t0)
+
+	mul2(&z[0], &c0, &t0)
+	mul2(&z[1], &c1, &t0)
+	mul2(&z[2], &c2, &t0)
+}
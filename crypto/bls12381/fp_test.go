@@ -0,0 +1,78 @@
+package bls12381
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func randFe(t *testing.T) (*fe, *big.Int) {
+	b, err := rand.Int(rand.Reader, pBig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newFe(b), b
+}
+
+func TestFpArithmetic(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		x, xb := randFe(t)
+		y, yb := randFe(t)
+
+		var z fe
+		check := func(op string, expected *big.Int) {
+			expected.Mod(expected, pBig)
+			if z.big().Cmp(expected) != 0 {
+				t.Fatalf("bad %s: %s %s", op, xb, yb)
+			}
+		}
+
+		add(&z, x, y)
+		check("add", new(big.Int).Add(xb, yb))
+
+		sub(&z, x, y)
+		check("sub", new(big.Int).Sub(xb, yb))
+
+		neg(&z, x)
+		check("neg", new(big.Int).Neg(xb))
+
+		mul(&z, x, y)
+		check("mul", new(big.Int).Mul(xb, yb))
+
+		inverse(&z, x)
+		check("inverse", new(big.Int).ModInverse(xb, pBig))
+	}
+}
+
+func TestFpSqrt(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		x, _ := randFe(t)
+
+		var x2, s, c fe
+		square(&x2, x)
+		if !isSquare(&x2) {
+			t.Fatal("square not detected")
+		}
+		if !sqrt(&s, &x2) {
+			t.Fatal("square root not found")
+		}
+		square(&c, &s)
+		if !c.equal(&x2) {
+			t.Fatal("bad square root")
+		}
+	}
+}
+
+func TestFpBytes(t *testing.T) {
+	x, _ := randFe(t)
+	y, err := feFromBytes(x.bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !x.equal(y) {
+		t.Fatal("bad encoding")
+	}
+	if _, err := feFromBytes(padBytes(pBig.Bytes(), 48)); err == nil {
+		t.Fatal("the modulus is not a valid field element")
+	}
+}
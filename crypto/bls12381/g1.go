@@ -0,0 +1,285 @@
+package bls12381
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	errInvalidEncoding = errors.New("invalid point encoding")
+	errNotOnCurve      = errors.New("point is not on the curve")
+)
+
+// rBig is the order of the groups G1, G2 and GT
+var rBig, _ = new(big.Int).SetString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001", 16)
+
+// xBig is the absolute value of the parameter x of the curve, x is negative
+var xBig, _ = new(big.Int).SetString("d201000000010000", 16)
+
+var (
+	// b1 is the b coefficient of the curve y^2 = x^3 + 4
+	b1 = feFromHex("4")
+
+	g1Gen = &G1{
+		x: *feFromHex("17f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb"),
+		y: *feFromHex("08b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1"),
+		z: r1,
+	}
+)
+
+// G1 is a point of the curve E(Fp) in Jacobian coordinates
+type G1 struct {
+	x, y, z fe
+}
+
+// G1Generator returns the generator of the group G1
+func G1Generator() *G1 {
+	return new(G1).Set(g1Gen)
+}
+
+// Set sets the point to a
+func (p *G1) Set(a *G1) *G1 {
+	*p = *a
+	return p
+}
+
+// IsInfinity returns true if the point is the point at infinity
+func (p *G1) IsInfinity() bool {
+	return p.z.isZero()
+}
+
+func (p *G1) setInfinity() *G1 {
+	p.x.zero()
+	p.y.one()
+	p.z.zero()
+	return p
+}
+
+// affine returns the affine coordinates of the point
+func (p *G1) affine() (x, y fe) {
+	var zInv, zInv2 fe
+	inverse(&zInv, &p.z)
+	square(&zInv2, &zInv)
+	mul(&x, &p.x, &zInv2)
+	mul(&zInv2, &zInv2, &zInv)
+	mul(&y, &p.y, &zInv2)
+	return
+}
+
+// Equal returns true if both points are the same
+func (p *G1) Equal(a *G1) bool {
+	if p.IsInfinity() || a.IsInfinity() {
+		return p.IsInfinity() && a.IsInfinity()
+	}
+	// compare x1 z2^2 == x2 z1^2 and y1 z2^3 == y2 z1^3
+	var z1, z2, t0, t1 fe
+	square(&z1, &p.z)
+	square(&z2, &a.z)
+	mul(&t0, &p.x, &z2)
+	mul(&t1, &a.x, &z1)
+	if !t0.equal(&t1) {
+		return false
+	}
+	mul(&z1, &z1, &p.z)
+	mul(&z2, &z2, &a.z)
+	mul(&t0, &p.y, &z2)
+	mul(&t1, &a.y, &z1)
+	return t0.equal(&t1)
+}
+
+// IsOnCurve returns true if the point satisfies the curve equation
+func (p *G1) IsOnCurve() bool {
+	if p.IsInfinity() {
+		return true
+	}
+	x, y := p.affine()
+	return isOnCurveG1(&x, &y)
+}
+
+func isOnCurveG1(x, y *fe) bool {
+	var lhs, rhs fe
+	square(&lhs, y)
+	square(&rhs, x)
+	mul(&rhs, &rhs, x)
+	add(&rhs, &rhs, b1)
+	return lhs.equal(&rhs)
+}
+
+// IsInSubgroup returns true if the point belongs to the subgroup of order r
+func (p *G1) IsInSubgroup() bool {
+	return new(G1).ScalarMult(p, rBig).IsInfinity()
+}
+
+// Neg sets p = -a
+func (p *G1) Neg(a *G1) *G1 {
+	p.x = a.x
+	neg(&p.y, &a.y)
+	p.z = a.z
+	return p
+}
+
+// Double sets p = 2a
+func (p *G1) Double(a *G1) *G1 {
+	if a.IsInfinity() {
+		return p.Set(a)
+	}
+	// http://hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-0.html#doubling-dbl-2009-l
+	var A, B, C, D, E, F, t fe
+	square(&A, &a.x)
+	square(&B, &a.y)
+	square(&C, &B)
+	add(&D, &a.x, &B)
+	square(&D, &D)
+	sub(&D, &D, &A)
+	sub(&D, &D, &C)
+	double(&D, &D)
+	double(&E, &A)
+	add(&E, &E, &A)
+	square(&F, &E)
+
+	// z3 = 2 y1 z1
+	mul(&t, &a.y, &a.z)
+	double(&p.z, &t)
+
+	// x3 = F - 2D
+	double(&t, &D)
+	sub(&p.x, &F, &t)
+
+	// y3 = E (D - x3) - 8C
+	sub(&t, &D, &p.x)
+	mul(&t, &t, &E)
+	double(&C, &C)
+	double(&C, &C)
+	double(&C, &C)
+	sub(&p.y, &t, &C)
+	return p
+}
+
+// Add sets p = a + b
+func (p *G1) Add(a, b *G1) *G1 {
+	if a.IsInfinity() {
+		return p.Set(b)
+	}
+	if b.IsInfinity() {
+		return p.Set(a)
+	}
+
+	// http://hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-0.html#addition-add-2007-bl
+	var z1z1, z2z2, u1, u2, s1, s2, h, i, j, r, v, t fe
+	square(&z1z1, &a.z)
+	square(&z2z2, &b.z)
+	mul(&u1, &a.x, &z2z2)
+	mul(&u2, &b.x, &z1z1)
+	mul(&s1, &a.y, &b.z)
+	mul(&s1, &s1, &z2z2)
+	mul(&s2, &b.y, &a.z)
+	mul(&s2, &s2, &z1z1)
+
+	sub(&h, &u2, &u1)
+	sub(&r, &s2, &s1)
+	if h.isZero() {
+		if r.isZero() {
+			return p.Double(a)
+		}
+		return p.setInfinity()
+	}
+
+	double(&i, &h)
+	square(&i, &i)
+	mul(&j, &h, &i)
+	double(&r, &r)
+	mul(&v, &u1, &i)
+
+	// z3 = ((z1 + z2)^2 - z1z1 - z2z2) h
+	add(&t, &a.z, &b.z)
+	square(&t, &t)
+	sub(&t, &t, &z1z1)
+	sub(&t, &t, &z2z2)
+	mul(&p.z, &t, &h)
+
+	// x3 = r^2 - j - 2v
+	square(&t, &r)
+	sub(&t, &t, &j)
+	sub(&t, &t, &v)
+	sub(&p.x, &t, &v)
+
+	// y3 = r (v - x3) - 2 s1 j
+	sub(&t, &v, &p.x)
+	mul(&t, &t, &r)
+	mul(&s1, &s1, &j)
+	double(&s1, &s1)
+	sub(&p.y, &t, &s1)
+	return p
+}
+
+// ScalarMult sets p = k * a
+func (p *G1) ScalarMult(a *G1, k *big.Int) *G1 {
+	res := new(G1).setInfinity()
+	base := new(G1).Set(a)
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		res.Double(res)
+		if k.Bit(i) == 1 {
+			res.Add(res, base)
+		}
+	}
+	return p.Set(res)
+}
+
+// decodeFe decodes a field element encoded as 64 bytes where the
+// top 16 bytes are zero
+func decodeFe(buf []byte) (*fe, error) {
+	if len(buf) != 64 {
+		return nil, errInvalidEncoding
+	}
+	for _, b := range buf[:16] {
+		if b != 0 {
+			return nil, errInvalidEncoding
+		}
+	}
+	return feFromBytes(buf[16:])
+}
+
+func encodeFe(dst []byte, z *fe) {
+	copy(dst[16:64], z.bytes())
+}
+
+// Unmarshal decodes the point from the 128 bytes encoding of EIP-2537,
+// the x and y coordinates each one as a padded 64 bytes field element.
+// The point at infinity is encoded as all zeros. It returns an error
+// if the point is not on the curve.
+func (p *G1) Unmarshal(buf []byte) error {
+	if len(buf) != 128 {
+		return errInvalidEncoding
+	}
+	x, err := decodeFe(buf[:64])
+	if err != nil {
+		return err
+	}
+	y, err := decodeFe(buf[64:])
+	if err != nil {
+		return err
+	}
+	if x.isZero() && y.isZero() {
+		p.setInfinity()
+		return nil
+	}
+	if !isOnCurveG1(x, y) {
+		return errNotOnCurve
+	}
+	p.x = *x
+	p.y = *y
+	p.z.one()
+	return nil
+}
+
+// Marshal encodes the point with the 128 bytes encoding of EIP-2537
+func (p *G1) Marshal() []byte {
+	buf := make([]byte, 128)
+	if p.IsInfinity() {
+		return buf
+	}
+	x, y := p.affine()
+	encodeFe(buf[:64], &x)
+	encodeFe(buf[64:], &y)
+	return buf
+}
@@ -0,0 +1,283 @@
+package bls12381
+
+import (
+	"math/big"
+)
+
+var (
+	// b2 is the b coefficient of the twist y^2 = x^3 + 4(1 + u)
+	b2 = fe2FromHex("4", "4")
+
+	g2Gen = &G2{
+		x: *fe2FromHex(
+			"024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8",
+			"13e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e",
+		),
+		y: *fe2FromHex(
+			"0ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801",
+			"0606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be",
+		),
+		z: fe2{r1, fe{}},
+	}
+)
+
+// G2 is a point of the twisted curve E'(Fp2) in Jacobian coordinates
+type G2 struct {
+	x, y, z fe2
+}
+
+// G2Generator returns the generator of the group G2
+func G2Generator() *G2 {
+	return new(G2).Set(g2Gen)
+}
+
+// Set sets the point to a
+func (p *G2) Set(a *G2) *G2 {
+	*p = *a
+	return p
+}
+
+// IsInfinity returns true if the point is the point at infinity
+func (p *G2) IsInfinity() bool {
+	return p.z.isZero()
+}
+
+func (p *G2) setInfinity() *G2 {
+	p.x.zero()
+	p.y.one()
+	p.z.zero()
+	return p
+}
+
+// affine returns the affine coordinates of the point
+func (p *G2) affine() (x, y fe2) {
+	var zInv, zInv2 fe2
+	inverse2(&zInv, &p.z)
+	square2(&zInv2, &zInv)
+	mul2(&x, &p.x, &zInv2)
+	mul2(&zInv2, &zInv2, &zInv)
+	mul2(&y, &p.y, &zInv2)
+	return
+}
+
+// Equal returns true if both points are the same
+func (p *G2) Equal(a *G2) bool {
+	if p.IsInfinity() || a.IsInfinity() {
+		return p.IsInfinity() && a.IsInfinity()
+	}
+	// compare x1 z2^2 == x2 z1^2 and y1 z2^3 == y2 z1^3
+	var z1, z2, t0, t1 fe2
+	square2(&z1, &p.z)
+	square2(&z2, &a.z)
+	mul2(&t0, &p.x, &z2)
+	mul2(&t1, &a.x, &z1)
+	if !t0.equal(&t1) {
+		return false
+	}
+	mul2(&z1, &z1, &p.z)
+	mul2(&z2, &z2, &a.z)
+	mul2(&t0, &p.y, &z2)
+	mul2(&t1, &a.y, &z1)
+	return t0.equal(&t1)
+}
+
+// IsOnCurve returns true if the point satisfies the curve equation
+func (p *G2) IsOnCurve() bool {
+	if p.IsInfinity() {
+		return true
+	}
+	x, y := p.affine()
+	return isOnCurveG2(&x, &y)
+}
+
+func isOnCurveG2(x, y *fe2) bool {
+	var lhs, rhs fe2
+	square2(&lhs, y)
+	square2(&rhs, x)
+	mul2(&rhs, &rhs, x)
+	add2(&rhs, &rhs, b2)
+	return lhs.equal(&rhs)
+}
+
+// IsInSubgroup returns true if the point belongs to the subgroup of order r
+func (p *G2) IsInSubgroup() bool {
+	return new(G2).ScalarMult(p, rBig).IsInfinity()
+}
+
+// Neg sets p = -a
+func (p *G2) Neg(a *G2) *G2 {
+	p.x = a.x
+	neg2(&p.y, &a.y)
+	p.z = a.z
+	return p
+}
+
+// Double sets p = 2a
+func (p *G2) Double(a *G2) *G2 {
+	if a.IsInfinity() {
+		return p.Set(a)
+	}
+	// http://hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-0.html#doubling-dbl-2009-l
+	var A, B, C, D, E, F, t fe2
+	square2(&A, &a.x)
+	square2(&B, &a.y)
+	square2(&C, &B)
+	add2(&D, &a.x, &B)
+	square2(&D, &D)
+	sub2(&D, &D, &A)
+	sub2(&D, &D, &C)
+	double2(&D, &D)
+	double2(&E, &A)
+	add2(&E, &E, &A)
+	square2(&F, &E)
+
+	// z3 = 2 y1 z1
+	mul2(&t, &a.y, &a.z)
+	double2(&p.z, &t)
+
+	// x3 = F - 2D
+	double2(&t, &D)
+	sub2(&p.x, &F, &t)
+
+	// y3 = E (D - x3) - 8C
+	sub2(&t, &D, &p.x)
+	mul2(&t, &t, &E)
+	double2(&C, &C)
+	double2(&C, &C)
+	double2(&C, &C)
+	sub2(&p.y, &t, &C)
+	return p
+}
+
+// Add sets p = a + b
+func (p *G2) Add(a, b *G2) *G2 {
+	if a.IsInfinity() {
+		return p.Set(b)
+	}
+	if b.IsInfinity() {
+		return p.Set(a)
+	}
+
+	// http://hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-0.html#addition-add-2007-bl
+	var z1z1, z2z2, u1, u2, s1, s2, h, i, j, r, v, t fe2
+	square2(&z1z1, &a.z)
+	square2(&z2z2, &b.z)
+	mul2(&u1, &a.x, &z2z2)
+	mul2(&u2, &b.x, &z1z1)
+	mul2(&s1, &a.y, &b.z)
+	mul2(&s1, &s1, &z2z2)
+	mul2(&s2, &b.y, &a.z)
+	mul2(&s2, &s2, &z1z1)
+
+	sub2(&h, &u2, &u1)
+	sub2(&r, &s2, &s1)
+	if h.isZero() {
+		if r.isZero() {
+			return p.Double(a)
+		}
+		return p.setInfinity()
+	}
+
+	double2(&i, &h)
+	square2(&i, &i)
+	mul2(&j, &h, &i)
+	double2(&r, &r)
+	mul2(&v, &u1, &i)
+
+	// z3 = ((z1 + z2)^2 - z1z1 - z2z2) h
+	add2(&t, &a.z, &b.z)
+	square2(&t, &t)
+	sub2(&t, &t, &z1z1)
+	sub2(&t, &t, &z2z2)
+	mul2(&p.z, &t, &h)
+
+	// x3 = r^2 - j - 2v
+	square2(&t, &r)
+	sub2(&t, &t, &j)
+	sub2(&t, &t, &v)
+	sub2(&p.x, &t, &v)
+
+	// y3 = r (v - x3) - 2 s1 j
+	sub2(&t, &v, &p.x)
+	mul2(&t, &t, &r)
+	mul2(&s1, &s1, &j)
+	double2(&s1, &s1)
+	sub2(&p.y, &t, &s1)
+	return p
+}
+
+// ScalarMult sets p = k * a
+func (p *G2) ScalarMult(a *G2, k *big.Int) *G2 {
+	res := new(G2).setInfinity()
+	base := new(G2).Set(a)
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		res.Double(res)
+		if k.Bit(i) == 1 {
+			res.Add(res, base)
+		}
+	}
+	return p.Set(res)
+}
+
+// decodeFe2 decodes an element of Fp2 encoded as two padded 64 bytes
+// field elements
+func decodeFe2(buf []byte) (*fe2, error) {
+	if len(buf) != 128 {
+		return nil, errInvalidEncoding
+	}
+	c0, err := decodeFe(buf[:64])
+	if err != nil {
+		return nil, err
+	}
+	c1, err := decodeFe(buf[64:])
+	if err != nil {
+		return nil, err
+	}
+	return &fe2{*c0, *c1}, nil
+}
+
+func encodeFe2(dst []byte, z *fe2) {
+	encodeFe(dst[:64], &z[0])
+	encodeFe(dst[64:128], &z[1])
+}
+
+// Unmarshal decodes the point from the 256 bytes encoding of EIP-2537,
+// the x and y coordinates each one as an encoded element of Fp2.
+// The point at infinity is encoded as all zeros. It returns an error
+// if the point is not on the curve.
+func (p *G2) Unmarshal(buf []byte) error {
+	if len(buf) != 256 {
+		return errInvalidEncoding
+	}
+	x, err := decodeFe2(buf[:128])
+	if err != nil {
+		return err
+	}
+	y, err := decodeFe2(buf[128:])
+	if err != nil {
+		return err
+	}
+	if x.isZero() && y.isZero() {
+		p.setInfinity()
+		return nil
+	}
+	if !isOnCurveG2(x, y) {
+		return errNotOnCurve
+	}
+	p.x = *x
+	p.y = *y
+	p.z.one()
+	return nil
+}
+
+// Marshal encodes the point with the 256 bytes encoding of EIP-2537
+func (p *G2) Marshal() []byte {
+	buf := make([]byte, 256)
+	if p.IsInfinity() {
+		return buf
+	}
+	x, y := p.affine()
+	encodeFe2(buf[:128], &x)
+	encodeFe2(buf[128:], &y)
+	return buf
+}
@@ -0,0 +1,242 @@
+package bls12381
+
+import (
+	"math/big"
+)
+
+var (
+	// coefficients of the curve E'(Fp) isogenous to the curve of G1
+	sswuA1 = feFromHex("144698a3b8e9433d693a02c96d4982b0ea985383ee66a8d8e8981aefd881ac98936f8da0e0f97f5cf428082d584c1d")
+	sswuB1 = feFromHex("12e2908d11688030018b12e8753eee3b2016c1f0f24f4070a0b9c14fcef35ef55a23215a316ceaa5d1cc48e98e172be0")
+	sswuZ1 = feFromHex("b")
+
+	// coefficients of the curve E'(Fp2) isogenous to the twist of G2
+	sswuA2 = fe2FromHex("0", "f0")
+	sswuB2 = fe2FromHex("3f4", "3f4")
+	sswuZ2 = &fe2{*feFromHex("-2"), *feFromHex("-1")}
+
+	// hEff1 is the effective cofactor of G1
+	hEff1, _ = new(big.Int).SetString("d201000000010001", 16)
+
+	// psiX and psiY are the coefficients of the endomorphism psi of G2,
+	// 1 / (1 + u)^((p - 1) / 3) and 1 / (1 + u)^((p - 1) / 2)
+	psiX, psiY = psiCoeffs()
+)
+
+func psiCoeffs() (x, y fe2) {
+	var xi fe2
+	xi[0].one()
+	xi[1].one()
+
+	e := new(big.Int).Sub(pBig, big.NewInt(1))
+	exp2(&x, &xi, new(big.Int).Div(e, big.NewInt(3)))
+	inverse2(&x, &x)
+	exp2(&y, &xi, new(big.Int).Div(e, big.NewInt(2)))
+	inverse2(&y, &y)
+	return
+}
+
+// MapToG1 maps a field element encoded as 64 bytes to a point of G1
+// with the simplified SWU map and the 11-isogeny of the hash to curve
+// specification followed by the clearing of the cofactor
+func MapToG1(buf []byte) (*G1, error) {
+	u, err := decodeFe(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	x, y := sswuG1(u)
+	p, ok := isogenyG1(&x, &y)
+	if !ok {
+		return new(G1).setInfinity(), nil
+	}
+	return p.ScalarMult(p, hEff1), nil
+}
+
+// sswuG1 maps the field element to the affine point (x, y) of E'(Fp)
+func sswuG1(u *fe) (x, y fe) {
+	var u2, zu2, tv1, t, gx fe
+
+	// tv1 = 1 / (Z^2 u^4 + Z u^2)
+	square(&u2, u)
+	mul(&zu2, sswuZ1, &u2)
+	square(&tv1, &zu2)
+	add(&tv1, &tv1, &zu2)
+
+	if tv1.isZero() {
+		// x1 = B / (Z A)
+		mul(&t, sswuZ1, sswuA1)
+		inverse(&t, &t)
+		mul(&x, sswuB1, &t)
+	} else {
+		// x1 = (-B / A) (1 + tv1)
+		inverse(&tv1, &tv1)
+		add(&tv1, &tv1, &r1)
+		inverse(&t, sswuA1)
+		mul(&t, &t, sswuB1)
+		neg(&t, &t)
+		mul(&x, &t, &tv1)
+	}
+
+	sswuCurveG1(&gx, &x)
+	if !sqrt(&y, &gx) {
+		// x2 = Z u^2 x1, g(x2) is a square if g(x1) is not
+		mul(&x, &x, &zu2)
+		sswuCurveG1(&gx, &x)
+		sqrt(&y, &gx)
+	}
+	if u.sign0() != y.sign0() {
+		neg(&y, &y)
+	}
+	return
+}
+
+// sswuCurveG1 computes z = x^3 + A x + B
+func sswuCurveG1(z, x *fe) {
+	var t fe
+	square(&t, x)
+	add(&t, &t, sswuA1)
+	mul(&t, &t, x)
+	add(z, &t, sswuB1)
+}
+
+// isogenyG1 maps the point of E'(Fp) to the curve of G1. It returns
+// false if the image is the point at infinity.
+func isogenyG1(x, y *fe) (*G1, bool) {
+	var xNum, xDen, yNum, yDen fe
+	evalPoly(&xNum, iso11XNum, x)
+	evalPoly(&xDen, iso11XDen, x)
+	evalPoly(&yNum, iso11YNum, x)
+	evalPoly(&yDen, iso11YDen, x)
+	if xDen.isZero() || yDen.isZero() {
+		return nil, false
+	}
+
+	p := &G1{}
+	inverse(&xDen, &xDen)
+	mul(&p.x, &xNum, &xDen)
+	inverse(&yDen, &yDen)
+	mul(&p.y, &yNum, &yDen)
+	mul(&p.y, &p.y, y)
+	p.z.one()
+	return p, true
+}
+
+// MapToG2 maps an element of Fp2 encoded as 128 bytes to a point of G2
+// with the simplified SWU map and the 3-isogeny of the hash to curve
+// specification followed by the clearing of the cofactor
+func MapToG2(buf []byte) (*G2, error) {
+	u, err := decodeFe2(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	x, y := sswuG2(u)
+	p, ok := isogenyG2(&x, &y)
+	if !ok {
+		return new(G2).setInfinity(), nil
+	}
+	return clearCofactorG2(p), nil
+}
+
+// sswuG2 maps the element to the affine point (x, y) of E'(Fp2)
+func sswuG2(u *fe2) (x, y fe2) {
+	var u2, zu2, tv1, t, gx fe2
+
+	// tv1 = 1 / (Z^2 u^4 + Z u^2)
+	square2(&u2, u)
+	mul2(&zu2, sswuZ2, &u2)
+	square2(&tv1, &zu2)
+	add2(&tv1, &tv1, &zu2)
+
+	if tv1.isZero() {
+		// x1 = B / (Z A)
+		mul2(&t, sswuZ2, sswuA2)
+		inverse2(&t, &t)
+		mul2(&x, sswuB2, &t)
+	} else {
+		// x1 = (-B / A) (1 + tv1)
+		inverse2(&tv1, &tv1)
+		add(&tv1[0], &tv1[0], &r1)
+		inverse2(&t, sswuA2)
+		mul2(&t, &t, sswuB2)
+		neg2(&t, &t)
+		mul2(&x, &t, &tv1)
+	}
+
+	sswuCurveG2(&gx, &x)
+	if !sqrt2(&y, &gx) {
+		// x2 = Z u^2 x1, g(x2) is a square if g(x1) is not
+		mul2(&x, &x, &zu2)
+		sswuCurveG2(&gx, &x)
+		sqrt2(&y, &gx)
+	}
+	if u.sign0() != y.sign0() {
+		neg2(&y, &y)
+	}
+	return
+}
+
+// sswuCurveG2 computes z = x^3 + A x + B
+func sswuCurveG2(z, x *fe2) {
+	var t fe2
+	square2(&t, x)
+	add2(&t, &t, sswuA2)
+	mul2(&t, &t, x)
+	add2(z, &t, sswuB2)
+}
+
+// isogenyG2 maps the point of E'(Fp2) to the twist of G2. It returns
+// false if the image is the point at infinity.
+func isogenyG2(x, y *fe2) (*G2, bool) {
+	var xNum, xDen, yNum, yDen fe2
+	evalPoly2(&xNum, iso3XNum, x)
+	evalPoly2(&xDen, iso3XDen, x)
+	evalPoly2(&yNum, iso3YNum, x)
+	evalPoly2(&yDen, iso3YDen, x)
+	if xDen.isZero() || yDen.isZero() {
+		return nil, false
+	}
+
+	p := &G2{}
+	inverse2(&xDen, &xDen)
+	mul2(&p.x, &xNum, &xDen)
+	inverse2(&yDen, &yDen)
+	mul2(&p.y, &yNum, &yDen)
+	mul2(&p.y, &p.y, y)
+	p.z.one()
+	return p, true
+}
+
+// psi computes the endomorphism untwist-Frobenius-twist of G2
+func psi(p *G2) *G2 {
+	// the coordinates are Jacobian, the z coordinate is conjugated
+	// since the conjugation is an automorphism of Fp2
+	res := &G2{}
+	conjugate2(&res.x, &p.x)
+	mul2(&res.x, &res.x, &psiX)
+	conjugate2(&res.y, &p.y)
+	mul2(&res.y, &res.y, &psiY)
+	conjugate2(&res.z, &p.z)
+	return res
+}
+
+// mulByX multiplies the point by the parameter x of the curve
+func mulByX(p *G2) *G2 {
+	res := new(G2).ScalarMult(p, xBig)
+	return res.Neg(res)
+}
+
+// clearCofactorG2 multiplies the point by the effective cofactor of G2
+// with the method of Budroni and Pintore
+func clearCofactorG2(p *G2) *G2 {
+	t1 := mulByX(p)
+	t2 := psi(p)
+	t3 := psi(psi(new(G2).Double(p)))
+	t3.Add(t3, new(G2).Neg(t2))
+	t2.Add(t1, t2)
+	t2 = mulByX(t2)
+	t3.Add(t3, t2)
+	t3.Add(t3, new(G2).Neg(t1))
+	return t3.Add(t3, new(G2).Neg(p))
+}
@@ -0,0 +1,73 @@
+package bls12381
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func encodeHex(t *testing.T, strs ...string) []byte {
+	buf := []byte{}
+	for _, str := range strs {
+		b, err := hex.DecodeString(strings.Repeat("0", 128-len(str)) + str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf = append(buf, b...)
+	}
+	return buf
+}
+
+func TestMapToG1(t *testing.T) {
+	u := encodeHex(t, "156c8a6a2c184569d69a76be144b5cdc5141d2d2ca4fe341f011e25e3969c55ad9e9b9ce2eb833c81a908e5fa4ac5f03")
+	expected := encodeHex(t,
+		"184bb665c37ff561a89ec2122dd343f20e0f4cbcaec84e3c3052ea81d1834e192c426074b02ed3dca4e7676ce4ce48ba",
+		"04407b8d35af4dacc809927071fc0405218f1401a6d15af775810e4e460064bcc9468beeba82fdc751be70476c888bf3",
+	)
+
+	p, err := MapToG1(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsInSubgroup() {
+		t.Fatal("point not in the subgroup")
+	}
+	if hex.EncodeToString(p.Marshal()) != hex.EncodeToString(expected) {
+		t.Fatal("bad map to G1")
+	}
+}
+
+func TestMapToG2(t *testing.T) {
+	u := encodeHex(t,
+		"07355d25caf6e7f2f0cb2812ca0e513bd026ed09dda65b177500fa31714e09ea0ded3a078b526bed3307f804d4b93b04",
+		"02829ce3c021339ccb5caf3e187f6370e1e2a311dec9b75363117063ab2015603ff52c3d3b98f19c2f65575e99e8b78c",
+	)
+	expected := encodeHex(t,
+		"0e7f4568a82b4b7dc1f14c6aaa055edf51502319c723c4dc2688c7fe5944c213f510328082396515734b6612c4e7bb7",
+		"126b855e9e69b1f691f816e48ac6977664d24d99f8724868a184186469ddfd4617367e94527d4b74fc86413483afb35b",
+		"0caead0fd7b6176c01436833c79d305c78be307da5f6af6c133c47311def6ff1e0babf57a0fb5539fce7ee12407b0a42",
+		"1498aadcf7ae2b345243e281ae076df6de84455d766ab6fcdaad71fab60abb2e8b980a440043cd305db09d283c895e3d",
+	)
+
+	p, err := MapToG2(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsInSubgroup() {
+		t.Fatal("point not in the subgroup")
+	}
+	if hex.EncodeToString(p.Marshal()) != hex.EncodeToString(expected) {
+		t.Fatal("bad map to G2")
+	}
+}
+
+func TestClearCofactorG2(t *testing.T) {
+	// clearing the cofactor is the same as multiplying by the effective cofactor
+	hEff, _ := new(big.Int).SetString("bc69f08f2ee75b3584c6a0ea91b352888e2a8e9145ad7689986ff031508ffe1329c2f178731db956d82bf015d1212b02ec0ec69d7477c1ae954cbc06689f6a359894c0adebbf6b4e8020005aaa95551", 16)
+
+	p := new(G2).ScalarMult(G2Generator(), big.NewInt(5))
+	if !clearCofactorG2(p).Equal(new(G2).ScalarMult(p, hEff)) {
+		t.Fatal("bad cofactor clearing")
+	}
+}
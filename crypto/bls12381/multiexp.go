@@ -0,0 +1,118 @@
+package bls12381
+
+import (
+	"errors"
+	"math/big"
+	"math/bits"
+)
+
+var errMultiExpSize = errors.New("the number of points and scalars is different")
+
+// multiExpWindow returns the size in bits of the windows of the
+// multi exponentiation of n points
+func multiExpWindow(n int) uint {
+	if n < 4 {
+		return 1
+	}
+	return uint(bits.Len(uint(n))) - 1
+}
+
+// multiExpBits returns the number of bits of the largest scalar
+func multiExpBits(scalars []*big.Int) int {
+	max := 0
+	for _, k := range scalars {
+		if l := k.BitLen(); l > max {
+			max = l
+		}
+	}
+	return max
+}
+
+// windowValue returns the c bits of k starting at bit i
+func windowValue(k *big.Int, i int, c uint) int {
+	v := 0
+	for j := uint(0); j < c; j++ {
+		v |= int(k.Bit(i+int(j))) << j
+	}
+	return v
+}
+
+// MultiExp sets p to the sum of points[i]*scalars[i]. It uses the bucket
+// method of Pippenger: for each window of c bits of the scalars the points
+// are added to the bucket of their window value and the buckets are summed
+// with 2^c additions, instead of one scalar multiplication per point.
+func (p *G1) MultiExp(points []*G1, scalars []*big.Int) (*G1, error) {
+	if len(points) != len(scalars) {
+		return nil, errMultiExpSize
+	}
+
+	c := multiExpWindow(len(points))
+	buckets := make([]G1, 1<<c-1)
+
+	res := new(G1).setInfinity()
+	sum, acc := new(G1), new(G1)
+
+	for i := (multiExpBits(scalars)+int(c)-1)/int(c) - 1; i >= 0; i-- {
+		for j := uint(0); j < c; j++ {
+			res.Double(res)
+		}
+
+		for j := range buckets {
+			buckets[j].setInfinity()
+		}
+		for j, k := range scalars {
+			if v := windowValue(k, i*int(c), c); v != 0 {
+				buckets[v-1].Add(&buckets[v-1], points[j])
+			}
+		}
+
+		// the bucket v is added v times to the result
+		sum.setInfinity()
+		acc.setInfinity()
+		for j := len(buckets) - 1; j >= 0; j-- {
+			sum.Add(sum, &buckets[j])
+			acc.Add(acc, sum)
+		}
+		res.Add(res, acc)
+	}
+	return p.Set(res), nil
+}
+
+// MultiExp sets p to the sum of points[i]*scalars[i] with the bucket
+// method of Pippenger, see G1.MultiExp
+func (p *G2) MultiExp(points []*G2, scalars []*big.Int) (*G2, error) {
+	if len(points) != len(scalars) {
+		return nil, errMultiExpSize
+	}
+
+	c := multiExpWindow(len(points))
+	buckets := make([]G2, 1<<c-1)
+
+	res := new(G2).setInfinity()
+	sum, acc := new(G2), new(G2)
+
+	for i := (multiExpBits(scalars)+int(c)-1)/int(c) - 1; i >= 0; i-- {
+		for j := uint(0); j < c; j++ {
+			res.Double(res)
+		}
+
+		for j := range buckets {
+			buckets[j].setInfinity()
+		}
+		for j, k := range scalars {
+			if v := windowValue(k, i*int(c), c); v != 0 {
+				buckets[v-1].Add(&buckets[v-1], points[j])
+			}
+		}
+
+		// the bucket v is added v times to the result
+		sum.setInfinity()
+		acc.setInfinity()
+		for j := len(buckets) - 1; j >= 0; j-- {
+			sum.Add(sum, &buckets[j])
+			acc.Add(acc, sum)
+		}
+		res.Add(res, acc)
+	}
+	return p.Set(res), nil
+}
@@ -0,0 +1,69 @@
+package bls12381
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func randomScalar(r *rand.Rand) *big.Int {
+	buf := make([]byte, 32)
+	r.Read(buf)
+	return new(big.Int).SetBytes(buf)
+}
+
+func TestG1MultiExp(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{1, 2, 5, 17, 40} {
+		points := make([]*G1, n)
+		scalars := make([]*big.Int, n)
+
+		// the multi exponentiation is the sum of the scalar multiplications
+		expected := new(G1).setInfinity()
+		for i := 0; i < n; i++ {
+			points[i] = new(G1).ScalarMult(G1Generator(), big.NewInt(r.Int63()))
+			scalars[i] = randomScalar(r)
+			if i == 0 {
+				scalars[i] = big.NewInt(0)
+			}
+			expected.Add(expected, new(G1).ScalarMult(points[i], scalars[i]))
+		}
+
+		res, err := new(G1).MultiExp(points, scalars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Equal(expected) {
+			t.Fatalf("bad multi exponentiation of %d points", n)
+		}
+	}
+
+	if _, err := new(G1).MultiExp([]*G1{G1Generator()}, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestG2MultiExp(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{1, 3, 9} {
+		points := make([]*G2, n)
+		scalars := make([]*big.Int, n)
+
+		expected := new(G2).setInfinity()
+		for i := 0; i < n; i++ {
+			points[i] = new(G2).ScalarMult(G2Generator(), big.NewInt(r.Int63()))
+			scalars[i] = randomScalar(r)
+			expected.Add(expected, new(G2).ScalarMult(points[i], scalars[i]))
+		}
+
+		res, err := new(G2).MultiExp(points, scalars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Equal(expected) {
+			t.Fatalf("bad multi exponentiation of %d points", n)
+		}
+	}
+}
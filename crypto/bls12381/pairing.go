@@ -0,0 +1,130 @@
+package bls12381
+
+import (
+	"math/big"
+)
+
+// finalExpHard is the exponent (p^4 - p^2 + 1) / r of the hard part
+// of the final exponentiation
+var finalExpHard *big.Int
+
+func init() {
+	p2 := new(big.Int).Mul(pBig, pBig)
+	p4 := new(big.Int).Mul(p2, p2)
+	finalExpHard = new(big.Int).Sub(p4, p2)
+	finalExpHard.Add(finalExpHard, big.NewInt(1))
+	finalExpHard.Div(finalExpHard, rBig)
+}
+
+// lineEval multiplies f by the line with slope lambda that goes through
+// the point (tx, ty) of the twist evaluated at the point (px, py).
+// The line is scaled by w^3 which is removed by the final exponentiation.
+func lineEval(f *fe12, lambda, tx, ty *fe2, px, py *fe) {
+	var l fe12
+
+	// lambda tx - ty
+	mul2(&l[0][0], lambda, tx)
+	sub2(&l[0][0], &l[0][0], ty)
+
+	// -lambda px w^2
+	mulByFe(&l[0][1], lambda, px)
+	neg2(&l[0][1], &l[0][1])
+
+	// py w^3
+	l[1][1][0] = *py
+
+	mul12(f, f, &l)
+}
+
+// millerLoop computes the Miller loop of the optimal ate pairing of
+// the affine points p and q
+func millerLoop(f *fe12, px, py *fe, qx, qy *fe2) {
+	f.one()
+
+	tx, ty := *qx, *qy
+
+	var lambda, t0, t1 fe2
+	for i := xBig.BitLen() - 2; i >= 0; i-- {
+		square12(f, f)
+
+		// doubling step, lambda = 3 tx^2 / 2 ty
+		square2(&t0, &tx)
+		double2(&t1, &t0)
+		add2(&t0, &t0, &t1)
+		double2(&t1, &ty)
+		inverse2(&t1, &t1)
+		mul2(&lambda, &t0, &t1)
+		lineEval(f, &lambda, &tx, &ty, px, py)
+
+		square2(&t0, &lambda)
+		sub2(&t0, &t0, &tx)
+		sub2(&t0, &t0, &tx)
+		sub2(&t1, &tx, &t0)
+		mul2(&t1, &t1, &lambda)
+		sub2(&ty, &t1, &ty)
+		tx = t0
+
+		if xBig.Bit(i) == 0 {
+			continue
+		}
+
+		// addition step, lambda = (qy - ty) / (qx - tx)
+		sub2(&t0, qy, &ty)
+		sub2(&t1, qx, &tx)
+		inverse2(&t1, &t1)
+		mul2(&lambda, &t0, &t1)
+		lineEval(f, &lambda, &tx, &ty, px, py)
+
+		square2(&t0, &lambda)
+		sub2(&t0, &t0, &tx)
+		sub2(&t0, &t0, qx)
+		sub2(&t1, &tx, &t0)
+		mul2(&t1, &t1, &lambda)
+		sub2(&ty, &t1, &ty)
+		tx = t0
+	}
+
+	// the parameter x is negative
+	conjugate12(f, f)
+}
+
+// finalExp computes f^((p^12 - 1) / r)
+func finalExp(f *fe12) {
+	var t fe12
+
+	// f^(p^6 - 1)
+	inverse12(&t, f)
+	conjugate12(f, f)
+	mul12(f, f, &t)
+
+	// f^(p^2 + 1)
+	frobenius12(&t, f)
+	frobenius12(&t, &t)
+	mul12(f, f, &t)
+
+	exp12(f, f, finalExpHard)
+}
+
+// pairing computes the product of the pairings of the points.
+// The pairs with a point at infinity are skipped.
+func pairing(a []*G1, b []*G2) *fe12 {
+	var f, m fe12
+	f.one()
+	for i := range a {
+		if a[i].IsInfinity() || b[i].IsInfinity() {
+			continue
+		}
+		px, py := a[i].affine()
+		qx, qy := b[i].affine()
+		millerLoop(&m, &px, &py, &qx, &qy)
+		mul12(&f, &f, &m)
+	}
+	finalExp(&f)
+	return &f
+}
+
+// PairingCheck returns true if the product of the pairings
+// e(a[i], b[i]) is the identity
+func PairingCheck(a []*G1, b []*G2) bool {
+	return pairing(a, b).isOne()
+}
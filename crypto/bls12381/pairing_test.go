@@ -0,0 +1,33 @@
+package bls12381
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestPairingBilinearity(t *testing.T) {
+	a, b := big.NewInt(11), big.NewInt(213)
+	ab := new(big.Int).Mul(a, b)
+
+	p := new(G1).ScalarMult(G1Generator(), a)
+	q := new(G2).ScalarMult(G2Generator(), b)
+
+	// e(aG1, bG2) == e(abG1, G2)
+	e1 := pairing([]*G1{p}, []*G2{q})
+	e2 := pairing([]*G1{new(G1).ScalarMult(G1Generator(), ab)}, []*G2{G2Generator()})
+	if !e1.equal(e2) {
+		t.Fatal("pairing is not bilinear")
+	}
+	if e1.isOne() {
+		t.Fatal("pairing is degenerate")
+	}
+
+	// e(aG1, bG2) e(-abG1, G2) == 1
+	minus := new(G1).Neg(new(G1).ScalarMult(G1Generator(), ab))
+	if !PairingCheck([]*G1{p, minus}, []*G2{q, G2Generator()}) {
+		t.Fatal("pairing check failed")
+	}
+	if PairingCheck([]*G1{p, p}, []*G2{q, G2Generator()}) {
+		t.Fatal("pairing check must fail")
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"os"
+
 	logger "github.com/hashicorp/go-hclog"
 
 	"github.com/umbracle/minimal/command"
@@ -10,11 +12,17 @@ import (
 	_ "github.com/umbracle/minimal/command/agent"
 	_ "github.com/umbracle/minimal/command/dev"
 	_ "github.com/umbracle/minimal/command/debug"
+	_ "github.com/umbracle/minimal/command/evm"
+	_ "github.com/umbracle/minimal/command/statetest"
+	_ "github.com/umbracle/minimal/command/db"
+	_ "github.com/umbracle/minimal/command/state"
+	_ "github.com/umbracle/minimal/command/chaindata"
 )
 
 func main() {
 	// TODO: Change time format for the logger?
 	if err := command.Run(); err != nil {
 		logger.Default().Error(err.Error())
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,217 @@
+package minimal
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/umbracle/minimal/blockchain"
+	"github.com/umbracle/minimal/blockchain/storage"
+	"github.com/umbracle/minimal/consensus"
+	"github.com/umbracle/minimal/state"
+	itrie "github.com/umbracle/minimal/state/immutable-trie"
+	"github.com/umbracle/minimal/state/runtime/evm"
+	"github.com/umbracle/minimal/state/runtime/ewasm"
+	"github.com/umbracle/minimal/state/runtime/precompiled"
+	"github.com/umbracle/minimal/types"
+)
+
+var ripemd = types.StringToAddress("0000000000000000000000000000000000000003")
+
+var ripemdFailedTxn = types.StringToHash("0xcf416c536ec1a19ed1fb89e4ec7ffb3cf73aa413b3aa9b77d60e4fd81a4296ba")
+
+// ChainDB is the blockchain of the client with the consensus and
+// the state required to process its blocks
+type ChainDB struct {
+	Blockchain   *blockchain.Blockchain
+	Consensus    consensus.Consensus
+	Executor     *state.Executor
+	State        *itrie.State
+	StateStorage itrie.Storage
+}
+
+// OpenChainDB opens the storages of the chain in the data directory of the
+// config with its consensus and executor. The genesis of the chain is written
+// if the storage is empty. Only the chain, the data directory and the fields
+// of the storages, the consensus, the state and the executor are used.
+func OpenChainDB(logger hclog.Logger, config *Config) (*ChainDB, error) {
+	engineName := config.Chain.Params.GetEngine()
+	factory, ok := config.ConsensusBackends[engineName]
+	if !ok {
+		return nil, fmt.Errorf("consensus engine '%s' not found", engineName)
+	}
+
+	// Only one blockchain backend is allowed
+	if len(config.BlockchainEntries) != 1 {
+		return nil, fmt.Errorf("expected one blockchain backend but found %d", len(config.BlockchainEntries))
+	}
+
+	paths := []string{}
+	paths = addPath(paths, "blockchain", nil)
+	paths = addPath(paths, "consensus", nil)
+	paths = addPath(paths, "trie", nil)
+
+	if err := setupDataDir(config.DataDir, paths); err != nil {
+		return nil, fmt.Errorf("failed to create data directories: %v", err)
+	}
+
+	// Build storage backend
+	var storage storage.Storage
+	for name, entry := range config.BlockchainEntries {
+		storageFunc, ok := config.BlockchainBackends[name]
+		if !ok {
+			return nil, fmt.Errorf("storage '%s' not found", name)
+		}
+
+		// only some storages have an ancient store, the path is set
+		// by default but it is an error to set it for the others
+		_, ancient := entry.Config["ancient"]
+
+		entry.addPath(filepath.Join(config.DataDir, "blockchain"))
+		entry.addAncient(filepath.Join(config.DataDir, "ancient"))
+
+		var err error
+		storage, err = storageFunc(entry.Config, logger)
+		if err != nil {
+			return nil, err
+		}
+		if !hasAncientStore(storage) {
+			if ancient {
+				storage.Close()
+				return nil, fmt.Errorf("storage '%s' does not have an ancient store", name)
+			}
+			logger.Warn("the storage does not have an ancient store, the blocks are not frozen", "storage", name)
+		}
+	}
+
+	// Build the state storage
+	stateFunc, ok := config.StateBackends[config.StateStorage]
+	if !ok {
+		storage.Close()
+		return nil, fmt.Errorf("state storage '%s' not found", config.StateStorage)
+	}
+	stateStorage, err := stateFunc(map[string]interface{}{"path": filepath.Join(config.DataDir, "trie")}, logger)
+	if err != nil {
+		storage.Close()
+		return nil, err
+	}
+	if config.StateCache != 0 {
+		cached, err := itrie.NewCachedStorage(stateStorage, config.StateCache)
+		if err != nil {
+			storage.Close()
+			stateStorage.Close()
+			return nil, err
+		}
+		stateStorage = cached
+	}
+
+	st := itrie.NewState(stateStorage)
+	if config.Preimages {
+		st.EnablePreimages()
+	}
+
+	executor, err := newExecutor(config, st)
+	if err != nil {
+		storage.Close()
+		stateStorage.Close()
+		return nil, err
+	}
+
+	// Build consensus
+	consensusConfig := &consensus.Config{
+		Params: config.Chain.Params,
+	}
+	if config.ConsensusEntry != nil {
+		config.ConsensusEntry.addPath(filepath.Join(config.DataDir, "consensus"))
+		consensusConfig.Config = config.ConsensusEntry.Config
+	}
+	engine, err := factory(context.Background(), consensusConfig)
+	if err != nil {
+		storage.Close()
+		stateStorage.Close()
+		return nil, err
+	}
+
+	// blockchain object
+	b := blockchain.NewBlockchain(storage, engine, executor)
+	b.SetLogger(logger.Named("blockchain"))
+	if err := b.WriteGenesis(config.Chain.Genesis); err != nil {
+		b.Close()
+		engine.Close()
+		stateStorage.Close()
+		return nil, err
+	}
+	executor.GetHash = b.GetHashHelper
+
+	c := &ChainDB{
+		Blockchain:   b,
+		Consensus:    engine,
+		Executor:     executor,
+		State:        st,
+		StateStorage: stateStorage,
+	}
+	return c, nil
+}
+
+// newExecutor creates the executor of the chain with the
+// precompiled contracts of the params and the runtimes
+func newExecutor(config *Config, st state.State) (*state.Executor, error) {
+	precompiles := precompiled.NewPrecompiled()
+	for _, entry := range config.Chain.Params.Precompiles {
+		factory, ok := config.PrecompiledBackends[entry.Name]
+		if !ok {
+			return nil, fmt.Errorf("precompiled contract '%s' not found", entry.Name)
+		}
+		contract, err := factory(entry.Config)
+		if err != nil {
+			return nil, err
+		}
+		if err := precompiles.Register(entry.Address, contract, uint64(entry.Block)); err != nil {
+			return nil, err
+		}
+	}
+
+	executor := state.NewExecutor(config.Chain.Params, st)
+	executor.SetRuntime(precompiles)
+	evmConfig := config.EVM
+	if evmConfig == nil {
+		evmConfig = evm.DefaultConfig()
+	}
+	executor.SetRuntime(ewasm.NewEWASM())
+	executor.SetRuntime(evm.NewEVMWithConfig(evmConfig))
+	executor.SetParallel(config.ParallelWorkers)
+
+	executor.PostHook = func(t *state.Transition) {
+		if config.Chain.Params.ChainID == 1 && t.Context().Number == 2675119 {
+			if t.GetTxnHash() == ripemdFailedTxn {
+				// create the account
+				t.Txn().TouchAccount(ripemd)
+				// now remove it
+				t.Txn().Suicide(ripemd)
+			}
+		}
+	}
+	return executor, nil
+}
+
+// hasAncientStore checks if the blocks of the storage are moved to an ancient store
+func hasAncientStore(s storage.Storage) bool {
+	st, ok := s.(storage.AncientStorage)
+	if !ok {
+		return false
+	}
+	_, ok = st.Ancients()
+	return ok
+}
+
+// Close closes the blockchain, the consensus and the state storage
+func (c *ChainDB) Close() error {
+	if err := c.Blockchain.Close(); err != nil {
+		return err
+	}
+	if err := c.Consensus.Close(); err != nil {
+		return err
+	}
+	return c.StateStorage.Close()
+}
@@ -8,6 +8,9 @@ import (
 	"github.com/umbracle/minimal/minimal/keystore"
 	"github.com/umbracle/minimal/network/discovery"
 	"github.com/umbracle/minimal/protocol"
+	itrie "github.com/umbracle/minimal/state/immutable-trie"
+	"github.com/umbracle/minimal/state/runtime/evm"
+	"github.com/umbracle/minimal/state/runtime/precompiled"
 )
 
 // Config is used to parametrize the minimal client
@@ -27,6 +30,8 @@ type Config struct {
 	APIBackends map[string]api.Factory
 	APIEntries  map[string]*Entry
 
+	PrecompiledBackends map[string]precompiled.Factory
+
 	Keystore keystore.Keystore
 	Chain    *chain.Chain
 
@@ -37,5 +42,34 @@ type Config struct {
 	ServiceName string
 	Seal        bool
 
-	StateStorage string
+	// StateBackends are the storages of the state by name
+	StateBackends map[string]itrie.StorageFactory
+	StateStorage  string
+
+	// StateCache is the number of trie nodes cached in memory,
+	// the cache is disabled if it is zero
+	StateCache int
+
+	// Preimages stores the preimages of the hashed keys of the state
+	Preimages bool
+
+	EVM *evm.Config
+
+	// ParallelWorkers is the number of transactions of a block
+	// executed concurrently. The execution is sequential if it is
+	// lower than two.
+	ParallelWorkers int
+
+	// Prune enables the pruning of the old states if set
+	Prune *PruneConfig
+}
+
+// PruneConfig is the configuration of the state pruning
+type PruneConfig struct {
+	// Retain is the number of recent states kept
+	Retain uint64
+
+	// Checkpoint keeps the states of the blocks multiple of it.
+	// No checkpoints are kept if zero.
+	Checkpoint uint64
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/armon/go-metrics"
@@ -13,17 +14,12 @@ import (
 	"github.com/hashicorp/go-hclog"
 	"github.com/umbracle/minimal/api"
 	"github.com/umbracle/minimal/chain"
-	"github.com/umbracle/minimal/state"
-	"github.com/umbracle/minimal/types"
 
-	"github.com/umbracle/minimal/blockchain/storage"
 	"github.com/umbracle/minimal/network/discovery"
 	"github.com/umbracle/minimal/network/transport/rlpx"
 
 	"github.com/umbracle/minimal/protocol"
 	itrie "github.com/umbracle/minimal/state/immutable-trie"
-	"github.com/umbracle/minimal/state/runtime/evm"
-	"github.com/umbracle/minimal/state/runtime/precompiled"
 
 	"github.com/umbracle/minimal/blockchain"
 	"github.com/umbracle/minimal/consensus"
@@ -32,25 +28,24 @@ import (
 	"github.com/umbracle/minimal/sealer"
 )
 
-var ripemd = types.StringToAddress("0000000000000000000000000000000000000003")
-
-var ripemdFailedTxn = types.StringToHash("0xcf416c536ec1a19ed1fb89e4ec7ffb3cf73aa413b3aa9b77d60e4fd81a4296ba")
-
 // Minimal is the central manager of the blockchain client
 type Minimal struct {
-	logger     hclog.Logger
-	config     *Config
-	sealingCh  chan bool
-	Sealer     *sealer.Sealer
-	server     *network.Server
-	backends   []protocol.Backend
-	consensus  consensus.Consensus
-	Blockchain *blockchain.Blockchain
-	Key        *ecdsa.PrivateKey
-	chain      *chain.Chain
-	apis       []api.API
-	InmemSink  *metrics.InmemSink
-	devMode    bool
+	logger       hclog.Logger
+	config       *Config
+	sealingCh    chan bool
+	Sealer       *sealer.Sealer
+	server       *network.Server
+	backends     []protocol.Backend
+	consensus    consensus.Consensus
+	Blockchain   *blockchain.Blockchain
+	state        *itrie.State
+	stateStorage itrie.Storage
+	pruner       *statePruner
+	Key          *ecdsa.PrivateKey
+	chain        *chain.Chain
+	apis         []api.API
+	InmemSink    *metrics.InmemSink
+	devMode      bool
 }
 
 func NewMinimal(logger hclog.Logger, config *Config) (*Minimal, error) {
@@ -63,28 +58,15 @@ func NewMinimal(logger hclog.Logger, config *Config) (*Minimal, error) {
 		chain:     config.Chain,
 	}
 
-	// Check if the consensus engine exists
-	engineName := config.Chain.Params.GetEngine()
-	engine, ok := config.ConsensusBackends[engineName]
-	if !ok {
-		return nil, fmt.Errorf("consensus engine '%s' not found", engineName)
-	}
-
-	// Only one blockchain backend is allowed
-	if len(config.BlockchainEntries) > 1 {
-		return nil, fmt.Errorf("Only one blockchain backend allowed")
-	}
 	// Only one discovery backend is allowed (for now)
 	if len(config.DiscoveryEntries) > 1 {
 		return nil, fmt.Errorf("Only one discovery mechanism is allowed")
 	}
 
-	// Build necessary paths
+	// Build necessary paths, the ones of the chain are
+	// created with the chain
 	paths := []string{}
-	paths = addPath(paths, "blockchain", nil)
-	paths = addPath(paths, "consensus", nil)
 	paths = addPath(paths, "network", nil)
-	paths = addPath(paths, "trie", nil)
 
 	// Create paths
 	if err := setupDataDir(config.DataDir, paths); err != nil {
@@ -101,21 +83,6 @@ func NewMinimal(logger hclog.Logger, config *Config) (*Minimal, error) {
 		return nil, err
 	}
 
-	// Build storage backend
-	var storage storage.Storage
-	for name, entry := range config.BlockchainEntries {
-		storageFunc, ok := config.BlockchainBackends[name]
-		if !ok {
-			return nil, fmt.Errorf("storage '%s' not found", name)
-		}
-
-		entry.addPath(filepath.Join(config.DataDir, "blockchain"))
-		storage, err = storageFunc(entry.Config, logger)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	m.Key = key
 
 	// Start server
@@ -165,54 +132,46 @@ func NewMinimal(logger hclog.Logger, config *Config) (*Minimal, error) {
 		m.server.Discovery = discovery
 	}
 
-	// Build consensus
-	consensusConfig := &consensus.Config{
-		Params: config.Chain.Params,
-	}
-	if config.ConsensusEntry != nil {
-		config.ConsensusEntry.addPath(filepath.Join(m.config.DataDir, "consensus"))
-	}
-	consensusConfig.Config = config.ConsensusEntry.Config
-
-	m.consensus, err = engine(context.Background(), consensusConfig)
+	// Build the blockchain with the consensus and the state
+	chainDB, err := OpenChainDB(logger, config)
 	if err != nil {
 		return nil, err
 	}
+	if config.Prune != nil {
+		if _, ok := chainDB.StateStorage.(itrie.PrunableStorage); !ok {
+			return nil, fmt.Errorf("state storage '%s' cannot be pruned", config.StateStorage)
+		}
+	}
+	m.Blockchain = chainDB.Blockchain
+	m.consensus = chainDB.Consensus
+	m.state = chainDB.State
+	m.stateStorage = chainDB.StateStorage
 
-	stateStorage, err := itrie.NewLevelDBStorage(filepath.Join(m.config.DataDir, "trie"), logger)
-	if err != nil {
+	// rewind the chain if the data of the head is missing after a crash
+	if err := m.repairChain(); err != nil {
 		return nil, err
 	}
 
-	st := itrie.NewState(stateStorage)
-
-	executor := state.NewExecutor(config.Chain.Params, st)
-	executor.SetRuntime(precompiled.NewPrecompiled())
-	executor.SetRuntime(evm.NewEVM())
-
-	executor.PostHook = func(t *state.Transition) {
-		if config.Chain.Params.ChainID == 1 && t.Context().Number == 2675119 {
-			if t.GetTxnHash() == ripemdFailedTxn {
-				// create the account
-				t.Txn().TouchAccount(ripemd)
-				// now remove it
-				t.Txn().Suicide(ripemd)
-			}
+	// read the state from the flat snapshot
+	if head, ok := m.Blockchain.Header(); ok {
+		if err := m.state.EnableSnapshots(head.StateRoot, logger.Named("snapshot")); err != nil {
+			return nil, err
 		}
 	}
 
-	// blockchain object
-	m.Blockchain = blockchain.NewBlockchain(storage, m.consensus, executor)
-	if err := m.Blockchain.WriteGenesis(config.Chain.Genesis); err != nil {
-		return nil, err
+	// prune the old states in the background
+	if config.Prune != nil {
+		if config.Prune.Retain == 0 {
+			return nil, fmt.Errorf("prune requires to retain at least one state")
+		}
+		m.pruner = newStatePruner(logger.Named("pruner"), m.Blockchain, m.stateStorage.(itrie.PrunableStorage), config.Prune)
+		go m.pruner.run()
 	}
 
-	executor.GetHash = m.Blockchain.GetHashHelper
-
 	sealerConfig := &sealer.Config{
 		Coinbase: crypto.PubKeyToAddress(&m.Key.PublicKey),
 	}
-	m.Sealer = sealer.NewSealer(sealerConfig, logger, m.Blockchain, m.consensus, executor)
+	m.Sealer = sealer.NewSealer(sealerConfig, logger, m.Blockchain, m.consensus, chainDB.Executor)
 	m.Sealer.SetEnabled(m.config.Seal)
 
 	// Start protocol backends
@@ -265,6 +224,39 @@ func (m *Minimal) Server() *network.Server {
 	return m.server
 }
 
+// maxRepairLogs is the number of blocks with missing data logged by the repair
+const maxRepairLogs = 10
+
+// repairChain checks the consistency of the head of the chain and logs the
+// blocks removed if the chain is rewound
+func (m *Minimal) repairChain() error {
+	res, err := m.Blockchain.Repair()
+	if err != nil {
+		return fmt.Errorf("failed to repair the chain: %v", err)
+	}
+	if res == nil {
+		return nil
+	}
+
+	for indx, block := range res.Blocks {
+		if indx == maxRepairLogs {
+			m.logger.Warn("more blocks with missing data", "blocks", len(res.Blocks)-maxRepairLogs)
+			break
+		}
+		m.logger.Warn("block with missing data", "number", block.Number, "hash", block.Hash.String(), "missing", strings.Join(block.Missing, ", "))
+	}
+	if res.Stale != 0 {
+		m.logger.Warn("canonical blocks above the head", "blocks", res.Stale)
+	}
+	m.logger.Warn("chain repaired", "from", res.OldHead, "to", res.Head.Number, "hash", res.Head.Hash.String())
+	return nil
+}
+
+// State returns the state of the client
+func (m *Minimal) State() *itrie.State {
+	return m.state
+}
+
 // Chain returns the chain object of the client
 func (m *Minimal) Chain() *chain.Chain {
 	return m.chain
@@ -273,6 +265,15 @@ func (m *Minimal) Chain() *chain.Chain {
 func (m *Minimal) Close() {
 	m.server.Close()
 
+	if m.pruner != nil {
+		m.pruner.Close()
+	}
+
+	// write the snapshot of the head state to disk
+	if head, ok := m.Blockchain.Header(); ok {
+		m.state.CloseSnapshots(head.StateRoot)
+	}
+
 	if err := m.Blockchain.Close(); err != nil {
 		m.logger.Error("failed to close blockchain", "err", err.Error())
 	}
@@ -283,6 +284,10 @@ func (m *Minimal) Close() {
 	for _, i := range m.apis {
 		i.Close()
 	}
+
+	if err := m.stateStorage.Close(); err != nil {
+		m.logger.Error("failed to close state storage", "err", err.Error())
+	}
 }
 
 // Entry is a backend configuration entry
@@ -300,6 +305,15 @@ func (e *Entry) addPath(path string) {
 	}
 }
 
+func (e *Entry) addAncient(path string) {
+	if len(e.Config) == 0 {
+		e.Config = map[string]interface{}{}
+	}
+	if _, ok := e.Config["ancient"]; !ok {
+		e.Config["ancient"] = path
+	}
+}
+
 func addPath(paths []string, path string, entries map[string]*Entry) []string {
 	newpath := paths[0:]
 	newpath = append(newpath, path)
@@ -0,0 +1,58 @@
+package minimal
+
+import (
+	"fmt"
+
+	itrie "github.com/umbracle/minimal/state/immutable-trie"
+	"github.com/umbracle/minimal/state/runtime/evm"
+	"github.com/umbracle/minimal/state/runtime/evm/profiler"
+)
+
+// maxProfileBlocks is the maximum number of blocks profiled at once
+const maxProfileBlocks = 256
+
+// ProfileBlocks executes again the blocks in the range [from, to] and
+// returns the gas consumed by the contracts during their execution.
+// The state written by the execution is kept on memory and dropped.
+func (m *Minimal) ProfileBlocks(from, to uint64) (*profiler.Profiler, error) {
+	if from > to {
+		return nil, fmt.Errorf("invalid range from %d to %d", from, to)
+	}
+	if to-from >= maxProfileBlocks {
+		return nil, fmt.Errorf("range from %d to %d is larger than %d blocks", from, to, maxProfileBlocks)
+	}
+
+	p := profiler.NewProfiler()
+
+	evmConfig := evm.DefaultConfig()
+	if m.config != nil && m.config.EVM != nil {
+		evmConfig.JumpdestCacheSize = m.config.EVM.JumpdestCacheSize
+	}
+	evmConfig.Tracer = p
+
+	overlay := itrie.NewOverlayStorage(m.stateStorage)
+	defer overlay.Close()
+
+	executor := m.Blockchain.Executor().
+		WithRuntime(evm.NewEVMWithConfig(evmConfig)).
+		WithState(itrie.NewState(overlay))
+
+	for n := from; n <= to; n++ {
+		if n == 0 {
+			// the genesis block does not have transactions
+			continue
+		}
+		block, ok := m.Blockchain.GetBlockByNumber(n, true)
+		if !ok {
+			return nil, fmt.Errorf("block %d not found", n)
+		}
+		parent, ok := m.Blockchain.GetParent(block.Header)
+		if !ok {
+			return nil, fmt.Errorf("parent of block %d not found", n)
+		}
+		if _, _, err := executor.ProcessBlock(parent.StateRoot, block); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,18 @@
+package minimal
+
+import (
+	"fmt"
+
+	itrie "github.com/umbracle/minimal/state/immutable-trie"
+	"github.com/umbracle/minimal/types"
+)
+
+// GetProof returns the merkle proofs of the account and its storage
+// slots in the state after the given block
+func (m *Minimal) GetProof(addr types.Address, slots []types.Hash, block uint64) (*itrie.AccountProof, error) {
+	header, ok := m.Blockchain.GetHeaderByNumber(block)
+	if !ok {
+		return nil, fmt.Errorf("block %d not found", block)
+	}
+	return m.state.GetProof(header.StateRoot, addr, slots)
+}
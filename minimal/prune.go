@@ -0,0 +1,136 @@
+package minimal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/umbracle/minimal/blockchain"
+	itrie "github.com/umbracle/minimal/state/immutable-trie"
+	"github.com/umbracle/minimal/types"
+)
+
+// PruneState removes from the storage the state of all the blocks except
+// the last 'retain' blocks from the head and the checkpoint blocks. It
+// returns the number of deleted nodes.
+func PruneState(b *blockchain.Blockchain, storage itrie.PrunableStorage, retain, checkpoint uint64) (int, error) {
+	return storage.Prune(func() (roots []types.Hash, err error) {
+		// a block being processed has its state written but it is not
+		// in the chain yet, the roots are chosen once it is committed
+		err = b.WithStateLock(func() error {
+			if roots, err = retainedRoots(b, retain, checkpoint); err != nil {
+				return err
+			}
+			// the snapshot is generated from the state of its disk layer,
+			// which can be older than the retained states
+			if root, ok := itrie.SnapshotGeneratingRoot(storage); ok {
+				roots = append(roots, root)
+			}
+			return nil
+		})
+		return
+	})
+}
+
+func retainedRoots(b *blockchain.Blockchain, retain, checkpoint uint64) ([]types.Hash, error) {
+	head, ok := b.Header()
+	if !ok {
+		return nil, fmt.Errorf("head not found")
+	}
+
+	roots := []types.Hash{}
+	add := func(num uint64) error {
+		header, ok := b.GetHeaderByNumber(num)
+		if !ok {
+			return fmt.Errorf("header %d not found", num)
+		}
+		roots = append(roots, header.StateRoot)
+		return nil
+	}
+
+	first := uint64(0)
+	if head.Number >= retain {
+		first = head.Number - retain + 1
+	}
+	for i := first; i <= head.Number; i++ {
+		if err := add(i); err != nil {
+			return nil, err
+		}
+	}
+	if checkpoint != 0 {
+		for i := uint64(0); i < first; i += checkpoint {
+			if err := add(i); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return roots, nil
+}
+
+// statePruner prunes the state in the background as new blocks are written
+type statePruner struct {
+	logger     hclog.Logger
+	blockchain *blockchain.Blockchain
+	storage    itrie.PrunableStorage
+	config     *PruneConfig
+
+	// last is the head number when the last prune started
+	last    uint64
+	running bool
+	doneCh  chan struct{}
+	closeCh chan struct{}
+}
+
+func newStatePruner(logger hclog.Logger, b *blockchain.Blockchain, storage itrie.PrunableStorage, config *PruneConfig) *statePruner {
+	p := &statePruner{
+		logger:     logger,
+		blockchain: b,
+		storage:    storage,
+		config:     config,
+		doneCh:     make(chan struct{}, 1),
+		closeCh:    make(chan struct{}),
+	}
+	if head, ok := b.Header(); ok {
+		p.last = head.Number
+	}
+	return p
+}
+
+func (p *statePruner) run() {
+	sub := p.blockchain.Subscribe()
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-sub.EventCh():
+			// prune every time the chain advances 'retain' blocks
+			if ev.Type != blockchain.NewHead || p.running || ev.Header.Number < p.last+p.config.Retain {
+				continue
+			}
+			p.last = ev.Header.Number
+			p.running = true
+			go p.prune()
+
+		case <-p.doneCh:
+			p.running = false
+
+		case <-p.closeCh:
+			return
+		}
+	}
+}
+
+func (p *statePruner) prune() {
+	now := time.Now()
+	deleted, err := PruneState(p.blockchain, p.storage, p.config.Retain, p.config.Checkpoint)
+	if err != nil {
+		p.logger.Error("failed to prune the state", "err", err.Error())
+	} else {
+		p.logger.Info("state pruned", "nodes", deleted, "elapsed", time.Since(now))
+	}
+	p.doneCh <- struct{}{}
+}
+
+func (p *statePruner) Close() {
+	close(p.closeCh)
+}
@@ -15,7 +15,7 @@ import (
 	"github.com/umbracle/fastrlp"
 	"github.com/umbracle/minimal/blockchain"
 	"github.com/umbracle/minimal/minimal"
-	"github.com/umbracle/minimal/sealer"
+	itrie "github.com/umbracle/minimal/state/immutable-trie"
 
 	"sync"
 
@@ -45,6 +45,10 @@ type Backend struct {
 
 	syncing uint64
 
+	// state is served to the peers and downloaded during the fast sync
+	state    *itrie.State
+	fastSync bool
+
 	// watcher parts
 	target *Ethereum
 	wqueue chan *types.Block
@@ -54,7 +58,21 @@ type Backend struct {
 
 func Factory(ctx context.Context, logger hclog.Logger, m interface{}, config map[string]interface{}) (protocol.Backend, error) {
 	minimal := m.(*minimal.Minimal)
-	return NewBackend(minimal, logger, minimal.Blockchain)
+
+	var fastSync bool
+	fastSyncRaw, ok := config["fast_sync"]
+	if ok {
+		if fastSync, ok = fastSyncRaw.(bool); !ok {
+			return nil, fmt.Errorf("could not convert fast_sync flag to bool")
+		}
+	}
+
+	b, err := NewBackend(minimal, logger, minimal.Blockchain)
+	if err != nil {
+		return nil, err
+	}
+	b.fastSync = fastSync
+	return b, nil
 }
 
 // NewBackend creates a new ethereum backend
@@ -94,16 +112,13 @@ func NewBackend(minimal *minimal.Minimal, logger hclog.Logger, blockchain *block
 
 	if minimal != nil {
 		b.NetworkID = uint64(minimal.Chain().Params.ChainID)
+		b.state = minimal.State()
 	} else {
 		b.NetworkID = 1
 	}
 
 	logger.Info("Header", "num", header.Number, "hash", header.Hash.String())
 
-	if minimal != nil {
-		go b.WatchMinedBlocks(minimal.Sealer.SealedCh)
-	}
-
 	// Create the task slots
 	for i := 0; i < maxConcurrentTasks; i++ {
 		b.taskCh <- struct{}{}
@@ -155,6 +170,7 @@ func (b *Backend) Run() {
 	go b.syncHeader()
 	go b.runTasks()
 	go b.runWatcher()
+	go b.watchChain()
 }
 
 const maxUncleLen = 7
@@ -223,6 +239,15 @@ func (b *Backend) syncTarget(target *Ethereum) error {
 	// Ancestor query was correct, select this peer for the syncing
 	b.setSyncing(syncing)
 
+	// download the state of a recent block instead of processing all the blocks
+	if b.fastSync && ancestor.Number == 0 && height.Number > pivotDistance {
+		if ancestor, err = b.syncPivot(target, height); err != nil {
+			return fmt.Errorf("failed to fast sync: %v", err)
+		}
+	} else if err := b.resumeSyncPivot(); err != nil {
+		return fmt.Errorf("failed to resume the fast sync: %v", err)
+	}
+
 START:
 	// origin is the start position to sync
 	origin := ancestor.Number + 1
@@ -437,39 +462,61 @@ func (b *Backend) notifyAvailablePeer() {
 	}
 }
 
-func (b *Backend) WatchMinedBlocks(watch chan *sealer.SealedNotify) {
+// watchChain broadcasts the new head blocks, like the sealed blocks,
+// to the peers. The blocks are not broadcasted while syncing.
+func (b *Backend) watchChain() {
+	sub := b.blockchain.Subscribe()
+	defer sub.Unsubscribe()
+
 	for {
-		w := <-watch
-		// TODO, change with a blockchain listener
-		go b.broadcastBlock(w.Block)
+		select {
+		case ev := <-sub.EventCh():
+			if ev.Type != blockchain.NewHead || b.getSyncing() != notSyncing {
+				continue
+			}
+			block, ok := b.blockchain.GetBlockByHash(ev.Header.Hash, true)
+			if !ok {
+				continue
+			}
+			go b.broadcastBlock(block)
+
+		case <-b.closeCh:
+			return
+		}
 	}
 }
 
 var defaultArena fastrlp.ArenaPool
 
 func (b *Backend) broadcastBlock(block *types.Block) {
-	fmt.Println("== BROADCAST BLOCK ==")
-
 	// total difficulty so far at the parent
 	diff, ok := b.blockchain.GetTD(block.ParentHash())
 	if !ok {
-		// log error
+		b.logger.Error("failed to broadcast block, parent difficulty not found", "number", block.Number(), "hash", block.Hash())
 		return
 	}
 
 	// total difficulty + the difficulty of the block
 	blockDiff := big.NewInt(1).Add(diff, new(big.Int).SetUint64(block.Header.Difficulty))
 
+	b.peersLock.Lock()
+	peers := make([]*Ethereum, 0, len(b.peers))
+	for _, p := range b.peers {
+		peers = append(peers, p)
+	}
+	b.peersLock.Unlock()
+
 	ar := defaultArena.Get()
+	defer defaultArena.Put(ar)
 
 	// send block
 	v0 := ar.NewArray()
 	v0.Set(ar.NewBigInt(blockDiff))
 	v0.Set(block.MarshalWith(ar))
 
-	for _, i := range b.peers {
-		if err := i.writeRLP(NewBlockMsg, v0); err != nil {
-			fmt.Printf("Failed to send send block to peer %s: %v\n", i.peerID, err)
+	for _, p := range peers {
+		if err := p.writeRLP(NewBlockMsg, v0); err != nil {
+			b.logger.Debug("failed to send block", "peer", p.peerID, "err", err)
 		}
 	}
 
@@ -480,13 +527,11 @@ func (b *Backend) broadcastBlock(block *types.Block) {
 	tuple.Set(ar.NewUint(block.Number()))
 	v1.Set(tuple)
 
-	for _, i := range b.peers {
-		if err := i.writeRLP(NewBlockHashesMsg, v1); err != nil {
-			fmt.Printf("Failed to send hashes to peer %s: %s\n", i.peerID, err)
+	for _, p := range peers {
+		if err := p.writeRLP(NewBlockHashesMsg, v1); err != nil {
+			b.logger.Debug("failed to send block hash", "peer", p.peerID, "err", err)
 		}
 	}
-
-	defaultArena.Put(ar)
 }
 
 // Add is called when we connect to a new node
@@ -504,6 +549,9 @@ func (b *Backend) Add(conn net.Conn, peer *network.Peer) (network.ProtocolHandle
 
 	proto := NewEthereumProtocol(peer.Session(), peerID, logger, conn, b.blockchain)
 	proto.backend = b
+	if b.state != nil {
+		proto.nodeData = b.state
+	}
 
 	b.peersLock.Lock()
 	if _, ok := b.peers[peerID]; ok {
@@ -9,6 +9,7 @@ import (
 	"net"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/mitchellh/mapstructure"
@@ -134,6 +135,11 @@ type Blockchain interface {
 	GetBodyByHash(types.Hash) (*types.Body, bool)
 }
 
+// NodeDataSource is the source of the state trie nodes and code served to the peers
+type NodeDataSource interface {
+	GetNodeData(hash types.Hash) ([]byte, bool)
+}
+
 // Ethereum is the protocol for etheruem
 type Ethereum struct {
 	logger hclog.Logger
@@ -143,6 +149,12 @@ type Ethereum struct {
 
 	status     *Status // status of the remote peer
 	blockchain Blockchain
+	nodeData   NodeDataSource
+
+	// node data responses do not reference the request, there is only
+	// one request at a time and nodeDataSeq is its key in the pending queue
+	nodeDataLock sync.Mutex
+	nodeDataSeq  uint64
 
 	// pending objects
 	pending map[messageType]*pending
@@ -615,6 +627,10 @@ func (e *Ethereum) handleReceipts(p *fastrlp.Parser, v *fastrlp.Value) error {
 }
 
 func (e *Ethereum) handlerNodeData(p *fastrlp.Parser, v *fastrlp.Value) error {
+	key := strconv.FormatUint(atomic.LoadUint64(&e.nodeDataSeq), 10)
+	if handler := e.getHandler(key, dataMsg); handler != nil {
+		return handler(p, v)
+	}
 	return nil
 }
 
@@ -723,8 +739,45 @@ func (e *Ethereum) handleGetBodies(p *fastrlp.Parser, v *fastrlp.Value) error {
 	return nil
 }
 
+const maxNodeDataAmount = 384
+
+var nodeDataArenaPool fastrlp.ArenaPool
+
 func (e *Ethereum) handleGetNodeData(p *fastrlp.Parser, v *fastrlp.Value) error {
-	// TODO
+	elems, err := v.GetElems()
+	if err != nil {
+		return err
+	}
+	// validate that all the elements are hashes
+	if err := validateHashesReq(elems); err != nil {
+		return err
+	}
+
+	ar := nodeDataArenaPool.Get()
+	res := ar.NewArray()
+
+	// limit the number of elements
+	amount := min(len(elems), maxNodeDataAmount)
+
+	if e.nodeData != nil {
+		size := 0
+		for _, elem := range elems[:amount] {
+			// the unknown hashes are skipped
+			data, ok := e.nodeData.GetNodeData(types.BytesToHash(elem.Raw()))
+			if !ok {
+				continue
+			}
+			res.Set(ar.NewCopyBytes(data))
+
+			size += len(data)
+			if size >= softResponseLimit {
+				break
+			}
+		}
+	}
+
+	e.writeRLP(NodeDataMsg, res)
+	nodeDataArenaPool.Put(ar)
 	return nil
 }
 
@@ -965,6 +1018,52 @@ func (e *Ethereum) requestBody(hash types.Hash, beacon types.Hash) (*types.Body,
 	return body, nil
 }
 
+// requestReceipts requests the receipts of a block, the receipts root of
+// the block is the beacon of the response
+func (e *Ethereum) requestReceipts(hash types.Hash, root types.Hash) ([]*types.Receipt, error) {
+	ack := make(chan AckMessage, 1)
+
+	receipts := []*types.Receipt{}
+	e.setHandler2(context.Background(), receiptsMsg, root.String(), ack, func(p *fastrlp.Parser, v *fastrlp.Value) error {
+		items, err := v.GetElems()
+		if err != nil {
+			return err
+		}
+		if len(items) != 1 {
+			return fmt.Errorf("there should be only one")
+		}
+
+		elems, err := items[0].GetElems()
+		if err != nil {
+			return err
+		}
+		for _, elem := range elems {
+			receipt := new(types.Receipt)
+			if err := receipt.UnmarshalRLP(elem); err != nil {
+				return err
+			}
+			receipts = append(receipts, receipt)
+		}
+		return nil
+	})
+
+	a := defaultArenaPool.Get()
+	defer defaultArenaPool.Put(a)
+
+	v := a.NewArray()
+	v.Set(a.NewCopyBytes(hash.Bytes()))
+
+	if err := e.writeRLP(GetReceiptsMsg, v); err != nil {
+		return nil, err
+	}
+
+	resp := <-ack
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	return receipts, nil
+}
+
 func (e *Ethereum) requestHeaderByNumber2(originN uint64, originH *types.Hash, amount, skip uint64, reverse bool) ([]*types.Header, error) {
 	buf := reqHeadersQuery2(nil, originN, originH, amount, skip, reverse)
 
@@ -1044,6 +1143,56 @@ func (e *Ethereum) fetchHeight2() (*types.Header, error) {
 	return headers[0], nil
 }
 
+// RequestNodeData requests the trie nodes and code of the hashes. The peer
+// may return only some of them and in any order.
+func (e *Ethereum) RequestNodeData(hashes []types.Hash) ([][]byte, error) {
+	e.nodeDataLock.Lock()
+	defer e.nodeDataLock.Unlock()
+
+	key := strconv.FormatUint(atomic.AddUint64(&e.nodeDataSeq, 1), 10)
+
+	var data [][]byte
+	ack := make(chan AckMessage, 1)
+
+	e.setHandler2(context.Background(), dataMsg, key, ack, func(p *fastrlp.Parser, v *fastrlp.Value) error {
+		elems, err := v.GetElems()
+		if err != nil {
+			return err
+		}
+		if len(elems) > len(hashes) {
+			return fmt.Errorf("returned more elements than requested")
+		}
+
+		data = make([][]byte, len(elems))
+		for indx, elem := range elems {
+			buf, err := elem.Bytes()
+			if err != nil {
+				return err
+			}
+			data[indx] = append([]byte{}, buf...)
+		}
+		return nil
+	})
+
+	a := defaultArenaPool.Get()
+	v := a.NewArray()
+	for _, hash := range hashes {
+		v.Set(a.NewCopyBytes(hash.Bytes()))
+	}
+	err := e.writeRLP(GetNodeDataMsg, v)
+	defaultArenaPool.Put(a)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp := <-ack
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	return data, nil
+}
+
 // -- handlers --
 
 type rawFunc func(p *fastrlp.Parser, v *fastrlp.Value) error
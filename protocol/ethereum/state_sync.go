@@ -0,0 +1,220 @@
+package ethereum
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	itrie "github.com/umbracle/minimal/state/immutable-trie"
+	"github.com/umbracle/minimal/types"
+)
+
+const (
+	// pivotDistance is the distance from the head of the peer
+	// to the pivot block whose state is downloaded
+	pivotDistance = 64
+
+	// stateSyncBatch is the number of hashes requested at once
+	stateSyncBatch = maxNodeDataAmount
+)
+
+// syncPivot downloads the headers up to the pivot block, its receipts and its
+// state. The headers are verified before the state root of the pivot is
+// trusted. The blocks after the pivot are imported as usual. It returns the
+// pivot header.
+func (b *Backend) syncPivot(target *Ethereum, height *types.Header) (*types.Header, error) {
+	if b.state == nil {
+		return nil, fmt.Errorf("state not available")
+	}
+
+	pivot, err := target.requestHeaderByNumber(height.Number - pivotDistance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request pivot: %v", err)
+	}
+	if err := b.syncHeaders(target, pivot); err != nil {
+		return nil, err
+	}
+	if err := b.syncReceipts(target, pivot); err != nil {
+		return nil, err
+	}
+	// the blocks below the pivot are not executed
+	if err := b.blockchain.WriteSyncPivot(pivot); err != nil {
+		return nil, err
+	}
+
+	b.logger.Info("fast sync", "pivot", pivot.Number, "root", pivot.StateRoot.String())
+
+	if err := b.syncState(b.state, pivot.StateRoot); err != nil {
+		return nil, err
+	}
+	return pivot, nil
+}
+
+// resumeSyncPivot downloads the state of the pivot if the head is the pivot
+// of a fast sync that was interrupted. The nodes of the state are written
+// bottom up, the state is not available until the download is complete.
+func (b *Backend) resumeSyncPivot() error {
+	if b.state == nil {
+		return nil
+	}
+	head, ok := b.blockchain.Header()
+	if !ok {
+		return nil
+	}
+	if pivot, ok := b.blockchain.SyncPivot(); !ok || pivot != head.Number {
+		return nil
+	}
+	if _, err := b.state.NewSnapshotAt(head.StateRoot); err == nil {
+		return nil
+	}
+
+	b.logger.Info("resume fast sync", "pivot", head.Number, "root", head.StateRoot.String())
+	return b.syncState(b.state, head.StateRoot)
+}
+
+// RepairState downloads from the peers the items of the state with the given
+// root that are missing or corrupted in the storage. It waits up to timeout
+// for the handshake of the first peer, so it is meant to be used with a
+// backend that is not running the block sync.
+func (b *Backend) RepairState(state *itrie.State, root types.Hash, timeout time.Duration) error {
+	select {
+	case <-b.notifyCh:
+	case <-time.After(timeout):
+		return fmt.Errorf("no peers connected")
+	}
+	return b.syncState(state, root)
+}
+
+// syncState downloads the state with the given root from all the peers
+func (b *Backend) syncState(state *itrie.State, root types.Hash) error {
+	stateSync, err := state.NewSync(root)
+	if err != nil {
+		return err
+	}
+
+	b.peersLock.Lock()
+	peers := make([]*Ethereum, 0, len(b.peers))
+	for _, peer := range b.peers {
+		peers = append(peers, peer)
+	}
+	b.peersLock.Unlock()
+
+	var wg sync.WaitGroup
+	for _, peer := range peers {
+		wg.Add(1)
+		go func(peer *Ethereum) {
+			defer wg.Done()
+			if err := b.syncStatePeer(stateSync, peer); err != nil {
+				b.logger.Debug("failed to download state", "id", peer.peerID, "err", err.Error())
+			}
+		}(peer)
+	}
+	wg.Wait()
+
+	if !stateSync.Done() {
+		return fmt.Errorf("no peers left to download the state, %d items pending", stateSync.Pending())
+	}
+
+	b.logger.Info("state downloaded", "root", root.String())
+	return nil
+}
+
+// syncStatePeer requests the missing state to the peer until the state is
+// complete. The hashes the peer does not deliver are requested again.
+func (b *Backend) syncStatePeer(stateSync *itrie.Sync, peer *Ethereum) error {
+	for !stateSync.Done() {
+		hashes := stateSync.Missing(stateSyncBatch)
+		if len(hashes) == 0 {
+			// the rest of the hashes are being requested to other peers
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		data, err := peer.RequestNodeData(hashes)
+		if err != nil {
+			stateSync.Retry(hashes)
+			return err
+		}
+		for _, buf := range data {
+			// a late response may include data already delivered
+			if err := stateSync.Process(buf); err != nil && err != itrie.ErrSyncNotRequested {
+				stateSync.Retry(hashes)
+				return err
+			}
+		}
+		stateSync.Retry(hashes)
+
+		if len(data) == 0 {
+			return fmt.Errorf("peer does not have the state")
+		}
+	}
+	return nil
+}
+
+// syncHeaders verifies and writes the headers from the genesis to the pivot, and
+// the bodies of the blocks before the pivot needed to verify the uncles of the
+// next blocks
+func (b *Backend) syncHeaders(target *Ethereum, pivot *types.Header) error {
+	consensus := b.blockchain.Consensus()
+
+	for num := uint64(1); num <= pivot.Number; {
+		head, _ := b.blockchain.Header()
+
+		amount := minUint64(maxHeadersAmount, pivot.Number-num+1)
+		headers, err := target.requestHeaderByNumber2(num, nil, amount, 0, false)
+		if err != nil {
+			return fmt.Errorf("failed to request headers: %v", err)
+		}
+		if headers[0].ParentHash != head.Hash {
+			return fmt.Errorf("header %d does not follow the head", headers[0].Number)
+		}
+
+		parent := head
+		for _, header := range headers {
+			if err := consensus.VerifyHeader(parent, header, false, true); err != nil {
+				return fmt.Errorf("failed to verify the header %d: %v", header.Number, err)
+			}
+			parent = header
+		}
+		if err := b.blockchain.WriteHeaders(headers); err != nil {
+			return err
+		}
+		num += uint64(len(headers))
+	}
+
+	if head, _ := b.blockchain.Header(); head.Hash != pivot.Hash {
+		return fmt.Errorf("headers do not match the pivot")
+	}
+
+	hashes := []types.Hash{}
+	bodies := []*types.Body{}
+
+	header := pivot
+	for i := 0; i < maxUncleLen && header.Number != 0; i++ {
+		body := &types.Body{}
+		if hasBody(*header) {
+			var err error
+			if body, err = target.requestBody(header.Hash, types.BytesToHash(xor(header.TxRoot, header.Sha3Uncles))); err != nil {
+				return fmt.Errorf("failed to request body: %v", err)
+			}
+		}
+		hashes = append(hashes, header.Hash)
+		bodies = append(bodies, body)
+
+		header, _ = b.blockchain.GetHeaderByHash(header.ParentHash)
+	}
+	return b.blockchain.CommitBodies(hashes, bodies)
+}
+
+// syncReceipts writes the receipts of the pivot block, the receipts of the
+// blocks before it are not downloaded
+func (b *Backend) syncReceipts(target *Ethereum, pivot *types.Header) error {
+	if !hasReceipts(*pivot) {
+		return nil
+	}
+	receipts, err := target.requestReceipts(pivot.Hash, pivot.ReceiptsRoot)
+	if err != nil {
+		return fmt.Errorf("failed to request receipts: %v", err)
+	}
+	return b.blockchain.CommitReceipts([]types.Hash{pivot.Hash}, [][]*types.Receipt{receipts})
+}
@@ -0,0 +1,245 @@
+package ethereum
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"testing"
+
+	"github.com/umbracle/minimal/blockchain"
+	"github.com/umbracle/minimal/blockchain/storage/memory"
+	"github.com/umbracle/minimal/consensus"
+	"github.com/umbracle/minimal/crypto"
+	"github.com/umbracle/minimal/state"
+	itrie "github.com/umbracle/minimal/state/immutable-trie"
+	"github.com/umbracle/minimal/types"
+	"github.com/umbracle/minimal/types/buildroot"
+)
+
+// buildTestSyncState returns a state with accounts, storage and code
+func buildTestSyncState() (*itrie.State, []*state.Object, types.Hash) {
+	src := itrie.NewState(itrie.NewMemoryStorage())
+
+	objs := []*state.Object{}
+	for i := 0; i < 500; i++ {
+		obj := &state.Object{
+			Address:  types.BytesToAddress([]byte(strconv.Itoa(i))),
+			Balance:  big.NewInt(int64(i)),
+			CodeHash: types.BytesToHash(crypto.Keccak256(nil)),
+			Root:     types.EmptyRootHash,
+		}
+		if i%10 == 0 {
+			code := []byte(strconv.Itoa(i))
+			obj.CodeHash = types.BytesToHash(crypto.Keccak256(code))
+			obj.DirtyCode = true
+			obj.Code = code
+			obj.Storage = []*state.StorageObject{
+				{Key: types.StringToHash("1").Bytes(), Val: types.StringToHash(strconv.Itoa(i)).Bytes()},
+			}
+		}
+		objs = append(objs, obj)
+	}
+	_, root := src.NewSnapshot().Commit(objs)
+	return src, objs, types.BytesToHash(root)
+}
+
+func TestSyncState(t *testing.T) {
+	src, objs, root := buildTestSyncState()
+
+	headers := blockchain.NewTestHeaderChain(10)
+	b := newTestBackend(t, blockchain.NewTestBlockchain(t, headers))
+	b.state = itrie.NewState(itrie.NewMemoryStorage())
+
+	// two peers with the state and one without it
+	for i := 0; i < 3; i++ {
+		eth0, eth1 := ethPipe(blockchain.NewTestBlockchain(t, headers), blockchain.NewTestBlockchain(t, headers))
+		eth0.peerID = strconv.Itoa(i)
+		if i != 0 {
+			eth1.nodeData = src
+		}
+		b.peers[eth0.peerID] = eth0
+	}
+
+	if err := b.syncState(b.state, root); err != nil {
+		t.Fatal(err)
+	}
+
+	snap, err := b.state.NewSnapshotAt(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txn := state.NewTxn(b.state, snap)
+	for _, obj := range objs {
+		if txn.GetBalance(obj.Address).Cmp(obj.Balance) != 0 {
+			t.Fatal("bad balance")
+		}
+		if obj.DirtyCode {
+			if string(txn.GetCode(obj.Address)) != string(obj.Code) {
+				t.Fatal("bad code")
+			}
+			if txn.GetState(obj.Address, types.StringToHash("1")) != types.BytesToHash(obj.Storage[0].Val) {
+				t.Fatal("bad storage")
+			}
+		}
+	}
+}
+
+// limitedNodeData serves up to limit items of the state
+type limitedNodeData struct {
+	src   NodeDataSource
+	limit int
+}
+
+func (l *limitedNodeData) GetNodeData(hash types.Hash) ([]byte, bool) {
+	if l.limit == 0 {
+		return nil, false
+	}
+	l.limit--
+	return l.src.GetNodeData(hash)
+}
+
+func TestSyncStateResumePivot(t *testing.T) {
+	src, _, root := buildTestSyncState()
+
+	// the head is the pivot of the fast sync
+	headers := blockchain.NewTestHeaderChain(10)
+	pivot := headers[9]
+	pivot.StateRoot = root
+	pivot.ComputeHash()
+
+	b0 := blockchain.NewTestBlockchain(t, headers)
+	if err := b0.WriteSyncPivot(pivot); err != nil {
+		t.Fatal(err)
+	}
+	b := newTestBackend(t, b0)
+	b.state = itrie.NewState(itrie.NewMemoryStorage())
+
+	addPeer := func(id string, nodeData NodeDataSource) {
+		eth0, eth1 := ethPipe(b0, blockchain.NewTestBlockchain(t, headers))
+		eth0.peerID = id
+		eth1.nodeData = nodeData
+		b.peers[id] = eth0
+	}
+
+	// the only peer stops serving the state in the middle of the sync
+	addPeer("0", &limitedNodeData{src: src, limit: 300})
+	if err := b.resumeSyncPivot(); err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, err := b.state.NewSnapshotAt(root); err == nil {
+		t.Fatal("the partial state should not be available")
+	}
+
+	// the sync of the pivot state is resumed with another peer
+	addPeer("1", src)
+	if err := b.resumeSyncPivot(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.state.NewSnapshotAt(root); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncHeaders(t *testing.T) {
+	headers := blockchain.NewTestHeaderChain(500)
+
+	// b0 with only the genesis
+	b0 := blockchain.NewTestBlockchain(t, headers[0:1])
+
+	// b1 with the whole chain
+	b1 := blockchain.NewTestBlockchain(t, headers)
+
+	b := newTestBackend(t, b0)
+	eth0, _ := ethPipe(b0, b1)
+
+	pivot := headers[400]
+	if err := b.syncHeaders(eth0, pivot); err != nil {
+		t.Fatal(err)
+	}
+
+	head, _ := b0.Header()
+	if head.Hash != pivot.Hash {
+		t.Fatalf("the head should be the pivot but found %d", head.Number)
+	}
+	for _, header := range headers[pivot.Number-maxUncleLen+1 : pivot.Number+1] {
+		if _, ok := b0.GetBodyByHash(header.Hash); !ok {
+			t.Fatalf("body %d not found", header.Number)
+		}
+	}
+}
+
+// rejectConsensus fails to verify the header with the given number
+type rejectConsensus struct {
+	consensus.Consensus
+	number uint64
+}
+
+func (r *rejectConsensus) VerifyHeader(parent *types.Header, header *types.Header, uncle, seal bool) error {
+	if header.Number == r.number {
+		return fmt.Errorf("bad header")
+	}
+	return nil
+}
+
+func TestSyncHeadersVerify(t *testing.T) {
+	headers := blockchain.NewTestHeaderChain(500)
+
+	s, err := memory.NewMemoryStorage(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b0 := blockchain.NewBlockchain(s, &rejectConsensus{number: 250}, nil)
+	if err := b0.WriteHeaderGenesis(headers[0]); err != nil {
+		t.Fatal(err)
+	}
+	b1 := blockchain.NewTestBlockchain(t, headers)
+
+	b := newTestBackend(t, b0)
+	eth0, _ := ethPipe(b0, b1)
+
+	// the headers are not written past the one that fails to verify
+	if err := b.syncHeaders(eth0, headers[400]); err == nil {
+		t.Fatal("expected an error")
+	}
+	if head, _ := b0.Header(); head.Number >= 250 {
+		t.Fatalf("the head should be before the bad header but found %d", head.Number)
+	}
+}
+
+func TestSyncReceipts(t *testing.T) {
+	headers := blockchain.NewTestHeaderChain(5)
+
+	// the pivot is a block with receipts
+	status := types.ReceiptSuccess
+	receipts := []*types.Receipt{
+		{Status: &status, CumulativeGasUsed: 10, Logs: []*types.Log{}},
+	}
+	pivot := &types.Header{
+		ParentHash:   headers[4].Hash,
+		Number:       5,
+		Difficulty:   5,
+		TxRoot:       types.EmptyRootHash,
+		Sha3Uncles:   types.EmptyUncleHash,
+		ReceiptsRoot: buildroot.CalculateReceiptsRoot(receipts),
+	}
+	pivot.ComputeHash()
+	headers = append(headers, pivot)
+
+	b0 := blockchain.NewTestBlockchain(t, headers)
+	b1 := blockchain.NewTestBlockchain(t, headers)
+	if err := b1.CommitReceipts([]types.Hash{pivot.Hash}, [][]*types.Receipt{receipts}); err != nil {
+		t.Fatal(err)
+	}
+
+	b := newTestBackend(t, b0)
+	eth0, _ := ethPipe(b0, b1)
+
+	if err := b.syncReceipts(eth0, pivot); err != nil {
+		t.Fatal(err)
+	}
+
+	found := b0.GetReceiptsByHash(pivot.Hash)
+	if len(found) != 1 || found[0].CumulativeGasUsed != 10 {
+		t.Fatal("bad receipts")
+	}
+}
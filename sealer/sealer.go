@@ -48,16 +48,10 @@ type Sealer struct {
 	enabled bool
 
 	executor *state.Executor
-	SealedCh chan *SealedNotify
 
 	wakeCh chan struct{}
 }
 
-// TODO; this one is tricky
-type SealedNotify struct {
-	Block *types.Block
-}
-
 // NewSealer creates a new sealer for a specific engine
 func NewSealer(config *Config, logger hclog.Logger, blockchain *blockchain.Blockchain, engine consensus.Consensus, executor *state.Executor) *Sealer {
 	s := &Sealer{
@@ -67,7 +61,6 @@ func NewSealer(config *Config, logger hclog.Logger, blockchain *blockchain.Block
 		logger:     logger.Named("Sealer"),
 		txPool:     NewTxPool(blockchain),
 		signer:     crypto.NewEIP155Signer(1),
-		SealedCh:   make(chan *SealedNotify, 10),
 		executor:   executor,
 		wakeCh:     make(chan struct{}),
 	}
@@ -93,7 +86,8 @@ func (s *Sealer) SetEnabled(enabled bool) {
 }
 
 func (s *Sealer) run(ctx context.Context) {
-	listener := s.blockchain.Subscribe()
+	sub := s.blockchain.Subscribe()
+	defer sub.Unsubscribe()
 
 	for {
 		if s.config.DevMode {
@@ -110,14 +104,7 @@ func (s *Sealer) run(ctx context.Context) {
 		done := s.sealAsync(subCtx)
 
 		// wait for the sealing to be done
-		select {
-		case <-done:
-			// the sealing process has finished
-		case <-ctx.Done():
-			// the sealing routine has been canceled
-		case <-listener:
-			// there is a new head
-		}
+		waitSealing(ctx, done, sub)
 
 		// cancel the sealing process context
 		cancel()
@@ -128,6 +115,24 @@ func (s *Sealer) run(ctx context.Context) {
 	}
 }
 
+func waitSealing(ctx context.Context, done chan struct{}, sub *blockchain.Subscription) {
+	for {
+		select {
+		case <-done:
+			// the sealing process has finished
+			return
+		case <-ctx.Done():
+			// the sealing routine has been canceled
+			return
+		case ev := <-sub.EventCh():
+			if ev.Type == blockchain.NewHead {
+				// there is a new head
+				return
+			}
+		}
+	}
+}
+
 var emptyFrom = types.StringToAddress("0")
 
 // AddTx adds a new transaction to the transaction pool
@@ -257,13 +262,6 @@ func (s *Sealer) seal(ctx context.Context) error {
 	}
 
 	s.logger.Info("Block sealed", "number", num+1, "hash", header.Hash)
-
-	// Broadcast the block to the network
-	select {
-	case s.SealedCh <- &SealedNotify{Block: block}:
-	default:
-	}
-
 	return nil
 }
 
@@ -186,20 +186,16 @@ func TestCancelSealingOnAdvanceChain(t *testing.T) {
 
 	s.SetEnabled(true)
 
-	// listen for sealing events
-	resCh := make(chan *SealedNotify)
-	go func() {
-		for {
-			resCh <- <-s.SealedCh
-		}
-	}()
-
 	// sealing block 1
 	wait1 := <-seal
 
 	// advance block 1 (the sealer has to discard current block 1 sealing)
 	advance()
 
+	// listen for the new heads after block 1
+	sub := s.blockchain.Subscribe()
+	defer sub.Unsubscribe()
+
 	// sealing block 2
 	wait2 := <-seal
 
@@ -207,10 +203,10 @@ func TestCancelSealingOnAdvanceChain(t *testing.T) {
 	wait1 <- struct{}{}
 	wait2 <- struct{}{}
 
-	// expect only one event (block 2 sealed)
+	// expect only one new head (block 2 sealed)
 	select {
-	case evnt := <-resCh:
-		if evnt.Block.Number() != 2 {
+	case evnt := <-sub.EventCh():
+		if evnt.Type != blockchain.NewHead || evnt.Header.Number != 2 {
 			t.Fatal("incorrect sealed block")
 		}
 	case <-time.After(500 * time.Millisecond):
@@ -219,9 +215,9 @@ func TestCancelSealingOnAdvanceChain(t *testing.T) {
 
 	// do not expect more events
 	select {
-	case <-resCh:
+	case <-sub.EventCh():
 		t.Fatal("more sealing events not expected")
-	default:
+	case <-time.After(100 * time.Millisecond):
 	}
 }
 
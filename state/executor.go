@@ -35,6 +35,10 @@ type Executor struct {
 	state    State
 	GetHash  GetHashByNumberHelper
 
+	// number of transactions executed concurrently in a block,
+	// the execution is sequential if it is lower than two
+	parallel int
+
 	PostHook func(txn *Transition)
 }
 
@@ -48,6 +52,11 @@ func NewExecutor(config *chain.Params, s State) *Executor {
 	}
 }
 
+// State returns the state of the executor
+func (e *Executor) State() State {
+	return e.state
+}
+
 func (e *Executor) WriteGenesis(alloc chain.GenesisAlloc) types.Hash {
 	snap := e.state.NewSnapshot()
 	txn := NewTxn(e.state, snap)
@@ -81,6 +90,48 @@ func (e *Executor) SetRuntime(r runtime.Runtime) {
 	e.runtimes = append(e.runtimes, r)
 }
 
+// WithRuntime returns a copy of the executor where the runtime with the
+// same name as r is replaced by r. The copy executes the transactions of
+// a block sequentially.
+func (e *Executor) WithRuntime(r runtime.Runtime) *Executor {
+	runtimes := make([]runtime.Runtime, len(e.runtimes))
+	for i, rr := range e.runtimes {
+		if rr.Name() == r.Name() {
+			rr = r
+		}
+		runtimes[i] = rr
+	}
+
+	return &Executor{
+		config:   e.config,
+		runtimes: runtimes,
+		daoBlock: e.daoBlock,
+		state:    e.state,
+		GetHash:  e.GetHash,
+		PostHook: e.PostHook,
+	}
+}
+
+// WithState returns a copy of the executor that reads and commits
+// the state in s. The copy executes the transactions of a block
+// sequentially.
+func (e *Executor) WithState(s State) *Executor {
+	return &Executor{
+		config:   e.config,
+		runtimes: e.runtimes,
+		daoBlock: e.daoBlock,
+		state:    s,
+		GetHash:  e.GetHash,
+		PostHook: e.PostHook,
+	}
+}
+
+// SetParallel sets the number of transactions of a block executed
+// concurrently. The execution is sequential if it is lower than two.
+func (e *Executor) SetParallel(workers int) {
+	e.parallel = workers
+}
+
 // ProcessBlock already does all the handling of the whole process, TODO
 func (e *Executor) ProcessBlock(parentRoot types.Hash, block *types.Block) (*Transition, types.Hash, error) {
 	txn, err := e.BeginTxn(parentRoot, block.Header)
@@ -89,10 +140,16 @@ func (e *Executor) ProcessBlock(parentRoot types.Hash, block *types.Block) (*Tra
 	}
 
 	txn.block = block
-	for _, t := range block.Transactions {
-		if err := txn.Write(t); err != nil {
+	if e.parallel > 1 && len(block.Transactions) > 1 {
+		if err := txn.writeParallel(block.Transactions, e.parallel); err != nil {
 			return nil, types.Hash{}, err
 		}
+	} else {
+		for _, t := range block.Transactions {
+			if err := txn.Write(t); err != nil {
+				return nil, types.Hash{}, err
+			}
+		}
 	}
 	_, root := txn.Commit()
 	return txn, root, nil
@@ -156,6 +213,11 @@ type Transition struct {
 	ctx     runtime.TxContext
 	gasPool uint64
 
+	// deferCoinbase does not pay the fee to the coinbase during the
+	// speculative execution, it is stored in coinbaseFee instead
+	deferCoinbase bool
+	coinbaseFee   *big.Int
+
 	// result
 	receipts []*types.Receipt
 	totalGas uint64
@@ -173,24 +235,36 @@ var emptyFrom = types.Address{}
 
 // Write writes another transaction to the executor
 func (t *Transition) Write(txn *types.Transaction) error {
-	signer := crypto.NewSigner(t.config, uint64(t.r.config.ChainID))
-
-	var err error
-	if txn.From == emptyFrom {
-		txn.From, err = signer.Sender(txn)
-		if err != nil {
-			return err
-		}
+	if err := t.recoverSender(txn); err != nil {
+		return err
 	}
 
 	msg := txn.Copy()
 
 	gasUsed, failed, _ := t.Apply(msg)
 
-	t.totalGas += gasUsed
-
 	logs := t.state.Logs()
 
+	t.writeReceipt(txn, msg, gasUsed, failed, logs)
+	return nil
+}
+
+func (t *Transition) recoverSender(txn *types.Transaction) error {
+	if txn.From != emptyFrom {
+		return nil
+	}
+
+	signer := crypto.NewSigner(t.config, uint64(t.r.config.ChainID))
+
+	var err error
+	txn.From, err = signer.Sender(txn)
+	return err
+}
+
+// writeReceipt finalizes the transaction and creates its receipt
+func (t *Transition) writeReceipt(txn *types.Transaction, msg *types.Transaction, gasUsed uint64, failed bool, logs []*types.Log) {
+	t.totalGas += gasUsed
+
 	var root []byte
 
 	receipt := &types.Receipt{
@@ -211,7 +285,11 @@ func (t *Transition) Write(txn *types.Transaction) error {
 
 	} else {
 		ss, aux := t.state.Commit(t.config.EIP155)
+
+		writes := t.state.writes
 		t.state = NewTxn(t.auxState, ss)
+		t.state.writes = writes
+
 		root = aux
 		receipt.Root = types.BytesToHash(root)
 	}
@@ -225,8 +303,6 @@ func (t *Transition) Write(txn *types.Transaction) error {
 	receipt.Logs = buildLogs(logs, txn.Hash, types.Hash{}, uint(len(t.receipts)))
 	receipt.LogsBloom = types.CreateBloom([]*types.Receipt{receipt})
 	t.receipts = append(t.receipts, receipt)
-
-	return nil
 }
 
 // Commit commits the final result
@@ -462,7 +538,11 @@ func (t *Transition) apply(msg *types.Transaction) (uint64, bool, error) {
 
 	// pay the coinbase
 	coinbaseFee := new(big.Int).Mul(new(big.Int).SetUint64(gasUsed), gasPrice)
-	txn.AddBalance(t.ctx.Coinbase, coinbaseFee)
+	if t.deferCoinbase {
+		t.coinbaseFee = coinbaseFee
+	} else {
+		txn.AddBalance(t.ctx.Coinbase, coinbaseFee)
+	}
 
 	// return gas to the pool
 	t.addGasPool(gasLeft)
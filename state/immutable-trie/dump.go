@@ -0,0 +1,102 @@
+package itrie
+
+import (
+	"fmt"
+
+	"github.com/umbracle/minimal/state"
+	"github.com/umbracle/minimal/types"
+)
+
+// DumpAccount is an account of the state dump
+type DumpAccount struct {
+	Hash types.Hash
+
+	// Address is nil if the preimage of the hash is not known
+	Address *types.Address
+
+	Account *state.Account
+	Code    []byte
+	Storage []*DumpStorage
+}
+
+// DumpStorage is a storage slot of the state dump
+type DumpStorage struct {
+	Hash types.Hash
+
+	// Key is nil if the preimage of the hash is not known
+	Key   *types.Hash
+	Value types.Hash
+}
+
+// Dump calls the handler with the accounts of the state in the range of
+// hashes [start, limit), together with their code and storage
+func (s *State) Dump(root types.Hash, start, limit []byte, handler func(*DumpAccount) error) error {
+	snap, err := s.NewSnapshotAt(root)
+	if err != nil {
+		return err
+	}
+
+	p := parserPool.Get()
+	defer parserPool.Put(p)
+
+	it := snap.(*Trie).NewIterator(start, limit)
+	for it.Next() {
+		account := &state.Account{}
+		if err := account.UnmarshalRlp(it.Value()); err != nil {
+			return err
+		}
+
+		entry := &DumpAccount{
+			Hash:    types.BytesToHash(it.Key()),
+			Account: account,
+			Storage: []*DumpStorage{},
+		}
+		if buf, ok := s.GetPreimage(entry.Hash); ok {
+			addr := types.BytesToAddress(buf)
+			entry.Address = &addr
+		}
+
+		if codeHash := types.BytesToHash(account.CodeHash); codeHash != types.BytesToHash(hashit(nil)) {
+			code, ok := s.GetCode(codeHash)
+			if !ok {
+				return fmt.Errorf("code %s not found", codeHash)
+			}
+			entry.Code = code
+		}
+
+		if account.Root != types.EmptyRootHash {
+			storage, err := s.NewSnapshotAt(account.Root)
+			if err != nil {
+				return err
+			}
+			storageIt := storage.(*Trie).NewIterator(nil, nil)
+			for storageIt.Next() {
+				v, err := p.Parse(storageIt.Value())
+				if err != nil {
+					return err
+				}
+				val, err := v.Bytes()
+				if err != nil {
+					return err
+				}
+				slot := &DumpStorage{
+					Hash:  types.BytesToHash(storageIt.Key()),
+					Value: types.BytesToHash(val),
+				}
+				if buf, ok := s.GetPreimage(slot.Hash); ok {
+					key := types.BytesToHash(buf)
+					slot.Key = &key
+				}
+				entry.Storage = append(entry.Storage, slot)
+			}
+			if err := storageIt.Err(); err != nil {
+				return err
+			}
+		}
+
+		if err := handler(entry); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
@@ -49,3 +49,12 @@ func compactToHex(compact []byte) []byte {
 	chop := 2 - base[0]&1
 	return base[chop:]
 }
+
+func hexToKeybytes(hex []byte) []byte {
+	if hasTerm(hex) {
+		hex = hex[:len(hex)-1]
+	}
+	key := make([]byte, len(hex)/2)
+	decodeNibbles(hex, key)
+	return key
+}
@@ -3,6 +3,7 @@ package itrie
 import (
 	"fmt"
 	"hash"
+	"runtime"
 	"sync"
 
 	"github.com/umbracle/fastrlp"
@@ -12,6 +13,16 @@ import (
 
 var arenaPool fastrlp.ArenaPool
 
+const (
+	// parallelHashThreshold is the number of changed keys of a trie
+	// from which the trie is hashed concurrently
+	parallelHashThreshold = 1000
+
+	// parallelHashDepth is the depth up to which the children of the
+	// full nodes are hashed concurrently
+	parallelHashDepth = 1
+)
+
 var (
 	emptyRoot = types.StringToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421").Bytes()
 )
@@ -141,6 +152,10 @@ func (t *Txn) hash(node Node, h *hasher, a *fastrlp.Arena, d int) *fastrlp.Value
 		val.Set(child)
 
 	case *FullNode:
+		if t.parallel && d < parallelHashDepth {
+			t.hashChildren(n, d)
+		}
+
 		val = a.NewArray()
 
 		aa, idx = h.AcquireArena()
@@ -184,3 +199,66 @@ func (t *Txn) hash(node Node, h *hasher, a *fastrlp.Arena, d int) *fastrlp.Value
 
 	return a.NewCopyBytes(hh)
 }
+
+// parallelHash returns true if a trie with the given number
+// of changed keys should be hashed concurrently
+func parallelHash(changes int) bool {
+	return changes >= parallelHashThreshold && runtime.GOMAXPROCS(0) > 1
+}
+
+// putBuffer holds the nodes written by a concurrent hasher
+type putBuffer struct {
+	keys [][]byte
+	vals [][]byte
+}
+
+func (p *putBuffer) Put(k, v []byte) {
+	// the hasher reuses the buffers
+	p.keys = append(p.keys, append([]byte{}, k...))
+	p.vals = append(p.vals, append([]byte{}, v...))
+}
+
+// hashChildren hashes the children of the full node concurrently. The hashes
+// are set in the nodes so that the sequential hasher only has to read them,
+// and the nodes are written in the order of the children so that the writes
+// are the same as with the sequential hasher.
+func (t *Txn) hashChildren(n *FullNode, d int) {
+	var wg sync.WaitGroup
+	var buffers [16]*putBuffer
+
+	for i, child := range n.children {
+		if child == nil {
+			continue
+		}
+		if _, ok := child.Hash(); ok {
+			continue
+		}
+
+		txn := &Txn{parallel: t.parallel}
+		if t.batch != nil {
+			buffers[i] = &putBuffer{}
+			txn.batch = buffers[i]
+		}
+
+		wg.Add(1)
+		go func(txn *Txn, child Node) {
+			defer wg.Done()
+
+			h := hasherPool.Get().(*hasher)
+			arena, _ := h.AcquireArena()
+			txn.hash(child, h, arena, d+1)
+			h.ReleaseArenas(0)
+			hasherPool.Put(h)
+		}(txn, child)
+	}
+	wg.Wait()
+
+	for _, buffer := range buffers {
+		if buffer == nil {
+			continue
+		}
+		for i := range buffer.keys {
+			t.batch.Put(buffer.keys[i], buffer.vals[i])
+		}
+	}
+}
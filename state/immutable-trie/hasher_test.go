@@ -0,0 +1,92 @@
+package itrie
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+type recordBatch struct {
+	keys [][]byte
+	vals [][]byte
+}
+
+func (r *recordBatch) Put(k, v []byte) {
+	r.keys = append(r.keys, append([]byte{}, k...))
+	r.vals = append(r.vals, append([]byte{}, v...))
+}
+
+// randomTxn returns a txn with n random keys, the values are small
+// and large to have both embedded and hashed nodes
+func randomTxn(r *rand.Rand, n int) *Txn {
+	txn := NewTrie().Txn()
+	for i := 0; i < n; i++ {
+		key := make([]byte, 32)
+		r.Read(key)
+		val := make([]byte, 1+r.Intn(40))
+		r.Read(val)
+		txn.Insert(key, val)
+	}
+	return txn
+}
+
+func TestParallelHash(t *testing.T) {
+	for _, n := range []int{1, 2, 16, 100, 5000} {
+		// the same trie hashed sequentially and concurrently
+		txn1 := randomTxn(rand.New(rand.NewSource(int64(n))), n)
+		txn2 := randomTxn(rand.New(rand.NewSource(int64(n))), n)
+
+		batch1, batch2 := &recordBatch{}, &recordBatch{}
+		txn1.batch, txn2.batch = batch1, batch2
+		txn2.parallel = true
+
+		root1, _ := txn1.Hash()
+		root2, _ := txn2.Hash()
+		if !bytes.Equal(root1, root2) {
+			t.Fatalf("bad root with %d keys", n)
+		}
+
+		// the nodes are written in the same order
+		if len(batch1.keys) != len(batch2.keys) {
+			t.Fatalf("expected %d nodes but found %d", len(batch1.keys), len(batch2.keys))
+		}
+		for i := range batch1.keys {
+			if !bytes.Equal(batch1.keys[i], batch2.keys[i]) || !bytes.Equal(batch1.vals[i], batch2.vals[i]) {
+				t.Fatal("bad node")
+			}
+		}
+	}
+}
+
+func benchmarkHash(b *testing.B, n int, parallel bool) {
+	r := rand.New(rand.NewSource(1))
+
+	txns := make([]*Txn, b.N)
+	for i := range txns {
+		txns[i] = randomTxn(r, n)
+		txns[i].parallel = parallel
+		txns[i].batch = &recordBatch{}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		txns[i].Hash()
+	}
+}
+
+func BenchmarkHashSequential1000(b *testing.B) {
+	benchmarkHash(b, 1000, false)
+}
+
+func BenchmarkHashParallel1000(b *testing.B) {
+	benchmarkHash(b, 1000, true)
+}
+
+func BenchmarkHashSequential10000(b *testing.B) {
+	benchmarkHash(b, 10000, false)
+}
+
+func BenchmarkHashParallel10000(b *testing.B) {
+	benchmarkHash(b, 10000, true)
+}
@@ -0,0 +1,139 @@
+package itrie
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Iterator walks the leaves of a trie in key order
+type Iterator struct {
+	storage Storage
+	stack   []*iteratorFrame
+
+	start []byte
+	limit []byte
+
+	key   []byte
+	value []byte
+	err   error
+}
+
+type iteratorFrame struct {
+	node Node
+	path []byte
+
+	// idx is the next edge of a full node, the value first
+	// and then the children
+	idx int
+}
+
+// NewIterator returns an iterator over the keys of the trie in the
+// range [start, limit). A nil start or limit means no bound.
+func (t *Trie) NewIterator(start, limit []byte) *Iterator {
+	it := &Iterator{
+		storage: t.storage,
+		limit:   limit,
+	}
+	if len(start) != 0 {
+		// the start key without the terminator
+		it.start = keybytesToHex(start)
+		it.start = it.start[:len(it.start)-1]
+	}
+	if t.root != nil {
+		it.stack = append(it.stack, &iteratorFrame{node: t.root, path: []byte{}})
+	}
+	return it
+}
+
+// Next moves the iterator to the next leaf, it returns false
+// when there are no more leaves or an error happened
+func (it *Iterator) Next() bool {
+	for len(it.stack) != 0 {
+		frame := it.stack[len(it.stack)-1]
+
+		switch n := frame.node.(type) {
+		case *ValueNode:
+			it.stack = it.stack[:len(it.stack)-1]
+
+			if n.hash {
+				nc, ok, err := GetNode(n.buf, it.storage)
+				if err == nil && !ok {
+					err = fmt.Errorf("node %x not found", n.buf)
+				}
+				if err != nil {
+					it.err = err
+					it.stack = nil
+					return false
+				}
+				it.push(nc, frame.path)
+				continue
+			}
+
+			key := hexToKeybytes(frame.path)
+			if it.limit != nil && bytes.Compare(key, it.limit) >= 0 {
+				it.stack = nil
+				return false
+			}
+			it.key, it.value = key, n.buf
+			return true
+
+		case *ShortNode:
+			it.stack = it.stack[:len(it.stack)-1]
+			it.push(n.child, concat(frame.path, n.key))
+
+		case *FullNode:
+			if frame.idx == 17 {
+				it.stack = it.stack[:len(it.stack)-1]
+				continue
+			}
+			idx := frame.idx
+			frame.idx++
+
+			if idx == 0 {
+				it.push(n.value, concat(frame.path, []byte{16}))
+			} else {
+				it.push(n.children[idx-1], concat(frame.path, []byte{byte(idx - 1)}))
+			}
+
+		default:
+			panic(fmt.Sprintf("unknown node type %v", n))
+		}
+	}
+	return false
+}
+
+// push adds the node to the stack unless all its leaves are before the start key
+func (it *Iterator) push(node Node, path []byte) {
+	if node == nil {
+		return
+	}
+	prefix, leaf := path, hasTerm(path)
+	if leaf {
+		prefix = prefix[:len(prefix)-1]
+	}
+	l := len(prefix)
+	if len(it.start) < l {
+		l = len(it.start)
+	}
+	cmp := bytes.Compare(prefix[:l], it.start[:l])
+	if cmp < 0 || (cmp == 0 && leaf && len(prefix) < len(it.start)) {
+		// a leaf whose key is a prefix of the start key is also before it
+		return
+	}
+	it.stack = append(it.stack, &iteratorFrame{node: node, path: path})
+}
+
+// Key returns the key of the current leaf
+func (it *Iterator) Key() []byte {
+	return it.key
+}
+
+// Value returns the value of the current leaf
+func (it *Iterator) Value() []byte {
+	return it.value
+}
+
+// Err returns the error of the iteration if any
+func (it *Iterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,125 @@
+package itrie
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/umbracle/minimal/state"
+	"github.com/umbracle/minimal/types"
+)
+
+func TestIterator(t *testing.T) {
+	storage := NewMemoryStorage()
+	root, entries := buildProofTrie(t, storage, 500)
+
+	snap, err := NewState(storage).NewSnapshotAt(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt := snap.(*Trie)
+
+	keys := []string{}
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	iterate := func(start, limit []byte) []string {
+		res := []string{}
+		it := tt.NewIterator(start, limit)
+		for it.Next() {
+			if !bytes.Equal(it.Value(), entries[string(it.Key())]) {
+				t.Fatal("bad value")
+			}
+			res = append(res, string(it.Key()))
+		}
+		if err := it.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	equal := func(a, b []string) {
+		if len(a) != len(b) {
+			t.Fatalf("expected %d keys but found %d", len(b), len(a))
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				t.Fatal("bad order")
+			}
+		}
+	}
+
+	// all the keys
+	equal(iterate(nil, nil), keys)
+
+	// range of keys
+	equal(iterate([]byte(keys[100]), []byte(keys[200])), keys[100:200])
+
+	// the bounds do not need to be in the trie
+	start := append([]byte(keys[10]), 0x0)
+	equal(iterate(start[:32], nil), keys[10:])
+	equal(iterate(append([]byte(keys[10]), 0x0), nil), keys[11:])
+
+	// empty trie
+	if NewTrie().NewIterator(nil, nil).Next() {
+		t.Fatal("the empty trie should not have keys")
+	}
+}
+
+func TestDump(t *testing.T) {
+	addr := types.StringToAddress("1")
+	slot := types.StringToHash("2")
+
+	s := NewState(NewMemoryStorage())
+	s.EnablePreimages()
+	_, root := s.NewSnapshot().Commit([]*state.Object{
+		{
+			Address:   addr,
+			Balance:   big.NewInt(100),
+			Nonce:     5,
+			CodeHash:  types.BytesToHash(hashit([]byte{0x1})),
+			Root:      types.EmptyRootHash,
+			DirtyCode: true,
+			Code:      []byte{0x1},
+			Storage: []*state.StorageObject{
+				{Key: slot.Bytes(), Val: types.StringToHash("3").Bytes()},
+			},
+		},
+		{
+			Address:  types.StringToAddress("4"),
+			Balance:  big.NewInt(1),
+			CodeHash: types.BytesToHash(hashit(nil)),
+			Root:     types.EmptyRootHash,
+		},
+	})
+
+	accounts := []*DumpAccount{}
+	err := s.Dump(types.BytesToHash(root), nil, nil, func(account *DumpAccount) error {
+		accounts = append(accounts, account)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 2 {
+		t.Fatalf("expected two accounts but found %d", len(accounts))
+	}
+
+	for _, account := range accounts {
+		if account.Address == nil {
+			t.Fatal("the address should be recovered")
+		}
+		if *account.Address != addr {
+			continue
+		}
+		if account.Account.Nonce != 5 || !bytes.Equal(account.Code, []byte{0x1}) {
+			t.Fatal("bad account")
+		}
+		if len(account.Storage) != 1 || *account.Storage[0].Key != slot || account.Storage[0].Value != types.StringToHash("3") {
+			t.Fatal("bad storage")
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package itrie
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/umbracle/fastrlp"
+	"github.com/umbracle/minimal/state"
+	"github.com/umbracle/minimal/types"
+)
+
+// AccountProof is the merkle proof of an account and some of its storage slots
+type AccountProof struct {
+	Address types.Address
+
+	// Account is nil if the account does not exist
+	Account *state.Account
+	Proof   [][]byte
+	Storage []*StorageProof
+}
+
+// StorageProof is the merkle proof of a storage slot
+type StorageProof struct {
+	Key   types.Hash
+	Value types.Hash
+	Proof [][]byte
+}
+
+// GetProof returns the merkle proofs of the account and the storage
+// slots in the state with the given root
+func (s *State) GetProof(root types.Hash, addr types.Address, slots []types.Hash) (*AccountProof, error) {
+	snap, err := s.NewSnapshotAt(root)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AccountProof{
+		Address: addr,
+		Storage: []*StorageProof{},
+	}
+	if res.Proof, err = snap.(*Trie).Prove(hashit(addr.Bytes())); err != nil {
+		return nil, err
+	}
+
+	var storage *Trie
+	if data, ok := snap.Get(hashit(addr.Bytes())); ok {
+		res.Account = &state.Account{}
+		if err := res.Account.UnmarshalRlp(data); err != nil {
+			return nil, err
+		}
+		accountSnap, err := s.NewSnapshotAt(res.Account.Root)
+		if err != nil {
+			return nil, err
+		}
+		storage = accountSnap.(*Trie)
+	}
+
+	p := proofParserPool.Get()
+	defer proofParserPool.Put(p)
+
+	for _, slot := range slots {
+		entry := &StorageProof{
+			Key:   slot,
+			Proof: [][]byte{},
+		}
+		res.Storage = append(res.Storage, entry)
+
+		if storage == nil {
+			continue
+		}
+		if entry.Proof, err = storage.Prove(hashit(slot.Bytes())); err != nil {
+			return nil, err
+		}
+		if data, ok := storage.Get(hashit(slot.Bytes())); ok {
+			v, err := p.Parse(data)
+			if err != nil {
+				return nil, err
+			}
+			val, err := v.Bytes()
+			if err != nil {
+				return nil, err
+			}
+			entry.Value = types.BytesToHash(val)
+		}
+	}
+	return res, nil
+}
+
+// Prove returns the merkle proof of the key. The proof is the list of
+// encoded nodes in the path from the root to the value, the nodes that
+// are embedded in their parent are not included. If the key is not in
+// the trie the proof shows the path up to the point where it diverges.
+func (t *Trie) Prove(key []byte) ([][]byte, error) {
+	return t.Txn().Prove(key)
+}
+
+// Prove returns the merkle proof of the key
+func (t *Txn) Prove(key []byte) ([][]byte, error) {
+	h := hasherPool.Get().(*hasher)
+	defer func() {
+		h.ReleaseArenas(0)
+		hasherPool.Put(h)
+	}()
+
+	proof := [][]byte{}
+	search := keybytesToHex(key)
+
+	node := t.root
+	for node != nil {
+		if v, ok := node.(*ValueNode); ok && v.hash {
+			// resolve the reference to the stored node
+			nc, ok, err := GetNode(v.buf, t.storage)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, fmt.Errorf("node %x not found", v.buf)
+			}
+			node = nc
+			continue
+		}
+
+		arena, _ := h.AcquireArena()
+		val := t.encodeNode(node, h, arena)
+		if val != nil {
+			if buf := val.MarshalTo(nil); len(buf) >= 32 || len(proof) == 0 {
+				proof = append(proof, buf)
+			}
+		}
+
+		switch n := node.(type) {
+		case *ValueNode:
+			node = nil
+
+		case *ShortNode:
+			plen := len(n.key)
+			if plen > len(search) || !bytes.Equal(search[:plen], n.key) {
+				node = nil
+			} else {
+				node = n.child
+				search = search[plen:]
+			}
+
+		case *FullNode:
+			if len(search) == 0 || search[0] == 16 {
+				node = n.value
+				search = nil
+			} else {
+				node = n.getEdge(search[0])
+				search = search[1:]
+			}
+
+		default:
+			panic(fmt.Sprintf("unknown node type %v", n))
+		}
+	}
+	return proof, nil
+}
+
+// encodeNode returns the full encoding of a short or a full node,
+// the children are referenced by their hash or embedded as in the
+// hash of the trie.
+func (t *Txn) encodeNode(node Node, h *hasher, a *fastrlp.Arena) *fastrlp.Value {
+	var val *fastrlp.Value
+
+	switch n := node.(type) {
+	case *ShortNode:
+		val = a.NewArray()
+		val.Set(a.NewBytes(hexToCompact(n.key)))
+		val.Set(t.hash(n.child, h, a, 0))
+
+	case *FullNode:
+		val = a.NewArray()
+		for _, i := range n.children {
+			if i == nil {
+				val.Set(a.NewNull())
+			} else {
+				val.Set(t.hash(i, h, a, 0))
+			}
+		}
+		if n.value == nil {
+			val.Set(a.NewNull())
+		} else {
+			val.Set(t.hash(n.value, h, a, 0))
+		}
+	}
+	return val
+}
+
+var proofParserPool fastrlp.ParserPool
+
+// VerifyProof checks the merkle proof of the key against the root of the
+// trie and returns the value of the key. It returns a nil value if the
+// proof shows that the key is not in the trie and an error if the proof
+// is not valid.
+func VerifyProof(root types.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	nodes := map[types.Hash][]byte{}
+	for _, buf := range proof {
+		nodes[types.BytesToHash(hashit(buf))] = buf
+	}
+
+	p := proofParserPool.Get()
+	defer proofParserPool.Put(p)
+
+	search := keybytesToHex(key)
+	wanted := root
+
+	for {
+		buf, ok := nodes[wanted]
+		if !ok {
+			return nil, fmt.Errorf("proof node %s not found", wanted)
+		}
+		v, err := p.Parse(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		// walk the node and the nodes embedded in it
+		for {
+			if v.Type() == fastrlp.TypeBytes {
+				raw := v.Raw()
+				if len(raw) == 0 {
+					// empty edge, the key is not in the trie
+					return nil, nil
+				}
+				if len(raw) != 32 {
+					return nil, fmt.Errorf("invalid node reference %x", raw)
+				}
+				wanted = types.BytesToHash(raw)
+				break
+			}
+
+			switch v.Elems() {
+			case 2:
+				key := v.Get(0)
+				if key.Type() != fastrlp.TypeBytes {
+					return nil, fmt.Errorf("short key expected to be bytes")
+				}
+				nibbles := compactToHex(key.Raw())
+				if hasTerm(nibbles) {
+					// leaf node
+					if !bytes.Equal(search, nibbles) {
+						return nil, nil
+					}
+					return append([]byte{}, v.Get(1).Raw()...), nil
+				}
+				if len(nibbles) > len(search) || !bytes.Equal(search[:len(nibbles)], nibbles) {
+					return nil, nil
+				}
+				search = search[len(nibbles):]
+				v = v.Get(1)
+
+			case 17:
+				if search[0] == 16 {
+					value := v.Get(16).Raw()
+					if len(value) == 0 {
+						return nil, nil
+					}
+					return append([]byte{}, value...), nil
+				}
+				v = v.Get(int(search[0]))
+				search = search[1:]
+
+			default:
+				return nil, fmt.Errorf("node has incorrect number of leafs")
+			}
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package itrie
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/umbracle/minimal/state"
+	"github.com/umbracle/minimal/types"
+)
+
+func buildProofTrie(t *testing.T, storage Storage, n int) (types.Hash, map[string][]byte) {
+	entries := map[string][]byte{}
+
+	txn := NewTrie().Txn()
+	txn.storage = storage
+	batch := storage.Batch()
+	txn.batch = batch
+
+	for i := 0; i < n; i++ {
+		key := make([]byte, 32)
+		rand.Read(key)
+		// small and large values to have both embedded and hashed nodes
+		val := make([]byte, 1+rand.Intn(40))
+		rand.Read(val)
+
+		txn.Insert(key, val)
+		entries[string(key)] = val
+	}
+
+	root, err := txn.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch.Write()
+	return types.BytesToHash(root), entries
+}
+
+func TestProof(t *testing.T) {
+	storage := NewMemoryStorage()
+	root, entries := buildProofTrie(t, storage, 500)
+
+	snap, err := NewState(storage).NewSnapshotAt(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt := snap.(*Trie)
+
+	for key, val := range entries {
+		proof, err := tt.Prove([]byte(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		found, err := VerifyProof(root, []byte(key), proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(found, val) {
+			t.Fatalf("bad value %x, expected %x", found, val)
+		}
+
+		// the proof is not valid for another root
+		if _, err := VerifyProof(types.Hash{0x1}, []byte(key), proof); err == nil {
+			t.Fatal("proof valid for a different root")
+		}
+	}
+}
+
+func TestProofMissingKey(t *testing.T) {
+	storage := NewMemoryStorage()
+	root, _ := buildProofTrie(t, storage, 100)
+
+	snap, err := NewState(storage).NewSnapshotAt(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		key := make([]byte, 32)
+		rand.Read(key)
+
+		proof, err := snap.(*Trie).Prove(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found, err := VerifyProof(root, key, proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found != nil {
+			t.Fatal("key should not be found")
+		}
+	}
+}
+
+func TestProofTampered(t *testing.T) {
+	storage := NewMemoryStorage()
+	root, entries := buildProofTrie(t, storage, 50)
+
+	snap, err := NewState(storage).NewSnapshotAt(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key := range entries {
+		proof, err := snap.(*Trie).Prove([]byte(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// modify the last node of the proof
+		last := proof[len(proof)-1]
+		last[len(last)-1]++
+
+		if _, err := VerifyProof(root, []byte(key), proof); err == nil {
+			t.Fatal("tampered proof should not be valid")
+		}
+		break
+	}
+}
+
+func TestStateGetProof(t *testing.T) {
+	addr := types.StringToAddress("1")
+	slot := types.StringToHash("2")
+
+	s := NewState(NewMemoryStorage())
+	_, root := s.NewSnapshot().Commit([]*state.Object{
+		{
+			Address:  addr,
+			Balance:  big.NewInt(100),
+			Nonce:    5,
+			CodeHash: types.BytesToHash(hashit(nil)),
+			Root:     types.EmptyRootHash,
+			Storage: []*state.StorageObject{
+				{Key: slot.Bytes(), Val: types.StringToHash("3").Bytes()},
+			},
+		},
+	})
+
+	proof, err := s.GetProof(types.BytesToHash(root), addr, []types.Hash{slot, types.StringToHash("4")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proof.Account == nil || proof.Account.Nonce != 5 || proof.Account.Balance.Uint64() != 100 {
+		t.Fatal("bad account")
+	}
+
+	// the account proof is valid for the state root
+	data, err := VerifyProof(types.BytesToHash(root), hashit(addr.Bytes()), proof.Proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var account state.Account
+	if err := account.UnmarshalRlp(data); err != nil {
+		t.Fatal(err)
+	}
+
+	// the storage proofs are valid for the storage root of the account
+	if proof.Storage[0].Value != types.StringToHash("3") {
+		t.Fatal("bad storage value")
+	}
+	if _, err := VerifyProof(account.Root, hashit(slot.Bytes()), proof.Storage[0].Proof); err != nil {
+		t.Fatal(err)
+	}
+	if proof.Storage[1].Value != (types.Hash{}) {
+		t.Fatal("the slot should be empty")
+	}
+
+	// proof of a missing account
+	proof, err = s.GetProof(types.BytesToHash(root), types.StringToAddress("5"), []types.Hash{slot})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proof.Account != nil || len(proof.Storage[0].Proof) != 0 {
+		t.Fatal("the account should not exist")
+	}
+}
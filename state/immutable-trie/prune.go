@@ -0,0 +1,288 @@
+package itrie
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/umbracle/minimal/helper/hex"
+	"github.com/umbracle/minimal/state"
+	"github.com/umbracle/minimal/types"
+)
+
+// pruneBatchSize is the number of nodes deleted on each write
+const pruneBatchSize = 1000
+
+// pruneBloomSize is the size in bytes of the bloom filter in front of
+// the marked nodes, it only has to fit a fraction of the state since
+// the positive matches are checked in the storage
+const pruneBloomSize = 32 * 1024 * 1024
+
+var (
+	// pruneMarkPrefix is the prefix of the nodes marked during a prune
+	pruneMarkPrefix = []byte("prunemark")
+
+	// pruneMarkLimit is the end of the range of the marked nodes
+	pruneMarkLimit = []byte("prunemarl")
+)
+
+// track records the keys written while the storage is being pruned so
+// that nodes added (or re-added) by new blocks are not swept
+func (kv *KVStorage) track(k []byte) {
+	kv.lock.Lock()
+	if kv.written != nil {
+		kv.written[string(k)] = struct{}{}
+	}
+	kv.lock.Unlock()
+}
+
+// Prune removes from the storage all the trie nodes that are not reachable
+// from the state roots returned by roots and returns the number of deleted
+// nodes. Contract code is not pruned. Prune can run while new states are
+// being committed, the nodes written after roots is called are always kept.
+// roots must only return once the states written before are either
+// included in the roots or not referenced anymore.
+func (kv *KVStorage) Prune(roots func() ([]types.Hash, error)) (int, error) {
+	kv.lock.Lock()
+	if kv.written != nil {
+		kv.lock.Unlock()
+		return 0, fmt.Errorf("prune already in progress")
+	}
+	kv.written = map[string]struct{}{}
+	kv.lock.Unlock()
+
+	defer func() {
+		kv.lock.Lock()
+		kv.written = nil
+		kv.lock.Unlock()
+	}()
+
+	// the writes are tracked before the roots are chosen, a node written
+	// in between is kept even if its state is not in the roots
+	retained, err := roots()
+	if err != nil {
+		return 0, err
+	}
+
+	// take the snapshot before the mark phase, any node written afterwards
+	// is either tracked or not part of the snapshot
+	snap, err := kv.db.GetSnapshot()
+	if err != nil {
+		return 0, err
+	}
+	defer snap.Release()
+
+	// the marks of an interrupted prune are not valid anymore
+	marked, err := kv.newMarkSet()
+	if err != nil {
+		return 0, err
+	}
+	defer marked.release()
+
+	// mark
+	for _, root := range retained {
+		if _, ok := kv.Get(root.Bytes()); !ok {
+			// the state of the root is not available (i.e. empty,
+			// pruned before or older than the fast sync pivot)
+			continue
+		}
+		if err := kv.mark(root.Bytes(), true, marked); err != nil {
+			return 0, err
+		}
+	}
+	if err := marked.flush(); err != nil {
+		return 0, err
+	}
+
+	// sweep
+	deleted := 0
+	pending := [][]byte{}
+
+	flush := func() error {
+		kv.lock.Lock()
+		defer kv.lock.Unlock()
+
+		batch := &leveldb.Batch{}
+		for _, k := range pending {
+			if _, ok := kv.written[string(k)]; ok {
+				continue
+			}
+			batch.Delete(k)
+		}
+		if err := kv.db.Write(batch, nil); err != nil {
+			return err
+		}
+		deleted += batch.Len()
+		pending = pending[:0]
+		return nil
+	}
+
+	iter := snap.NewIterator(nil, nil)
+	for iter.Next() {
+		k := iter.Key()
+		if len(k) != types.HashLength {
+			// not a trie node
+			continue
+		}
+		ok, err := marked.has(k)
+		if err != nil {
+			iter.Release()
+			return deleted, err
+		}
+		if ok {
+			continue
+		}
+		pending = append(pending, append([]byte{}, k...))
+		if len(pending) == pruneBatchSize {
+			if err := flush(); err != nil {
+				iter.Release()
+				return deleted, err
+			}
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return deleted, err
+	}
+	if err := flush(); err != nil {
+		return deleted, err
+	}
+	return deleted, nil
+}
+
+// mark marks as reachable the node with the given hash and all its
+// children. If the node is part of the account trie, the storage
+// tries of the accounts are marked too.
+func (kv *KVStorage) mark(hash []byte, accounts bool, marked *markSet) error {
+	ok, err := marked.has(hash)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return nil
+	}
+	node, ok, err := GetNode(hash, kv)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// the nodes below the retained roots must be in the storage,
+		// otherwise the sweep would remove the rest of the state
+		return fmt.Errorf("trie node %s not found", hex.EncodeToHex(hash))
+	}
+	if err := marked.add(hash); err != nil {
+		return err
+	}
+	return kv.markNode(node, accounts, marked)
+}
+
+func (kv *KVStorage) markNode(node Node, accounts bool, marked *markSet) error {
+	switch n := node.(type) {
+	case *ValueNode:
+		if n.hash {
+			return kv.mark(n.buf, accounts, marked)
+		}
+
+	case *ShortNode:
+		if !hasTerm(n.key) {
+			return kv.markNode(n.child, accounts, marked)
+		}
+		if !accounts {
+			return nil
+		}
+		var account state.Account
+		if err := account.UnmarshalRlp(n.child.(*ValueNode).buf); err != nil {
+			return err
+		}
+		if account.Root != types.EmptyRootHash {
+			return kv.mark(account.Root.Bytes(), false, marked)
+		}
+
+	case *FullNode:
+		for _, child := range n.children {
+			if child == nil {
+				continue
+			}
+			if err := kv.markNode(child, accounts, marked); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// markSet is the set of the nodes marked during a prune. The marks are
+// written to the storage so that the memory does not grow with the size
+// of the state, a bloom filter skips the lookups of most unmarked nodes.
+type markSet struct {
+	kv      *KVStorage
+	bloom   []byte
+	pending map[string]struct{}
+}
+
+func (kv *KVStorage) newMarkSet() (*markSet, error) {
+	if _, err := kv.DeleteRange(pruneMarkPrefix, pruneMarkLimit); err != nil {
+		return nil, err
+	}
+	m := &markSet{
+		kv:      kv,
+		bloom:   make([]byte, pruneBloomSize),
+		pending: map[string]struct{}{},
+	}
+	return m, nil
+}
+
+// bloomBits returns the positions of the hash in the bloom filter, the
+// hash is already random so its words are used as the hash functions
+func (m *markSet) bloomBits(hash []byte) [4]uint64 {
+	var bits [4]uint64
+	for i := range bits {
+		bits[i] = binary.BigEndian.Uint64(hash[i*8:]) % uint64(len(m.bloom)*8)
+	}
+	return bits
+}
+
+func (m *markSet) add(hash []byte) error {
+	for _, bit := range m.bloomBits(hash) {
+		m.bloom[bit/8] |= 1 << (bit % 8)
+	}
+	m.pending[string(hash)] = struct{}{}
+	if len(m.pending) == pruneBatchSize {
+		return m.flush()
+	}
+	return nil
+}
+
+func (m *markSet) has(hash []byte) (bool, error) {
+	for _, bit := range m.bloomBits(hash) {
+		if m.bloom[bit/8]&(1<<(bit%8)) == 0 {
+			return false, nil
+		}
+	}
+	if _, ok := m.pending[string(hash)]; ok {
+		return true, nil
+	}
+	ok, err := m.kv.db.Has(append(append([]byte{}, pruneMarkPrefix...), hash...), nil)
+	if err != nil {
+		return false, err
+	}
+	return ok, nil
+}
+
+// flush writes the pending marks to the storage
+func (m *markSet) flush() error {
+	batch := &leveldb.Batch{}
+	for k := range m.pending {
+		batch.Put(append(append([]byte{}, pruneMarkPrefix...), k...), nil)
+	}
+	if err := m.kv.db.Write(batch, nil); err != nil {
+		return err
+	}
+	m.pending = map[string]struct{}{}
+	return nil
+}
+
+// release removes the marks from the storage
+func (m *markSet) release() {
+	m.kv.DeleteRange(pruneMarkPrefix, pruneMarkLimit)
+}
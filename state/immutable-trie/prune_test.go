@@ -0,0 +1,218 @@
+package itrie
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/umbracle/minimal/state"
+	"github.com/umbracle/minimal/types"
+)
+
+func pruneTestObject(addr types.Address, balance int64, slots int) *state.Object {
+	obj := &state.Object{
+		Address:  addr,
+		Balance:  big.NewInt(balance),
+		CodeHash: types.BytesToHash(hashit(nil)),
+		Root:     types.EmptyRootHash,
+	}
+	for i := 0; i < slots; i++ {
+		obj.Storage = append(obj.Storage, &state.StorageObject{
+			Key: types.BytesToHash([]byte{byte(i + 1)}).Bytes(),
+			Val: types.BytesToHash([]byte{byte(balance), byte(i + 1)}).Bytes(),
+		})
+	}
+	return obj
+}
+
+// pruneRoots returns the roots callback of a prune with fixed roots
+func pruneRoots(roots ...[]byte) func() ([]types.Hash, error) {
+	return func() ([]types.Hash, error) {
+		res := []types.Hash{}
+		for _, root := range roots {
+			res = append(res, types.BytesToHash(root))
+		}
+		return res, nil
+	}
+}
+
+func newPruneTestStorage(t *testing.T) (*KVStorage, func()) {
+	dir, err := ioutil.TempDir("", "prune")
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage, err := NewLevelDBStorage(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeFn := func() {
+		storage.Close()
+		os.RemoveAll(dir)
+	}
+	return storage.(*KVStorage), closeFn
+}
+
+func checkPrunedState(t *testing.T, s *State, root []byte) {
+	t.Helper()
+
+	s.Verify(types.BytesToHash(root), func(issue *VerifyIssue) {
+		t.Fatalf("state %s not complete: %s", types.BytesToHash(root), issue.String())
+	})
+}
+
+func TestPrune(t *testing.T) {
+	kv, closeFn := newPruneTestStorage(t)
+	defer closeFn()
+
+	s := NewState(kv)
+
+	objs := []*state.Object{}
+	for i := 0; i < 50; i++ {
+		objs = append(objs, pruneTestObject(types.BytesToAddress([]byte{byte(i + 1)}), 1, 5))
+	}
+	snap1, root1 := s.NewSnapshot().Commit(objs)
+
+	// modify half of the accounts and their storage
+	objs = []*state.Object{}
+	for i := 0; i < 25; i++ {
+		objs = append(objs, pruneTestObject(types.BytesToAddress([]byte{byte(i + 1)}), 2, 5))
+	}
+	_, root2 := snap1.Commit(objs)
+
+	deleted, err := kv.Prune(pruneRoots(root2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted == 0 {
+		t.Fatal("expected nodes to be deleted")
+	}
+
+	// the first state is not complete anymore
+	if _, ok := kv.Get(root1); ok {
+		t.Fatal("the first root should be pruned")
+	}
+
+	// the second state is complete
+	for i := 0; i < 50; i++ {
+		addr := types.BytesToAddress([]byte{byte(i + 1)})
+		proof, err := s.GetProof(types.BytesToHash(root2), addr, []types.Hash{types.BytesToHash([]byte{1})})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if proof.Account == nil {
+			t.Fatalf("account %d not found", i)
+		}
+		if proof.Storage[0].Value == (types.Hash{}) {
+			t.Fatalf("storage of account %d not found", i)
+		}
+	}
+
+	// pruning again does not delete anything
+	if deleted, err = kv.Prune(pruneRoots(root2)); err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 0 {
+		t.Fatalf("expected no deleted nodes but found %d", deleted)
+	}
+
+	// the roots whose state is not available are skipped
+	if _, err = kv.Prune(pruneRoots(root1, root2)); err != nil {
+		t.Fatal(err)
+	}
+
+	// a node missing below a retained root is an error
+	iter := kv.db.NewIterator(nil, nil)
+	var node []byte
+	for iter.Next() {
+		if k := iter.Key(); len(k) == types.HashLength && !bytes.Equal(k, root2) {
+			node = append([]byte{}, k...)
+			break
+		}
+	}
+	iter.Release()
+
+	if err := kv.db.Delete(node, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = kv.Prune(pruneRoots(root2)); err == nil {
+		t.Fatal("expected an error for the missing node")
+	}
+}
+
+func TestPruneConcurrentCommits(t *testing.T) {
+	kv, closeFn := newPruneTestStorage(t)
+	defer closeFn()
+
+	s := NewState(kv)
+
+	commit := func(snap state.Snapshot, balance int64) (state.Snapshot, []byte) {
+		objs := []*state.Object{}
+		for i := 0; i < 20; i++ {
+			objs = append(objs, pruneTestObject(types.BytesToAddress([]byte{byte(i + 1)}), balance, 3))
+		}
+		return snap.Commit(objs)
+	}
+
+	snap1, root1 := commit(s.NewSnapshot(), 1)
+
+	// a state committed once the prune started but before the roots
+	// are chosen is not swept even if it is not retained
+	var root2 []byte
+	_, err := kv.Prune(func() ([]types.Hash, error) {
+		_, root2 = commit(snap1, 2)
+		return pruneRoots(root1)()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPrunedState(t, s, root1)
+	checkPrunedState(t, s, root2)
+
+	// commit new states while the storage is pruned, the roots are the
+	// head at the moment they are chosen like with the chain lock
+	var lock sync.Mutex
+	head, root := snap1, root1
+
+	doneCh := make(chan struct{})
+	stopCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		for i := int64(3); ; i++ {
+			select {
+			case <-stopCh:
+				return
+			default:
+			}
+			lock.Lock()
+			head, root = commit(head, i)
+			lock.Unlock()
+		}
+	}()
+
+	for i := 0; i < 5; i++ {
+		_, err := kv.Prune(func() ([]types.Hash, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			return pruneRoots(root)()
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(stopCh)
+	<-doneCh
+
+	checkPrunedState(t, s, root)
+
+	// the marks are removed after the prune
+	iter := kv.db.NewIterator(util.BytesPrefix(pruneMarkPrefix), nil)
+	defer iter.Release()
+	if iter.Next() {
+		t.Fatal("marks not removed")
+	}
+}
@@ -0,0 +1,442 @@
+package itrie
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/umbracle/minimal/state"
+	"github.com/umbracle/minimal/types"
+)
+
+// The snapshot is a flat representation of the state. The accounts and the
+// storage slots of a (not so) recent state are stored flat in the storage,
+// the disk layer, and the changes of the following blocks are kept in memory
+// as diff layers on top of it. A read on a state with a snapshot layer does
+// not need to walk the trie. The disk layer is generated in the background
+// from the trie, the reads of the accounts not generated yet fall back to
+// the trie.
+
+var (
+	// snapAccountPrefix is the prefix of the flat accounts
+	snapAccountPrefix = []byte("snapa")
+
+	// snapStoragePrefix is the prefix of the flat storage slots
+	snapStoragePrefix = []byte("snaps")
+
+	// snapIncarnationPrefix is the prefix of the incarnation of an account.
+	// The incarnation changes every time the storage of the account is
+	// destroyed, which makes the old slots unreachable.
+	snapIncarnationPrefix = []byte("snapi")
+
+	// snapMetaKey is the key of the metadata of the disk layer
+	snapMetaKey = []byte("snapmeta")
+)
+
+const (
+	// snapDiffLayers is the maximum number of diff layers on top
+	// of the disk layer
+	snapDiffLayers = 64
+
+	// snapGenerateBatch is the number of accounts generated on each step
+	snapGenerateBatch = 1000
+)
+
+// diffLayer is the set of changes of a block on top of its parent layer
+type diffLayer struct {
+	root types.Hash
+
+	// parent is nil if the layer is on top of the disk layer
+	parent *diffLayer
+
+	// accounts are the modified accounts, nil if deleted
+	accounts map[types.Hash][]byte
+
+	// destructed are the accounts whose storage was removed
+	destructed map[types.Hash]struct{}
+
+	// storage are the modified slots, nil if deleted
+	storage map[types.Hash]map[types.Hash][]byte
+}
+
+func newDiffLayer() *diffLayer {
+	return &diffLayer{
+		accounts:   map[types.Hash][]byte{},
+		destructed: map[types.Hash]struct{}{},
+		storage:    map[types.Hash]map[types.Hash][]byte{},
+	}
+}
+
+func (d *diffLayer) setStorage(account, slot types.Hash, val []byte) {
+	slots, ok := d.storage[account]
+	if !ok {
+		slots = map[types.Hash][]byte{}
+		d.storage[account] = slots
+	}
+	slots[slot] = val
+}
+
+// diskLayer is the flat state stored on disk
+type diskLayer struct {
+	root types.Hash
+
+	// epoch is part of the keys of the flat state, it changes every
+	// time the snapshot is generated from scratch
+	epoch uint64
+
+	// marker is the next account to generate, the accounts with a lower
+	// hash are already generated. It is nil if the generation is done.
+	marker []byte
+}
+
+func (d *diskLayer) isGenerated(account []byte) bool {
+	return d.marker == nil || bytes.Compare(account, d.marker) < 0
+}
+
+func (d *diskLayer) key(prefix []byte, parts ...[]byte) []byte {
+	k := append([]byte{}, prefix...)
+	k = append(k, make([]byte, 8)...)
+	binary.BigEndian.PutUint64(k[len(prefix):], d.epoch)
+	for _, p := range parts {
+		k = append(k, p...)
+	}
+	return k
+}
+
+func (d *diskLayer) marshal() []byte {
+	buf := make([]byte, 41, 41+len(d.marker))
+	copy(buf[0:], d.root.Bytes())
+	binary.BigEndian.PutUint64(buf[32:], d.epoch)
+	if d.marker == nil {
+		buf[40] = 1
+	}
+	return append(buf, d.marker...)
+}
+
+func (d *diskLayer) unmarshal(buf []byte) error {
+	if len(buf) < 41 {
+		return fmt.Errorf("incorrect snapshot metadata length %d", len(buf))
+	}
+	d.root = types.BytesToHash(buf[0:32])
+	d.epoch = binary.BigEndian.Uint64(buf[32:40])
+	if buf[40] == 1 {
+		d.marker = nil
+	} else {
+		d.marker = append([]byte{}, buf[41:]...)
+	}
+	return nil
+}
+
+// snapshotTree is the tree of the snapshot layers
+type snapshotTree struct {
+	logger  hclog.Logger
+	storage Storage
+
+	lock   sync.RWMutex
+	disk   *diskLayer
+	layers map[types.Hash]*diffLayer
+
+	closeCh chan struct{}
+	doneCh  chan struct{}
+}
+
+// EnableSnapshots enables the flat snapshot of the state with the given
+// root. The snapshot on disk is reused if it belongs to the same root,
+// otherwise it is generated again in the background and the old one
+// is deleted.
+func (s *State) EnableSnapshots(root types.Hash, logger hclog.Logger) error {
+	if s.snap != nil {
+		return fmt.Errorf("snapshots already enabled")
+	}
+
+	disk := &diskLayer{}
+	if buf, ok := s.storage.Get(snapMetaKey); ok && len(buf) != 0 {
+		if err := disk.unmarshal(buf); err != nil {
+			return err
+		}
+	}
+	if disk.root != root {
+		// discard the old flat state
+		disk.root = root
+		disk.epoch++
+		disk.marker = []byte{}
+	}
+
+	t := &snapshotTree{
+		logger:  logger,
+		storage: s.storage,
+		disk:    disk,
+		layers:  map[types.Hash]*diffLayer{},
+		closeCh: make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+	t.writeMeta()
+
+	s.snap = t
+	go t.generate()
+	return nil
+}
+
+// CloseSnapshots stops the generation of the snapshot and writes to disk
+// the diff layers up to the given root so that the snapshot can be reused
+// on the next start.
+func (s *State) CloseSnapshots(root types.Hash) {
+	if s.snap == nil {
+		return
+	}
+	t := s.snap
+
+	close(t.closeCh)
+	<-t.doneCh
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if l, ok := t.layers[root]; ok {
+		chain := []*diffLayer{}
+		for ; l != nil; l = l.parent {
+			chain = append(chain, l)
+		}
+		for i := len(chain) - 1; i >= 0; i-- {
+			t.flatten(chain[i])
+		}
+	}
+	s.snap = nil
+}
+
+// hasLayer returns whether there is a layer for the state root
+func (t *snapshotTree) hasLayer(root types.Hash) bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	_, ok := t.layers[root]
+	return ok || root == t.disk.root
+}
+
+// getAccount returns the account in the state with the given root. The bool
+// is false if the layers cannot tell and the trie has to be used.
+func (t *snapshotTree) getAccount(root types.Hash, account []byte) ([]byte, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	l, ok := t.layers[root]
+	if !ok && root != t.disk.root {
+		return nil, false
+	}
+
+	hash := types.BytesToHash(account)
+	for ; l != nil; l = l.parent {
+		if data, ok := l.accounts[hash]; ok {
+			return data, true
+		}
+	}
+
+	if !t.disk.isGenerated(account) {
+		return nil, false
+	}
+	data, ok := t.storage.Get(t.disk.key(snapAccountPrefix, account))
+	if !ok || len(data) == 0 {
+		return nil, true
+	}
+	return data, true
+}
+
+// getStorage returns the storage slot of the account in the state
+// with the given root
+func (t *snapshotTree) getStorage(root types.Hash, account, slot []byte) ([]byte, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	l, ok := t.layers[root]
+	if !ok && root != t.disk.root {
+		return nil, false
+	}
+
+	accountHash, slotHash := types.BytesToHash(account), types.BytesToHash(slot)
+	for ; l != nil; l = l.parent {
+		if slots, ok := l.storage[accountHash]; ok {
+			if data, ok := slots[slotHash]; ok {
+				return data, true
+			}
+		}
+		if _, ok := l.destructed[accountHash]; ok {
+			return nil, true
+		}
+	}
+
+	if !t.disk.isGenerated(account) {
+		return nil, false
+	}
+	data, ok := t.storage.Get(t.disk.key(snapStoragePrefix, account, t.incarnation(account), slot))
+	if !ok || len(data) == 0 {
+		return nil, true
+	}
+	return data, true
+}
+
+func (t *snapshotTree) incarnation(account []byte) []byte {
+	data, ok := t.storage.Get(t.disk.key(snapIncarnationPrefix, account))
+	if !ok || len(data) != 8 {
+		return make([]byte, 8)
+	}
+	return data
+}
+
+// update adds the diff layer of the state root on top of the parent layer.
+// It returns false if there is no layer for the parent.
+func (t *snapshotTree) update(parent, root types.Hash, diff *diffLayer) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, ok := t.layers[root]; ok || root == t.disk.root {
+		return true
+	}
+	parentLayer, ok := t.layers[parent]
+	if !ok && parent != t.disk.root {
+		return false
+	}
+
+	diff.root = root
+	diff.parent = parentLayer
+	t.layers[root] = diff
+
+	// write the oldest diff layer to disk if there are too many
+	depth := 1
+	bottom := diff
+	for bottom.parent != nil {
+		bottom = bottom.parent
+		depth++
+	}
+	if depth > snapDiffLayers {
+		t.flatten(bottom)
+	}
+	return true
+}
+
+// flatten writes a diff layer on top of the disk layer to disk. The
+// layers that do not descend from it are discarded. The lock is held.
+func (t *snapshotTree) flatten(diff *diffLayer) {
+	disk := t.disk
+	batch := t.storage.Batch()
+
+	incarnations := map[types.Hash][]byte{}
+	for account := range diff.destructed {
+		if !disk.isGenerated(account.Bytes()) {
+			continue
+		}
+		inc := binary.BigEndian.Uint64(t.incarnation(account.Bytes())) + 1
+		buf := make([]byte, 8)
+		binary.BigEndian.PutUint64(buf, inc)
+		batch.Put(disk.key(snapIncarnationPrefix, account.Bytes()), buf)
+		incarnations[account] = buf
+	}
+	for account, data := range diff.accounts {
+		if !disk.isGenerated(account.Bytes()) {
+			continue
+		}
+		if data == nil {
+			data = []byte{}
+		}
+		batch.Put(disk.key(snapAccountPrefix, account.Bytes()), data)
+	}
+	for account, slots := range diff.storage {
+		if !disk.isGenerated(account.Bytes()) {
+			continue
+		}
+		inc, ok := incarnations[account]
+		if !ok {
+			inc = t.incarnation(account.Bytes())
+		}
+		for slot, data := range slots {
+			if data == nil {
+				data = []byte{}
+			}
+			batch.Put(disk.key(snapStoragePrefix, account.Bytes(), inc, slot.Bytes()), data)
+		}
+	}
+
+	// the layers on top of other children of the disk layer are stale
+	for root, l := range t.layers {
+		bottom := l
+		for bottom.parent != nil {
+			bottom = bottom.parent
+		}
+		if bottom != diff {
+			delete(t.layers, root)
+		}
+	}
+	for _, l := range t.layers {
+		if l.parent == diff {
+			l.parent = nil
+		}
+	}
+	delete(t.layers, diff.root)
+
+	disk.root = diff.root
+	batch.Put(snapMetaKey, disk.marshal())
+	batch.Write()
+}
+
+// SnapshotGeneratingRoot returns the root of the disk layer of the snapshot
+// in the storage if it is still being generated. The generation reads the
+// trie of the root, which must not be pruned until it is done.
+func SnapshotGeneratingRoot(storage Storage) (types.Hash, bool) {
+	buf, ok := storage.Get(snapMetaKey)
+	if !ok || len(buf) == 0 {
+		return types.Hash{}, false
+	}
+	var disk diskLayer
+	if err := disk.unmarshal(buf); err != nil || disk.marker == nil {
+		return types.Hash{}, false
+	}
+	return disk.root, true
+}
+
+func (t *snapshotTree) writeMeta() {
+	t.storage.Put(snapMetaKey, t.disk.marshal())
+}
+
+// snapStorage reads the storage of an account from the snapshot
+type snapStorage struct {
+	snap      *snapshotTree
+	state     *State
+	stateRoot types.Hash
+	account   []byte
+	root      types.Hash
+}
+
+// StorageSnapshot returns the snapshot of the storage of the account
+func (t *Trie) StorageSnapshot(account []byte, root types.Hash) (state.Snapshot, error) {
+	if t.snap == nil {
+		return t.state.NewSnapshotAt(root)
+	}
+	s := &snapStorage{
+		snap:      t.snap,
+		state:     t.state,
+		stateRoot: t.snapRoot,
+		account:   append([]byte{}, account...),
+		root:      root,
+	}
+	return s, nil
+}
+
+func (s *snapStorage) Get(k []byte) ([]byte, bool) {
+	if data, ok := s.snap.getStorage(s.stateRoot, s.account, k); ok {
+		return data, data != nil
+	}
+	trie, err := s.state.NewSnapshotAt(s.root)
+	if err != nil {
+		return nil, false
+	}
+	return trie.Get(k)
+}
+
+func (s *snapStorage) Commit(objs []*state.Object) (state.Snapshot, []byte) {
+	trie, err := s.state.NewSnapshotAt(s.root)
+	if err != nil {
+		panic(err)
+	}
+	return trie.Commit(objs)
+}
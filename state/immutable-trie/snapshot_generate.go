@@ -0,0 +1,140 @@
+package itrie
+
+import (
+	"fmt"
+
+	"github.com/umbracle/minimal/state"
+	"github.com/umbracle/minimal/types"
+)
+
+// generate builds the disk layer from the trie. The accounts are generated
+// in batches, if the disk layer moves to a new root in the middle of a
+// batch the batch is discarded and generated again from the new root.
+func (t *snapshotTree) generate() {
+	defer close(t.doneCh)
+
+	t.deleteStale()
+
+	for {
+		select {
+		case <-t.closeCh:
+			return
+		default:
+		}
+
+		t.lock.RLock()
+		disk := *t.disk
+		t.lock.RUnlock()
+
+		if disk.marker == nil {
+			t.logger.Debug("snapshot generated", "root", disk.root)
+			return
+		}
+
+		batch := t.storage.Batch()
+		next, err := t.generateBatch(&disk, batch)
+		if err != nil {
+			t.logger.Error("failed to generate the snapshot, the accounts not generated are read from the trie", "root", disk.root, "err", err.Error())
+			return
+		}
+
+		t.lock.Lock()
+		if t.disk.root == disk.root {
+			t.disk.marker = next
+			batch.Put(snapMetaKey, t.disk.marshal())
+			batch.Write()
+		}
+		t.lock.Unlock()
+	}
+}
+
+// deleteStale removes the flat state of the epochs before the current
+// one, which is not reachable anymore. It runs again on every start
+// until all the keys are removed.
+func (t *snapshotTree) deleteStale() {
+	t.lock.RLock()
+	epoch := t.disk.epoch
+	t.lock.RUnlock()
+
+	if epoch <= 1 {
+		return
+	}
+	storage, ok := t.storage.(RangeStorage)
+	if !ok {
+		t.logger.Warn("the snapshots of the old epochs cannot be deleted from the storage")
+		return
+	}
+
+	first, current := &diskLayer{epoch: 0}, &diskLayer{epoch: epoch}
+	for _, prefix := range [][]byte{snapAccountPrefix, snapStoragePrefix, snapIncarnationPrefix} {
+		select {
+		case <-t.closeCh:
+			return
+		default:
+		}
+
+		deleted, err := storage.DeleteRange(first.key(prefix), current.key(prefix))
+		if err != nil {
+			t.logger.Error("failed to delete the old snapshots", "prefix", string(prefix), "err", err.Error())
+			return
+		}
+		if deleted != 0 {
+			t.logger.Debug("old snapshots deleted", "prefix", string(prefix), "keys", deleted)
+		}
+	}
+}
+
+// generateBatch writes in the batch the next accounts of the disk layer and
+// returns the next marker, nil if all the accounts are generated.
+func (t *snapshotTree) generateBatch(disk *diskLayer, batch Batch) ([]byte, error) {
+	if disk.root == types.EmptyRootHash {
+		return nil, nil
+	}
+	root, ok, err := GetNode(disk.root.Bytes(), t.storage)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("state root %s not found", disk.root)
+	}
+
+	// the storage of the accounts not generated yet has the
+	// first incarnation
+	inc := make([]byte, 8)
+
+	count := 0
+
+	it := (&Trie{root: root, storage: t.storage}).NewIterator(disk.marker, nil)
+	for it.Next() {
+		k, v := it.Key(), it.Value()
+		if count == snapGenerateBatch {
+			return k, nil
+		}
+		count++
+
+		batch.Put(disk.key(snapAccountPrefix, k), v)
+
+		var account state.Account
+		if err := account.UnmarshalRlp(v); err != nil {
+			return nil, err
+		}
+		if account.Root == types.EmptyRootHash {
+			continue
+		}
+		storageRoot, ok, err := GetNode(account.Root.Bytes(), t.storage)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("storage root %s not found", account.Root)
+		}
+		storageIt := (&Trie{root: storageRoot, storage: t.storage}).NewIterator(nil, nil)
+		for storageIt.Next() {
+			batch.Put(disk.key(snapStoragePrefix, k, inc, storageIt.Key()), storageIt.Value())
+		}
+		if err := storageIt.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return nil, it.Err()
+}
@@ -0,0 +1,288 @@
+package itrie
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/umbracle/minimal/helper/hex"
+	"github.com/umbracle/minimal/state"
+	"github.com/umbracle/minimal/types"
+)
+
+func snapTestAddr(i int) types.Address {
+	return types.BytesToAddress([]byte{byte(i + 1)})
+}
+
+func snapTestSlot(i int) types.Hash {
+	return types.BytesToHash([]byte{byte(i + 1)})
+}
+
+// randomObjects returns random changes of the accounts and their storage
+func randomObjects(r *rand.Rand, snap state.Snapshot, accounts, slots int) []*state.Object {
+	objs := []*state.Object{}
+	for i := 0; i < accounts; i++ {
+		if r.Intn(3) != 0 {
+			continue
+		}
+		addr := snapTestAddr(i)
+
+		if r.Intn(10) == 0 {
+			objs = append(objs, &state.Object{Address: addr, Deleted: true})
+			continue
+		}
+
+		obj := &state.Object{
+			Address:  addr,
+			Balance:  big.NewInt(int64(r.Intn(1000))),
+			Nonce:    uint64(r.Intn(1000)),
+			CodeHash: types.BytesToHash(hashit(nil)),
+			Root:     types.EmptyRootHash,
+		}
+		if data, ok := snap.Get(hashit(addr.Bytes())); ok {
+			var account state.Account
+			if err := account.UnmarshalRlp(data); err != nil {
+				panic(err)
+			}
+			// reset the storage sometimes
+			if r.Intn(10) != 0 {
+				obj.Root = account.Root
+			}
+		}
+		for j := 0; j < slots; j++ {
+			if r.Intn(3) != 0 {
+				continue
+			}
+			entry := &state.StorageObject{Key: snapTestSlot(j).Bytes()}
+			if r.Intn(4) == 0 {
+				entry.Deleted = true
+			} else {
+				entry.Val = types.BytesToHash([]byte{byte(r.Intn(255) + 1)}).Bytes()
+			}
+			obj.Storage = append(obj.Storage, entry)
+		}
+		objs = append(objs, obj)
+	}
+	return objs
+}
+
+// checkSnapshot checks that the reads with the snapshot
+// match the reads of the trie
+func checkSnapshot(t *testing.T, s *State, root types.Hash, accounts, slots int) {
+	snap, err := s.NewSnapshotAt(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.(*Trie).snap == nil {
+		t.Fatal("the snapshot should have a snapshot layer")
+	}
+
+	trie, err := NewState(s.storage).NewSnapshotAt(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < accounts; i++ {
+		k := hashit(snapTestAddr(i).Bytes())
+
+		data1, ok1 := snap.Get(k)
+		data2, ok2 := trie.Get(k)
+		if ok1 != ok2 || !bytes.Equal(data1, data2) {
+			t.Fatalf("bad account %d", i)
+		}
+		if !ok1 {
+			continue
+		}
+
+		var account state.Account
+		if err := account.UnmarshalRlp(data1); err != nil {
+			t.Fatal(err)
+		}
+		storage1, err := snap.(*Trie).StorageSnapshot(k, account.Root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		storage2, err := NewState(s.storage).NewSnapshotAt(account.Root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < slots; j++ {
+			slot := hashit(snapTestSlot(j).Bytes())
+
+			data1, ok1 := storage1.Get(slot)
+			data2, ok2 := storage2.Get(slot)
+			if ok1 != ok2 || !bytes.Equal(data1, data2) {
+				t.Fatalf("bad slot %d of account %d", j, i)
+			}
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	accounts, slots := 100, 10
+
+	storage := NewMemoryStorage()
+	s := NewState(storage)
+
+	snap, root := s.NewSnapshot().Commit(randomObjects(r, s.NewSnapshot(), accounts, slots))
+
+	if err := s.EnableSnapshots(types.BytesToHash(root), hclog.NewNullLogger()); err != nil {
+		t.Fatal(err)
+	}
+	<-s.snap.doneCh
+
+	snap, err := s.NewSnapshotAt(types.BytesToHash(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSnapshot(t, s, types.BytesToHash(root), accounts, slots)
+
+	// more blocks than diff layers so that the oldest are written to disk
+	roots := []types.Hash{}
+	for i := 0; i < snapDiffLayers+10; i++ {
+		snap, root = snap.Commit(randomObjects(r, snap, accounts, slots))
+		roots = append(roots, types.BytesToHash(root))
+	}
+
+	if len(s.snap.layers) != snapDiffLayers {
+		t.Fatalf("expected %d diff layers but found %d", snapDiffLayers, len(s.snap.layers))
+	}
+	if s.snap.disk.root != roots[9] {
+		t.Fatal("bad disk layer root")
+	}
+	for _, root := range roots[9:] {
+		checkSnapshot(t, s, root, accounts, slots)
+	}
+
+	// the states older than the disk layer do not have a snapshot
+	if snap, _ := s.NewSnapshotAt(roots[0]); snap.(*Trie).snap != nil {
+		t.Fatal("the old state should not have a snapshot layer")
+	}
+
+	// the snapshot is reused after a restart
+	head := roots[len(roots)-1]
+	s.CloseSnapshots(head)
+
+	s = NewState(storage)
+	if err := s.EnableSnapshots(head, hclog.NewNullLogger()); err != nil {
+		t.Fatal(err)
+	}
+	<-s.snap.doneCh
+
+	if s.snap.disk.epoch != 1 {
+		t.Fatal("the snapshot should not be generated again")
+	}
+	checkSnapshot(t, s, head, accounts, slots)
+}
+
+func TestSnapshotStaleLayers(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	accounts, slots := 20, 5
+
+	s := NewState(NewMemoryStorage())
+	_, genesis := s.NewSnapshot().Commit(randomObjects(r, s.NewSnapshot(), accounts, slots))
+
+	if err := s.EnableSnapshots(types.BytesToHash(genesis), hclog.NewNullLogger()); err != nil {
+		t.Fatal(err)
+	}
+	<-s.snap.doneCh
+
+	// fork from the genesis, only one of the branches is kept
+	// once the layers are written to disk
+	snap, err := s.NewSnapshotAt(types.BytesToHash(genesis))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, sideRoot := snap.Commit(randomObjects(r, snap, accounts, slots))
+
+	var head state.Snapshot = snap
+	var root []byte
+	for i := 0; i < snapDiffLayers+1; i++ {
+		head, root = head.Commit(randomObjects(r, head, accounts, slots))
+	}
+
+	if _, ok := s.snap.layers[types.BytesToHash(sideRoot)]; ok {
+		t.Fatal("the side layer should be discarded")
+	}
+	checkSnapshot(t, s, types.BytesToHash(root), accounts, slots)
+}
+
+func TestSnapshotDeleteOldEpochs(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	storage := NewMemoryStorage()
+	s := NewState(storage)
+
+	snap, root := s.NewSnapshot().Commit(randomObjects(r, s.NewSnapshot(), 20, 5))
+	if err := s.EnableSnapshots(types.BytesToHash(root), hclog.NewNullLogger()); err != nil {
+		t.Fatal(err)
+	}
+	<-s.snap.doneCh
+	s.CloseSnapshots(types.BytesToHash(root))
+
+	// countEpoch returns the number of flat keys of the epoch
+	countEpoch := func(epoch uint64) int {
+		disk := &diskLayer{epoch: epoch}
+		count := 0
+		for _, prefix := range [][]byte{snapAccountPrefix, snapStoragePrefix, snapIncarnationPrefix} {
+			for k := range storage.(*memStorage).db {
+				if strings.HasPrefix(k, hex.EncodeToHex(disk.key(prefix))) {
+					count++
+				}
+			}
+		}
+		return count
+	}
+	if countEpoch(1) == 0 {
+		t.Fatal("expected the snapshot of the first epoch")
+	}
+
+	// the snapshot is generated again for another root
+	_, root = snap.Commit(randomObjects(r, snap, 20, 5))
+
+	s = NewState(storage)
+	if err := s.EnableSnapshots(types.BytesToHash(root), hclog.NewNullLogger()); err != nil {
+		t.Fatal(err)
+	}
+	<-s.snap.doneCh
+
+	if s.snap.disk.epoch != 2 {
+		t.Fatal("the snapshot should be generated again")
+	}
+	if count := countEpoch(1); count != 0 {
+		t.Fatalf("expected the old epoch to be deleted but found %d keys", count)
+	}
+	if countEpoch(2) == 0 {
+		t.Fatal("expected the snapshot of the second epoch")
+	}
+	checkSnapshot(t, s, types.BytesToHash(root), 20, 5)
+}
+
+func TestSnapshotGeneratingRoot(t *testing.T) {
+	storage := NewMemoryStorage()
+	if _, ok := SnapshotGeneratingRoot(storage); ok {
+		t.Fatal("there is no snapshot")
+	}
+
+	root := types.StringToHash("1")
+
+	disk := &diskLayer{root: root, epoch: 1, marker: []byte{0x1}}
+	storage.Put(snapMetaKey, disk.marshal())
+	if found, ok := SnapshotGeneratingRoot(storage); !ok || found != root {
+		t.Fatal("expected the root of the snapshot being generated")
+	}
+
+	// the root is not required once the snapshot is generated
+	disk.marker = nil
+	storage.Put(snapMetaKey, disk.marshal())
+	if _, ok := SnapshotGeneratingRoot(storage); ok {
+		t.Fatal("the snapshot is generated")
+	}
+}
@@ -11,6 +11,12 @@ import (
 type State struct {
 	storage Storage
 	cache   *lru.Cache
+
+	// snap is the flat snapshot of the state if enabled
+	snap *snapshotTree
+
+	// preimages stores the keys of the hashes on every commit
+	preimages bool
 }
 
 func NewState(storage Storage) *State {
@@ -38,6 +44,27 @@ func (s *State) GetCode(hash types.Hash) ([]byte, bool) {
 	return s.storage.GetCode(hash)
 }
 
+// EnablePreimages stores the addresses of the accounts and the keys of the
+// storage slots by their hash on every commit. They are needed to dump the
+// state but they use as much space as the flat snapshot.
+func (s *State) EnablePreimages() {
+	s.preimages = true
+}
+
+// GetPreimage returns the key of an account or a storage slot from its hash.
+// The preimages are only stored if they are enabled.
+func (s *State) GetPreimage(hash types.Hash) ([]byte, bool) {
+	return s.storage.Get(preimageKey(hash.Bytes()))
+}
+
+// GetNodeData returns a trie node or contract code by its hash
+func (s *State) GetNodeData(hash types.Hash) ([]byte, bool) {
+	if data, ok := s.storage.Get(hash.Bytes()); ok {
+		return data, true
+	}
+	return s.storage.GetCode(hash)
+}
+
 func (s *State) NewSnapshotAt(root types.Hash) (state.Snapshot, error) {
 	if root == types.EmptyRootHash {
 		// empty state
@@ -48,7 +75,7 @@ func (s *State) NewSnapshotAt(root types.Hash) (state.Snapshot, error) {
 	if ok {
 		t := tt.(*Trie)
 		t.state = s
-		return tt.(*Trie), nil
+		return s.withSnapshot(root, t), nil
 	}
 	n, ok, err := GetNode(root.Bytes(), s.storage)
 	if err != nil {
@@ -62,7 +89,19 @@ func (s *State) NewSnapshotAt(root types.Hash) (state.Snapshot, error) {
 		state:   s,
 		storage: s.storage,
 	}
-	return t, nil
+	return s.withSnapshot(root, t), nil
+}
+
+// withSnapshot returns a copy of the trie that reads from the
+// snapshot layer of the root if there is any
+func (s *State) withSnapshot(root types.Hash, t *Trie) *Trie {
+	if s.snap == nil || !s.snap.hasLayer(root) {
+		return t
+	}
+	nt := *t
+	nt.snap = s.snap
+	nt.snapRoot = root
+	return &nt
 }
 
 func (s *State) AddState(root types.Hash, t *Trie) {
@@ -1,10 +1,13 @@
 package itrie
 
 import (
+	"bytes"
 	"fmt"
+	"sync"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
 	"github.com/umbracle/fastrlp"
 	"github.com/umbracle/minimal/helper/hex"
 	"github.com/umbracle/minimal/types"
@@ -15,8 +18,17 @@ var parserPool fastrlp.ParserPool
 var (
 	// codePrefix is the code prefix for leveldb
 	codePrefix = []byte("code")
+
+	// preimagePrefix is the prefix of the preimages of the hashed keys
+	preimagePrefix = []byte("preimage")
 )
 
+func preimageKey(hash []byte) []byte {
+	k := make([]byte, 0, len(preimagePrefix)+len(hash))
+	k = append(k, preimagePrefix...)
+	return append(k, hash...)
+}
+
 type Batch interface {
 	Put(k, v []byte)
 	Write()
@@ -29,25 +41,58 @@ type Storage interface {
 	Batch() Batch
 	SetCode(hash types.Hash, code []byte)
 	GetCode(hash types.Hash) ([]byte, bool)
+	Close() error
+}
+
+// StorageFactory is a factory method to create a trie storage
+type StorageFactory func(config map[string]interface{}, logger hclog.Logger) (Storage, error)
+
+// PrunableStorage is a storage that can remove the unreachable trie nodes
+type PrunableStorage interface {
+	Storage
+	Prune(roots func() ([]types.Hash, error)) (int, error)
+}
+
+// RangeStorage is a storage that can remove all the keys in a range
+type RangeStorage interface {
+	Storage
+	DeleteRange(start, limit []byte) (int, error)
+}
+
+func readPath(config map[string]interface{}) (string, error) {
+	path, ok := config["path"]
+	if !ok {
+		return "", fmt.Errorf("path not found")
+	}
+	pathStr, ok := path.(string)
+	if !ok {
+		return "", fmt.Errorf("path is not a string")
+	}
+	return pathStr, nil
 }
 
 // KVStorage is a k/v storage on memory using leveldb
 type KVStorage struct {
 	db *leveldb.DB
+
+	// lock protects the keys written while the storage is pruned
+	lock    sync.Mutex
+	written map[string]struct{}
 }
 
 // KVBatch is a batch write for leveldb
 type KVBatch struct {
-	db    *leveldb.DB
+	kv    *KVStorage
 	batch *leveldb.Batch
 }
 
 func (b *KVBatch) Put(k, v []byte) {
+	b.kv.track(k)
 	b.batch.Put(k, v)
 }
 
 func (b *KVBatch) Write() {
-	b.db.Write(b.batch, nil)
+	b.kv.db.Write(b.batch, nil)
 }
 
 func (kv *KVStorage) SetCode(hash types.Hash, code []byte) {
@@ -59,10 +104,11 @@ func (kv *KVStorage) GetCode(hash types.Hash) ([]byte, bool) {
 }
 
 func (kv *KVStorage) Batch() Batch {
-	return &KVBatch{db: kv.db, batch: &leveldb.Batch{}}
+	return &KVBatch{kv: kv, batch: &leveldb.Batch{}}
 }
 
 func (kv *KVStorage) Put(k, v []byte) {
+	kv.track(k)
 	kv.db.Put(k, v, nil)
 }
 
@@ -78,12 +124,54 @@ func (kv *KVStorage) Get(k []byte) ([]byte, bool) {
 	return data, true
 }
 
+// DeleteRange removes the keys in the range [start, limit) and
+// returns the number of deleted keys
+func (kv *KVStorage) DeleteRange(start, limit []byte) (int, error) {
+	deleted := 0
+	batch := &leveldb.Batch{}
+
+	iter := kv.db.NewIterator(&util.Range{Start: start, Limit: limit}, nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		batch.Delete(iter.Key())
+		if batch.Len() == pruneBatchSize {
+			if err := kv.db.Write(batch, nil); err != nil {
+				return deleted, err
+			}
+			deleted += batch.Len()
+			batch.Reset()
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return deleted, err
+	}
+	if err := kv.db.Write(batch, nil); err != nil {
+		return deleted, err
+	}
+	return deleted + batch.Len(), nil
+}
+
+// Close closes the storage
+func (kv *KVStorage) Close() error {
+	return kv.db.Close()
+}
+
+// LevelDBFactory creates a leveldb trie storage
+func LevelDBFactory(config map[string]interface{}, logger hclog.Logger) (Storage, error) {
+	path, err := readPath(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewLevelDBStorage(path, logger)
+}
+
 func NewLevelDBStorage(path string, logger hclog.Logger) (Storage, error) {
 	db, err := leveldb.OpenFile(path, nil)
 	if err != nil {
 		return nil, err
 	}
-	return &KVStorage{db}, nil
+	return &KVStorage{db: db}, nil
 }
 
 type memStorage struct {
@@ -123,6 +211,25 @@ func (m *memStorage) GetCode(hash types.Hash) ([]byte, bool) {
 	return code, ok
 }
 
+func (m *memStorage) DeleteRange(start, limit []byte) (int, error) {
+	deleted := 0
+	for k := range m.db {
+		buf, err := hex.DecodeHex(k)
+		if err != nil {
+			return deleted, err
+		}
+		if bytes.Compare(buf, start) >= 0 && bytes.Compare(buf, limit) < 0 {
+			delete(m.db, k)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (m *memStorage) Close() error {
+	return nil
+}
+
 func (m *memStorage) Batch() Batch {
 	return &memBatch{db: &m.db}
 }
@@ -0,0 +1,148 @@
+package itrie
+
+import (
+	"bytes"
+
+	"github.com/dgraph-io/badger"
+	"github.com/hashicorp/go-hclog"
+	"github.com/umbracle/minimal/types"
+)
+
+// BadgerDBStorage is a trie storage using badgerdb
+type BadgerDBStorage struct {
+	db *badger.DB
+}
+
+// BadgerDBBatch is a batch write for badgerdb
+type BadgerDBBatch struct {
+	db   *badger.DB
+	keys [][]byte
+	vals [][]byte
+}
+
+func (b *BadgerDBBatch) Put(k, v []byte) {
+	b.keys = append(b.keys, append([]byte{}, k...))
+	b.vals = append(b.vals, append([]byte{}, v...))
+}
+
+func (b *BadgerDBBatch) Write() {
+	wb := b.db.NewWriteBatch()
+	for i := range b.keys {
+		if err := wb.Set(b.keys[i], b.vals[i], 0); err != nil {
+			wb.Cancel()
+			panic(err)
+		}
+	}
+	if err := wb.Flush(); err != nil {
+		panic(err)
+	}
+}
+
+func (b *BadgerDBStorage) SetCode(hash types.Hash, code []byte) {
+	b.Put(append(codePrefix, hash.Bytes()...), code)
+}
+
+func (b *BadgerDBStorage) GetCode(hash types.Hash) ([]byte, bool) {
+	return b.Get(append(codePrefix, hash.Bytes()...))
+}
+
+func (b *BadgerDBStorage) Batch() Batch {
+	return &BadgerDBBatch{db: b.db}
+}
+
+func (b *BadgerDBStorage) Put(k, v []byte) {
+	err := b.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(k, v)
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (b *BadgerDBStorage) Get(k []byte) ([]byte, bool) {
+	var val []byte
+	err := b.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(k)
+		if err != nil {
+			return err
+		}
+		val, err = item.ValueCopy(nil)
+		return err
+	})
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, false
+		}
+		panic(err)
+	}
+	return val, true
+}
+
+// DeleteRange removes the keys in the range [start, limit) and
+// returns the number of deleted keys
+func (b *BadgerDBStorage) DeleteRange(start, limit []byte) (int, error) {
+	deleted := 0
+	for {
+		// the keys are deleted in batches of pruneBatchSize
+		keys := [][]byte{}
+		err := b.db.View(func(txn *badger.Txn) error {
+			opts := badger.DefaultIteratorOptions
+			opts.PrefetchValues = false
+			it := txn.NewIterator(opts)
+			defer it.Close()
+
+			for it.Seek(start); it.Valid() && len(keys) < pruneBatchSize; it.Next() {
+				k := it.Item().KeyCopy(nil)
+				if bytes.Compare(k, limit) >= 0 {
+					break
+				}
+				keys = append(keys, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return deleted, err
+		}
+		if len(keys) == 0 {
+			return deleted, nil
+		}
+
+		wb := b.db.NewWriteBatch()
+		for _, k := range keys {
+			if err := wb.Delete(k); err != nil {
+				wb.Cancel()
+				return deleted, err
+			}
+		}
+		if err := wb.Flush(); err != nil {
+			return deleted, err
+		}
+		deleted += len(keys)
+	}
+}
+
+// Close closes the storage
+func (b *BadgerDBStorage) Close() error {
+	return b.db.Close()
+}
+
+// BadgerDBFactory creates a badgerdb trie storage
+func BadgerDBFactory(config map[string]interface{}, logger hclog.Logger) (Storage, error) {
+	path, err := readPath(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewBadgerDBStorage(path, logger)
+}
+
+// NewBadgerDBStorage creates a trie storage with badgerdb
+func NewBadgerDBStorage(path string, logger hclog.Logger) (Storage, error) {
+	opts := badger.DefaultOptions
+	opts.Dir = path
+	opts.ValueDir = path
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &BadgerDBStorage{db: db}, nil
+}
@@ -0,0 +1,129 @@
+package itrie
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	"github.com/boltdb/bolt"
+	"github.com/hashicorp/go-hclog"
+	"github.com/umbracle/minimal/types"
+)
+
+// boltBucket is the bucket of the trie in boltdb
+var boltBucket = []byte("trie")
+
+// BoltDBStorage is a trie storage using boltdb
+type BoltDBStorage struct {
+	db *bolt.DB
+}
+
+// BoltDBBatch is a batch write for boltdb
+type BoltDBBatch struct {
+	db   *bolt.DB
+	keys [][]byte
+	vals [][]byte
+}
+
+func (b *BoltDBBatch) Put(k, v []byte) {
+	b.keys = append(b.keys, append([]byte{}, k...))
+	b.vals = append(b.vals, append([]byte{}, v...))
+}
+
+func (b *BoltDBBatch) Write() {
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		for i := range b.keys {
+			if err := bucket.Put(b.keys[i], b.vals[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (b *BoltDBStorage) SetCode(hash types.Hash, code []byte) {
+	b.Put(append(codePrefix, hash.Bytes()...), code)
+}
+
+func (b *BoltDBStorage) GetCode(hash types.Hash) ([]byte, bool) {
+	return b.Get(append(codePrefix, hash.Bytes()...))
+}
+
+func (b *BoltDBStorage) Batch() Batch {
+	return &BoltDBBatch{db: b.db}
+}
+
+func (b *BoltDBStorage) Put(k, v []byte) {
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put(k, v)
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (b *BoltDBStorage) Get(k []byte) ([]byte, bool) {
+	var data []byte
+	b.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(boltBucket).Get(k); v != nil {
+			data = append([]byte{}, v...)
+		}
+		return nil
+	})
+	return data, data != nil
+}
+
+// DeleteRange removes the keys in the range [start, limit) and
+// returns the number of deleted keys
+func (b *BoltDBStorage) DeleteRange(start, limit []byte) (int, error) {
+	deleted := 0
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltBucket).Cursor()
+		for k, _ := c.Seek(start); k != nil && bytes.Compare(k, limit) < 0; k, _ = c.Next() {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+// Close closes the storage
+func (b *BoltDBStorage) Close() error {
+	return b.db.Close()
+}
+
+// BoltDBFactory creates a boltdb trie storage
+func BoltDBFactory(config map[string]interface{}, logger hclog.Logger) (Storage, error) {
+	path, err := readPath(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewBoltDBStorage(path, logger)
+}
+
+// NewBoltDBStorage creates a trie storage with boltdb in the given directory
+func NewBoltDBStorage(path string, logger hclog.Logger) (Storage, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(filepath.Join(path, "trie.db"), 0600, &bolt.Options{})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltDBStorage{db: db}, nil
+}
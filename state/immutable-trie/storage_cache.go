@@ -0,0 +1,96 @@
+package itrie
+
+import (
+	"fmt"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/umbracle/minimal/types"
+)
+
+// CachedStorage is a storage with a LRU cache of the trie nodes in front
+// of it. Only the trie nodes are cached since they never change once
+// written, the rest of the keys are read from the storage.
+type CachedStorage struct {
+	Storage
+	cache *lru.Cache
+}
+
+// NewCachedStorage creates a storage that caches up to size trie nodes
+func NewCachedStorage(storage Storage, size int) (*CachedStorage, error) {
+	cache, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &CachedStorage{Storage: storage, cache: cache}, nil
+}
+
+// cachedBatch adds the trie nodes to the cache once they are written
+type cachedBatch struct {
+	batch Batch
+	cache *lru.Cache
+	keys  [][]byte
+	vals  [][]byte
+}
+
+func (b *cachedBatch) Put(k, v []byte) {
+	b.batch.Put(k, v)
+	if len(k) == types.HashLength {
+		b.keys = append(b.keys, append([]byte{}, k...))
+		b.vals = append(b.vals, append([]byte{}, v...))
+	}
+}
+
+func (b *cachedBatch) Write() {
+	b.batch.Write()
+	for i := range b.keys {
+		b.cache.Add(string(b.keys[i]), b.vals[i])
+	}
+}
+
+func (c *CachedStorage) Batch() Batch {
+	return &cachedBatch{batch: c.Storage.Batch(), cache: c.cache}
+}
+
+func (c *CachedStorage) Put(k, v []byte) {
+	c.Storage.Put(k, v)
+	if len(k) == types.HashLength {
+		c.cache.Add(string(k), append([]byte{}, v...))
+	}
+}
+
+func (c *CachedStorage) Get(k []byte) ([]byte, bool) {
+	if len(k) != types.HashLength {
+		return c.Storage.Get(k)
+	}
+	if v, ok := c.cache.Get(string(k)); ok {
+		return v.([]byte), true
+	}
+	v, ok := c.Storage.Get(k)
+	if ok {
+		c.cache.Add(string(k), v)
+	}
+	return v, ok
+}
+
+// Prune prunes the underlying storage and purges the cache
+func (c *CachedStorage) Prune(roots func() ([]types.Hash, error)) (int, error) {
+	storage, ok := c.Storage.(PrunableStorage)
+	if !ok {
+		return 0, fmt.Errorf("the storage cannot be pruned")
+	}
+	deleted, err := storage.Prune(roots)
+	c.cache.Purge()
+	return deleted, err
+}
+
+// DeleteRange removes the keys in the range from the underlying
+// storage and purges the cache
+func (c *CachedStorage) DeleteRange(start, limit []byte) (int, error) {
+	storage, ok := c.Storage.(RangeStorage)
+	if !ok {
+		return 0, fmt.Errorf("the storage cannot delete ranges")
+	}
+	deleted, err := storage.DeleteRange(start, limit)
+	c.cache.Purge()
+	return deleted, err
+}
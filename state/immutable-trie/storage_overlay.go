@@ -0,0 +1,93 @@
+package itrie
+
+import (
+	"sync"
+
+	"github.com/umbracle/minimal/types"
+)
+
+// OverlayStorage is a storage that keeps the writes on memory on top
+// of another storage. The keys are read from memory first and then from
+// the storage below, which is never written. It is used to execute
+// blocks again without changing the state of the node.
+type OverlayStorage struct {
+	Storage
+
+	lock sync.Mutex
+	mem  Storage
+}
+
+// NewOverlayStorage creates a storage that writes on memory on top of storage
+func NewOverlayStorage(storage Storage) *OverlayStorage {
+	return &OverlayStorage{Storage: storage, mem: NewMemoryStorage()}
+}
+
+// overlayBatch writes the keys on the memory of the overlay
+type overlayBatch struct {
+	o    *OverlayStorage
+	keys [][]byte
+	vals [][]byte
+}
+
+func (b *overlayBatch) Put(k, v []byte) {
+	b.keys = append(b.keys, append([]byte{}, k...))
+	b.vals = append(b.vals, append([]byte{}, v...))
+}
+
+func (b *overlayBatch) Write() {
+	b.o.lock.Lock()
+	defer b.o.lock.Unlock()
+
+	for i := range b.keys {
+		b.o.mem.Put(b.keys[i], b.vals[i])
+	}
+}
+
+func (o *OverlayStorage) Batch() Batch {
+	return &overlayBatch{o: o}
+}
+
+func (o *OverlayStorage) Put(k, v []byte) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.mem.Put(k, v)
+}
+
+func (o *OverlayStorage) Get(k []byte) ([]byte, bool) {
+	o.lock.Lock()
+	v, ok := o.mem.Get(k)
+	o.lock.Unlock()
+
+	if ok {
+		return v, true
+	}
+	return o.Storage.Get(k)
+}
+
+func (o *OverlayStorage) SetCode(hash types.Hash, code []byte) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.mem.SetCode(hash, code)
+}
+
+func (o *OverlayStorage) GetCode(hash types.Hash) ([]byte, bool) {
+	o.lock.Lock()
+	code, ok := o.mem.GetCode(hash)
+	o.lock.Unlock()
+
+	if ok {
+		return code, true
+	}
+	return o.Storage.GetCode(hash)
+}
+
+// Close drops the writes, the storage below is not closed
+func (o *OverlayStorage) Close() error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.mem = NewMemoryStorage()
+	return nil
+}
@@ -0,0 +1,177 @@
+package itrie
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/umbracle/minimal/types"
+)
+
+func testStorageFactory(t *testing.T, factory StorageFactory, cache int) {
+	dir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	open := func() Storage {
+		storage, err := factory(map[string]interface{}{"path": dir}, hclog.NewNullLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cache != 0 {
+			if storage, err = NewCachedStorage(storage, cache); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return storage
+	}
+
+	storage := open()
+
+	// put and get
+	storage.Put([]byte{0x1}, []byte{0x2})
+	if v, ok := storage.Get([]byte{0x1}); !ok || !bytes.Equal(v, []byte{0x2}) {
+		t.Fatal("bad value")
+	}
+	if _, ok := storage.Get([]byte{0x2}); ok {
+		t.Fatal("the key should not exist")
+	}
+
+	// batch
+	batch := storage.Batch()
+	batch.Put([]byte{0x3}, []byte{0x4})
+	if _, ok := storage.Get([]byte{0x3}); ok {
+		t.Fatal("the batch should not be written yet")
+	}
+	batch.Write()
+	if v, ok := storage.Get([]byte{0x3}); !ok || !bytes.Equal(v, []byte{0x4}) {
+		t.Fatal("bad batch value")
+	}
+
+	// code
+	storage.SetCode(types.StringToHash("1"), []byte{0x5})
+	if code, ok := storage.GetCode(types.StringToHash("1")); !ok || !bytes.Equal(code, []byte{0x5}) {
+		t.Fatal("bad code")
+	}
+
+	// delete range
+	storage.Put([]byte{0x6, 0x1}, []byte{0x1})
+	storage.Put([]byte{0x6, 0x2}, []byte{0x1})
+	storage.Put([]byte{0x7}, []byte{0x1})
+	deleted, err := storage.(RangeStorage).DeleteRange([]byte{0x6}, []byte{0x7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 2 {
+		t.Fatalf("expected two deleted keys but found %d", deleted)
+	}
+	if _, ok := storage.Get([]byte{0x6, 0x1}); ok {
+		t.Fatal("the key should be deleted")
+	}
+	if _, ok := storage.Get([]byte{0x7}); !ok {
+		t.Fatal("the limit should not be deleted")
+	}
+
+	// the state is read back after the storage is opened again
+	r := rand.New(rand.NewSource(1))
+	s := NewState(storage)
+	_, root := s.NewSnapshot().Commit(randomObjects(r, s.NewSnapshot(), 50, 5))
+
+	expected := dumpState(t, s, types.BytesToHash(root))
+	if err := storage.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	storage = open()
+	defer storage.Close()
+
+	s = NewState(storage)
+	if found := dumpState(t, s, types.BytesToHash(root)); len(found) != len(expected) {
+		t.Fatal("bad state")
+	}
+}
+
+func TestStorageBackends(t *testing.T) {
+	backends := map[string]StorageFactory{
+		"leveldb":  LevelDBFactory,
+		"badgerdb": BadgerDBFactory,
+		"boltdb":   BoltDBFactory,
+	}
+	for name, factory := range backends {
+		t.Run(name, func(t *testing.T) {
+			testStorageFactory(t, factory, 0)
+		})
+		t.Run(name+"-cache", func(t *testing.T) {
+			testStorageFactory(t, factory, 16)
+		})
+	}
+}
+
+func TestCachedStorage(t *testing.T) {
+	storage, err := NewCachedStorage(NewMemoryStorage(), 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only the trie nodes are cached
+	node := types.StringToHash("1").Bytes()
+	storage.Put(node, []byte{0x1})
+	storage.Put([]byte{0x1}, []byte{0x2})
+
+	if storage.cache.Len() != 1 {
+		t.Fatalf("expected one cached node but found %d", storage.cache.Len())
+	}
+	if v, ok := storage.Get(node); !ok || !bytes.Equal(v, []byte{0x1}) {
+		t.Fatal("bad cached value")
+	}
+
+	// the memory storage cannot be pruned
+	if _, err := storage.Prune(nil); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestOverlayStorage(t *testing.T) {
+	storage := NewMemoryStorage()
+
+	r := rand.New(rand.NewSource(1))
+	s := NewState(storage)
+	_, root := s.NewSnapshot().Commit(randomObjects(r, s.NewSnapshot(), 50, 5))
+	expected := dumpState(t, s, types.BytesToHash(root))
+
+	overlay := NewOverlayStorage(storage)
+
+	// the state on top of the root is committed on the overlay
+	s = NewState(overlay)
+	snap, err := s.NewSnapshotAt(types.BytesToHash(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, newRoot := snap.Commit(randomObjects(r, snap, 50, 5))
+
+	if found := dumpState(t, s, types.BytesToHash(newRoot)); len(found) == 0 {
+		t.Fatal("the new state should be read from the overlay")
+	}
+	if _, err := NewState(storage).NewSnapshotAt(types.BytesToHash(newRoot)); err == nil {
+		t.Fatal("the new state should not be written on the storage")
+	}
+
+	// the code is written on the overlay too
+	overlay.SetCode(types.StringToHash("1"), []byte{0x1})
+	if _, ok := storage.GetCode(types.StringToHash("1")); ok {
+		t.Fatal("the code should not be written on the storage")
+	}
+
+	// the storage below is still open
+	if err := overlay.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if found := dumpState(t, NewState(storage), types.BytesToHash(root)); len(found) != len(expected) {
+		t.Fatal("bad state")
+	}
+}
@@ -0,0 +1,262 @@
+package itrie
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	"github.com/umbracle/fastrlp"
+	"github.com/umbracle/minimal/state"
+	"github.com/umbracle/minimal/types"
+)
+
+var emptyCodeHash = types.BytesToHash(hashit(nil))
+
+// ErrSyncNotRequested is returned when the data delivered to the sync
+// does not match any of the scheduled hashes
+var ErrSyncNotRequested = fmt.Errorf("data not requested")
+
+type syncRequest struct {
+	hash types.Hash
+
+	// code is true if the request is for contract code
+	code bool
+
+	// accounts is true if the node is part of the account trie
+	accounts bool
+
+	// data is the node delivered, it is written once its children are
+	data []byte
+
+	// deps is the number of children not written yet
+	deps int
+
+	// parents are the delivered nodes waiting for this one
+	parents []*syncRequest
+}
+
+// Sync downloads the state of a root. It schedules the hashes of the trie nodes
+// and the contract code that are missing in the storage, breadth-first. A node
+// is delivered and its hash verified but it is only written once all its
+// children are in the storage, the root is the last one, so that the state is
+// not available until it is complete. The nodes already in the storage are
+// walked too, an interrupted sync is resumed and the items missing below the
+// nodes of a damaged state are requested again.
+type Sync struct {
+	storage Storage
+
+	lock sync.Mutex
+
+	// queue are the requests not handed out yet
+	queue []*syncRequest
+
+	// requests are all the requests not delivered yet
+	requests map[types.Hash]*syncRequest
+
+	// delivered are the nodes delivered that wait for their children
+	delivered map[types.Hash]*syncRequest
+}
+
+// NewSync creates a new sync of the state with the given root
+func (s *State) NewSync(root types.Hash) (*Sync, error) {
+	return newSync(root, s.storage)
+}
+
+func newSync(root types.Hash, storage Storage) (*Sync, error) {
+	s := &Sync{
+		storage:   storage,
+		queue:     []*syncRequest{},
+		requests:  map[types.Hash]*syncRequest{},
+		delivered: map[types.Hash]*syncRequest{},
+	}
+	if root == types.EmptyRootHash {
+		return s, nil
+	}
+	if err := s.schedule(&syncRequest{hash: root, accounts: true}, nil); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Missing returns up to max hashes to request from the peers
+func (s *Sync) Missing(max int) []types.Hash {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if max > len(s.queue) {
+		max = len(s.queue)
+	}
+	hashes := make([]types.Hash, max)
+	for i, req := range s.queue[:max] {
+		hashes[i] = req.hash
+	}
+	s.queue = s.queue[max:]
+	return hashes
+}
+
+// Retry schedules again the hashes requested with Missing that were not delivered
+func (s *Sync) Retry(hashes []types.Hash) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, hash := range hashes {
+		if req, ok := s.requests[hash]; ok {
+			s.queue = append(s.queue, req)
+		}
+	}
+}
+
+// Process verifies a trie node or code delivered by a peer and schedules its
+// children. The data is written once all its children are in the storage.
+func (s *Sync) Process(data []byte) error {
+	hash := types.BytesToHash(hashit(data))
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	req, ok := s.requests[hash]
+	if !ok {
+		return ErrSyncNotRequested
+	}
+	delete(s.requests, hash)
+	req.data = data
+
+	if req.code {
+		s.commit(req)
+		return nil
+	}
+
+	p := parserPool.Get()
+	defer parserPool.Put(p)
+
+	v, err := p.Parse(data)
+	if err != nil {
+		return err
+	}
+	if v.Type() != fastrlp.TypeArray {
+		return fmt.Errorf("storage item should be an array")
+	}
+	node, err := decodeNode(v, s.storage)
+	if err != nil {
+		return err
+	}
+
+	s.delivered[hash] = req
+	if err := s.children(node, req.accounts, req); err != nil {
+		return err
+	}
+	if req.deps == 0 {
+		s.commit(req)
+	}
+	return nil
+}
+
+// commit writes the data of a request whose children are in the storage
+// and the data of the parents that do not wait for other children
+func (s *Sync) commit(req *syncRequest) {
+	if req.code {
+		s.storage.SetCode(req.hash, req.data)
+	} else {
+		s.storage.Put(req.hash.Bytes(), req.data)
+	}
+	delete(s.delivered, req.hash)
+
+	for _, parent := range req.parents {
+		parent.deps--
+		if parent.deps == 0 {
+			s.commit(parent)
+		}
+	}
+}
+
+// Pending returns the number of hashes not delivered yet
+func (s *Sync) Pending() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.requests)
+}
+
+// Done returns true if the whole state is in the storage
+func (s *Sync) Done() bool {
+	return s.Pending() == 0
+}
+
+// schedule requests the hash unless it is already in the storage. The
+// parent, if any, waits for it to be written.
+func (s *Sync) schedule(req *syncRequest, parent *syncRequest) error {
+	prev, ok := s.requests[req.hash]
+	if !ok {
+		prev, ok = s.delivered[req.hash]
+	}
+	if ok {
+		if parent != nil {
+			parent.deps++
+			prev.parents = append(prev.parents, parent)
+		}
+		return nil
+	}
+
+	if req.code {
+		if code, ok := s.storage.GetCode(req.hash); ok && bytes.Equal(hashit(code), req.hash.Bytes()) {
+			return nil
+		}
+	} else {
+		// corrupted nodes are requested again like the missing ones
+		if node, err := getVerifiedNode(req.hash, s.storage); err == nil {
+			// the node is known but its children may be missing
+			return s.children(node, req.accounts, nil)
+		}
+	}
+	if parent != nil {
+		parent.deps++
+		req.parents = append(req.parents, parent)
+	}
+	s.requests[req.hash] = req
+	s.queue = append(s.queue, req)
+	return nil
+}
+
+// children schedules the nodes referenced by the node, the parent request
+// waits for them. The account leaves schedule their storage trie and code.
+func (s *Sync) children(node Node, accounts bool, parent *syncRequest) error {
+	switch n := node.(type) {
+	case *ValueNode:
+		if n.hash {
+			return s.schedule(&syncRequest{hash: types.BytesToHash(n.buf), accounts: accounts}, parent)
+		}
+
+	case *ShortNode:
+		if !hasTerm(n.key) {
+			return s.children(n.child, accounts, parent)
+		}
+		if !accounts {
+			return nil
+		}
+		var account state.Account
+		if err := account.UnmarshalRlp(n.child.(*ValueNode).buf); err != nil {
+			return err
+		}
+		if account.Root != types.EmptyRootHash {
+			if err := s.schedule(&syncRequest{hash: account.Root}, parent); err != nil {
+				return err
+			}
+		}
+		if codeHash := types.BytesToHash(account.CodeHash); codeHash != emptyCodeHash {
+			if err := s.schedule(&syncRequest{hash: codeHash, code: true}, parent); err != nil {
+				return err
+			}
+		}
+
+	case *FullNode:
+		for _, child := range n.children {
+			if child == nil {
+				continue
+			}
+			if err := s.children(child, accounts, parent); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
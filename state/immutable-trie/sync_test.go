@@ -0,0 +1,150 @@
+package itrie
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/umbracle/minimal/state"
+	"github.com/umbracle/minimal/types"
+)
+
+func buildSyncState(t *testing.T) (*State, types.Hash) {
+	r := rand.New(rand.NewSource(1))
+
+	s := NewState(NewMemoryStorage())
+	objs := randomObjects(r, s.NewSnapshot(), 200, 10)
+
+	// some accounts with code, two of them share it
+	for i, code := range [][]byte{{0x1}, {0x1}, {0x2, 0x3}} {
+		objs = append(objs, &state.Object{
+			Address:   types.BytesToAddress([]byte{0xff, byte(i)}),
+			Balance:   big.NewInt(1),
+			CodeHash:  types.BytesToHash(hashit(code)),
+			Root:      types.EmptyRootHash,
+			DirtyCode: true,
+			Code:      code,
+		})
+	}
+
+	_, root := s.NewSnapshot().Commit(objs)
+	return s, types.BytesToHash(root)
+}
+
+func dumpState(t *testing.T, s *State, root types.Hash) []*DumpAccount {
+	accounts := []*DumpAccount{}
+	err := s.Dump(root, nil, nil, func(account *DumpAccount) error {
+		// the preimages are not synced
+		account.Address = nil
+		for _, slot := range account.Storage {
+			slot.Key = nil
+		}
+		accounts = append(accounts, account)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return accounts
+}
+
+// syncState delivers the missing data from the source state until the
+// sync is done or the limit of deliveries is reached
+func syncState(t *testing.T, sync *Sync, src *State, limit int) {
+	for count := 0; !sync.Done() && count < limit; {
+		hashes := sync.Missing(16)
+		if len(hashes) == 0 {
+			t.Fatal("there should be missing hashes")
+		}
+		for _, hash := range hashes {
+			// the nodes in the storage are not requested unless corrupted
+			if _, err := getVerifiedNode(hash, sync.storage); err == nil {
+				t.Fatalf("hash %s already in the storage", hash)
+			}
+			if count == limit {
+				sync.Retry([]types.Hash{hash})
+				continue
+			}
+			data, ok := src.GetNodeData(hash)
+			if !ok {
+				t.Fatalf("hash %s not found", hash)
+			}
+			if err := sync.Process(data); err != nil {
+				t.Fatal(err)
+			}
+			count++
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	src, root := buildSyncState(t)
+
+	dst := NewState(NewMemoryStorage())
+	sync, err := dst.NewSync(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// data that was not requested
+	if err := sync.Process([]byte{0x1}); err != ErrSyncNotRequested {
+		t.Fatal("expected not requested error")
+	}
+
+	// undelivered hashes are requested again
+	hashes := sync.Missing(1)
+	if len(sync.Missing(1)) != 0 {
+		t.Fatal("the root should be the only hash")
+	}
+	sync.Retry(hashes)
+	if missing := sync.Missing(1); !reflect.DeepEqual(missing, hashes) {
+		t.Fatal("the root should be requested again")
+	}
+	sync.Retry(hashes)
+
+	syncState(t, sync, src, 1<<20)
+	if !reflect.DeepEqual(dumpState(t, src, root), dumpState(t, dst, root)) {
+		t.Fatal("the states are not equal")
+	}
+}
+
+func TestSyncResume(t *testing.T) {
+	src, root := buildSyncState(t)
+
+	dst := NewState(NewMemoryStorage())
+	sync, err := dst.NewSync(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncState(t, sync, src, 100)
+	if sync.Done() {
+		t.Fatal("the sync should not be done")
+	}
+
+	// the root is written last, the partial state is not available
+	if _, err := dst.NewSnapshotAt(root); err == nil {
+		t.Fatal("the partial state should not be available")
+	}
+
+	// a new sync continues with the subtrees already in the storage
+	sync, err = dst.NewSync(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncState(t, sync, src, 1<<20)
+
+	if !reflect.DeepEqual(dumpState(t, src, root), dumpState(t, dst, root)) {
+		t.Fatal("the states are not equal")
+	}
+
+	// the synced state is served as it is
+	for _, hash := range [][]byte{root.Bytes(), hashit([]byte{0x2, 0x3})} {
+		data1, _ := src.GetNodeData(types.BytesToHash(hash))
+		data2, ok := dst.GetNodeData(types.BytesToHash(hash))
+		if !ok || !bytes.Equal(data1, data2) {
+			t.Fatal("bad node data")
+		}
+	}
+}
@@ -101,6 +101,11 @@ type Trie struct {
 	root    Node
 	epoch   uint32
 	storage Storage
+
+	// snap is the snapshot tree if the trie has a
+	// snapshot layer for snapRoot
+	snap     *snapshotTree
+	snapRoot types.Hash
 }
 
 func NewTrie() *Trie {
@@ -108,6 +113,11 @@ func NewTrie() *Trie {
 }
 
 func (t *Trie) Get(k []byte) ([]byte, bool) {
+	if t.snap != nil {
+		if data, ok := t.snap.getAccount(t.snapRoot, k); ok {
+			return data, data != nil
+		}
+	}
 	txn := t.Txn()
 	res := txn.Lookup(k)
 	return res, res != nil
@@ -129,17 +139,45 @@ func (t *Trie) Commit(objs []*state.Object) (state.Snapshot, []byte) {
 
 	tt := t.Txn()
 	tt.batch = batch
+	tt.parallel = parallelHash(len(objs))
 
 	arena := accountArenaPool.Get()
 	defer accountArenaPool.Put(arena)
 
+	// changes for the snapshot layer
+	var diff *diffLayer
+	if t.snap != nil {
+		diff = newDiffLayer()
+	}
+
 	ar1 := stateArenaPool.Get()
 	defer stateArenaPool.Put(ar1)
 
+	preimages := t.state != nil && t.state.preimages
+
 	for _, obj := range objs {
+		accountHash := hashit(obj.Address.Bytes())
+		if preimages {
+			batch.Put(preimageKey(accountHash), obj.Address.Bytes())
+		}
+
 		if obj.Deleted {
-			tt.Delete(hashit(obj.Address.Bytes()))
+			tt.Delete(accountHash)
+			if diff != nil {
+				diff.accounts[types.BytesToHash(accountHash)] = nil
+				diff.destructed[types.BytesToHash(accountHash)] = struct{}{}
+			}
 		} else {
+			if diff != nil && obj.Root == types.EmptyRootHash {
+				// the storage was destroyed if the account had
+				// some storage in the parent state
+				if data, ok := t.Get(accountHash); ok {
+					var prev state.Account
+					if err := prev.UnmarshalRlp(data); err == nil && prev.Root != types.EmptyRootHash {
+						diff.destructed[types.BytesToHash(accountHash)] = struct{}{}
+					}
+				}
+			}
 
 			account := state.Account{
 				Balance:  obj.Balance,
@@ -156,14 +194,25 @@ func (t *Trie) Commit(objs []*state.Object) (state.Snapshot, []byte) {
 
 				localTxn := localSnapshot.(*Trie).Txn()
 				localTxn.batch = batch
+				localTxn.parallel = parallelHash(len(obj.Storage))
 
 				for _, entry := range obj.Storage {
 					k := hashit(entry.Key)
+					if preimages {
+						batch.Put(preimageKey(k), entry.Key)
+					}
 					if entry.Deleted {
 						localTxn.Delete(k)
+						if diff != nil {
+							diff.setStorage(types.BytesToHash(accountHash), types.BytesToHash(k), nil)
+						}
 					} else {
 						vv := ar1.NewBytes(bytes.TrimLeft(entry.Val, "\x00"))
-						localTxn.Insert(k, vv.MarshalTo(nil))
+						data := vv.MarshalTo(nil)
+						localTxn.Insert(k, data)
+						if diff != nil {
+							diff.setStorage(types.BytesToHash(accountHash), types.BytesToHash(k), data)
+						}
 					}
 				}
 
@@ -183,7 +232,10 @@ func (t *Trie) Commit(objs []*state.Object) (state.Snapshot, []byte) {
 			vv := account.MarshalWith(arena)
 			data := vv.MarshalTo(nil)
 
-			tt.Insert(hashit(obj.Address.Bytes()), data)
+			tt.Insert(accountHash, data)
+			if diff != nil {
+				diff.accounts[types.BytesToHash(accountHash)] = data
+			}
 			arena.Reset()
 		}
 	}
@@ -197,6 +249,11 @@ func (t *Trie) Commit(objs []*state.Object) (state.Snapshot, []byte) {
 	// Write all the entries to db
 	batch.Write()
 
+	if diff != nil && t.snap.update(t.snapRoot, types.BytesToHash(root), diff) {
+		nTrie.snap = t.snap
+		nTrie.snapRoot = types.BytesToHash(root)
+	}
+
 	t.state.AddState(types.BytesToHash(root), nTrie)
 	return nTrie, root
 }
@@ -214,6 +271,9 @@ type Txn struct {
 	epoch   uint32
 	storage Storage
 	batch   Putter
+
+	// parallel hashes the top of the trie concurrently
+	parallel bool
 }
 
 func (t *Txn) Commit() *Trie {
@@ -0,0 +1,200 @@
+package itrie
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/umbracle/fastrlp"
+	"github.com/umbracle/minimal/state"
+	"github.com/umbracle/minimal/types"
+)
+
+var (
+	// ErrMissingNode is returned when a trie node is not in the storage
+	ErrMissingNode = fmt.Errorf("missing trie node")
+
+	// ErrCorruptedNode is returned when the data of a trie node does not
+	// match its hash or cannot be decoded
+	ErrCorruptedNode = fmt.Errorf("corrupted trie node")
+
+	// ErrMissingCode is returned when the code of an account is not in the storage
+	ErrMissingCode = fmt.Errorf("missing code")
+
+	// ErrCorruptedCode is returned when the code of an account does not match its hash
+	ErrCorruptedCode = fmt.Errorf("corrupted code")
+)
+
+// getVerifiedNode returns the trie node with the given hash after checking
+// that the data in the storage hashes to it
+func getVerifiedNode(hash types.Hash, storage Storage) (Node, error) {
+	data, ok := storage.Get(hash.Bytes())
+	if !ok {
+		return nil, ErrMissingNode
+	}
+	if !bytes.Equal(hashit(data), hash.Bytes()) {
+		return nil, ErrCorruptedNode
+	}
+
+	p := parserPool.Get()
+	defer parserPool.Put(p)
+
+	v, err := p.Parse(data)
+	if err != nil || v.Type() != fastrlp.TypeArray {
+		return nil, ErrCorruptedNode
+	}
+	node, err := decodeNode(v, storage)
+	if err != nil {
+		return nil, ErrCorruptedNode
+	}
+	return node, nil
+}
+
+// VerifyIssue is a missing or corrupted item of the state
+type VerifyIssue struct {
+	Err  error
+	Hash types.Hash
+
+	// Account is the hashed key of the account if the item
+	// belongs to its storage trie or is its code
+	Account *types.Hash
+
+	// Path is the path in nibbles of the node in its trie
+	Path []byte
+}
+
+func (i *VerifyIssue) String() string {
+	str := fmt.Sprintf("%s %s", i.Err.Error(), i.Hash.String())
+	if i.Account != nil {
+		str += fmt.Sprintf(" account=%s", i.Account.String())
+	}
+	if i.Err == ErrMissingNode || i.Err == ErrCorruptedNode {
+		str += fmt.Sprintf(" path=%s", nibblesToString(i.Path))
+	}
+	return str
+}
+
+func nibblesToString(nibbles []byte) string {
+	if len(nibbles) == 0 {
+		return "root"
+	}
+	if hasTerm(nibbles) {
+		nibbles = nibbles[:len(nibbles)-1]
+	}
+	buf := make([]byte, len(nibbles))
+	for i, n := range nibbles {
+		buf[i] = "0123456789abcdef"[n]
+	}
+	return string(buf)
+}
+
+// VerifyStats are the number of items checked during the verification
+type VerifyStats struct {
+	Nodes    int
+	Accounts int
+	Slots    int
+	Codes    int
+}
+
+type verifier struct {
+	storage Storage
+	handler func(*VerifyIssue)
+	stats   *VerifyStats
+
+	// roots and codes are the storage tries and the code
+	// already verified since many accounts share them
+	roots map[types.Hash]struct{}
+	codes map[types.Hash]struct{}
+}
+
+// Verify walks the account trie with the given root and all the storage
+// tries, recomputes the hash of every node and checks that the code of
+// the accounts exists. Every missing or corrupted item is passed to the
+// handler, the children of a missing or corrupted node are not checked.
+func (s *State) Verify(root types.Hash, handler func(*VerifyIssue)) *VerifyStats {
+	v := &verifier{
+		storage: s.storage,
+		handler: handler,
+		stats:   &VerifyStats{},
+		roots:   map[types.Hash]struct{}{},
+		codes:   map[types.Hash]struct{}{},
+	}
+	if root != types.EmptyRootHash {
+		v.verify(root, []byte{}, nil)
+	}
+	return v.stats
+}
+
+func (v *verifier) verify(hash types.Hash, path []byte, account *types.Hash) {
+	node, err := getVerifiedNode(hash, v.storage)
+	if err != nil {
+		v.handler(&VerifyIssue{Err: err, Hash: hash, Account: account, Path: path})
+		return
+	}
+	v.stats.Nodes++
+	v.verifyNode(hash, node, path, account)
+}
+
+// verifyNode checks the children of a node. The account leaves are the
+// ones in the account trie, their storage trie and code are checked too.
+func (v *verifier) verifyNode(parent types.Hash, node Node, path []byte, account *types.Hash) {
+	switch n := node.(type) {
+	case *ValueNode:
+		if n.hash {
+			v.verify(types.BytesToHash(n.buf), path, account)
+		}
+
+	case *ShortNode:
+		path = concat(path, n.key)
+		if !hasTerm(n.key) {
+			v.verifyNode(parent, n.child, path, account)
+			return
+		}
+		if account != nil {
+			v.stats.Slots++
+			return
+		}
+		v.stats.Accounts++
+
+		var acct state.Account
+		if err := acct.UnmarshalRlp(n.child.(*ValueNode).buf); err != nil {
+			// the node matches its hash but it is not an account
+			v.handler(&VerifyIssue{Err: ErrCorruptedNode, Hash: parent, Path: path})
+			return
+		}
+		key := types.BytesToHash(hexToKeybytes(path))
+
+		if acct.Root != types.EmptyRootHash {
+			if _, ok := v.roots[acct.Root]; !ok {
+				v.roots[acct.Root] = struct{}{}
+				v.verify(acct.Root, []byte{}, &key)
+			}
+		}
+		if codeHash := types.BytesToHash(acct.CodeHash); codeHash != emptyCodeHash {
+			if _, ok := v.codes[codeHash]; !ok {
+				v.codes[codeHash] = struct{}{}
+				v.verifyCode(codeHash, &key)
+			}
+		}
+
+	case *FullNode:
+		for i, child := range n.children {
+			if child == nil {
+				continue
+			}
+			v.verifyNode(parent, child, concat(path, []byte{byte(i)}), account)
+		}
+	}
+}
+
+func (v *verifier) verifyCode(hash types.Hash, account *types.Hash) {
+	code, ok := v.storage.GetCode(hash)
+	if !ok {
+		v.handler(&VerifyIssue{Err: ErrMissingCode, Hash: hash, Account: account})
+		return
+	}
+	if !bytes.Equal(hashit(code), hash.Bytes()) {
+		v.handler(&VerifyIssue{Err: ErrCorruptedCode, Hash: hash, Account: account})
+		return
+	}
+	v.stats.Codes++
+}
@@ -0,0 +1,99 @@
+package itrie
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/umbracle/minimal/types"
+)
+
+func verifyIssues(s *State, root types.Hash) ([]*VerifyIssue, *VerifyStats) {
+	issues := []*VerifyIssue{}
+	stats := s.Verify(root, func(issue *VerifyIssue) {
+		issues = append(issues, issue)
+	})
+	return issues, stats
+}
+
+func TestVerify(t *testing.T) {
+	src, root := buildSyncState(t)
+
+	// copy the state to a new storage
+	dst := NewState(NewMemoryStorage())
+	sync, err := dst.NewSync(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncState(t, sync, src, 1<<20)
+
+	issues, stats := verifyIssues(dst, root)
+	if len(issues) != 0 {
+		t.Fatalf("expected no issues but found %d", len(issues))
+	}
+	if stats.Accounts != len(dumpState(t, src, root)) || stats.Codes != 2 || stats.Slots == 0 {
+		t.Fatalf("bad stats %v", stats)
+	}
+
+	db := dst.storage.(*memStorage).db
+	keys := []string{}
+	for k := range db {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	expectIssue := func(err error, key string) {
+		// the same node can be referenced from more than one trie
+		issues, _ := verifyIssues(dst, root)
+		if len(issues) == 0 {
+			t.Fatal("expected an issue")
+		}
+		for _, issue := range issues {
+			if issue.Err != err || issue.Hash != types.StringToHash(key) {
+				t.Fatalf("bad issue %s", issue.String())
+			}
+		}
+	}
+
+	// missing node
+	missing, data := keys[0], db[keys[0]]
+	delete(db, missing)
+	expectIssue(ErrMissingNode, missing)
+	db[missing] = data
+
+	// corrupted node
+	corrupted := keys[1]
+	db[corrupted] = append([]byte{}, db[corrupted]...)
+	db[corrupted][len(db[corrupted])-1]++
+	expectIssue(ErrCorruptedNode, corrupted)
+
+	// missing code
+	delete(db, missing)
+	code := types.BytesToHash(hashit([]byte{0x2, 0x3}))
+	delete(dst.storage.(*memStorage).code, code.String())
+
+	issues, _ = verifyIssues(dst, root)
+	hashes := map[types.Hash]struct{}{}
+	for _, issue := range issues {
+		if issue.Err == ErrMissingCode && (issue.Hash != code || issue.Account == nil) {
+			t.Fatal("bad missing code issue")
+		}
+		hashes[issue.Hash] = struct{}{}
+	}
+	if len(hashes) != 3 {
+		t.Fatalf("expected three items with issues but found %d", len(hashes))
+	}
+
+	// the sync requests the missing and corrupted items again
+	sync, err = dst.NewSync(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sync.Pending() != 3 {
+		t.Fatalf("expected 3 pending items but found %d", sync.Pending())
+	}
+	syncState(t, sync, src, 1<<20)
+
+	if issues, _ := verifyIssues(dst, root); len(issues) != 0 {
+		t.Fatalf("expected no issues after the repair but found %d", len(issues))
+	}
+}
@@ -0,0 +1,322 @@
+package state
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/armon/go-metrics"
+	iradix "github.com/hashicorp/go-immutable-radix"
+	"github.com/umbracle/minimal/types"
+)
+
+// accessSet is the set of accounts and storage slots accessed by a
+// transaction. All the methods are no-ops on a nil set.
+type accessSet struct {
+	// accounts whose balance, nonce, code or existence is accessed
+	accounts map[types.Address]struct{}
+
+	// storage slots accessed
+	slots map[types.Address]map[types.Hash]struct{}
+
+	// accounts that are created, suicided or deleted. A reset replaces
+	// the whole account, including its storage
+	resets map[types.Address]struct{}
+
+	// existing accounts that are touched but not modified
+	touched map[types.Address]struct{}
+}
+
+func newAccessSet() *accessSet {
+	return &accessSet{
+		accounts: map[types.Address]struct{}{},
+		slots:    map[types.Address]map[types.Hash]struct{}{},
+		resets:   map[types.Address]struct{}{},
+		touched:  map[types.Address]struct{}{},
+	}
+}
+
+func (a *accessSet) addAccount(addr types.Address) {
+	if a != nil {
+		a.accounts[addr] = struct{}{}
+	}
+}
+
+func (a *accessSet) addSlot(addr types.Address, key types.Hash) {
+	if a == nil {
+		return
+	}
+	slots, ok := a.slots[addr]
+	if !ok {
+		slots = map[types.Hash]struct{}{}
+		a.slots[addr] = slots
+	}
+	slots[key] = struct{}{}
+}
+
+func (a *accessSet) addReset(addr types.Address) {
+	if a != nil {
+		a.resets[addr] = struct{}{}
+	}
+}
+
+func (a *accessSet) addTouch(addr types.Address) {
+	if a != nil {
+		a.touched[addr] = struct{}{}
+	}
+}
+
+func (a *accessSet) hasAccount(addr types.Address) bool {
+	_, ok := a.accounts[addr]
+	return ok
+}
+
+func (a *accessSet) hasSlot(addr types.Address, key types.Hash) bool {
+	_, ok := a.slots[addr][key]
+	return ok
+}
+
+func (a *accessSet) hasReset(addr types.Address) bool {
+	_, ok := a.resets[addr]
+	return ok
+}
+
+// modifies returns true if the account or any of its slots is written
+func (a *accessSet) modifies(addr types.Address) bool {
+	if _, ok := a.slots[addr]; ok {
+		return true
+	}
+	return a.hasAccount(addr) || a.hasReset(addr)
+}
+
+// addresses returns all the accounts in the set
+func (a *accessSet) addresses() map[types.Address]struct{} {
+	res := map[types.Address]struct{}{}
+	for _, m := range []map[types.Address]struct{}{a.accounts, a.resets, a.touched} {
+		for addr := range m {
+			res[addr] = struct{}{}
+		}
+	}
+	for addr := range a.slots {
+		res[addr] = struct{}{}
+	}
+	return res
+}
+
+// merge adds all the entries of b to the set
+func (a *accessSet) merge(b *accessSet) {
+	for addr := range b.addresses() {
+		if b.hasAccount(addr) {
+			a.addAccount(addr)
+		}
+		if b.hasReset(addr) {
+			a.addReset(addr)
+		}
+		if _, ok := b.touched[addr]; ok {
+			a.addTouch(addr)
+		}
+		for key := range b.slots[addr] {
+			a.addSlot(addr, key)
+		}
+	}
+}
+
+// speculativeTxn is the result of executing a transaction on top
+// of the state at the beginning of the block
+type speculativeTxn struct {
+	txn *types.Transaction
+	msg *types.Transaction
+
+	// err is set if the sender cannot be recovered
+	err error
+
+	state   *Txn
+	gasUsed uint64
+	failed  bool
+	logs    []*types.Log
+
+	// gas taken from the block gas pool
+	gasPool uint64
+
+	// fee paid to the coinbase, it is paid during the merge
+	fee *big.Int
+
+	reads  *accessSet
+	writes *accessSet
+}
+
+// conflicts returns true if the transaction accessed any entry
+// modified by the transactions already merged in the block
+func (s *speculativeTxn) conflicts(written *accessSet) bool {
+	for addr := range s.reads.accounts {
+		if written.hasAccount(addr) || written.hasReset(addr) {
+			return true
+		}
+	}
+	for addr, slots := range s.reads.slots {
+		for key := range slots {
+			if written.hasSlot(addr, key) || written.hasReset(addr) {
+				return true
+			}
+		}
+	}
+	for addr := range s.writes.addresses() {
+		// a reset replaces the whole account and must be applied
+		// on the same account the transaction was executed with
+		if s.writes.hasReset(addr) && written.modifies(addr) {
+			return true
+		}
+		if written.hasReset(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeParallel executes the transactions concurrently on top of the
+// state at the beginning of the block, then it validates them in order.
+// The transactions that accessed the state modified by a previous
+// transaction are executed again. The result is the same as if the
+// transactions were executed sequentially with Write.
+func (t *Transition) writeParallel(txns []*types.Transaction, workers int) error {
+	base := t.state.txn.CommitOnly()
+	lock := &sync.Mutex{}
+
+	results := make([]*speculativeTxn, len(txns))
+
+	jobs := make(chan int)
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for indx := range jobs {
+				results[indx] = t.speculate(base, lock, txns[indx])
+			}
+		}()
+	}
+	for indx := range txns {
+		jobs <- indx
+	}
+	close(jobs)
+	wg.Wait()
+
+	// record the entries modified by the merged transactions
+	written := newAccessSet()
+	t.state.writes = written
+	defer func() {
+		t.state.writes = nil
+	}()
+
+	for _, res := range results {
+		if res.err != nil {
+			return res.err
+		}
+		if t.gasPool < res.msg.Gas || res.conflicts(written) {
+			metrics.IncrCounter([]string{"executor", "parallel", "conflict"}, 1)
+
+			if err := t.Write(res.txn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		metrics.IncrCounter([]string{"executor", "parallel", "merge"}, 1)
+		t.merge(res, written)
+	}
+	return nil
+}
+
+// speculate executes the transaction on top of the base state
+func (t *Transition) speculate(base *iradix.Tree, lock *sync.Mutex, txn *types.Transaction) *speculativeTxn {
+	res := &speculativeTxn{
+		txn:    txn,
+		reads:  newAccessSet(),
+		writes: newAccessSet(),
+	}
+	if err := t.recoverSender(txn); err != nil {
+		res.err = err
+		return res
+	}
+
+	state := newTxn(t.state.state, t.state.snapshot)
+	state.txn = base.Txn()
+	state.lock = lock
+	state.reads = res.reads
+	state.writes = res.writes
+
+	spec := &Transition{
+		auxState:      t.auxState,
+		block:         t.block,
+		r:             t.r,
+		config:        t.config,
+		state:         state,
+		getHash:       t.getHash,
+		ctx:           t.ctx,
+		gasPool:       t.gasPool,
+		deferCoinbase: true,
+		receipts:      []*types.Receipt{},
+	}
+
+	res.msg = txn.Copy()
+	res.gasUsed, res.failed, _ = spec.Apply(res.msg)
+	res.logs = state.Logs()
+	res.gasPool = t.gasPool - spec.gasPool
+	res.fee = spec.coinbaseFee
+	res.state = state
+
+	return res
+}
+
+// merge applies the changes of a speculative transaction that does not
+// conflict with the transactions merged before
+func (t *Transition) merge(res *speculativeTxn, written *accessSet) {
+	t.gasPool -= res.gasPool
+
+	for addr := range res.writes.addresses() {
+		val, ok := res.state.txn.Get(addr.Bytes())
+		if !ok {
+			// the change was reverted
+			continue
+		}
+		obj := val.(*StateObject)
+
+		if !written.modifies(addr) {
+			// nobody else modified the account in the block, the object
+			// is the same as if the transaction was applied sequentially
+			t.state.txn.Insert(addr.Bytes(), obj.Copy())
+			continue
+		}
+
+		// the account is modified by other transactions but the
+		// entries written by this one are not accessed by them
+		current, _ := t.state.loadStateObject(addr)
+		if res.writes.hasAccount(addr) {
+			current.Account.Nonce = obj.Account.Nonce
+			current.Account.Balance = new(big.Int).Set(obj.Account.Balance)
+			current.Account.CodeHash = obj.Account.CodeHash
+			current.Code = obj.Code
+			current.DirtyCode = obj.DirtyCode
+		}
+		for key := range res.writes.slots[addr] {
+			if obj.Txn == nil {
+				break
+			}
+			v, ok := obj.Txn.Get(key.Bytes())
+			if !ok {
+				continue
+			}
+			if current.Txn == nil {
+				current.Txn = iradix.New().Txn()
+			}
+			current.Txn.Insert(key.Bytes(), v)
+		}
+		t.state.txn.Insert(addr.Bytes(), current)
+	}
+	written.merge(res.writes)
+
+	if res.fee != nil {
+		t.state.AddBalance(t.ctx.Coinbase, res.fee)
+	}
+
+	t.writeReceipt(res.txn, res.msg, res.gasUsed, res.failed, res.logs)
+}
@@ -0,0 +1,166 @@
+package state_test
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/minimal/chain"
+	"github.com/umbracle/minimal/state"
+	itrie "github.com/umbracle/minimal/state/immutable-trie"
+	"github.com/umbracle/minimal/state/runtime/evm"
+	"github.com/umbracle/minimal/state/runtime/precompiled"
+	"github.com/umbracle/minimal/types"
+)
+
+var (
+	// increments the slot 0
+	counterCode = []byte{0x60, 0x00, 0x54, 0x60, 0x01, 0x01, 0x60, 0x00, 0x55, 0x00}
+
+	// stores 1 in the slot of the caller
+	callerSlotCode = []byte{0x60, 0x01, 0x33, 0x55, 0x00}
+
+	// emits an empty log
+	logCode = []byte{0x60, 0x00, 0x60, 0x00, 0xa0, 0x00}
+
+	// stores the balance of the coinbase in the slot 0
+	coinbaseCode = []byte{0x41, 0x31, 0x60, 0x00, 0x55, 0x00}
+
+	// selfdestructs in favor of the caller
+	selfdestructCode = []byte{0x33, 0xff}
+
+	// deploys the counter contract
+	deployCode = append([]byte{0x69}, append(counterCode, 0x60, 0x00, 0x52, 0x60, 0x0a, 0x60, 0x16, 0xf3)...)
+)
+
+var (
+	counterAddr      = types.StringToAddress("0x1000")
+	callerSlotAddr   = types.StringToAddress("0x1001")
+	logAddr          = types.StringToAddress("0x1002")
+	coinbaseAddr     = types.StringToAddress("0x1003")
+	selfdestructAddr = types.StringToAddress("0x1004")
+	coinbase         = types.StringToAddress("0x2000")
+)
+
+func parallelTestTxns(senders []types.Address) []*types.Transaction {
+	r := rand.New(rand.NewSource(1))
+
+	targets := []*types.Address{
+		&counterAddr, &callerSlotAddr, &logAddr, &coinbaseAddr, &selfdestructAddr, &coinbase, nil,
+	}
+
+	nonces := map[types.Address]uint64{}
+	txns := []*types.Transaction{}
+	for i := 0; i < 200; i++ {
+		from := senders[r.Intn(len(senders))]
+
+		var to *types.Address
+		if i%5 == 0 {
+			// value transfer to a fresh account
+			addr := types.BytesToAddress(big.NewInt(int64(0x3000 + i)).Bytes())
+			to = &addr
+		} else {
+			to = targets[r.Intn(len(targets))]
+		}
+
+		txn := &types.Transaction{
+			Nonce:    nonces[from],
+			GasPrice: big.NewInt(int64(1 + r.Intn(3))).Bytes(),
+			Gas:      100000,
+			To:       to,
+			Value:    big.NewInt(int64(r.Intn(2))).Bytes(),
+			From:     from,
+		}
+		if to == nil {
+			txn.Input = deployCode
+		}
+		// some transactions have a wrong nonce
+		if i%17 != 0 {
+			nonces[from]++
+		}
+		txns = append(txns, txn)
+	}
+	return txns
+}
+
+func processParallelTestBlock(t *testing.T, forks *chain.Forks, workers int, txns []*types.Transaction, senders []types.Address) (types.Hash, []*types.Receipt) {
+	st := itrie.NewState(itrie.NewMemoryStorage())
+
+	executor := state.NewExecutor(&chain.Params{Forks: forks}, st)
+	executor.SetRuntime(precompiled.NewPrecompiled())
+	executor.SetRuntime(evm.NewEVM())
+	executor.SetParallel(workers)
+	executor.GetHash = func(*types.Header) state.GetHashByNumber {
+		return func(i uint64) types.Hash {
+			return types.Hash{}
+		}
+	}
+
+	alloc := chain.GenesisAlloc{
+		counterAddr:      {Code: counterCode},
+		callerSlotAddr:   {Code: callerSlotCode},
+		logAddr:          {Code: logCode},
+		coinbaseAddr:     {Code: coinbaseCode},
+		selfdestructAddr: {Code: selfdestructCode, Balance: big.NewInt(1000)},
+	}
+	for _, addr := range senders {
+		alloc[addr] = chain.GenesisAccount{Balance: big.NewInt(1000000000)}
+	}
+	root := executor.WriteGenesis(alloc)
+
+	block := &types.Block{
+		Header: &types.Header{
+			Number:   1,
+			GasLimit: 100000000,
+			Miner:    coinbase,
+		},
+	}
+	// copy the transactions since the executor modifies them
+	for _, txn := range txns {
+		block.Transactions = append(block.Transactions, txn.Copy())
+	}
+
+	transition, root, err := executor.ProcessBlock(root, block)
+	assert.NoError(t, err)
+	return root, transition.Receipts()
+}
+
+func TestParallelExecution(t *testing.T) {
+	senders := []types.Address{}
+	for i := 0; i < 10; i++ {
+		senders = append(senders, types.BytesToAddress(big.NewInt(int64(0x4000+i)).Bytes()))
+	}
+	txns := parallelTestTxns(senders)
+
+	cases := map[string]*chain.Forks{
+		"Frontier": {},
+		"EIP158": {
+			Homestead: chain.NewFork(0),
+			EIP150:    chain.NewFork(0),
+			EIP155:    chain.NewFork(0),
+			EIP158:    chain.NewFork(0),
+		},
+		"Constantinople": {
+			Homestead:      chain.NewFork(0),
+			EIP150:         chain.NewFork(0),
+			EIP155:         chain.NewFork(0),
+			EIP158:         chain.NewFork(0),
+			Byzantium:      chain.NewFork(0),
+			Constantinople: chain.NewFork(0),
+			Petersburg:     chain.NewFork(0),
+		},
+	}
+
+	for name, forks := range cases {
+		t.Run(name, func(t *testing.T) {
+			root, receipts := processParallelTestBlock(t, forks, 0, txns, senders)
+
+			for _, workers := range []int{2, 8} {
+				parallelRoot, parallelReceipts := processParallelTestBlock(t, forks, workers, txns, senders)
+				assert.Equal(t, root, parallelRoot)
+				assert.Equal(t, receipts, parallelReceipts)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package state_test
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/minimal/chain"
+	"github.com/umbracle/minimal/helper/hex"
+	"github.com/umbracle/minimal/state"
+	itrie "github.com/umbracle/minimal/state/immutable-trie"
+	"github.com/umbracle/minimal/state/runtime/evm"
+	"github.com/umbracle/minimal/state/runtime/ewasm"
+	"github.com/umbracle/minimal/state/runtime/ewasm/wasm"
+	"github.com/umbracle/minimal/types"
+)
+
+var (
+	// clears the slot 0
+	clearCode = []byte{0x60, 0x00, 0x60, 0x00, 0x55, 0x00}
+
+	// wasm module that stores 0 under the key 0 and finishes with
+	// the stored value (the storeAndFinish module of the ewasm tests)
+	clearWasmCode = strings.TrimSuffix("0x0061736d0100000001090260027f7f00600000022b0208657468657265756d0c73746f7261676553746f7265000008657468657265756d0666696e6973680000030201010503010001071102046d61696e0002066d656d6f727902000a10010e004100412010004120412010010b0b46010041000b4000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001", "01") + "00"
+)
+
+func TestSStoreRefunds(t *testing.T) {
+	sender := types.StringToAddress("0x3000")
+	evmAddr := types.StringToAddress("0x1000")
+	wasmAddr := types.StringToAddress("0x1001")
+
+	slot := map[types.Hash]types.Hash{
+		{}: types.BytesToHash([]byte{0x1}),
+	}
+
+	st := itrie.NewState(itrie.NewMemoryStorage())
+	executor := state.NewExecutor(&chain.Params{Forks: &chain.Forks{EWASM: chain.NewFork(0)}}, st)
+	executor.SetRuntime(ewasm.NewEWASM())
+	executor.SetRuntime(evm.NewEVM())
+	executor.GetHash = func(*types.Header) state.GetHashByNumber {
+		return func(i uint64) types.Hash {
+			return types.Hash{}
+		}
+	}
+
+	root := executor.WriteGenesis(chain.GenesisAlloc{
+		sender:   {Balance: big.NewInt(1000000000)},
+		evmAddr:  {Code: clearCode, Storage: slot},
+		wasmAddr: {Code: hex.MustDecodeHex(clearWasmCode), Storage: slot},
+	})
+
+	block := &types.Block{
+		Header: &types.Header{
+			Number:   1,
+			GasLimit: 1000000,
+		},
+	}
+	for i, to := range []types.Address{evmAddr, wasmAddr} {
+		to := to
+		block.Transactions = append(block.Transactions, &types.Transaction{
+			Nonce:    uint64(i),
+			GasPrice: big.NewInt(1).Bytes(),
+			Gas:      100000,
+			To:       &to,
+			From:     sender,
+		})
+	}
+
+	transition, _, err := executor.ProcessBlock(root, block)
+	assert.NoError(t, err)
+
+	// the refund of the cleared slot is capped by half of the gas used
+	refunded := func(used uint64) uint64 {
+		if used/2 < 15000 {
+			return used - used/2
+		}
+		return used - 15000
+	}
+
+	receipts := transition.Receipts()
+	assert.Equal(t, refunded(21000+3+3+5000), receipts[0].GasUsed)
+	assert.Equal(t, refunded(21000+7+5000+wasm.MemoryCost(1)), receipts[1].GasUsed)
+}
@@ -1,6 +1,11 @@
 package evm
 
-import "fmt"
+import (
+	"fmt"
+	"sync"
+
+	"github.com/umbracle/minimal/chain"
+)
 
 type handler struct {
 	inst  instruction
@@ -8,124 +13,198 @@ type handler struct {
 	gas   uint64
 }
 
-var dispatchTable [256]handler
+// dispatchTable is the set of instructions (and their base gas cost)
+// available in a specific fork
+type dispatchTable [256]handler
 
-func register(op OpCode, h handler) {
-	if dispatchTable[op].inst != nil {
+func (d *dispatchTable) register(op OpCode, h handler) {
+	if d[op].inst != nil {
 		panic(fmt.Errorf("instruction already exists"))
 	}
-	dispatchTable[op] = h
+	d[op] = h
 }
 
-func registerRange(from, to OpCode, factory func(n int) instruction, gas uint64) {
+func (d *dispatchTable) registerRange(from, to OpCode, factory func(n int) instruction, gas uint64) {
 	c := 1
 	for i := from; i <= to; i++ {
-		register(i, handler{factory(c), 0, gas})
+		d.register(i, handler{factory(c), 0, gas})
 		c++
 	}
 }
 
-func init() {
+// replace swaps the implementation of an already registered instruction
+func (d *dispatchTable) replace(op OpCode, inst instruction) {
+	if d[op].inst == nil {
+		panic(fmt.Errorf("instruction does not exists"))
+	}
+	d[op].inst = inst
+}
+
+// reprice changes the base gas cost of an already registered instruction
+func (d *dispatchTable) reprice(op OpCode, gas uint64) {
+	if d[op].inst == nil {
+		panic(fmt.Errorf("instruction does not exists"))
+	}
+	d[op].gas = gas
+}
+
+var (
+	dispatchTables     = map[chain.ForksInTime]*dispatchTable{}
+	dispatchTablesLock sync.RWMutex
+)
+
+// getDispatchTable returns the dispatch table for the given set of forks.
+// Tables are built once for each fork combination and cached afterwards.
+func getDispatchTable(config *chain.ForksInTime) *dispatchTable {
+	dispatchTablesLock.RLock()
+	table, ok := dispatchTables[*config]
+	dispatchTablesLock.RUnlock()
+	if ok {
+		return table
+	}
+
+	dispatchTablesLock.Lock()
+	defer dispatchTablesLock.Unlock()
+
+	if table, ok = dispatchTables[*config]; !ok {
+		table = newDispatchTable(config)
+		dispatchTables[*config] = table
+	}
+	return table
+}
+
+// newDispatchTable builds the dispatch table for the given set of forks. It starts
+// from the frontier instruction set and applies the changes of each fork on top.
+func newDispatchTable(config *chain.ForksInTime) *dispatchTable {
+	d := &dispatchTable{}
+	d.registerFrontier()
+
+	if config.Homestead {
+		d.register(DELEGATECALL, handler{opCall(DELEGATECALL), 6, 40})
+	}
+	if config.Byzantium {
+		d.register(STATICCALL, handler{opCall(STATICCALL), 6, 40})
+		d.register(REVERT, handler{opHalt(REVERT), 2, 0})
+		d.register(RETURNDATASIZE, handler{opReturnDataSize, 0, 2})
+		d.register(RETURNDATACOPY, handler{opReturnDataCopy, 3, 3})
+	}
+	if config.Constantinople {
+		d.register(SHL, handler{opShl, 2, 3})
+		d.register(SHR, handler{opShr, 2, 3})
+		d.register(SAR, handler{opSar, 2, 3})
+		d.register(EXTCODEHASH, handler{opExtCodeHash, 1, 400})
+		d.register(CREATE2, handler{opCreate(CREATE2), 4, 32000})
+	}
+
+	// repricings
+	if config.EIP150 {
+		d.reprice(SLOAD, 200)
+		d.reprice(BALANCE, 400)
+		d.reprice(EXTCODESIZE, 700)
+		d.reprice(EXTCODECOPY, 700)
+		d.reprice(SELFDESTRUCT, 5000)
+
+		for _, op := range []OpCode{CALL, CALLCODE, DELEGATECALL, STATICCALL} {
+			if d[op].inst != nil {
+				d.reprice(op, 700)
+			}
+		}
+	}
+	if config.EIP158 {
+		d.replace(EXP, opExp(50))
+	}
+	return d
+}
+
+func (d *dispatchTable) registerFrontier() {
 	// unsigned arithmetic operations
-	register(STOP, handler{opStop, 0, 0})
-	register(ADD, handler{opAdd, 2, 3})
-	register(SUB, handler{opSub, 2, 3})
-	register(MUL, handler{opMul, 2, 5})
-	register(DIV, handler{opDiv, 2, 5})
-	register(SDIV, handler{opSDiv, 2, 5})
-	register(MOD, handler{opMod, 2, 5})
-	register(SMOD, handler{opSMod, 2, 5})
-	register(EXP, handler{opExp, 2, 10})
-
-	registerRange(PUSH1, PUSH32, opPush, 3)
-	registerRange(DUP1, DUP16, opDup, 3)
-	registerRange(SWAP1, SWAP16, opSwap, 3)
-	registerRange(LOG0, LOG4, opLog, 375)
-
-	register(ADDMOD, handler{opAddMod, 3, 8})
-	register(MULMOD, handler{opMulMod, 3, 8})
-
-	register(AND, handler{opAnd, 2, 3})
-	register(OR, handler{opOr, 2, 3})
-	register(XOR, handler{opXor, 2, 3})
-	register(BYTE, handler{opByte, 2, 3})
-
-	register(NOT, handler{opNot, 1, 3})
-	register(ISZERO, handler{opIsZero, 1, 3})
-
-	register(EQ, handler{opEq, 2, 3})
-	register(LT, handler{opLt, 2, 3})
-	register(GT, handler{opGt, 2, 3})
-	register(SLT, handler{opSlt, 2, 3})
-	register(SGT, handler{opSgt, 2, 3})
-
-	register(SIGNEXTEND, handler{opSignExtension, 1, 5})
-
-	register(SHL, handler{opShl, 2, 3})
-	register(SHR, handler{opShr, 2, 3})
-	register(SAR, handler{opSar, 2, 3})
-
-	register(CREATE, handler{opCreate(CREATE), 3, 32000})
-	register(CREATE2, handler{opCreate(CREATE2), 4, 32000})
-
-	register(CALL, handler{opCall(CALL), 7, 0})
-	register(CALLCODE, handler{opCall(CALLCODE), 7, 0})
-	register(DELEGATECALL, handler{opCall(DELEGATECALL), 6, 0})
-	register(STATICCALL, handler{opCall(STATICCALL), 6, 0})
-
-	register(REVERT, handler{opHalt(REVERT), 2, 0})
-	register(RETURN, handler{opHalt(RETURN), 2, 0})
+	d.register(STOP, handler{opStop, 0, 0})
+	d.register(ADD, handler{opAdd, 2, 3})
+	d.register(SUB, handler{opSub, 2, 3})
+	d.register(MUL, handler{opMul, 2, 5})
+	d.register(DIV, handler{opDiv, 2, 5})
+	d.register(SDIV, handler{opSDiv, 2, 5})
+	d.register(MOD, handler{opMod, 2, 5})
+	d.register(SMOD, handler{opSMod, 2, 5})
+	d.register(EXP, handler{opExp(10), 2, 10})
+
+	d.registerRange(PUSH1, PUSH32, opPush, 3)
+	d.registerRange(DUP1, DUP16, opDup, 3)
+	d.registerRange(SWAP1, SWAP16, opSwap, 3)
+	d.registerRange(LOG0, LOG4, opLog, 375)
+
+	d.register(ADDMOD, handler{opAddMod, 3, 8})
+	d.register(MULMOD, handler{opMulMod, 3, 8})
+
+	d.register(AND, handler{opAnd, 2, 3})
+	d.register(OR, handler{opOr, 2, 3})
+	d.register(XOR, handler{opXor, 2, 3})
+	d.register(BYTE, handler{opByte, 2, 3})
+
+	d.register(NOT, handler{opNot, 1, 3})
+	d.register(ISZERO, handler{opIsZero, 1, 3})
+
+	d.register(EQ, handler{opEq, 2, 3})
+	d.register(LT, handler{opLt, 2, 3})
+	d.register(GT, handler{opGt, 2, 3})
+	d.register(SLT, handler{opSlt, 2, 3})
+	d.register(SGT, handler{opSgt, 2, 3})
+
+	d.register(SIGNEXTEND, handler{opSignExtension, 1, 5})
+
+	d.register(CREATE, handler{opCreate(CREATE), 3, 32000})
+
+	d.register(CALL, handler{opCall(CALL), 7, 40})
+	d.register(CALLCODE, handler{opCall(CALLCODE), 7, 40})
+
+	d.register(RETURN, handler{opHalt(RETURN), 2, 0})
 
 	// memory
-	register(MLOAD, handler{opMload, 1, 3})
-	register(MSTORE, handler{opMStore, 2, 3})
-	register(MSTORE8, handler{opMStore8, 2, 3})
+	d.register(MLOAD, handler{opMload, 1, 3})
+	d.register(MSTORE, handler{opMStore, 2, 3})
+	d.register(MSTORE8, handler{opMStore8, 2, 3})
 
 	// store
-	register(SLOAD, handler{opSload, 1, 0})
-	register(SSTORE, handler{opSStore, 2, 0})
-
-	register(SHA3, handler{opSha3, 2, 30})
+	d.register(SLOAD, handler{opSload, 1, 50})
+	d.register(SSTORE, handler{opSStore, 2, 0})
 
-	register(POP, handler{opPop, 1, 2})
+	d.register(SHA3, handler{opSha3, 2, 30})
 
-	register(EXTCODEHASH, handler{opExtCodeHash, 1, 400})
+	d.register(POP, handler{opPop, 1, 2})
 
 	// context operations
-	register(ADDRESS, handler{opAddress, 0, 2})
-	register(BALANCE, handler{opBalance, 1, 0})
-	register(ORIGIN, handler{opOrigin, 0, 2})
-	register(CALLER, handler{opCaller, 0, 2})
-	register(CALLVALUE, handler{opCallValue, 0, 2})
-	register(CALLDATALOAD, handler{opCallDataLoad, 1, 3})
-	register(CALLDATASIZE, handler{opCallDataSize, 0, 2})
-	register(CODESIZE, handler{opCodeSize, 0, 2})
-	register(EXTCODESIZE, handler{opExtCodeSize, 1, 0})
-	register(GASPRICE, handler{opGasPrice, 0, 2})
-	register(RETURNDATASIZE, handler{opReturnDataSize, 0, 2})
-
-	register(PC, handler{opPC, 0, 2})
-	register(MSIZE, handler{opMSize, 0, 2})
-	register(GAS, handler{opGas, 0, 2})
-
-	register(EXTCODECOPY, handler{opExtCodeCopy, 4, 0})
-
-	register(CALLDATACOPY, handler{opCallDataCopy, 3, 3})
-	register(RETURNDATACOPY, handler{opReturnDataCopy, 3, 3})
-	register(CODECOPY, handler{opCodeCopy, 3, 3})
+	d.register(ADDRESS, handler{opAddress, 0, 2})
+	d.register(BALANCE, handler{opBalance, 1, 20})
+	d.register(ORIGIN, handler{opOrigin, 0, 2})
+	d.register(CALLER, handler{opCaller, 0, 2})
+	d.register(CALLVALUE, handler{opCallValue, 0, 2})
+	d.register(CALLDATALOAD, handler{opCallDataLoad, 1, 3})
+	d.register(CALLDATASIZE, handler{opCallDataSize, 0, 2})
+	d.register(CODESIZE, handler{opCodeSize, 0, 2})
+	d.register(EXTCODESIZE, handler{opExtCodeSize, 1, 20})
+	d.register(GASPRICE, handler{opGasPrice, 0, 2})
+
+	d.register(PC, handler{opPC, 0, 2})
+	d.register(MSIZE, handler{opMSize, 0, 2})
+	d.register(GAS, handler{opGas, 0, 2})
+
+	d.register(EXTCODECOPY, handler{opExtCodeCopy, 4, 20})
+
+	d.register(CALLDATACOPY, handler{opCallDataCopy, 3, 3})
+	d.register(CODECOPY, handler{opCodeCopy, 3, 3})
 
 	// block information
-	register(BLOCKHASH, handler{opBlockHash, 1, 20})
-	register(COINBASE, handler{opCoinbase, 0, 2})
-	register(TIMESTAMP, handler{opTimestamp, 0, 2})
-	register(NUMBER, handler{opNumber, 0, 2})
-	register(DIFFICULTY, handler{opDifficulty, 0, 2})
-	register(GASLIMIT, handler{opGasLimit, 0, 2})
+	d.register(BLOCKHASH, handler{opBlockHash, 1, 20})
+	d.register(COINBASE, handler{opCoinbase, 0, 2})
+	d.register(TIMESTAMP, handler{opTimestamp, 0, 2})
+	d.register(NUMBER, handler{opNumber, 0, 2})
+	d.register(DIFFICULTY, handler{opDifficulty, 0, 2})
+	d.register(GASLIMIT, handler{opGasLimit, 0, 2})
 
-	register(SELFDESTRUCT, handler{opSelfDestruct, 1, 0})
+	d.register(SELFDESTRUCT, handler{opSelfDestruct, 1, 0})
 
 	// jumps
-	register(JUMP, handler{opJump, 1, 8})
-	register(JUMPI, handler{opJumpi, 2, 10})
-	register(JUMPDEST, handler{opJumpDest, 0, 1})
+	d.register(JUMP, handler{opJump, 1, 8})
+	d.register(JUMPI, handler{opJumpi, 2, 10})
+	d.register(JUMPDEST, handler{opJumpDest, 0, 1})
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/minimal/chain"
 )
 
 func TestPushOpcodes(t *testing.T) {
@@ -13,13 +14,15 @@ func TestPushOpcodes(t *testing.T) {
 		code[i] = byte(i + 1)
 	}
 
+	table := getDispatchTable(&chain.ForksInTime{})
+
 	c := 1
 	for i := PUSH1; i <= PUSH32; i++ {
 		s := &state{
 			code: code,
 		}
 
-		inst := dispatchTable[i]
+		inst := table[i]
 		inst.inst(s)
 
 		assert.False(t, s.stop)
@@ -31,3 +34,42 @@ func TestPushOpcodes(t *testing.T) {
 		c++
 	}
 }
+
+func TestDispatchTableForks(t *testing.T) {
+	frontier := getDispatchTable(&chain.ForksInTime{})
+	constantinople := getDispatchTable(&chain.ForksInTime{
+		Homestead:      true,
+		Byzantium:      true,
+		Constantinople: true,
+		EIP150:         true,
+		EIP158:         true,
+	})
+
+	// opcodes not available in frontier
+	for _, op := range []OpCode{DELEGATECALL, STATICCALL, REVERT, SHL, SHR, SAR, EXTCODEHASH, CREATE2} {
+		assert.Nil(t, frontier[op].inst)
+		assert.NotNil(t, constantinople[op].inst)
+	}
+
+	// EIP150 repricings
+	assert.Equal(t, uint64(50), frontier[SLOAD].gas)
+	assert.Equal(t, uint64(200), constantinople[SLOAD].gas)
+
+	assert.Equal(t, uint64(40), frontier[CALL].gas)
+	assert.Equal(t, uint64(700), constantinople[CALL].gas)
+
+	// tables are cached
+	assert.Equal(t, frontier, getDispatchTable(&chain.ForksInTime{}))
+}
+
+func TestDispatchTableInvalidOpcode(t *testing.T) {
+	s, close := getState()
+	defer close()
+
+	// SHL is not available before constantinople
+	s.code = []byte{PUSH1, 0x1, PUSH1, 0x1, SHL}
+	s.gas = 1000
+
+	_, err := s.Run()
+	assert.Equal(t, errOpCodeNotFound, err)
+}
@@ -7,14 +7,49 @@ import (
 
 var _ runtime.Runtime = &EVM{}
 
+// Config is the configuration of the EVM
+type Config struct {
+	// JumpdestCacheSize is the number of jumpdest analysis kept
+	// in memory. A size of zero disables the cache.
+	JumpdestCacheSize int
+
+	// Tracer, if set, is notified of every instruction executed
+	Tracer Tracer
+}
+
+// DefaultConfig returns the default configuration of the EVM
+func DefaultConfig() *Config {
+	return &Config{
+		JumpdestCacheSize: 4096,
+	}
+}
+
 // EVM is the ethereum virtual machine
 type EVM struct {
 	vs []state
+
+	jumpdests  *jumpdestCache
+	tracer     Tracer
+	traceStack bool
 }
 
-// NewEVM creates a new EVM
+// NewEVM creates a new EVM with the default configuration
 func NewEVM() *EVM {
-	return &EVM{}
+	return NewEVMWithConfig(DefaultConfig())
+}
+
+// NewEVMWithConfig creates a new EVM
+func NewEVMWithConfig(config *Config) *EVM {
+	e := &EVM{
+		tracer: config.Tracer,
+	}
+	if tracer, ok := config.Tracer.(StackTracer); ok {
+		e.traceStack = tracer.TraceStack()
+	}
+	if config.JumpdestCacheSize > 0 {
+		e.jumpdests = newJumpdestCache(config.JumpdestCacheSize)
+	}
+	return e
 }
 
 /*
@@ -51,8 +86,15 @@ func (e *EVM) Run(c *runtime.Contract, host runtime.Host, config *chain.ForksInT
 	contract.gas = c.Gas
 	contract.host = host
 	contract.config = config
+	contract.table = getDispatchTable(config)
+	contract.tracer = e.tracer
 
-	contract.bitmap.setCode(c.Code)
+	contract.setJumpdests(c, host)
+
+	tracer, traceContract := e.tracer.(ContractTracer)
+	if traceContract {
+		tracer.CaptureEnter(c.Depth, c.Address, c.Gas)
+	}
 
 	ret, err := contract.Run()
 
@@ -67,5 +109,9 @@ func (e *EVM) Run(c *runtime.Contract, host runtime.Host, config *chain.ForksInT
 		gas = 0
 	}
 
+	if traceContract {
+		tracer.CaptureExit(c.Depth, gas, err)
+	}
+
 	return rett, gas, err
 }
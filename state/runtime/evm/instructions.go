@@ -118,35 +118,33 @@ func releaseBig(b *big.Int) {
 	bigPool.Put(b)
 }
 
-func opExp(c *state) {
-	x := c.pop()
-	y := c.top()
+// opExp returns the EXP instruction charging byteGas for each
+// byte of the exponent (repriced in EIP158)
+func opExp(byteGas uint64) instruction {
+	return func(c *state) {
+		x := c.pop()
+		y := c.top()
 
-	var gas uint64
-	if c.config.EIP158 {
-		gas = 50
-	} else {
-		gas = 10
-	}
-	gasCost := uint64((y.BitLen()+7)/8) * gas
-	if !c.consumeGas(gasCost) {
-		return
-	}
+		gasCost := uint64((y.BitLen()+7)/8) * byteGas
+		if !c.consumeGas(gasCost) {
+			return
+		}
 
-	z := acquireBig().Set(one)
+		z := acquireBig().Set(one)
 
-	// https://www.programminglogic.com/fast-exponentiation-algorithms/
-	for _, d := range y.Bits() {
-		for i := 0; i < _W; i++ {
-			if d&1 == 1 {
-				toU256(z.Mul(z, x))
+		// https://www.programminglogic.com/fast-exponentiation-algorithms/
+		for _, d := range y.Bits() {
+			for i := 0; i < _W; i++ {
+				if d&1 == 1 {
+					toU256(z.Mul(z, x))
+				}
+				d >>= 1
+				toU256(x.Mul(x, x))
 			}
-			d >>= 1
-			toU256(x.Mul(x, x))
 		}
+		y.Set(z)
+		releaseBig(z)
 	}
-	y.Set(z)
-	releaseBig(z)
 }
 
 func opAddMod(c *state) {
@@ -321,11 +319,6 @@ func equalOrOverflowsUint256(b *big.Int) bool {
 }
 
 func opShl(c *state) {
-	if !c.config.Constantinople {
-		c.exit(errOpCodeNotFound)
-		return
-	}
-
 	shift := c.pop()
 	value := c.top()
 
@@ -338,11 +331,6 @@ func opShl(c *state) {
 }
 
 func opShr(c *state) {
-	if !c.config.Constantinople {
-		c.exit(errOpCodeNotFound)
-		return
-	}
-
 	shift := c.pop()
 	value := c.top()
 
@@ -355,11 +343,6 @@ func opShr(c *state) {
 }
 
 func opSar(c *state) {
-	if !c.config.Constantinople {
-		c.exit(errOpCodeNotFound)
-		return
-	}
-
 	shift := c.pop()
 	value := to256(c.top())
 
@@ -444,16 +427,6 @@ func opMStore8(c *state) {
 func opSload(c *state) {
 	loc := c.top()
 
-	var gas uint64
-	if c.config.EIP150 {
-		gas = 200
-	} else {
-		gas = 50
-	}
-	if !c.consumeGas(gas) {
-		return
-	}
-
 	val := c.host.GetStorage(c.msg.Address, bigToHash(loc))
 	loc.SetBytes(val.Bytes())
 }
@@ -532,16 +505,6 @@ func opAddress(c *state) {
 func opBalance(c *state) {
 	addr, _ := c.popAddr()
 
-	var gas uint64
-	if c.config.EIP150 {
-		gas = 400
-	} else {
-		gas = 20
-	}
-	if !c.consumeGas(gas) {
-		return
-	}
-
 	c.push1().Set(c.host.GetBalance(addr))
 }
 
@@ -589,16 +552,6 @@ func opCodeSize(c *state) {
 func opExtCodeSize(c *state) {
 	addr, _ := c.popAddr()
 
-	var gas uint64
-	if c.config.EIP150 {
-		gas = 700
-	} else {
-		gas = 20
-	}
-	if !c.consumeGas(gas) {
-		return
-	}
-
 	c.push1().SetUint64(uint64(c.host.GetCodeSize(addr)))
 }
 
@@ -607,19 +560,10 @@ func opGasPrice(c *state) {
 }
 
 func opReturnDataSize(c *state) {
-	if !c.config.Byzantium {
-		c.exit(errOpCodeNotFound)
-	} else {
-		c.push1().SetUint64(uint64(len(c.returnData)))
-	}
+	c.push1().SetUint64(uint64(len(c.returnData)))
 }
 
 func opExtCodeHash(c *state) {
-	if !c.config.Constantinople {
-		c.exit(errOpCodeNotFound)
-		return
-	}
-
 	address, _ := c.popAddr()
 
 	v := c.push1()
@@ -683,16 +627,6 @@ func opExtCodeCopy(c *state) {
 		return
 	}
 
-	var gas uint64
-	if c.config.EIP150 {
-		gas = 700
-	} else {
-		gas = 20
-	}
-	if !c.consumeGas(gas) {
-		return
-	}
-
 	code := c.host.GetCode(address)
 	if size != 0 {
 		c.setBytes(c.memory[memOffset.Uint64():], code, size, codeOffset)
@@ -719,11 +653,6 @@ func opCallDataCopy(c *state) {
 }
 
 func opReturnDataCopy(c *state) {
-	if !c.config.Byzantium {
-		c.exit(errOpCodeNotFound)
-		return
-	}
-
 	memOffset := c.pop()
 	dataOffset := c.pop()
 	length := c.pop()
@@ -818,12 +747,12 @@ func opSelfDestruct(c *state) {
 
 	address, _ := c.popAddr()
 
-	// try to remove the gas first
+	// try to remove the gas first. The base cost of the
+	// instruction is charged by the dispatch table
 	var gas uint64
 
 	// EIP150 reprice fork
 	if c.config.EIP150 {
-		gas = 5000
 		if c.config.EIP158 {
 			// if empty and transfers value
 			if c.host.Empty(address) && c.host.GetBalance(c.msg.Address).Sign() != 0 {
@@ -954,13 +883,6 @@ func opCreate(op OpCode) instruction {
 			return
 		}
 
-		if op == CREATE2 {
-			if !c.config.Constantinople {
-				c.exit(errOpCodeNotFound)
-				return
-			}
-		}
-
 		// reset the return data
 		c.resetReturnData()
 
@@ -1008,15 +930,6 @@ func opCall(op OpCode) instruction {
 			}
 		}
 
-		if op == DELEGATECALL && !c.config.Homestead {
-			c.exit(errOpCodeNotFound)
-			return
-		}
-		if op == STATICCALL && !c.config.Byzantium {
-			c.exit(errOpCodeNotFound)
-			return
-		}
-
 		var callType runtime.CallType
 		switch op {
 		case CALL:
@@ -1097,12 +1010,8 @@ func (c *state) buildCallContract(op OpCode) (*runtime.Contract, uint64, uint64,
 		return nil, 0, 0, nil
 	}
 
+	// the base cost of the call is charged by the dispatch table
 	var gasCost uint64
-	if c.config.EIP150 {
-		gasCost = 700
-	} else {
-		gasCost = 40
-	}
 
 	eip158 := c.config.EIP158
 	transfersValue := (op == CALL || op == CALLCODE) && value != nil && value.Sign() != 0
@@ -1245,11 +1154,6 @@ func (c *state) buildCreateContract(op OpCode) (*runtime.Contract, error) {
 
 func opHalt(op OpCode) instruction {
 	return func(c *state) {
-		if op == REVERT && !c.config.Byzantium {
-			c.exit(errOpCodeNotFound)
-			return
-		}
-
 		offset := c.pop()
 		size := c.pop()
 
@@ -0,0 +1,53 @@
+package evm
+
+import (
+	"github.com/armon/go-metrics"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/umbracle/minimal/crypto"
+	"github.com/umbracle/minimal/state/runtime"
+	"github.com/umbracle/minimal/types"
+)
+
+var emptyCodeHash = types.BytesToHash(crypto.Keccak256(nil))
+
+// jumpdestCache is a bounded cache of jumpdest bitmaps indexed by the hash
+// of the code. The bitmaps are shared across frames, transactions and blocks
+// and must not be modified once they are in the cache.
+type jumpdestCache struct {
+	cache *lru.Cache
+}
+
+func newJumpdestCache(size int) *jumpdestCache {
+	cache, err := lru.New(size)
+	if err != nil {
+		panic(err)
+	}
+	return &jumpdestCache{cache}
+}
+
+// get returns the jumpdest bitmap of the contract code or nil if the code
+// cannot be cached (i.e. the init code of a contract creation).
+func (j *jumpdestCache) get(contract *runtime.Contract, host runtime.Host) *bitmap {
+	if len(contract.Code) == 0 {
+		return nil
+	}
+
+	// during a contract creation there is no code stored at the address
+	// yet and the code being executed is not indexed by any hash
+	hash := host.GetCodeHash(contract.CodeAddress)
+	if hash == emptyCodeHash || hash == (types.Hash{}) {
+		return nil
+	}
+
+	if b, ok := j.cache.Get(hash); ok {
+		metrics.IncrCounter([]string{"evm", "jumpdest", "hit"}, 1)
+		return b.(*bitmap)
+	}
+	metrics.IncrCounter([]string{"evm", "jumpdest", "miss"}, 1)
+
+	b := &bitmap{}
+	b.setCode(contract.Code)
+	j.cache.Add(hash, b)
+
+	return b
+}
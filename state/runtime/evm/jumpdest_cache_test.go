@@ -0,0 +1,45 @@
+package evm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/minimal/state/runtime"
+	"github.com/umbracle/minimal/types"
+)
+
+type mockCodeHashHost struct {
+	runtime.Host
+	hashes map[types.Address]types.Hash
+}
+
+func (m *mockCodeHashHost) GetCodeHash(addr types.Address) types.Hash {
+	return m.hashes[addr]
+}
+
+func TestJumpdestCache(t *testing.T) {
+	addr1 := types.StringToAddress("1")
+	addr2 := types.StringToAddress("2")
+
+	host := &mockCodeHashHost{
+		hashes: map[types.Address]types.Hash{
+			addr1: types.StringToHash("1"),
+		},
+	}
+
+	code := []byte{PUSH1, 0x3, JUMP, JUMPDEST}
+	cache := newJumpdestCache(1)
+
+	b := cache.get(&runtime.Contract{Code: code, CodeAddress: addr1}, host)
+	assert.NotNil(t, b)
+	assert.True(t, b.isSet(3))
+	assert.False(t, b.isSet(2))
+
+	// the bitmap is shared on the second call
+	assert.Equal(t, b, cache.get(&runtime.Contract{Code: code, CodeAddress: addr1}, host))
+	assert.Equal(t, 1, cache.cache.Len())
+
+	// code without hash (i.e. contract creation) is not cached
+	assert.Nil(t, cache.get(&runtime.Contract{Code: code, CodeAddress: addr2}, host))
+	assert.Equal(t, 1, cache.cache.Len())
+}
@@ -0,0 +1,175 @@
+package profiler
+
+import (
+	"compress/gzip"
+	"io"
+	"sort"
+)
+
+// WritePprof writes the profile in the gzip compressed protobuf format
+// used by pprof. Each contract is a function and each basic block is a
+// line of the function, numbered by the program counter where it starts.
+// The samples are the call paths of the basic blocks with the gas and
+// the number of instructions executed.
+func (p *Profiler) WritePprof(w io.Writer) error {
+	b := &protobuf{}
+	table := newStringTable()
+
+	// sample types
+	for _, typ := range [][2]string{{"gas", "count"}, {"instructions", "count"}} {
+		b.message(1, func(b *protobuf) {
+			b.int64(1, table.index(typ[0]))
+			b.int64(2, table.index(typ[1]))
+		})
+	}
+
+	keys := make([]string, 0, len(p.samples))
+	for key := range p.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	locations := map[location]uint64{}
+	functions := map[string]uint64{}
+
+	for _, key := range keys {
+		s := p.samples[key]
+		if s.Count == 0 && s.Gas == 0 {
+			continue
+		}
+
+		ids := make([]uint64, len(s.path))
+		for i, loc := range s.path {
+			id, ok := locations[loc]
+			if !ok {
+				name := loc.addr.String()
+				fn, ok := functions[name]
+				if !ok {
+					fn = uint64(len(functions) + 1)
+					functions[name] = fn
+
+					b.message(5, func(b *protobuf) {
+						b.uint64(1, fn)
+						b.int64(2, table.index(name))
+						b.int64(3, table.index(name))
+						b.int64(4, table.index(name))
+					})
+				}
+
+				id = uint64(len(locations) + 1)
+				locations[loc] = id
+
+				block := loc.block
+				b.message(4, func(b *protobuf) {
+					b.uint64(1, id)
+					b.uint64(3, block)
+					b.message(4, func(b *protobuf) {
+						b.uint64(1, fn)
+						b.int64(2, int64(block))
+					})
+				})
+			}
+			ids[i] = id
+		}
+
+		b.message(2, func(b *protobuf) {
+			b.uint64s(1, ids)
+			b.uint64s(2, []uint64{s.Gas, s.Count})
+		})
+	}
+
+	// period type
+	b.message(11, func(b *protobuf) {
+		b.int64(1, table.index("gas"))
+		b.int64(2, table.index("count"))
+	})
+	b.int64(12, 1)
+
+	// default sample type
+	b.int64(14, table.index("gas"))
+
+	for _, str := range table.strs {
+		b.string(6, str)
+	}
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(b.data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+type stringTable struct {
+	strs []string
+	indx map[string]int64
+}
+
+func newStringTable() *stringTable {
+	// the first entry of the table must be the empty string
+	return &stringTable{
+		strs: []string{""},
+		indx: map[string]int64{"": 0},
+	}
+}
+
+func (s *stringTable) index(str string) int64 {
+	i, ok := s.indx[str]
+	if !ok {
+		i = int64(len(s.strs))
+		s.strs = append(s.strs, str)
+		s.indx[str] = i
+	}
+	return i
+}
+
+// protobuf is a minimal protocol buffers encoder with the types
+// required by the pprof format
+type protobuf struct {
+	data []byte
+}
+
+func (b *protobuf) varint(x uint64) {
+	for x >= 0x80 {
+		b.data = append(b.data, byte(x)|0x80)
+		x >>= 7
+	}
+	b.data = append(b.data, byte(x))
+}
+
+func (b *protobuf) key(tag int, wireType int) {
+	b.varint(uint64(tag)<<3 | uint64(wireType))
+}
+
+func (b *protobuf) uint64(tag int, x uint64) {
+	b.key(tag, 0)
+	b.varint(x)
+}
+
+func (b *protobuf) int64(tag int, x int64) {
+	b.uint64(tag, uint64(x))
+}
+
+// uint64s encodes a packed repeated field
+func (b *protobuf) uint64s(tag int, x []uint64) {
+	b.message(tag, func(b *protobuf) {
+		for _, i := range x {
+			b.varint(i)
+		}
+	})
+}
+
+func (b *protobuf) string(tag int, s string) {
+	b.key(tag, 2)
+	b.varint(uint64(len(s)))
+	b.data = append(b.data, s...)
+}
+
+// message encodes a length delimited field
+func (b *protobuf) message(tag int, f func(b *protobuf)) {
+	msg := &protobuf{}
+	f(msg)
+
+	b.key(tag, 2)
+	b.varint(uint64(len(msg.data)))
+	b.data = append(b.data, msg.data...)
+}
@@ -0,0 +1,269 @@
+package profiler
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/umbracle/minimal/state/runtime/evm"
+	"github.com/umbracle/minimal/types"
+)
+
+var _ evm.ContractTracer = &Profiler{}
+
+// Profiler is an evm tracer that aggregates the gas consumed during the
+// execution of the contracts by contract address, by opcode and by basic
+// block. A basic block starts at the beginning of the code or at a
+// JUMPDEST. The gas of a CALL or CREATE does not include the gas consumed
+// by the callee, which is accounted to the callee instead.
+// It is not safe to use it concurrently.
+type Profiler struct {
+	frames []*frame
+
+	contracts map[types.Address]*contractStats
+	opcodes   map[evm.OpCode]*Stats
+
+	// samples indexed by the call path of basic blocks
+	samples map[string]*sample
+}
+
+// Stats is the gas consumed by a set of instructions
+type Stats struct {
+	Gas   uint64
+	Count uint64
+}
+
+func (s *Stats) add(gas, count uint64) {
+	s.Gas += gas
+	s.Count += count
+}
+
+type contractStats struct {
+	Stats
+	calls  uint64
+	blocks map[uint64]*Stats
+}
+
+type location struct {
+	addr  types.Address
+	block uint64
+}
+
+type sample struct {
+	Stats
+	// call path from the leaf to the root
+	path []location
+}
+
+// frame is a contract being executed
+type frame struct {
+	addr  types.Address
+	block uint64
+
+	// gas available at the beginning of the call
+	gas uint64
+
+	// gas charged to the instructions, including the callees
+	used uint64
+
+	// gas consumed by the callee of the current instruction
+	child uint64
+
+	contract *contractStats
+	sample   *sample
+}
+
+// NewProfiler creates a new profiler
+func NewProfiler() *Profiler {
+	return &Profiler{
+		frames:    []*frame{},
+		contracts: map[types.Address]*contractStats{},
+		opcodes:   map[evm.OpCode]*Stats{},
+		samples:   map[string]*sample{},
+	}
+}
+
+// CaptureEnter implements the evm.ContractTracer interface
+func (p *Profiler) CaptureEnter(depth int, addr types.Address, gas uint64) {
+	contract, ok := p.contracts[addr]
+	if !ok {
+		contract = &contractStats{
+			blocks: map[uint64]*Stats{},
+		}
+		p.contracts[addr] = contract
+	}
+	contract.calls++
+
+	f := &frame{
+		addr:     addr,
+		gas:      gas,
+		contract: contract,
+	}
+	p.frames = append(p.frames, f)
+	p.enterBlock(f, 0)
+}
+
+// CaptureExit implements the evm.ContractTracer interface
+func (p *Profiler) CaptureExit(depth int, gasLeft uint64, err error) {
+	if len(p.frames) == 0 {
+		return
+	}
+	f := p.frames[len(p.frames)-1]
+	p.frames = p.frames[:len(p.frames)-1]
+
+	consumed := f.gas - gasLeft
+	if consumed > f.used {
+		// gas burned by the instruction that failed
+		p.charge(f, consumed-f.used, 0)
+	}
+	if len(p.frames) != 0 {
+		p.frames[len(p.frames)-1].child += consumed
+	}
+}
+
+// CaptureState implements the evm.Tracer interface
+func (p *Profiler) CaptureState(depth int, pc uint64, op evm.OpCode, gas, cost uint64, stack []*big.Int, memory []byte, err error) {
+	if len(p.frames) == 0 {
+		return
+	}
+	f := p.frames[len(p.frames)-1]
+	if op == evm.JUMPDEST {
+		p.enterBlock(f, pc)
+	}
+	f.used += cost
+
+	// remove the gas of the callee from the cost of the call
+	if f.child != 0 {
+		if cost > f.child {
+			cost -= f.child
+		} else {
+			cost = 0
+		}
+		f.child = 0
+	}
+
+	stats, ok := p.opcodes[op]
+	if !ok {
+		stats = &Stats{}
+		p.opcodes[op] = stats
+	}
+	stats.add(cost, 1)
+
+	p.charge(f, cost, 1)
+}
+
+func (p *Profiler) charge(f *frame, gas, count uint64) {
+	f.contract.add(gas, count)
+	f.contract.blocks[f.block].add(gas, count)
+	f.sample.add(gas, count)
+}
+
+// enterBlock sets the basic block the frame is executing
+func (p *Profiler) enterBlock(f *frame, block uint64) {
+	f.block = block
+	if _, ok := f.contract.blocks[block]; !ok {
+		f.contract.blocks[block] = &Stats{}
+	}
+
+	path := make([]location, len(p.frames))
+	var key bytes.Buffer
+	for i, frame := range p.frames {
+		path[len(path)-1-i] = location{frame.addr, frame.block}
+		key.Write(frame.addr.Bytes())
+		key.WriteString(strconv.FormatUint(frame.block, 10))
+	}
+
+	s, ok := p.samples[key.String()]
+	if !ok {
+		s = &sample{
+			path: path,
+		}
+		p.samples[key.String()] = s
+	}
+	f.sample = s
+}
+
+// Profile is the gas consumed by contract and by opcode
+type Profile struct {
+	Contracts []*ContractProfile `json:"contracts"`
+	Opcodes   []*OpcodeProfile   `json:"opcodes"`
+}
+
+// ContractProfile is the gas consumed by a contract
+type ContractProfile struct {
+	Address      types.Address   `json:"address"`
+	Gas          uint64          `json:"gas"`
+	Instructions uint64          `json:"instructions"`
+	Calls        uint64          `json:"calls"`
+	Blocks       []*BlockProfile `json:"blocks"`
+}
+
+// BlockProfile is the gas consumed by a basic block of a contract
+type BlockProfile struct {
+	Start        uint64 `json:"start"`
+	Gas          uint64 `json:"gas"`
+	Instructions uint64 `json:"instructions"`
+}
+
+// OpcodeProfile is the gas consumed by an opcode
+type OpcodeProfile struct {
+	Op    string `json:"op"`
+	Gas   uint64 `json:"gas"`
+	Count uint64 `json:"count"`
+}
+
+// Profile returns the gas aggregated so far sorted by gas
+func (p *Profiler) Profile() *Profile {
+	res := &Profile{
+		Contracts: []*ContractProfile{},
+		Opcodes:   []*OpcodeProfile{},
+	}
+	for addr, stats := range p.contracts {
+		contract := &ContractProfile{
+			Address:      addr,
+			Gas:          stats.Gas,
+			Instructions: stats.Count,
+			Calls:        stats.calls,
+			Blocks:       []*BlockProfile{},
+		}
+		for start, block := range stats.blocks {
+			contract.Blocks = append(contract.Blocks, &BlockProfile{
+				Start:        start,
+				Gas:          block.Gas,
+				Instructions: block.Count,
+			})
+		}
+		sort.Slice(contract.Blocks, func(i, j int) bool {
+			a, b := contract.Blocks[i], contract.Blocks[j]
+			if a.Gas != b.Gas {
+				return a.Gas > b.Gas
+			}
+			return a.Start < b.Start
+		})
+		res.Contracts = append(res.Contracts, contract)
+	}
+	sort.Slice(res.Contracts, func(i, j int) bool {
+		a, b := res.Contracts[i], res.Contracts[j]
+		if a.Gas != b.Gas {
+			return a.Gas > b.Gas
+		}
+		return bytes.Compare(a.Address.Bytes(), b.Address.Bytes()) < 0
+	})
+
+	for op, stats := range p.opcodes {
+		res.Opcodes = append(res.Opcodes, &OpcodeProfile{
+			Op:    op.String(),
+			Gas:   stats.Gas,
+			Count: stats.Count,
+		})
+	}
+	sort.Slice(res.Opcodes, func(i, j int) bool {
+		a, b := res.Opcodes[i], res.Opcodes[j]
+		if a.Gas != b.Gas {
+			return a.Gas > b.Gas
+		}
+		return a.Op < b.Op
+	})
+	return res
+}
@@ -0,0 +1,110 @@
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/minimal/chain"
+	"github.com/umbracle/minimal/state"
+	itrie "github.com/umbracle/minimal/state/immutable-trie"
+	"github.com/umbracle/minimal/state/runtime/evm"
+	"github.com/umbracle/minimal/types"
+)
+
+var (
+	callerAddr = types.StringToAddress("0x2000")
+	calleeAddr = types.StringToAddress("0x1000")
+)
+
+var (
+	// calls the callee with all the gas available
+	callerCode = []byte{
+		0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00,
+		0x61, 0x10, 0x00, 0x5a, 0xf1, 0x50, 0x00,
+	}
+
+	// jumps to the basic block at pc 5 that stores 1 in the slot 0
+	calleeCode = []byte{
+		0x60, 0x05, 0x56, 0x00, 0x00,
+		0x5b, 0x60, 0x01, 0x60, 0x00, 0x55, 0x00,
+	}
+)
+
+func profileCall(t *testing.T) *Profiler {
+	p := NewProfiler()
+
+	params := &chain.Params{
+		Forks: &chain.Forks{
+			Homestead:      chain.NewFork(0),
+			Byzantium:      chain.NewFork(0),
+			Constantinople: chain.NewFork(0),
+			Petersburg:     chain.NewFork(0),
+			EIP150:         chain.NewFork(0),
+			EIP155:         chain.NewFork(0),
+			EIP158:         chain.NewFork(0),
+		},
+	}
+
+	executor := state.NewExecutor(params, itrie.NewState(itrie.NewMemoryStorage()))
+	executor.GetHash = func(*types.Header) state.GetHashByNumber {
+		return func(i uint64) types.Hash {
+			return types.Hash{}
+		}
+	}
+	executor.SetRuntime(evm.NewEVMWithConfig(&evm.Config{Tracer: p}))
+
+	root := executor.WriteGenesis(chain.GenesisAlloc{
+		callerAddr: {Code: callerCode},
+		calleeAddr: {Code: calleeCode},
+	})
+
+	transition, err := executor.BeginTxn(root, &types.Header{GasLimit: 1000000})
+	assert.NoError(t, err)
+
+	_, _, err = transition.Call2(types.StringToAddress("0x1"), callerAddr, nil, big.NewInt(0), 100000)
+	assert.NoError(t, err)
+	return p
+}
+
+func TestProfiler(t *testing.T) {
+	profile := profileCall(t).Profile()
+
+	assert.Len(t, profile.Contracts, 2)
+
+	callee := profile.Contracts[0]
+	assert.Equal(t, calleeAddr, callee.Address)
+	assert.Equal(t, uint64(1), callee.Calls)
+	assert.Equal(t, uint64(20018), callee.Gas)
+	assert.Equal(t, uint64(7), callee.Instructions)
+	assert.Equal(t, []*BlockProfile{
+		{Start: 5, Gas: 20007, Instructions: 5},
+		{Start: 0, Gas: 11, Instructions: 2},
+	}, callee.Blocks)
+
+	// the gas of the callee is not included in the call
+	caller := profile.Contracts[1]
+	assert.Equal(t, callerAddr, caller.Address)
+	assert.Equal(t, uint64(722), caller.Gas)
+	assert.Equal(t, uint64(10), caller.Instructions)
+
+	assert.Equal(t, &OpcodeProfile{Op: "SSTORE", Gas: 20000, Count: 1}, profile.Opcodes[0])
+	assert.Equal(t, &OpcodeProfile{Op: "CALL", Gas: 700, Count: 1}, profile.Opcodes[1])
+}
+
+func TestProfilerPprof(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, profileCall(t).WritePprof(&buf))
+
+	zr, err := gzip.NewReader(&buf)
+	assert.NoError(t, err)
+	data, err := ioutil.ReadAll(zr)
+	assert.NoError(t, err)
+
+	// both contracts are functions in the string table
+	assert.True(t, bytes.Contains(data, []byte(callerAddr.String())))
+	assert.True(t, bytes.Contains(data, []byte(calleeAddr.String())))
+}
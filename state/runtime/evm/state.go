@@ -54,6 +54,8 @@ type state struct {
 	host   runtime.Host
 	msg    *runtime.Contract // change with msg
 	config *chain.ForksInTime
+	table  *dispatchTable
+	tracer Tracer
 
 	// memory
 	memory      []byte
@@ -71,8 +73,10 @@ type state struct {
 
 	gas uint64
 
-	// bitvec bitvec
-	bitmap bitmap
+	// bitmap with the valid jump destinations. It either points
+	// to localBitmap or to an entry in the jumpdest cache
+	bitmap      *bitmap
+	localBitmap bitmap
 
 	returnData []byte
 	ret        []byte
@@ -85,9 +89,11 @@ func (c *state) reset() {
 	c.lastGasCost = 0
 	c.stop = false
 	c.err = nil
+	c.tracer = nil
 
 	// reset bitmap
-	c.bitmap.reset()
+	c.bitmap = nil
+	c.localBitmap.reset()
 
 	// reset memory
 	for i := range c.memory {
@@ -101,6 +107,18 @@ func (c *state) reset() {
 	c.memory = c.memory[:0]
 }
 
+// setJumpdests sets the bitmap of valid jump destinations for the contract
+func (c *state) setJumpdests(contract *runtime.Contract, host runtime.Host) {
+	if c.evm.jumpdests != nil {
+		if b := c.evm.jumpdests.get(contract, host); b != nil {
+			c.bitmap = b
+			return
+		}
+	}
+	c.localBitmap.setCode(contract.Code)
+	c.bitmap = &c.localBitmap
+}
+
 func (c *state) validJumpdest(dest *big.Int) bool {
 	udest := dest.Uint64()
 	if dest.BitLen() >= 63 || udest >= uint64(len(c.code)) {
@@ -223,7 +241,7 @@ func (c *state) Run() ([]byte, error) {
 		//fmt.Printf("OP [%d]: %s (%d)\n", c.msg.Depth, op.String(), c.gas)
 		//fmt.Println(c.showStack())
 
-		inst := dispatchTable[op]
+		inst := c.table[op]
 		if inst.inst == nil {
 			c.exit(errOpCodeNotFound)
 			break
@@ -240,7 +258,11 @@ func (c *state) Run() ([]byte, error) {
 		}
 
 		// execute the instruction
-		inst.inst(c)
+		if c.tracer != nil {
+			c.traceInstruction(op, inst)
+		} else {
+			inst.inst(c)
+		}
 
 		// check if stack size exceeds the max size
 		if c.sp > stackSize {
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/minimal/chain"
 )
 
 type codeHelper struct {
@@ -25,6 +26,7 @@ func (c *codeHelper) pop() {
 
 func getState() (*state, func()) {
 	c := statePool.Get().(*state)
+	c.table = getDispatchTable(&chain.ForksInTime{})
 	return c, func() {
 		c.reset()
 		statePool.Put(c)
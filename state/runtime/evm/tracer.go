@@ -0,0 +1,54 @@
+package evm
+
+import (
+	"math/big"
+
+	"github.com/umbracle/minimal/types"
+)
+
+// Tracer is notified of every instruction executed by the EVM. The stack
+// (before the instruction, only for a StackTracer) and the memory (after the
+// instruction) are only valid during the call and must be copied if they have
+// to be retained.
+type Tracer interface {
+	CaptureState(depth int, pc uint64, op OpCode, gas, cost uint64, stack []*big.Int, memory []byte, err error)
+}
+
+// StackTracer is a Tracer that receives the stack of the instructions.
+// The stack is copied on every instruction, the tracers that do not
+// implement StackTracer (or do not enable it) receive a nil stack.
+type StackTracer interface {
+	Tracer
+
+	TraceStack() bool
+}
+
+// ContractTracer is a Tracer that is also notified when the EVM starts and
+// finishes the execution of a contract. gasLeft is zero if the execution
+// failed with an error other than a revert.
+type ContractTracer interface {
+	Tracer
+
+	CaptureEnter(depth int, addr types.Address, gas uint64)
+	CaptureExit(depth int, gasLeft uint64, err error)
+}
+
+// traceInstruction executes the instruction and reports it to the tracer
+func (c *state) traceInstruction(op OpCode, inst handler) {
+	pc := c.ip
+
+	// the static gas of the instruction has already been consumed
+	gas := c.gas + inst.gas
+
+	var stack []*big.Int
+	if c.evm.traceStack {
+		stack = make([]*big.Int, c.sp)
+		for i := range stack {
+			stack[i] = new(big.Int).Set(c.stack[i])
+		}
+	}
+
+	inst.inst(c)
+
+	c.tracer.CaptureState(c.msg.Depth, uint64(pc), op, gas, gas-c.gas, stack, c.memory, c.err)
+}
@@ -0,0 +1,73 @@
+package evm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/minimal/chain"
+	"github.com/umbracle/minimal/state/runtime"
+)
+
+type opsTracer struct {
+	ops    []OpCode
+	costs  []uint64
+	stacks [][]*big.Int
+}
+
+func (o *opsTracer) CaptureState(depth int, pc uint64, op OpCode, gas, cost uint64, stack []*big.Int, memory []byte, err error) {
+	o.ops = append(o.ops, op)
+	o.costs = append(o.costs, cost)
+	o.stacks = append(o.stacks, stack)
+}
+
+type stackTracer struct {
+	opsTracer
+}
+
+func (s *stackTracer) TraceStack() bool {
+	return true
+}
+
+func TestTracer(t *testing.T) {
+	tracer := &opsTracer{}
+
+	config := &Config{
+		Tracer: tracer,
+	}
+
+	// PUSH1 0x1, PUSH1 0x0, MSTORE
+	c := &runtime.Contract{
+		Code: []byte{PUSH1, 0x1, PUSH1, 0x0, MSTORE},
+		Gas:  1000,
+	}
+	_, gas, err := NewEVMWithConfig(config).Run(c, nil, &chain.ForksInTime{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []OpCode{PUSH1, PUSH1, MSTORE}, tracer.ops)
+	// MSTORE includes the memory expansion
+	assert.Equal(t, []uint64{3, 3, 6}, tracer.costs)
+	assert.Equal(t, uint64(1000-12), gas)
+
+	// the stack is only captured for the stack tracers
+	assert.Equal(t, [][]*big.Int{nil, nil, nil}, tracer.stacks)
+}
+
+func TestStackTracer(t *testing.T) {
+	tracer := &stackTracer{}
+
+	config := &Config{
+		Tracer: tracer,
+	}
+
+	// PUSH1 0x1, PUSH1 0x0, MSTORE
+	c := &runtime.Contract{
+		Code: []byte{PUSH1, 0x1, PUSH1, 0x0, MSTORE},
+		Gas:  1000,
+	}
+	_, _, err := NewEVMWithConfig(config).Run(c, nil, &chain.ForksInTime{})
+	assert.NoError(t, err)
+
+	one, zero := big.NewInt(1), big.NewInt(0)
+	assert.Equal(t, [][]*big.Int{{}, {one}, {one, zero}}, tracer.stacks)
+}
@@ -0,0 +1,583 @@
+package ewasm
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/umbracle/minimal/chain"
+	"github.com/umbracle/minimal/crypto"
+	"github.com/umbracle/minimal/state/runtime"
+	"github.com/umbracle/minimal/state/runtime/ewasm/wasm"
+	"github.com/umbracle/minimal/types"
+)
+
+// eei implements the Ethereum Environment Interface host functions
+// for a single contract execution.
+// https://github.com/ewasm/design/blob/master/eth_interface.md
+type eei struct {
+	contract *runtime.Contract
+	host     runtime.Host
+	config   *chain.ForksInTime
+
+	// ret is the output of finish or revert
+	ret []byte
+
+	// returnData is the output of the last call
+	returnData []byte
+}
+
+type eeiFunc struct {
+	params  []wasm.ValueType
+	results []wasm.ValueType
+	fn      func(e *eei, i *wasm.Instance, args []uint64) (uint64, error)
+}
+
+const (
+	i32 = wasm.I32
+	i64 = wasm.I64
+)
+
+func params(p ...wasm.ValueType) []wasm.ValueType {
+	return p
+}
+
+var eeiFuncs = map[string]*eeiFunc{
+	"useGas":              {params(i64), nil, eeiUseGas},
+	"getAddress":          {params(i32), nil, eeiGetAddress},
+	"getExternalBalance":  {params(i32, i32), nil, eeiGetExternalBalance},
+	"getBlockHash":        {params(i64, i32), params(i32), eeiGetBlockHash},
+	"call":                {params(i64, i32, i32, i32, i32), params(i32), eeiCall(runtime.Call)},
+	"callCode":            {params(i64, i32, i32, i32, i32), params(i32), eeiCall(runtime.CallCode)},
+	"callDelegate":        {params(i64, i32, i32, i32), params(i32), eeiCall(runtime.DelegateCall)},
+	"callStatic":          {params(i64, i32, i32, i32), params(i32), eeiCall(runtime.StaticCall)},
+	"create":              {params(i32, i32, i32, i32), params(i32), eeiCreate},
+	"callDataCopy":        {params(i32, i32, i32), nil, eeiCallDataCopy},
+	"getCallDataSize":     {nil, params(i32), eeiGetCallDataSize},
+	"codeCopy":            {params(i32, i32, i32), nil, eeiCodeCopy},
+	"getCodeSize":         {nil, params(i32), eeiGetCodeSize},
+	"externalCodeCopy":    {params(i32, i32, i32, i32), nil, eeiExternalCodeCopy},
+	"getExternalCodeSize": {params(i32), params(i32), eeiGetExternalCodeSize},
+	"storageStore":        {params(i32, i32), nil, eeiStorageStore},
+	"storageLoad":         {params(i32, i32), nil, eeiStorageLoad},
+	"getCaller":           {params(i32), nil, eeiGetCaller},
+	"getCallValue":        {params(i32), nil, eeiGetCallValue},
+	"getBlockCoinbase":    {params(i32), nil, eeiGetBlockCoinbase},
+	"getBlockDifficulty":  {params(i32), nil, eeiGetBlockDifficulty},
+	"getBlockGasLimit":    {nil, params(i64), eeiGetBlockGasLimit},
+	"getBlockNumber":      {nil, params(i64), eeiGetBlockNumber},
+	"getBlockTimestamp":   {nil, params(i64), eeiGetBlockTimestamp},
+	"getTxGasPrice":       {params(i32), nil, eeiGetTxGasPrice},
+	"getTxOrigin":         {params(i32), nil, eeiGetTxOrigin},
+	"getGasLeft":          {nil, params(i64), eeiGetGasLeft},
+	"log":                 {params(i32, i32, i32, i32, i32, i32, i32), nil, eeiLog},
+	"finish":              {params(i32, i32), nil, eeiFinish},
+	"revert":              {params(i32, i32), nil, eeiRevert},
+	"getReturnDataSize":   {nil, params(i32), eeiGetReturnDataSize},
+	"returnDataCopy":      {params(i32, i32, i32), nil, eeiReturnDataCopy},
+	"selfDestruct":        {params(i32), nil, eeiSelfDestruct},
+}
+
+func (e *eei) resolve(module, field string) (*wasm.HostFunc, error) {
+	if module != "ethereum" {
+		return nil, fmt.Errorf("module %s not found", module)
+	}
+	f, ok := eeiFuncs[field]
+	if !ok {
+		return nil, fmt.Errorf("function %s.%s not found", module, field)
+	}
+	host := &wasm.HostFunc{
+		Type: &wasm.FuncType{Params: f.params, Results: f.results},
+		Fn: func(i *wasm.Instance, args []uint64) (uint64, error) {
+			return f.fn(e, i, args)
+		},
+	}
+	return host, nil
+}
+
+// gas costs of the host functions
+const (
+	gasBase         uint64 = 2
+	gasCopyWord     uint64 = 3
+	gasBalance      uint64 = 400
+	gasBlockHash    uint64 = 20
+	gasExtCode      uint64 = 700
+	gasSload        uint64 = 200
+	gasCall         uint64 = 700
+	gasCallValue    uint64 = 9000
+	gasCallStipend  uint64 = 2300
+	gasNewAccount   uint64 = 25000
+	gasCreate       uint64 = 32000
+	gasLog          uint64 = 375
+	gasLogData      uint64 = 8
+	gasSelfDestruct uint64 = 5000
+)
+
+// --- memory helpers ---
+
+func readAddress(i *wasm.Instance, offset uint64) (types.Address, error) {
+	buf, err := i.Read(uint32(offset), types.AddressLength)
+	if err != nil {
+		return types.Address{}, err
+	}
+	return types.BytesToAddress(buf), nil
+}
+
+func readHash(i *wasm.Instance, offset uint64) (types.Hash, error) {
+	buf, err := i.Read(uint32(offset), types.HashLength)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	return types.BytesToHash(buf), nil
+}
+
+// readU128 reads a 128 bits little endian number
+func readU128(i *wasm.Instance, offset uint64) (*big.Int, error) {
+	buf, err := i.Read(uint32(offset), 16)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(reverse(buf)), nil
+}
+
+// writeLittleEndian writes a number as a little endian integer of the given size
+func writeLittleEndian(i *wasm.Instance, offset uint64, n *big.Int, size int) error {
+	buf := make([]byte, size)
+	b := n.Bytes()
+	if len(b) > size {
+		b = b[len(b)-size:]
+	}
+	copy(buf[size-len(b):], b)
+	return i.Write(uint32(offset), reverse(buf))
+}
+
+func reverse(b []byte) []byte {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return b
+}
+
+// copyGas returns the gas cost to copy size bytes
+func copyGas(size uint64) uint64 {
+	return gasBase + ((size+31)/32)*gasCopyWord
+}
+
+// copyToMemory copies src[offset:offset+size] into the memory. Out of bounds bytes of
+// src are filled with zeros
+func copyToMemory(i *wasm.Instance, resultOffset, offset, size uint64, src []byte) error {
+	if resultOffset+size > uint64(len(i.Memory())) {
+		return errBadOffset
+	}
+	buf := make([]byte, size)
+	if offset < uint64(len(src)) {
+		copy(buf, src[offset:])
+	}
+	return i.Write(uint32(resultOffset), buf)
+}
+
+// --- host functions ---
+
+func eeiUseGas(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	return 0, i.UseGas(args[0])
+}
+
+func eeiGetAddress(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	if err := i.UseGas(gasBase); err != nil {
+		return 0, err
+	}
+	return 0, i.Write(uint32(args[0]), e.contract.Address.Bytes())
+}
+
+func eeiGetExternalBalance(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	if err := i.UseGas(gasBalance); err != nil {
+		return 0, err
+	}
+	addr, err := readAddress(i, args[0])
+	if err != nil {
+		return 0, err
+	}
+	return 0, writeLittleEndian(i, args[1], e.host.GetBalance(addr), 16)
+}
+
+func eeiGetBlockHash(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	if err := i.UseGas(gasBlockHash); err != nil {
+		return 0, err
+	}
+	n := int64(args[0])
+	lastBlock := e.host.GetTxContext().Number
+	if !(lastBlock-257 < n && n < lastBlock) {
+		return 1, nil
+	}
+	return 0, i.Write(uint32(args[1]), e.host.GetBlockHash(n).Bytes())
+}
+
+func eeiCall(typ runtime.CallType) func(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	return func(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+		addr, err := readAddress(i, args[1])
+		if err != nil {
+			return 0, err
+		}
+
+		value := big.NewInt(0)
+		dataArgs := args[2:]
+		if typ == runtime.Call || typ == runtime.CallCode {
+			if value, err = readU128(i, args[2]); err != nil {
+				return 0, err
+			}
+			dataArgs = args[3:]
+		}
+		input, err := i.Read(uint32(dataArgs[0]), uint32(dataArgs[1]))
+		if err != nil {
+			return 0, err
+		}
+
+		transfersValue := value.Sign() != 0
+		if transfersValue && typ == runtime.Call && e.contract.Static {
+			return 0, errReadOnly
+		}
+
+		gasCost := gasCall
+		if transfersValue {
+			gasCost += gasCallValue
+		}
+		if typ == runtime.Call && transfersValue && e.host.Empty(addr) {
+			gasCost += gasNewAccount
+		}
+		if err := i.UseGas(gasCost); err != nil {
+			return 0, err
+		}
+
+		// forward all but one 64th of the remaining gas
+		gas := args[0]
+		if available := i.Gas - i.Gas/64; gas > available {
+			gas = available
+		}
+		if err := i.UseGas(gas); err != nil {
+			return 0, err
+		}
+		if transfersValue {
+			gas += gasCallStipend
+		}
+
+		e.returnData = nil
+		if transfersValue && e.host.GetBalance(e.contract.Address).Cmp(value) < 0 {
+			i.Gas += gas
+			return 1, nil
+		}
+
+		contract := runtime.NewContractCall(e.contract.Depth+1, e.contract.Origin, e.contract.Address, addr, value, gas, e.host.GetCode(addr), input)
+		contract.Type = typ
+		contract.Static = e.contract.Static || typ == runtime.StaticCall
+		if typ == runtime.CallCode || typ == runtime.DelegateCall {
+			contract.Address = e.contract.Address
+			if typ == runtime.DelegateCall {
+				contract.Value = e.contract.Value
+				contract.Caller = e.contract.Caller
+			}
+		}
+
+		ret, gasLeft, err := e.host.Callx(contract, e.host)
+		i.Gas += gasLeft
+		e.returnData = ret
+
+		switch err {
+		case nil:
+			return 0, nil
+		case runtime.ErrExecutionReverted:
+			return 2, nil
+		default:
+			return 1, nil
+		}
+	}
+}
+
+func eeiCreate(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	if e.contract.Static {
+		return 0, errReadOnly
+	}
+	value, err := readU128(i, args[0])
+	if err != nil {
+		return 0, err
+	}
+	input, err := i.Read(uint32(args[1]), uint32(args[2]))
+	if err != nil {
+		return 0, err
+	}
+	if err := i.UseGas(gasCreate); err != nil {
+		return 0, err
+	}
+
+	gas := i.Gas - i.Gas/64
+	if err := i.UseGas(gas); err != nil {
+		return 0, err
+	}
+
+	e.returnData = nil
+	if e.host.GetBalance(e.contract.Address).Cmp(value) < 0 {
+		i.Gas += gas
+		return 1, nil
+	}
+
+	address := crypto.CreateAddress(e.contract.Address, e.host.GetNonce(e.contract.Address))
+	contract := runtime.NewContractCreation(e.contract.Depth+1, e.contract.Origin, e.contract.Address, address, value, gas, input)
+	contract.Type = runtime.Create
+
+	ret, gasLeft, err := e.host.Callx(contract, e.host)
+	i.Gas += gasLeft
+
+	switch err {
+	case nil:
+		return 0, i.Write(uint32(args[3]), address.Bytes())
+	case runtime.ErrExecutionReverted:
+		e.returnData = ret
+		return 2, nil
+	default:
+		return 1, nil
+	}
+}
+
+func eeiCallDataCopy(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	if err := i.UseGas(copyGas(args[2])); err != nil {
+		return 0, err
+	}
+	return 0, copyToMemory(i, args[0], args[1], args[2], e.contract.Input)
+}
+
+func eeiGetCallDataSize(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	return uint64(len(e.contract.Input)), i.UseGas(gasBase)
+}
+
+func eeiCodeCopy(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	if err := i.UseGas(copyGas(args[2])); err != nil {
+		return 0, err
+	}
+	return 0, copyToMemory(i, args[0], args[1], args[2], e.contract.Code)
+}
+
+func eeiGetCodeSize(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	return uint64(len(e.contract.Code)), i.UseGas(gasBase)
+}
+
+func eeiExternalCodeCopy(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	if err := i.UseGas(gasExtCode + copyGas(args[3])); err != nil {
+		return 0, err
+	}
+	addr, err := readAddress(i, args[0])
+	if err != nil {
+		return 0, err
+	}
+	return 0, copyToMemory(i, args[1], args[2], args[3], e.host.GetCode(addr))
+}
+
+func eeiGetExternalCodeSize(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	if err := i.UseGas(gasExtCode); err != nil {
+		return 0, err
+	}
+	addr, err := readAddress(i, args[0])
+	if err != nil {
+		return 0, err
+	}
+	return uint64(e.host.GetCodeSize(addr)), nil
+}
+
+func eeiStorageStore(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	if e.contract.Static {
+		return 0, errReadOnly
+	}
+	key, err := readHash(i, args[0])
+	if err != nil {
+		return 0, err
+	}
+	value, err := readHash(i, args[1])
+	if err != nil {
+		return 0, err
+	}
+
+	discount := e.config.Constantinople && !e.config.Petersburg
+
+	// the refunds of the cleared slots are added by the host,
+	// the cost is the same as the one of the evm sstore
+	status := e.host.SetStorage(e.contract.Address, key, value, discount)
+	cost := uint64(0)
+
+	switch status {
+	case runtime.StorageUnchanged:
+		if !discount {
+			cost = 5000
+		} else {
+			cost = 200
+		}
+	case runtime.StorageModified:
+		cost = 5000
+	case runtime.StorageModifiedAgain:
+		if !discount {
+			cost = 5000
+		} else {
+			cost = 200
+		}
+	case runtime.StorageAdded:
+		cost = 20000
+	case runtime.StorageDeleted:
+		cost = 5000
+	}
+	return 0, i.UseGas(cost)
+}
+
+func eeiStorageLoad(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	if err := i.UseGas(gasSload); err != nil {
+		return 0, err
+	}
+	key, err := readHash(i, args[0])
+	if err != nil {
+		return 0, err
+	}
+	value := e.host.GetStorage(e.contract.Address, key)
+	return 0, i.Write(uint32(args[1]), value.Bytes())
+}
+
+func eeiGetCaller(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	if err := i.UseGas(gasBase); err != nil {
+		return 0, err
+	}
+	return 0, i.Write(uint32(args[0]), e.contract.Caller.Bytes())
+}
+
+func eeiGetCallValue(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	if err := i.UseGas(gasBase); err != nil {
+		return 0, err
+	}
+	value := e.contract.Value
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	return 0, writeLittleEndian(i, args[0], value, 16)
+}
+
+func eeiGetBlockCoinbase(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	if err := i.UseGas(gasBase); err != nil {
+		return 0, err
+	}
+	return 0, i.Write(uint32(args[0]), e.host.GetTxContext().Coinbase.Bytes())
+}
+
+func eeiGetBlockDifficulty(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	if err := i.UseGas(gasBase); err != nil {
+		return 0, err
+	}
+	difficulty := new(big.Int).SetBytes(e.host.GetTxContext().Difficulty.Bytes())
+	return 0, writeLittleEndian(i, args[0], difficulty, 32)
+}
+
+func eeiGetBlockGasLimit(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	return uint64(e.host.GetTxContext().GasLimit), i.UseGas(gasBase)
+}
+
+func eeiGetBlockNumber(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	return uint64(e.host.GetTxContext().Number), i.UseGas(gasBase)
+}
+
+func eeiGetBlockTimestamp(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	return uint64(e.host.GetTxContext().Timestamp), i.UseGas(gasBase)
+}
+
+func eeiGetTxGasPrice(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	if err := i.UseGas(gasBase); err != nil {
+		return 0, err
+	}
+	price := new(big.Int).SetBytes(e.host.GetTxContext().GasPrice.Bytes())
+	return 0, writeLittleEndian(i, args[0], price, 16)
+}
+
+func eeiGetTxOrigin(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	if err := i.UseGas(gasBase); err != nil {
+		return 0, err
+	}
+	return 0, i.Write(uint32(args[0]), e.host.GetTxContext().Origin.Bytes())
+}
+
+func eeiGetGasLeft(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	if err := i.UseGas(gasBase); err != nil {
+		return 0, err
+	}
+	return i.Gas, nil
+}
+
+func eeiLog(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	if e.contract.Static {
+		return 0, errReadOnly
+	}
+	num := uint32(args[2])
+	if num > 4 {
+		return 0, fmt.Errorf("too many topics")
+	}
+	if err := i.UseGas(gasLog + uint64(num)*gasLog + uint64(uint32(args[1]))*gasLogData); err != nil {
+		return 0, err
+	}
+
+	data, err := i.Read(uint32(args[0]), uint32(args[1]))
+	if err != nil {
+		return 0, err
+	}
+	topics := make([]types.Hash, num)
+	for j := uint32(0); j < num; j++ {
+		if topics[j], err = readHash(i, args[3+j]); err != nil {
+			return 0, err
+		}
+	}
+	e.host.EmitLog(e.contract.Address, topics, data)
+	return 0, nil
+}
+
+func eeiFinish(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	ret, err := i.Read(uint32(args[0]), uint32(args[1]))
+	if err != nil {
+		return 0, err
+	}
+	e.ret = ret
+	return 0, errFinish
+}
+
+func eeiRevert(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	ret, err := i.Read(uint32(args[0]), uint32(args[1]))
+	if err != nil {
+		return 0, err
+	}
+	e.ret = ret
+	return 0, errRevert
+}
+
+func eeiGetReturnDataSize(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	return uint64(len(e.returnData)), i.UseGas(gasBase)
+}
+
+func eeiReturnDataCopy(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	if err := i.UseGas(copyGas(args[2])); err != nil {
+		return 0, err
+	}
+	offset, size := uint64(uint32(args[1])), uint64(uint32(args[2]))
+	if offset+size > uint64(len(e.returnData)) {
+		return 0, errBadOffset
+	}
+	return 0, i.Write(uint32(args[0]), e.returnData[offset:offset+size])
+}
+
+func eeiSelfDestruct(e *eei, i *wasm.Instance, args []uint64) (uint64, error) {
+	if e.contract.Static {
+		return 0, errReadOnly
+	}
+	addr, err := readAddress(i, args[0])
+	if err != nil {
+		return 0, err
+	}
+
+	gas := gasSelfDestruct
+	if e.host.Empty(addr) && e.host.GetBalance(e.contract.Address).Sign() != 0 {
+		gas += gasNewAccount
+	}
+	if err := i.UseGas(gas); err != nil {
+		return 0, err
+	}
+
+	e.host.Selfdestruct(e.contract.Address, addr)
+	return 0, errFinish
+}
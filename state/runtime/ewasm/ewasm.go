@@ -0,0 +1,124 @@
+package ewasm
+
+import (
+	"bytes"
+	"errors"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/umbracle/minimal/chain"
+	"github.com/umbracle/minimal/crypto"
+	"github.com/umbracle/minimal/state/runtime"
+	"github.com/umbracle/minimal/state/runtime/ewasm/wasm"
+	"github.com/umbracle/minimal/types"
+)
+
+var _ runtime.Runtime = &EWASM{}
+
+var (
+	errFinish    = errors.New("finish")
+	errRevert    = errors.New("revert")
+	errReadOnly  = errors.New("read only")
+	errNoMain    = errors.New("main function not found")
+	errBadOffset = errors.New("copy out of bounds")
+)
+
+// moduleCacheSize is the number of metered modules kept in memory
+const moduleCacheSize = 256
+
+var emptyCodeHash = types.BytesToHash(crypto.Keccak256(nil))
+
+// EWASM is the runtime for WebAssembly contracts that follow
+// the Ethereum Environment Interface (EEI)
+type EWASM struct {
+	// modules are the metered modules indexed by the hash of the code,
+	// they are shared by the instances and must not be modified
+	modules *lru.Cache
+}
+
+// NewEWASM creates a new ewasm runtime
+func NewEWASM() *EWASM {
+	modules, err := lru.New(moduleCacheSize)
+	if err != nil {
+		panic(err)
+	}
+	return &EWASM{modules: modules}
+}
+
+// CanRun implements the runtime interface
+func (e *EWASM) CanRun(c *runtime.Contract, host runtime.Host, config *chain.ForksInTime) bool {
+	return config.EWASM && bytes.HasPrefix(c.Code, wasm.Magic)
+}
+
+// Name implements the runtime interface
+func (e *EWASM) Name() string {
+	return "ewasm"
+}
+
+// Run implements the runtime interface
+func (e *EWASM) Run(c *runtime.Contract, host runtime.Host, config *chain.ForksInTime) ([]byte, uint64, error) {
+	module, err := e.getModule(c, host)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// the initial memory is charged like the memory grown
+	// later and before it is allocated
+	var memoryGas uint64
+	if module.Memory != nil {
+		memoryGas = wasm.MemoryCost(uint64(module.Memory.Min))
+	}
+	if memoryGas > c.Gas {
+		return nil, 0, wasm.ErrOutOfGas
+	}
+
+	ctx := &eei{
+		contract: c,
+		host:     host,
+		config:   config,
+	}
+	instance, err := wasm.NewInstance(module, ctx.resolve)
+	if err != nil {
+		return nil, 0, err
+	}
+	instance.Gas = c.Gas - memoryGas
+
+	if err = instance.Start(); err == nil {
+		_, err = instance.Invoke("main")
+	}
+
+	switch err {
+	case nil, errFinish:
+		return ctx.ret, instance.Gas, nil
+	case errRevert:
+		return ctx.ret, instance.Gas, runtime.ErrExecutionReverted
+	default:
+		return nil, 0, err
+	}
+}
+
+// getModule returns the metered module of the contract. The module is
+// cached unless the code is the init code of a contract creation, which
+// is not indexed by any hash.
+func (e *EWASM) getModule(c *runtime.Contract, host runtime.Host) (*wasm.Module, error) {
+	hash := host.GetCodeHash(c.CodeAddress)
+	cache := hash != emptyCodeHash && hash != (types.Hash{})
+	if cache {
+		if module, ok := e.modules.Get(hash); ok {
+			return module.(*wasm.Module), nil
+		}
+	}
+
+	module, err := wasm.ParseModule(c.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !module.ExportsFunction("main") {
+		return nil, errNoMain
+	}
+	module.InjectMetering(wasm.DefaultCost)
+
+	if cache {
+		e.modules.Add(hash, module)
+	}
+	return module, nil
+}
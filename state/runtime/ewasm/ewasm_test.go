@@ -0,0 +1,107 @@
+package ewasm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/minimal/chain"
+	"github.com/umbracle/minimal/helper/hex"
+	"github.com/umbracle/minimal/state/runtime"
+	"github.com/umbracle/minimal/state/runtime/ewasm/wasm"
+	"github.com/umbracle/minimal/types"
+)
+
+// storeAndFinish is a module that stores 1 under the key 0
+// and finishes with the stored value:
+//
+// (module
+//
+//	(import "ethereum" "storageStore" (func $store (param i32 i32)))
+//	(import "ethereum" "finish" (func $finish (param i32 i32)))
+//	(memory (export "memory") 1)
+//	(data (i32.const 0) "\00...\00\01")
+//	(func (export "main")
+//	  (call $store (i32.const 0) (i32.const 32))
+//	  (call $finish (i32.const 32) (i32.const 32))))
+var storeAndFinish = "0x0061736d0100000001090260027f7f00600000022b0208657468657265756d0c73746f7261676553746f7265000008657468657265756d0666696e6973680000030201010503010001071102046d61696e0002066d656d6f727902000a10010e004100412010004120412010010b0b46010041000b4000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001"
+
+type mockHost struct {
+	runtime.Host
+	storage  map[types.Hash]types.Hash
+	codeHash types.Hash
+}
+
+func (m *mockHost) GetCodeHash(addr types.Address) types.Hash {
+	return m.codeHash
+}
+
+func (m *mockHost) SetStorage(addr types.Address, key types.Hash, value types.Hash, discount bool) runtime.StorageStatus {
+	m.storage[key] = value
+	return runtime.StorageAdded
+}
+
+func TestEWASMCanRun(t *testing.T) {
+	e := NewEWASM()
+	c := &runtime.Contract{Code: hex.MustDecodeHex(storeAndFinish)}
+
+	assert.False(t, e.CanRun(c, nil, &chain.ForksInTime{}))
+	assert.True(t, e.CanRun(c, nil, &chain.ForksInTime{EWASM: true}))
+
+	// evm bytecode
+	c.Code = []byte{0x60, 0x01}
+	assert.False(t, e.CanRun(c, nil, &chain.ForksInTime{EWASM: true}))
+}
+
+func TestEWASMRun(t *testing.T) {
+	host := &mockHost{storage: map[types.Hash]types.Hash{}}
+	c := &runtime.Contract{
+		Code: hex.MustDecodeHex(storeAndFinish),
+		Gas:  100000,
+	}
+
+	ret, gas, err := NewEWASM().Run(c, host, &chain.ForksInTime{EWASM: true})
+	assert.NoError(t, err)
+
+	one := types.BytesToHash([]byte{0x1})
+	assert.Equal(t, one.Bytes(), ret)
+	assert.Equal(t, one, host.storage[types.Hash{}])
+
+	// 7 instructions, a new storage slot and a page of memory
+	assert.Equal(t, uint64(100000-7-20000)-wasm.MemoryCost(1), gas)
+}
+
+func TestEWASMModuleCache(t *testing.T) {
+	e := NewEWASM()
+
+	run := func(host *mockHost) {
+		c := &runtime.Contract{
+			Code: hex.MustDecodeHex(storeAndFinish),
+			Gas:  100000,
+		}
+		_, gas, err := e.Run(c, host, &chain.ForksInTime{EWASM: true})
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(100000-7-20000)-wasm.MemoryCost(1), gas)
+	}
+
+	// the init code of a contract creation is not cached
+	run(&mockHost{storage: map[types.Hash]types.Hash{}})
+	assert.Equal(t, 0, e.modules.Len())
+
+	// the module is metered once and reused
+	host := &mockHost{storage: map[types.Hash]types.Hash{}, codeHash: types.StringToHash("1")}
+	run(host)
+	run(host)
+	assert.Equal(t, 1, e.modules.Len())
+}
+
+func TestEWASMOutOfGas(t *testing.T) {
+	host := &mockHost{storage: map[types.Hash]types.Hash{}}
+	c := &runtime.Contract{
+		Code: hex.MustDecodeHex(storeAndFinish),
+		Gas:  1000,
+	}
+
+	_, gas, err := NewEWASM().Run(c, host, &chain.ForksInTime{EWASM: true})
+	assert.Error(t, err)
+	assert.Equal(t, uint64(0), gas)
+}
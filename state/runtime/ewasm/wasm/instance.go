@@ -0,0 +1,865 @@
+package wasm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+const (
+	// PageSize is the size of a memory page
+	PageSize = 65536
+
+	// maxPages is the max number of memory pages if the module does not specify one
+	maxPages = 1024
+
+	// maxCallDepth is the max depth of the call stack
+	maxCallDepth = 1024
+
+	// maxStackSize is the max number of values in the stack
+	maxStackSize = 1 << 20
+)
+
+var (
+	// ErrOutOfGas is returned when the instance runs out of gas
+	ErrOutOfGas = errors.New("out of gas")
+
+	errUnreachable          = errors.New("unreachable executed")
+	errCallStackExhausted   = errors.New("call stack exhausted")
+	errStackOverflow        = errors.New("stack overflow")
+	errStackUnderflow       = errors.New("stack underflow")
+	errOutOfBoundsMemory    = errors.New("out of bounds memory access")
+	errIntegerDivideByZero  = errors.New("integer divide by zero")
+	errIntegerOverflow      = errors.New("integer overflow")
+	errIndirectCallMismatch = errors.New("indirect call type mismatch")
+	errUndefinedElement     = errors.New("undefined element")
+)
+
+// HostFunc is a function implemented by the host
+type HostFunc struct {
+	Type *FuncType
+	Fn   func(i *Instance, args []uint64) (uint64, error)
+}
+
+// Resolver resolves the imports of a module
+type Resolver func(module, field string) (*HostFunc, error)
+
+type function struct {
+	typ  *FuncType
+	host *HostFunc
+	code *Code
+}
+
+type label struct {
+	cont   int
+	height int
+	arity  int
+	loop   bool
+}
+
+// Instance is an instantiated wasm module
+type Instance struct {
+	module    *Module
+	functions []*function
+	table     []*uint32
+	globals   []uint64
+	memory    []byte
+	maxPages  uint32
+	stack     []uint64
+	depth     int
+
+	// Gas is the gas available for the execution
+	Gas uint64
+}
+
+// NewInstance instantiates a module. It does not run the start function.
+// The module is not modified, it can be instantiated many times at once.
+func NewInstance(m *Module, resolver Resolver) (*Instance, error) {
+	i := &Instance{
+		module: m,
+		stack:  make([]uint64, 0, 1024),
+	}
+
+	for _, imp := range m.Imports {
+		host, err := resolver(imp.Module, imp.Field)
+		if err != nil {
+			return nil, err
+		}
+		if !host.Type.Equal(m.Types[imp.Type]) {
+			return nil, fmt.Errorf("import %s.%s has a wrong signature", imp.Module, imp.Field)
+		}
+		i.functions = append(i.functions, &function{typ: m.Types[imp.Type], host: host})
+	}
+	for indx, typ := range m.Functions {
+		i.functions = append(i.functions, &function{typ: m.Types[typ], code: m.Codes[indx]})
+	}
+
+	for _, g := range m.Globals {
+		i.globals = append(i.globals, g.Init)
+	}
+
+	if m.Table != nil {
+		i.table = make([]*uint32, m.Table.Min)
+	}
+	for _, e := range m.Elements {
+		if uint64(e.Offset)+uint64(len(e.Funcs)) > uint64(len(i.table)) {
+			return nil, fmt.Errorf("elements segment does not fit")
+		}
+		for j, f := range e.Funcs {
+			if int(f) >= len(i.functions) {
+				return nil, fmt.Errorf("function %d not found", f)
+			}
+			f := f
+			i.table[int(e.Offset)+j] = &f
+		}
+	}
+
+	i.maxPages = maxPages
+	if m.Memory != nil {
+		if m.Memory.Max != nil && *m.Memory.Max < i.maxPages {
+			i.maxPages = *m.Memory.Max
+		}
+		if m.Memory.Min > i.maxPages {
+			return nil, fmt.Errorf("memory too large")
+		}
+		i.memory = make([]byte, int(m.Memory.Min)*PageSize)
+	}
+	for _, d := range m.Data {
+		if uint64(d.Offset)+uint64(len(d.Init)) > uint64(len(i.memory)) {
+			return nil, fmt.Errorf("data segment does not fit")
+		}
+		copy(i.memory[d.Offset:], d.Init)
+	}
+	return i, nil
+}
+
+// resolveBlocks sets the position of the else and end instructions of each block
+func resolveBlocks(body []instr) error {
+	blocks := []int{}
+	for pc := range body {
+		switch body[pc].op {
+		case opBlock, opLoop, opIf:
+			body[pc].els = -1
+			blocks = append(blocks, pc)
+
+		case opElse:
+			if len(blocks) == 0 || body[blocks[len(blocks)-1]].op != opIf {
+				return fmt.Errorf("else without if")
+			}
+			body[blocks[len(blocks)-1]].els = pc
+
+		case opEnd:
+			if len(blocks) == 0 {
+				// end of the function
+				if pc != len(body)-1 {
+					return fmt.Errorf("unexpected end")
+				}
+				continue
+			}
+			start := blocks[len(blocks)-1]
+			blocks = blocks[:len(blocks)-1]
+
+			body[start].end = pc
+			if els := body[start].els; els != -1 {
+				body[els].end = pc
+			}
+		}
+	}
+	if len(blocks) != 0 {
+		return fmt.Errorf("block not terminated")
+	}
+	return nil
+}
+
+// Memory returns the memory of the instance
+func (i *Instance) Memory() []byte {
+	return i.memory
+}
+
+// MemoryCost returns the gas cost of a memory with the given number of pages.
+// Like the memory of the EVM, the cost is linear with the number of words
+// and quadratic for large memories.
+func MemoryCost(pages uint64) uint64 {
+	if pages > maxPages {
+		// the memory cannot be allocated
+		return math.MaxUint64
+	}
+	words := pages * PageSize / 32
+	return words*3 + words*words/512
+}
+
+// UseGas consumes gas from the instance
+func (i *Instance) UseGas(gas uint64) error {
+	if i.Gas < gas {
+		i.Gas = 0
+		return ErrOutOfGas
+	}
+	i.Gas -= gas
+	return nil
+}
+
+// Read returns a copy of a slice of the memory
+func (i *Instance) Read(offset, size uint32) ([]byte, error) {
+	if uint64(offset)+uint64(size) > uint64(len(i.memory)) {
+		return nil, errOutOfBoundsMemory
+	}
+	buf := make([]byte, size)
+	copy(buf, i.memory[offset:])
+	return buf, nil
+}
+
+// Write writes a buffer into the memory
+func (i *Instance) Write(offset uint32, buf []byte) error {
+	if uint64(offset)+uint64(len(buf)) > uint64(len(i.memory)) {
+		return errOutOfBoundsMemory
+	}
+	copy(i.memory[offset:], buf)
+	return nil
+}
+
+// Invoke calls an exported function
+func (i *Instance) Invoke(name string, args ...uint64) (res uint64, err error) {
+	if !i.module.ExportsFunction(name) {
+		return 0, fmt.Errorf("function %s not exported", name)
+	}
+	return i.invoke(i.module.Exports[name].Index, args)
+}
+
+// Start runs the start function of the module if any
+func (i *Instance) Start() error {
+	if i.module.Start == nil {
+		return nil
+	}
+	_, err := i.invoke(*i.module.Start, nil)
+	return err
+}
+
+func (i *Instance) invoke(indx uint32, args []uint64) (res uint64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("wasm trap: %v", r)
+		}
+	}()
+
+	if int(indx) >= len(i.functions) {
+		return 0, fmt.Errorf("function %d not found", indx)
+	}
+	f := i.functions[indx]
+	if len(args) != len(f.typ.Params) {
+		return 0, fmt.Errorf("expected %d arguments but found %d", len(f.typ.Params), len(args))
+	}
+
+	i.stack = append(i.stack[:0], args...)
+	if err := i.call(indx); err != nil {
+		return 0, err
+	}
+	if len(f.typ.Results) != 0 {
+		res = i.stack[len(i.stack)-1]
+	}
+	return res, nil
+}
+
+func (i *Instance) push(v uint64) error {
+	if len(i.stack) >= maxStackSize {
+		return errStackOverflow
+	}
+	i.stack = append(i.stack, v)
+	return nil
+}
+
+func (i *Instance) pop() (uint64, error) {
+	if len(i.stack) == 0 {
+		return 0, errStackUnderflow
+	}
+	v := i.stack[len(i.stack)-1]
+	i.stack = i.stack[:len(i.stack)-1]
+	return v, nil
+}
+
+func (i *Instance) call(indx uint32) error {
+	if int(indx) >= len(i.functions) {
+		return fmt.Errorf("function %d not found", indx)
+	}
+	f := i.functions[indx]
+
+	n := len(f.typ.Params)
+	if len(i.stack) < n {
+		return errStackUnderflow
+	}
+
+	if f.host != nil {
+		args := make([]uint64, n)
+		copy(args, i.stack[len(i.stack)-n:])
+		i.stack = i.stack[:len(i.stack)-n]
+
+		res, err := f.host.Fn(i, args)
+		if err != nil {
+			return err
+		}
+		if len(f.typ.Results) != 0 {
+			return i.push(res)
+		}
+		return nil
+	}
+
+	if i.depth >= maxCallDepth {
+		return errCallStackExhausted
+	}
+	i.depth++
+	defer func() {
+		i.depth--
+	}()
+
+	locals := make([]uint64, n+len(f.code.Locals))
+	copy(locals, i.stack[len(i.stack)-n:])
+	i.stack = i.stack[:len(i.stack)-n]
+
+	return i.exec(f, locals)
+}
+
+func (i *Instance) exec(f *function, locals []uint64) error {
+	body := f.code.Body
+	base := len(i.stack)
+
+	labels := []label{{cont: len(body), height: base, arity: len(f.typ.Results)}}
+
+	// branch to the label at the given depth
+	branch := func(depth uint64) (int, error) {
+		if depth >= uint64(len(labels)) {
+			return 0, fmt.Errorf("label %d not found", depth)
+		}
+		l := labels[len(labels)-1-int(depth)]
+
+		arity := l.arity
+		if l.loop {
+			arity = 0
+		}
+		if len(i.stack)-l.height < arity {
+			return 0, errStackUnderflow
+		}
+		copy(i.stack[l.height:], i.stack[len(i.stack)-arity:])
+		i.stack = i.stack[:l.height+arity]
+
+		if l.loop {
+			labels = labels[:len(labels)-int(depth)]
+		} else {
+			labels = labels[:len(labels)-1-int(depth)]
+		}
+		return l.cont, nil
+	}
+
+	var err error
+	for pc := 0; pc < len(body); pc++ {
+		in := &body[pc]
+
+		switch in.op {
+		case opMeter:
+			err = i.UseGas(in.imm)
+
+		case opUnreachable:
+			err = errUnreachable
+
+		case opNop:
+
+		case opBlock:
+			labels = append(labels, label{cont: in.end + 1, height: len(i.stack), arity: in.arity})
+
+		case opLoop:
+			labels = append(labels, label{cont: pc + 1, height: len(i.stack), arity: in.arity, loop: true})
+
+		case opIf:
+			var cond uint64
+			if cond, err = i.pop(); err != nil {
+				break
+			}
+			labels = append(labels, label{cont: in.end + 1, height: len(i.stack), arity: in.arity})
+			if uint32(cond) == 0 {
+				if in.els != -1 {
+					pc = in.els
+				} else {
+					// run the end instruction to pop the label
+					pc = in.end - 1
+				}
+			}
+
+		case opElse:
+			// end of the true branch
+			pc = in.end - 1
+
+		case opEnd:
+			labels = labels[:len(labels)-1]
+
+		case opBr:
+			var cont int
+			if cont, err = branch(in.imm); err == nil {
+				pc = cont - 1
+			}
+
+		case opBrIf:
+			var cond uint64
+			if cond, err = i.pop(); err != nil {
+				break
+			}
+			if uint32(cond) != 0 {
+				var cont int
+				if cont, err = branch(in.imm); err == nil {
+					pc = cont - 1
+				}
+			}
+
+		case opBrTable:
+			var v uint64
+			if v, err = i.pop(); err != nil {
+				break
+			}
+			depth := in.labels[len(in.labels)-1]
+			if uint32(v) < uint32(len(in.labels)-1) {
+				depth = in.labels[uint32(v)]
+			}
+			var cont int
+			if cont, err = branch(uint64(depth)); err == nil {
+				pc = cont - 1
+			}
+
+		case opReturn:
+			var cont int
+			if cont, err = branch(uint64(len(labels) - 1)); err == nil {
+				pc = cont - 1
+			}
+
+		case opCall:
+			err = i.call(uint32(in.imm))
+
+		case opCallIndirect:
+			var v uint64
+			if v, err = i.pop(); err != nil {
+				break
+			}
+			if uint32(v) >= uint32(len(i.table)) || i.table[uint32(v)] == nil {
+				err = errUndefinedElement
+				break
+			}
+			if in.imm >= uint64(len(i.module.Types)) {
+				err = fmt.Errorf("type %d not found", in.imm)
+				break
+			}
+			indx := *i.table[uint32(v)]
+			if !i.functions[indx].typ.Equal(i.module.Types[in.imm]) {
+				err = errIndirectCallMismatch
+				break
+			}
+			err = i.call(indx)
+
+		case opDrop:
+			_, err = i.pop()
+
+		case opSelect:
+			var cond, b uint64
+			if cond, err = i.pop(); err != nil {
+				break
+			}
+			if b, err = i.pop(); err != nil {
+				break
+			}
+			if len(i.stack) == 0 {
+				err = errStackUnderflow
+				break
+			}
+			if uint32(cond) == 0 {
+				i.stack[len(i.stack)-1] = b
+			}
+
+		case opLocalGet:
+			if in.imm >= uint64(len(locals)) {
+				err = fmt.Errorf("local %d not found", in.imm)
+				break
+			}
+			err = i.push(locals[in.imm])
+
+		case opLocalSet, opLocalTee:
+			if in.imm >= uint64(len(locals)) {
+				err = fmt.Errorf("local %d not found", in.imm)
+				break
+			}
+			var v uint64
+			if v, err = i.pop(); err != nil {
+				break
+			}
+			locals[in.imm] = v
+			if in.op == opLocalTee {
+				err = i.push(v)
+			}
+
+		case opGlobalGet:
+			if in.imm >= uint64(len(i.globals)) {
+				err = fmt.Errorf("global %d not found", in.imm)
+				break
+			}
+			err = i.push(i.globals[in.imm])
+
+		case opGlobalSet:
+			if in.imm >= uint64(len(i.globals)) || !i.module.Globals[in.imm].Mutable {
+				err = fmt.Errorf("global %d not found or immutable", in.imm)
+				break
+			}
+			i.globals[in.imm], err = i.pop()
+
+		case opMemorySize:
+			err = i.push(uint64(len(i.memory) / PageSize))
+
+		case opMemoryGrow:
+			var delta uint64
+			if delta, err = i.pop(); err != nil {
+				break
+			}
+			pages := uint64(len(i.memory) / PageSize)
+			if pages+uint64(uint32(delta)) > uint64(i.maxPages) {
+				err = i.push(uint64(math.MaxUint32))
+				break
+			}
+			if err = i.UseGas(MemoryCost(pages+uint64(uint32(delta))) - MemoryCost(pages)); err != nil {
+				break
+			}
+			i.memory = append(i.memory, make([]byte, int(uint32(delta))*PageSize)...)
+			err = i.push(pages)
+
+		case opI32Const, opI64Const:
+			err = i.push(in.imm)
+
+		default:
+			switch {
+			case in.op >= opI32Load && in.op <= opI64Load32U:
+				err = i.load(in)
+			case in.op >= opI32Store && in.op <= opI64Store32:
+				err = i.store(in)
+			default:
+				err = i.numeric(in.op)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	// move the results to the base of the frame
+	arity := len(f.typ.Results)
+	if len(i.stack)-base < arity {
+		return errStackUnderflow
+	}
+	copy(i.stack[base:], i.stack[len(i.stack)-arity:])
+	i.stack = i.stack[:base+arity]
+	return nil
+}
+
+func (i *Instance) effectiveAddress(in *instr, size uint64) (uint64, error) {
+	v, err := i.pop()
+	if err != nil {
+		return 0, err
+	}
+	addr := uint64(uint32(v)) + in.imm
+	if addr+size > uint64(len(i.memory)) {
+		return 0, errOutOfBoundsMemory
+	}
+	return addr, nil
+}
+
+func (i *Instance) load(in *instr) error {
+	var size uint64
+	switch in.op {
+	case opI32Load, opI64Load32S, opI64Load32U:
+		size = 4
+	case opI64Load:
+		size = 8
+	case opI32Load8S, opI32Load8U, opI64Load8S, opI64Load8U:
+		size = 1
+	default:
+		size = 2
+	}
+
+	addr, err := i.effectiveAddress(in, size)
+	if err != nil {
+		return err
+	}
+	mem := i.memory[addr:]
+
+	var v uint64
+	switch in.op {
+	case opI32Load:
+		v = uint64(binary.LittleEndian.Uint32(mem))
+	case opI64Load:
+		v = binary.LittleEndian.Uint64(mem)
+	case opI32Load8S:
+		v = uint64(uint32(int32(int8(mem[0]))))
+	case opI32Load8U, opI64Load8U:
+		v = uint64(mem[0])
+	case opI32Load16S:
+		v = uint64(uint32(int32(int16(binary.LittleEndian.Uint16(mem)))))
+	case opI32Load16U, opI64Load16U:
+		v = uint64(binary.LittleEndian.Uint16(mem))
+	case opI64Load8S:
+		v = uint64(int64(int8(mem[0])))
+	case opI64Load16S:
+		v = uint64(int64(int16(binary.LittleEndian.Uint16(mem))))
+	case opI64Load32S:
+		v = uint64(int64(int32(binary.LittleEndian.Uint32(mem))))
+	case opI64Load32U:
+		v = uint64(binary.LittleEndian.Uint32(mem))
+	}
+	return i.push(v)
+}
+
+func (i *Instance) store(in *instr) error {
+	v, err := i.pop()
+	if err != nil {
+		return err
+	}
+
+	var size uint64
+	switch in.op {
+	case opI32Store, opI64Store32:
+		size = 4
+	case opI64Store:
+		size = 8
+	case opI32Store8, opI64Store8:
+		size = 1
+	default:
+		size = 2
+	}
+
+	addr, err := i.effectiveAddress(in, size)
+	if err != nil {
+		return err
+	}
+	mem := i.memory[addr:]
+
+	switch size {
+	case 1:
+		mem[0] = byte(v)
+	case 2:
+		binary.LittleEndian.PutUint16(mem, uint16(v))
+	case 4:
+		binary.LittleEndian.PutUint32(mem, uint32(v))
+	case 8:
+		binary.LittleEndian.PutUint64(mem, v)
+	}
+	return nil
+}
+
+func b2i(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (i *Instance) numeric(op byte) error {
+	// unary operations
+	switch op {
+	case opI32Eqz, opI64Eqz, opI32Clz, opI32Ctz, opI32Popcnt, opI64Clz, opI64Ctz, opI64Popcnt, opI32WrapI64, opI64ExtendI32S, opI64ExtendI32U:
+		v, err := i.pop()
+		if err != nil {
+			return err
+		}
+
+		var res uint64
+		switch op {
+		case opI32Eqz:
+			res = b2i(uint32(v) == 0)
+		case opI64Eqz:
+			res = b2i(v == 0)
+		case opI32Clz:
+			res = uint64(bits.LeadingZeros32(uint32(v)))
+		case opI32Ctz:
+			res = uint64(bits.TrailingZeros32(uint32(v)))
+		case opI32Popcnt:
+			res = uint64(bits.OnesCount32(uint32(v)))
+		case opI64Clz:
+			res = uint64(bits.LeadingZeros64(v))
+		case opI64Ctz:
+			res = uint64(bits.TrailingZeros64(v))
+		case opI64Popcnt:
+			res = uint64(bits.OnesCount64(v))
+		case opI32WrapI64, opI64ExtendI32U:
+			res = uint64(uint32(v))
+		case opI64ExtendI32S:
+			res = uint64(int64(int32(v)))
+		}
+		return i.push(res)
+	}
+
+	// binary operations
+	b, err := i.pop()
+	if err != nil {
+		return err
+	}
+	a, err := i.pop()
+	if err != nil {
+		return err
+	}
+
+	var res uint64
+	if op <= opI32GeU || (op >= opI32Clz && op <= opI32Rotr) {
+		res, err = i32Binary(op, uint32(a), uint32(b))
+	} else {
+		res, err = i64Binary(op, a, b)
+	}
+	if err != nil {
+		return err
+	}
+	return i.push(res)
+}
+
+func i32Binary(op byte, a, b uint32) (uint64, error) {
+	var res uint32
+	switch op {
+	case opI32Eq:
+		return b2i(a == b), nil
+	case opI32Ne:
+		return b2i(a != b), nil
+	case opI32LtS:
+		return b2i(int32(a) < int32(b)), nil
+	case opI32LtU:
+		return b2i(a < b), nil
+	case opI32GtS:
+		return b2i(int32(a) > int32(b)), nil
+	case opI32GtU:
+		return b2i(a > b), nil
+	case opI32LeS:
+		return b2i(int32(a) <= int32(b)), nil
+	case opI32LeU:
+		return b2i(a <= b), nil
+	case opI32GeS:
+		return b2i(int32(a) >= int32(b)), nil
+	case opI32GeU:
+		return b2i(a >= b), nil
+	case opI32Add:
+		res = a + b
+	case opI32Sub:
+		res = a - b
+	case opI32Mul:
+		res = a * b
+	case opI32DivS:
+		if b == 0 {
+			return 0, errIntegerDivideByZero
+		}
+		if int32(a) == math.MinInt32 && int32(b) == -1 {
+			return 0, errIntegerOverflow
+		}
+		res = uint32(int32(a) / int32(b))
+	case opI32DivU:
+		if b == 0 {
+			return 0, errIntegerDivideByZero
+		}
+		res = a / b
+	case opI32RemS:
+		if b == 0 {
+			return 0, errIntegerDivideByZero
+		}
+		if int32(b) == -1 {
+			res = 0
+		} else {
+			res = uint32(int32(a) % int32(b))
+		}
+	case opI32RemU:
+		if b == 0 {
+			return 0, errIntegerDivideByZero
+		}
+		res = a % b
+	case opI32And:
+		res = a & b
+	case opI32Or:
+		res = a | b
+	case opI32Xor:
+		res = a ^ b
+	case opI32Shl:
+		res = a << (b % 32)
+	case opI32ShrS:
+		res = uint32(int32(a) >> (b % 32))
+	case opI32ShrU:
+		res = a >> (b % 32)
+	case opI32Rotl:
+		res = bits.RotateLeft32(a, int(b%32))
+	case opI32Rotr:
+		res = bits.RotateLeft32(a, -int(b%32))
+	default:
+		return 0, fmt.Errorf("opcode 0x%x not supported", op)
+	}
+	return uint64(res), nil
+}
+
+func i64Binary(op byte, a, b uint64) (uint64, error) {
+	switch op {
+	case opI64Eq:
+		return b2i(a == b), nil
+	case opI64Ne:
+		return b2i(a != b), nil
+	case opI64LtS:
+		return b2i(int64(a) < int64(b)), nil
+	case opI64LtU:
+		return b2i(a < b), nil
+	case opI64GtS:
+		return b2i(int64(a) > int64(b)), nil
+	case opI64GtU:
+		return b2i(a > b), nil
+	case opI64LeS:
+		return b2i(int64(a) <= int64(b)), nil
+	case opI64LeU:
+		return b2i(a <= b), nil
+	case opI64GeS:
+		return b2i(int64(a) >= int64(b)), nil
+	case opI64GeU:
+		return b2i(a >= b), nil
+	case opI64Add:
+		return a + b, nil
+	case opI64Sub:
+		return a - b, nil
+	case opI64Mul:
+		return a * b, nil
+	case opI64DivS:
+		if b == 0 {
+			return 0, errIntegerDivideByZero
+		}
+		if int64(a) == math.MinInt64 && int64(b) == -1 {
+			return 0, errIntegerOverflow
+		}
+		return uint64(int64(a) / int64(b)), nil
+	case opI64DivU:
+		if b == 0 {
+			return 0, errIntegerDivideByZero
+		}
+		return a / b, nil
+	case opI64RemS:
+		if b == 0 {
+			return 0, errIntegerDivideByZero
+		}
+		if int64(b) == -1 {
+			return 0, nil
+		}
+		return uint64(int64(a) % int64(b)), nil
+	case opI64RemU:
+		if b == 0 {
+			return 0, errIntegerDivideByZero
+		}
+		return a % b, nil
+	case opI64And:
+		return a & b, nil
+	case opI64Or:
+		return a | b, nil
+	case opI64Xor:
+		return a ^ b, nil
+	case opI64Shl:
+		return a << (b % 64), nil
+	case opI64ShrS:
+		return uint64(int64(a) >> (b % 64)), nil
+	case opI64ShrU:
+		return a >> (b % 64), nil
+	case opI64Rotl:
+		return bits.RotateLeft64(a, int(b%64)), nil
+	case opI64Rotr:
+		return bits.RotateLeft64(a, -int(b%64)), nil
+	}
+	return 0, fmt.Errorf("opcode 0x%x not supported", op)
+}
@@ -0,0 +1,205 @@
+package wasm
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func uleb(n uint32) []byte {
+	res := []byte{}
+	for {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if n != 0 {
+			b |= 0x80
+		}
+		res = append(res, b)
+		if n == 0 {
+			return res
+		}
+	}
+}
+
+func vec(items ...[]byte) []byte {
+	res := uleb(uint32(len(items)))
+	for _, i := range items {
+		res = append(res, i...)
+	}
+	return res
+}
+
+func section(id byte, content []byte) []byte {
+	return append(append([]byte{id}, uleb(uint32(len(content)))...), content...)
+}
+
+func name(str string) []byte {
+	return append(uleb(uint32(len(str))), str...)
+}
+
+func body(locals []byte, code ...byte) []byte {
+	b := append(locals, code...)
+	return append(uleb(uint32(len(b))), b...)
+}
+
+func module(sections ...[]byte) []byte {
+	res := append([]byte{}, Magic...)
+	res = append(res, 0x1, 0x0, 0x0, 0x0)
+	for _, s := range sections {
+		res = append(res, s...)
+	}
+	return res
+}
+
+// testModule exports 'sum', which adds all the numbers up to n
+// in a loop, and 'choose' which returns 10 if n != 0 or 20 otherwise
+var testModule = module(
+	section(sectionType, vec([]byte{0x60, 0x1, byte(I32), 0x1, byte(I32)})),
+	section(sectionFunction, vec([]byte{0x0}, []byte{0x0})),
+	section(sectionExport, vec(
+		append(name("sum"), externalFunction, 0x0),
+		append(name("choose"), externalFunction, 0x1),
+	)),
+	section(sectionCode, vec(
+		body(vec([]byte{0x1, byte(I32)}),
+			opBlock, 0x40,
+			opLoop, 0x40,
+			opLocalGet, 0x0,
+			opI32Eqz,
+			opBrIf, 0x1,
+			opLocalGet, 0x1,
+			opLocalGet, 0x0,
+			opI32Add,
+			opLocalSet, 0x1,
+			opLocalGet, 0x0,
+			opI32Const, 0x1,
+			opI32Sub,
+			opLocalSet, 0x0,
+			opBr, 0x0,
+			opEnd,
+			opEnd,
+			opLocalGet, 0x1,
+			opEnd,
+		),
+		body(vec(),
+			opLocalGet, 0x0,
+			opIf, byte(I32),
+			opI32Const, 0xa,
+			opElse,
+			opI32Const, 0x14,
+			opEnd,
+			opEnd,
+		),
+	)),
+)
+
+func TestInstanceInvoke(t *testing.T) {
+	m, err := ParseModule(testModule)
+	assert.NoError(t, err)
+
+	i, err := NewInstance(m, nil)
+	assert.NoError(t, err)
+
+	res, err := i.Invoke("sum", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(55), res)
+
+	res, err = i.Invoke("choose", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), res)
+
+	res, err = i.Invoke("choose", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(20), res)
+
+	_, err = i.Invoke("foo")
+	assert.Error(t, err)
+}
+
+func TestInstanceMetering(t *testing.T) {
+	run := func(gas uint64, n uint64) (uint64, error) {
+		m, err := ParseModule(testModule)
+		assert.NoError(t, err)
+
+		m.InjectMetering(DefaultCost)
+
+		i, err := NewInstance(m, nil)
+		assert.NoError(t, err)
+
+		i.Gas = gas
+		_, err = i.Invoke("sum", n)
+		return gas - i.Gas, err
+	}
+
+	used1, err := run(1000, 1)
+	assert.NoError(t, err)
+
+	used10, err := run(1000, 10)
+	assert.NoError(t, err)
+
+	// each iteration of the loop has 12 instructions
+	assert.Equal(t, uint64(9*12), used10-used1)
+
+	_, err = run(used10-1, 10)
+	assert.Equal(t, ErrOutOfGas, err)
+}
+
+// growModule exports 'grow', which grows the memory n pages and
+// returns the previous number of pages
+var growModule = module(
+	section(sectionType, vec([]byte{0x60, 0x1, byte(I32), 0x1, byte(I32)})),
+	section(sectionFunction, vec([]byte{0x0})),
+	section(sectionMemory, vec([]byte{0x0, 0x0})),
+	section(sectionExport, vec(
+		append(name("grow"), externalFunction, 0x0),
+	)),
+	section(sectionCode, vec(
+		body(vec(),
+			opLocalGet, 0x0,
+			opMemoryGrow, 0x0,
+			opEnd,
+		),
+	)),
+)
+
+func TestInstanceMemoryGrowGas(t *testing.T) {
+	m, err := ParseModule(growModule)
+	assert.NoError(t, err)
+
+	i, err := NewInstance(m, nil)
+	assert.NoError(t, err)
+
+	// the pages are charged like the memory expansion of the evm
+	i.Gas = MemoryCost(3)
+	res, err := i.Invoke("grow", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), res)
+	assert.Equal(t, MemoryCost(3)-MemoryCost(1), i.Gas)
+
+	res, err = i.Invoke("grow", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), res)
+	assert.Equal(t, uint64(0), i.Gas)
+
+	// there is no gas for another page
+	_, err = i.Invoke("grow", 1)
+	assert.Equal(t, ErrOutOfGas, err)
+	assert.Len(t, i.Memory(), 3*PageSize)
+
+	// a memory above the limit cannot be paid
+	assert.Equal(t, uint64(math.MaxUint64), MemoryCost(maxPages+1))
+}
+
+func TestParseModuleFloat(t *testing.T) {
+	m := module(
+		section(sectionType, vec([]byte{0x60, 0x0, 0x0})),
+		section(sectionFunction, vec([]byte{0x0})),
+		section(sectionCode, vec(
+			// f32.const 0
+			body(vec(), 0x43, 0x0, 0x0, 0x0, 0x0, opDrop, opEnd),
+		)),
+	)
+	_, err := ParseModule(m)
+	assert.Error(t, err)
+}
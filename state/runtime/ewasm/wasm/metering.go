@@ -0,0 +1,56 @@
+package wasm
+
+// CostFn returns the gas cost of an instruction
+type CostFn func(op byte) uint64
+
+// DefaultCost charges one unit of gas per instruction
+func DefaultCost(op byte) uint64 {
+	return 1
+}
+
+// InjectMetering injects a metering instruction at the beginning of each
+// basic block of the functions of the module. The metering instruction
+// charges at once the cost of all the instructions in the block.
+// It must be called before the module is instantiated.
+func (m *Module) InjectMetering(cost CostFn) {
+	for _, code := range m.Codes {
+		code.Body = injectMetering(code.Body, cost)
+
+		// the blocks were resolved when the module was parsed and
+		// the metering does not change them, only their position
+		if err := resolveBlocks(code.Body); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// endsBlock returns true if the instruction starts a new basic block after it
+func endsBlock(op byte) bool {
+	switch op {
+	case opBlock, opLoop, opIf, opElse, opEnd, opBr, opBrIf, opBrTable, opReturn, opUnreachable:
+		return true
+	}
+	return false
+}
+
+func injectMetering(body []instr, cost CostFn) []instr {
+	res := make([]instr, 0, len(body)+len(body)/4)
+
+	start := 0
+	for i := 0; i < len(body); i++ {
+		if !endsBlock(body[i].op) && i != len(body)-1 {
+			continue
+		}
+
+		var gas uint64
+		for _, in := range body[start : i+1] {
+			gas += cost(in.op)
+		}
+		if gas != 0 {
+			res = append(res, instr{op: opMeter, imm: gas})
+		}
+		res = append(res, body[start:i+1]...)
+		start = i + 1
+	}
+	return res
+}
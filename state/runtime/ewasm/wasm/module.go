@@ -0,0 +1,755 @@
+package wasm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Magic is the prefix of any wasm binary module
+var Magic = []byte{0x00, 0x61, 0x73, 0x6d}
+
+const version = 1
+
+// ValueType is the type of a wasm value
+type ValueType byte
+
+const (
+	// I32 is a 32 bits integer
+	I32 ValueType = 0x7f
+	// I64 is a 64 bits integer
+	I64 ValueType = 0x7e
+)
+
+const (
+	sectionCustom   = 0
+	sectionType     = 1
+	sectionImport   = 2
+	sectionFunction = 3
+	sectionTable    = 4
+	sectionMemory   = 5
+	sectionGlobal   = 6
+	sectionExport   = 7
+	sectionStart    = 8
+	sectionElement  = 9
+	sectionCode     = 10
+	sectionData     = 11
+)
+
+const (
+	externalFunction = 0
+	externalTable    = 1
+	externalMemory   = 2
+	externalGlobal   = 3
+)
+
+// FuncType is the signature of a function
+type FuncType struct {
+	Params  []ValueType
+	Results []ValueType
+}
+
+// Equal checks if two function types are the same
+func (f *FuncType) Equal(ff *FuncType) bool {
+	return bytes.Equal(valueTypes(f.Params), valueTypes(ff.Params)) && bytes.Equal(valueTypes(f.Results), valueTypes(ff.Results))
+}
+
+func valueTypes(v []ValueType) []byte {
+	b := make([]byte, len(v))
+	for i, t := range v {
+		b[i] = byte(t)
+	}
+	return b
+}
+
+// Import is an imported function
+type Import struct {
+	Module string
+	Field  string
+	Type   uint32
+}
+
+// Export is an exported item
+type Export struct {
+	Kind  byte
+	Index uint32
+}
+
+// Limits are the limits of memories and tables
+type Limits struct {
+	Min uint32
+	Max *uint32
+}
+
+// Global is a global variable
+type Global struct {
+	Type    ValueType
+	Mutable bool
+	Init    uint64
+}
+
+// Element initializes a range of the table
+type Element struct {
+	Offset uint32
+	Funcs  []uint32
+}
+
+// Data initializes a range of the memory
+type Data struct {
+	Offset uint32
+	Init   []byte
+}
+
+// Code is the body of a function
+type Code struct {
+	Locals []ValueType
+	Body   []instr
+}
+
+// Module is a decoded wasm module
+type Module struct {
+	Types     []*FuncType
+	Imports   []*Import
+	Functions []uint32
+	Table     *Limits
+	Memory    *Limits
+	Globals   []*Global
+	Exports   map[string]*Export
+	Start     *uint32
+	Elements  []*Element
+	Codes     []*Code
+	Data      []*Data
+}
+
+// instr is a decoded instruction
+type instr struct {
+	op  byte
+	imm uint64
+
+	// arity of the block for block, loop and if
+	arity int
+
+	// position of the matching else and end instructions for block, loop and if
+	els int
+	end int
+
+	// labels for br_table. The last item is the default label
+	labels []uint32
+}
+
+// ExportsFunction checks if the module exports a function with the given name
+func (m *Module) ExportsFunction(name string) bool {
+	export, ok := m.Exports[name]
+	return ok && export.Kind == externalFunction
+}
+
+type decoder struct {
+	buf []byte
+	pos int
+}
+
+func (d *decoder) eof() bool {
+	return d.pos >= len(d.buf)
+}
+
+func (d *decoder) byte() (byte, error) {
+	if d.eof() {
+		return 0, fmt.Errorf("unexpected end of input")
+	}
+	b := d.buf[d.pos]
+	d.pos++
+	return b, nil
+}
+
+func (d *decoder) bytes(n uint32) ([]byte, error) {
+	if uint64(d.pos)+uint64(n) > uint64(len(d.buf)) {
+		return nil, fmt.Errorf("unexpected end of input")
+	}
+	b := d.buf[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
+
+func (d *decoder) uleb(bits uint) (uint64, error) {
+	var res uint64
+	var shift uint
+	for {
+		b, err := d.byte()
+		if err != nil {
+			return 0, err
+		}
+		res |= uint64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			break
+		}
+		if shift >= bits+7 {
+			return 0, fmt.Errorf("integer representation too long")
+		}
+	}
+	if bits < 64 && res>>bits != 0 {
+		return 0, fmt.Errorf("integer too large")
+	}
+	return res, nil
+}
+
+func (d *decoder) sleb(bits uint) (int64, error) {
+	var res int64
+	var shift uint
+	var b byte
+	var err error
+	for {
+		b, err = d.byte()
+		if err != nil {
+			return 0, err
+		}
+		res |= int64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			break
+		}
+		if shift >= bits+7 {
+			return 0, fmt.Errorf("integer representation too long")
+		}
+	}
+	if shift < 64 && b&0x40 != 0 {
+		res |= -1 << shift
+	}
+	return res, nil
+}
+
+func (d *decoder) u32() (uint32, error) {
+	v, err := d.uleb(32)
+	return uint32(v), err
+}
+
+func (d *decoder) name() (string, error) {
+	n, err := d.u32()
+	if err != nil {
+		return "", err
+	}
+	b, err := d.bytes(n)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (d *decoder) valueType() (ValueType, error) {
+	b, err := d.byte()
+	if err != nil {
+		return 0, err
+	}
+	switch t := ValueType(b); t {
+	case I32, I64:
+		return t, nil
+	default:
+		return 0, fmt.Errorf("value type 0x%x not supported", b)
+	}
+}
+
+func (d *decoder) limits() (*Limits, error) {
+	flag, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+	l := &Limits{}
+	if l.Min, err = d.u32(); err != nil {
+		return nil, err
+	}
+	if flag == 1 {
+		max, err := d.u32()
+		if err != nil {
+			return nil, err
+		}
+		l.Max = &max
+	}
+	return l, nil
+}
+
+// constExpr decodes an initializer expression. Only constants are supported
+func (d *decoder) constExpr() (uint64, error) {
+	op, err := d.byte()
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	switch op {
+	case opI32Const:
+		n, err := d.sleb(32)
+		if err != nil {
+			return 0, err
+		}
+		v = uint64(uint32(n))
+	case opI64Const:
+		n, err := d.sleb(64)
+		if err != nil {
+			return 0, err
+		}
+		v = uint64(n)
+	default:
+		return 0, fmt.Errorf("init expression 0x%x not supported", op)
+	}
+	if op, err = d.byte(); err != nil {
+		return 0, err
+	}
+	if op != opEnd {
+		return 0, fmt.Errorf("init expression not terminated")
+	}
+	return v, nil
+}
+
+// ParseModule decodes a wasm binary module
+func ParseModule(buf []byte) (*Module, error) {
+	if !bytes.HasPrefix(buf, Magic) {
+		return nil, fmt.Errorf("magic header not found")
+	}
+	if len(buf) < 8 || binary.LittleEndian.Uint32(buf[4:8]) != version {
+		return nil, fmt.Errorf("version not supported")
+	}
+
+	m := &Module{
+		Exports: map[string]*Export{},
+	}
+
+	d := &decoder{buf: buf, pos: 8}
+	for !d.eof() {
+		id, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := d.u32()
+		if err != nil {
+			return nil, err
+		}
+		content, err := d.bytes(size)
+		if err != nil {
+			return nil, err
+		}
+
+		sd := &decoder{buf: content}
+		switch id {
+		case sectionCustom:
+			continue
+		case sectionType:
+			err = m.decodeTypes(sd)
+		case sectionImport:
+			err = m.decodeImports(sd)
+		case sectionFunction:
+			err = m.decodeFunctions(sd)
+		case sectionTable:
+			err = m.decodeTable(sd)
+		case sectionMemory:
+			err = m.decodeMemory(sd)
+		case sectionGlobal:
+			err = m.decodeGlobals(sd)
+		case sectionExport:
+			err = m.decodeExports(sd)
+		case sectionStart:
+			var start uint32
+			if start, err = sd.u32(); err == nil {
+				m.Start = &start
+			}
+		case sectionElement:
+			err = m.decodeElements(sd)
+		case sectionCode:
+			err = m.decodeCodes(sd)
+		case sectionData:
+			err = m.decodeData(sd)
+		default:
+			err = fmt.Errorf("unknown section %d", id)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode section %d: %v", id, err)
+		}
+		if !sd.eof() {
+			return nil, fmt.Errorf("section %d size mismatch", id)
+		}
+	}
+
+	if len(m.Functions) != len(m.Codes) {
+		return nil, fmt.Errorf("function and code section have inconsistent lengths")
+	}
+	for _, typ := range m.Functions {
+		if int(typ) >= len(m.Types) {
+			return nil, fmt.Errorf("function type %d not found", typ)
+		}
+	}
+	for indx, code := range m.Codes {
+		if err := resolveBlocks(code.Body); err != nil {
+			return nil, fmt.Errorf("function %d: %v", indx, err)
+		}
+	}
+	return m, nil
+}
+
+func (m *Module) decodeTypes(d *decoder) error {
+	n, err := d.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		form, err := d.byte()
+		if err != nil {
+			return err
+		}
+		if form != 0x60 {
+			return fmt.Errorf("function type expected")
+		}
+		typ := &FuncType{}
+		if typ.Params, err = d.valueTypes(); err != nil {
+			return err
+		}
+		if typ.Results, err = d.valueTypes(); err != nil {
+			return err
+		}
+		if len(typ.Results) > 1 {
+			return fmt.Errorf("multiple return values not supported")
+		}
+		m.Types = append(m.Types, typ)
+	}
+	return nil
+}
+
+func (d *decoder) valueTypes() ([]ValueType, error) {
+	n, err := d.u32()
+	if err != nil {
+		return nil, err
+	}
+	res := []ValueType{}
+	for i := uint32(0); i < n; i++ {
+		t, err := d.valueType()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, t)
+	}
+	return res, nil
+}
+
+func (m *Module) decodeImports(d *decoder) error {
+	n, err := d.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		imp := &Import{}
+		if imp.Module, err = d.name(); err != nil {
+			return err
+		}
+		if imp.Field, err = d.name(); err != nil {
+			return err
+		}
+		kind, err := d.byte()
+		if err != nil {
+			return err
+		}
+		if kind != externalFunction {
+			return fmt.Errorf("only function imports are supported")
+		}
+		if imp.Type, err = d.u32(); err != nil {
+			return err
+		}
+		if int(imp.Type) >= len(m.Types) {
+			return fmt.Errorf("import type %d not found", imp.Type)
+		}
+		m.Imports = append(m.Imports, imp)
+	}
+	return nil
+}
+
+func (m *Module) decodeFunctions(d *decoder) error {
+	n, err := d.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		typ, err := d.u32()
+		if err != nil {
+			return err
+		}
+		m.Functions = append(m.Functions, typ)
+	}
+	return nil
+}
+
+func (m *Module) decodeTable(d *decoder) error {
+	n, err := d.u32()
+	if err != nil {
+		return err
+	}
+	if n > 1 {
+		return fmt.Errorf("only one table is allowed")
+	}
+	if n == 1 {
+		elemType, err := d.byte()
+		if err != nil {
+			return err
+		}
+		if elemType != 0x70 {
+			return fmt.Errorf("table type 0x%x not supported", elemType)
+		}
+		if m.Table, err = d.limits(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Module) decodeMemory(d *decoder) error {
+	n, err := d.u32()
+	if err != nil {
+		return err
+	}
+	if n > 1 {
+		return fmt.Errorf("only one memory is allowed")
+	}
+	if n == 1 {
+		if m.Memory, err = d.limits(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Module) decodeGlobals(d *decoder) error {
+	n, err := d.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		g := &Global{}
+		if g.Type, err = d.valueType(); err != nil {
+			return err
+		}
+		mut, err := d.byte()
+		if err != nil {
+			return err
+		}
+		g.Mutable = mut == 1
+		if g.Init, err = d.constExpr(); err != nil {
+			return err
+		}
+		m.Globals = append(m.Globals, g)
+	}
+	return nil
+}
+
+func (m *Module) decodeExports(d *decoder) error {
+	n, err := d.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		name, err := d.name()
+		if err != nil {
+			return err
+		}
+		e := &Export{}
+		if e.Kind, err = d.byte(); err != nil {
+			return err
+		}
+		if e.Index, err = d.u32(); err != nil {
+			return err
+		}
+		if _, ok := m.Exports[name]; ok {
+			return fmt.Errorf("duplicated export %s", name)
+		}
+		m.Exports[name] = e
+	}
+	return nil
+}
+
+func (m *Module) decodeElements(d *decoder) error {
+	n, err := d.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		if _, err := d.u32(); err != nil {
+			return err
+		}
+		offset, err := d.constExpr()
+		if err != nil {
+			return err
+		}
+		e := &Element{Offset: uint32(offset)}
+		num, err := d.u32()
+		if err != nil {
+			return err
+		}
+		for j := uint32(0); j < num; j++ {
+			idx, err := d.u32()
+			if err != nil {
+				return err
+			}
+			e.Funcs = append(e.Funcs, idx)
+		}
+		m.Elements = append(m.Elements, e)
+	}
+	return nil
+}
+
+func (m *Module) decodeData(d *decoder) error {
+	n, err := d.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		if _, err := d.u32(); err != nil {
+			return err
+		}
+		offset, err := d.constExpr()
+		if err != nil {
+			return err
+		}
+		size, err := d.u32()
+		if err != nil {
+			return err
+		}
+		init, err := d.bytes(size)
+		if err != nil {
+			return err
+		}
+		m.Data = append(m.Data, &Data{Offset: uint32(offset), Init: init})
+	}
+	return nil
+}
+
+func (m *Module) decodeCodes(d *decoder) error {
+	n, err := d.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < n; i++ {
+		size, err := d.u32()
+		if err != nil {
+			return err
+		}
+		body, err := d.bytes(size)
+		if err != nil {
+			return err
+		}
+		code, err := decodeCode(&decoder{buf: body})
+		if err != nil {
+			return fmt.Errorf("function %d: %v", i, err)
+		}
+		m.Codes = append(m.Codes, code)
+	}
+	return nil
+}
+
+// maxLocals is the max number of locals in a function
+const maxLocals = 50000
+
+func decodeCode(d *decoder) (*Code, error) {
+	code := &Code{}
+
+	n, err := d.u32()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint32(0); i < n; i++ {
+		num, err := d.u32()
+		if err != nil {
+			return nil, err
+		}
+		typ, err := d.valueType()
+		if err != nil {
+			return nil, err
+		}
+		if len(code.Locals)+int(num) > maxLocals {
+			return nil, fmt.Errorf("too many locals")
+		}
+		for j := uint32(0); j < num; j++ {
+			code.Locals = append(code.Locals, typ)
+		}
+	}
+
+	for !d.eof() {
+		op, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		if !isSupported(op) {
+			return nil, fmt.Errorf("opcode 0x%x not supported", op)
+		}
+
+		in := instr{op: op}
+		switch op {
+		case opBlock, opLoop, opIf:
+			b, err := d.byte()
+			if err != nil {
+				return nil, err
+			}
+			if b != 0x40 {
+				if _, err := (&decoder{buf: []byte{b}}).valueType(); err != nil {
+					return nil, err
+				}
+				in.arity = 1
+			}
+
+		case opBr, opBrIf, opCall, opLocalGet, opLocalSet, opLocalTee, opGlobalGet, opGlobalSet:
+			if in.imm, err = d.uleb(32); err != nil {
+				return nil, err
+			}
+
+		case opBrTable:
+			num, err := d.u32()
+			if err != nil {
+				return nil, err
+			}
+			for i := uint32(0); i <= num; i++ {
+				l, err := d.u32()
+				if err != nil {
+					return nil, err
+				}
+				in.labels = append(in.labels, l)
+			}
+
+		case opCallIndirect:
+			if in.imm, err = d.uleb(32); err != nil {
+				return nil, err
+			}
+			if b, err := d.byte(); err != nil || b != 0 {
+				return nil, fmt.Errorf("call_indirect reserved byte expected")
+			}
+
+		case opMemorySize, opMemoryGrow:
+			if b, err := d.byte(); err != nil || b != 0 {
+				return nil, fmt.Errorf("memory reserved byte expected")
+			}
+
+		case opI32Const:
+			v, err := d.sleb(32)
+			if err != nil {
+				return nil, err
+			}
+			in.imm = uint64(uint32(v))
+
+		case opI64Const:
+			v, err := d.sleb(64)
+			if err != nil {
+				return nil, err
+			}
+			in.imm = uint64(v)
+
+		default:
+			if op >= opI32Load && op <= opI64Store32 {
+				// memarg (alignment and offset)
+				if _, err := d.u32(); err != nil {
+					return nil, err
+				}
+				if in.imm, err = d.uleb(32); err != nil {
+					return nil, err
+				}
+			}
+		}
+		code.Body = append(code.Body, in)
+	}
+
+	if len(code.Body) == 0 || code.Body[len(code.Body)-1].op != opEnd {
+		return nil, fmt.Errorf("function body not terminated")
+	}
+	return code, nil
+}
@@ -0,0 +1,288 @@
+package precompiled
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/umbracle/minimal/crypto/bls12381"
+)
+
+// BLS12-381 precompiles of EIP-2537. The inputs must have the exact size,
+// unlike the bn256 precompiles they are not padded with zeros.
+
+const (
+	blsG1Size     = 128
+	blsG2Size     = 256
+	blsScalarSize = 32
+
+	blsG1MulGas = 12000
+	blsG2MulGas = 55000
+)
+
+// blsMultiExpDiscount is the discount per mille applied to the multi
+// exponentiation of k points, k in [1, 128]. The discount is only right
+// because the multi exponentiation uses the bucket method of Pippenger.
+var blsMultiExpDiscount = []uint64{
+	1200, 888, 764, 641, 594, 547, 500, 453, 438, 423, 408, 394, 379, 364, 349, 334,
+	330, 326, 322, 318, 314, 310, 306, 302, 298, 294, 289, 285, 281, 277, 273, 269,
+	268, 266, 265, 263, 262, 260, 259, 257, 256, 254, 253, 251, 250, 248, 247, 245,
+	244, 242, 241, 239, 238, 236, 235, 233, 232, 231, 229, 228, 226, 225, 223, 222,
+	221, 220, 219, 219, 218, 217, 216, 216, 215, 214, 213, 213, 212, 211, 211, 210,
+	209, 208, 208, 207, 206, 205, 205, 204, 203, 202, 202, 201, 200, 199, 199, 198,
+	197, 196, 196, 195, 194, 193, 193, 192, 191, 191, 190, 189, 188, 188, 187, 186,
+	185, 185, 184, 183, 182, 182, 181, 180, 179, 179, 178, 177, 176, 176, 175, 174,
+}
+
+func blsMultiExpGas(k int, mulGas uint64) uint64 {
+	if k == 0 {
+		return 0
+	}
+	discount := blsMultiExpDiscount[len(blsMultiExpDiscount)-1]
+	if k <= len(blsMultiExpDiscount) {
+		discount = blsMultiExpDiscount[k-1]
+	}
+	return uint64(k) * mulGas * discount / 1000
+}
+
+func blsCheckSize(input []byte, size int) error {
+	if len(input) != size {
+		return fmt.Errorf("invalid input size %d, expected %d", len(input), size)
+	}
+	return nil
+}
+
+func blsCheckMultiple(input []byte, size int) (int, error) {
+	if len(input) == 0 || len(input)%size != 0 {
+		return 0, fmt.Errorf("invalid input size %d, expected a multiple of %d", len(input), size)
+	}
+	return len(input) / size, nil
+}
+
+func blsDecodeG1(buf []byte) (*bls12381.G1, error) {
+	p := new(bls12381.G1)
+	if err := p.Unmarshal(buf); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func blsDecodeG2(buf []byte) (*bls12381.G2, error) {
+	p := new(bls12381.G2)
+	if err := p.Unmarshal(buf); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+type blsG1Add struct{}
+
+func (b *blsG1Add) gas(input []byte) uint64 {
+	return 600
+}
+
+func (b *blsG1Add) run(input []byte) ([]byte, error) {
+	if err := blsCheckSize(input, 2*blsG1Size); err != nil {
+		return nil, err
+	}
+	p0, err := blsDecodeG1(input[:blsG1Size])
+	if err != nil {
+		return nil, err
+	}
+	p1, err := blsDecodeG1(input[blsG1Size:])
+	if err != nil {
+		return nil, err
+	}
+	return p0.Add(p0, p1).Marshal(), nil
+}
+
+type blsG1Mul struct{}
+
+func (b *blsG1Mul) gas(input []byte) uint64 {
+	return blsG1MulGas
+}
+
+func (b *blsG1Mul) run(input []byte) ([]byte, error) {
+	if err := blsCheckSize(input, blsG1Size+blsScalarSize); err != nil {
+		return nil, err
+	}
+	p, err := blsDecodeG1(input[:blsG1Size])
+	if err != nil {
+		return nil, err
+	}
+	k := new(big.Int).SetBytes(input[blsG1Size:])
+	return p.ScalarMult(p, k).Marshal(), nil
+}
+
+type blsG1MultiExp struct{}
+
+func (b *blsG1MultiExp) gas(input []byte) uint64 {
+	return blsMultiExpGas(len(input)/(blsG1Size+blsScalarSize), blsG1MulGas)
+}
+
+func (b *blsG1MultiExp) run(input []byte) ([]byte, error) {
+	num, err := blsCheckMultiple(input, blsG1Size+blsScalarSize)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]*bls12381.G1, num)
+	scalars := make([]*big.Int, num)
+	for i := 0; i < num; i++ {
+		p, err := blsDecodeG1(input[:blsG1Size])
+		if err != nil {
+			return nil, err
+		}
+		points[i] = p
+		scalars[i] = new(big.Int).SetBytes(input[blsG1Size : blsG1Size+blsScalarSize])
+		input = input[blsG1Size+blsScalarSize:]
+	}
+
+	res, err := new(bls12381.G1).MultiExp(points, scalars)
+	if err != nil {
+		return nil, err
+	}
+	return res.Marshal(), nil
+}
+
+type blsG2Add struct{}
+
+func (b *blsG2Add) gas(input []byte) uint64 {
+	return 4500
+}
+
+func (b *blsG2Add) run(input []byte) ([]byte, error) {
+	if err := blsCheckSize(input, 2*blsG2Size); err != nil {
+		return nil, err
+	}
+	p0, err := blsDecodeG2(input[:blsG2Size])
+	if err != nil {
+		return nil, err
+	}
+	p1, err := blsDecodeG2(input[blsG2Size:])
+	if err != nil {
+		return nil, err
+	}
+	return p0.Add(p0, p1).Marshal(), nil
+}
+
+type blsG2Mul struct{}
+
+func (b *blsG2Mul) gas(input []byte) uint64 {
+	return blsG2MulGas
+}
+
+func (b *blsG2Mul) run(input []byte) ([]byte, error) {
+	if err := blsCheckSize(input, blsG2Size+blsScalarSize); err != nil {
+		return nil, err
+	}
+	p, err := blsDecodeG2(input[:blsG2Size])
+	if err != nil {
+		return nil, err
+	}
+	k := new(big.Int).SetBytes(input[blsG2Size:])
+	return p.ScalarMult(p, k).Marshal(), nil
+}
+
+type blsG2MultiExp struct{}
+
+func (b *blsG2MultiExp) gas(input []byte) uint64 {
+	return blsMultiExpGas(len(input)/(blsG2Size+blsScalarSize), blsG2MulGas)
+}
+
+func (b *blsG2MultiExp) run(input []byte) ([]byte, error) {
+	num, err := blsCheckMultiple(input, blsG2Size+blsScalarSize)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]*bls12381.G2, num)
+	scalars := make([]*big.Int, num)
+	for i := 0; i < num; i++ {
+		p, err := blsDecodeG2(input[:blsG2Size])
+		if err != nil {
+			return nil, err
+		}
+		points[i] = p
+		scalars[i] = new(big.Int).SetBytes(input[blsG2Size : blsG2Size+blsScalarSize])
+		input = input[blsG2Size+blsScalarSize:]
+	}
+
+	res, err := new(bls12381.G2).MultiExp(points, scalars)
+	if err != nil {
+		return nil, err
+	}
+	return res.Marshal(), nil
+}
+
+type blsPairing struct{}
+
+func (b *blsPairing) gas(input []byte) uint64 {
+	return 115000 + 23000*uint64(len(input)/(blsG1Size+blsG2Size))
+}
+
+func (b *blsPairing) run(input []byte) ([]byte, error) {
+	num, err := blsCheckMultiple(input, blsG1Size+blsG2Size)
+	if err != nil {
+		return nil, err
+	}
+
+	ar := make([]*bls12381.G1, num)
+	br := make([]*bls12381.G2, num)
+
+	for i := 0; i < num; i++ {
+		a0, err := blsDecodeG1(input[:blsG1Size])
+		if err != nil {
+			return nil, err
+		}
+		b0, err := blsDecodeG2(input[blsG1Size : blsG1Size+blsG2Size])
+		if err != nil {
+			return nil, err
+		}
+		// the points of the pairing must be in the subgroups
+		if !a0.IsInSubgroup() || !b0.IsInSubgroup() {
+			return nil, fmt.Errorf("point %d not in the subgroup", i)
+		}
+		ar[i] = a0
+		br[i] = b0
+		input = input[blsG1Size+blsG2Size:]
+	}
+
+	if bls12381.PairingCheck(ar, br) {
+		return trueBytes, nil
+	}
+	return falseBytes, nil
+}
+
+type blsMapG1 struct{}
+
+func (b *blsMapG1) gas(input []byte) uint64 {
+	return 5500
+}
+
+func (b *blsMapG1) run(input []byte) ([]byte, error) {
+	if err := blsCheckSize(input, 64); err != nil {
+		return nil, err
+	}
+	p, err := bls12381.MapToG1(input)
+	if err != nil {
+		return nil, err
+	}
+	return p.Marshal(), nil
+}
+
+type blsMapG2 struct{}
+
+func (b *blsMapG2) gas(input []byte) uint64 {
+	return 110000
+}
+
+func (b *blsMapG2) run(input []byte) ([]byte, error) {
+	if err := blsCheckSize(input, 128); err != nil {
+		return nil, err
+	}
+	p, err := bls12381.MapToG2(input)
+	if err != nil {
+		return nil, err
+	}
+	return p.Marshal(), nil
+}
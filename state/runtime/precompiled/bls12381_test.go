@@ -0,0 +1,303 @@
+package precompiled
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/minimal/chain"
+	"github.com/umbracle/minimal/helper/hex"
+	"github.com/umbracle/minimal/state/runtime"
+	"github.com/umbracle/minimal/types"
+)
+
+// multiples of the generators of G1 and G2 encoded as in EIP-2537
+var (
+	blsG1   = "0000000000000000000000000000000017f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb0000000000000000000000000000000008b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1"
+	blsG1x2 = "000000000000000000000000000000000572cbea904d67468808c8eb50a9450c9721db309128012543902d0ac358a62ae28f75bb8f1c7c42c39a8c5529bf0f4e00000000000000000000000000000000166a9d8cabc673a322fda673779d8e3822ba3ecb8670e461f73bb9021d5fd76a4c56d9d4cd16bd1bba86881979749d28"
+	blsG1x3 = "0000000000000000000000000000000009ece308f9d1f0131765212deca99697b112d61f9be9a5f1f3780a51335b3ff981747a0b2ca2179b96d2c0c9024e522400000000000000000000000000000000032b80d3a6f5b09f8a84623389c5f80ca69a0cddabc3097f9d9c27310fd43be6e745256c634af45ca3473b0590ae30d1"
+	blsG1x8 = "00000000000000000000000000000000085ae765588126f5e860d019c0e26235f567a9c0c0b2d8ff30f3e8d436b1082596e5e7462d20f5be3764fd473e57f9cf0000000000000000000000000000000019e7dfab8a794b6abb9f84e57739de172a63415273f460d1607fa6a74f0acd97d9671b801dd1fd4f18232dd1259359a1"
+
+	blsG1Neg2 = "000000000000000000000000000000000572cbea904d67468808c8eb50a9450c9721db309128012543902d0ac358a62ae28f75bb8f1c7c42c39a8c5529bf0f4e000000000000000000000000000000000396745d8db972f7281e0142cbae1e9f41bd0cb96d142e5d6ff5199ed9511eb9d2552629e43d42e3ff7877e6868b0d83"
+
+	blsG2   = "00000000000000000000000000000000024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb80000000000000000000000000000000013e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e000000000000000000000000000000000ce5d527727d6e118cc9cdc6da2e351aadfd9baa8cbdd3a76d429a695160d12c923ac9cc3baca289e193548608b82801000000000000000000000000000000000606c4a02ea734cc32acd2b02bc28b99cb3e287e85a763af267492ab572e99ab3f370d275cec1da1aaa9075ff05f79be"
+	blsG2x2 = "000000000000000000000000000000001638533957d540a9d2370f17cc7ed5863bc0b995b8825e0ee1ea1e1e4d00dbae81f14b0bf3611b78c952aacab827a053000000000000000000000000000000000a4edef9c1ed7f729f520e47730a124fd70662a904ba1074728114d1031e1572c6c886f6b57ec72a6178288c47c33577000000000000000000000000000000000468fb440d82b0630aeb8dca2b5256789a66da69bf91009cbfe6bd221e47aa8ae88dece9764bf3bd999d95d71e4c9899000000000000000000000000000000000f6d4552fa65dd2638b361543f887136a43253d9c66c411697003f7a13c308f5422e1aa0a59c8967acdefd8b6e36ccf3"
+	blsG2x3 = "00000000000000000000000000000000122915c824a0857e2ee414a3dccb23ae691ae54329781315a0c75df1c04d6d7a50a030fc866f09d516020ef82324afae0000000000000000000000000000000009380275bbc8e5dcea7dc4dd7e0550ff2ac480905396eda55062650f8d251c96eb480673937cc6d9d6a44aaa56ca66dc000000000000000000000000000000000b21da7955969e61010c7a1abc1a6f0136961d1e3b20b1a7326ac738fef5c721479dfd948b52fdf2455e44813ecfd8920000000000000000000000000000000008f239ba329b3967fe48d718a36cfe5f62a7e42e0bf1c1ed714150a166bfbd6bcf6b3b58b975b9edea56d53f23a0e849"
+	blsG2x8 = "0000000000000000000000000000000002142a58bae275564a6d63cb6bd6266ca66bef07a6ab8ca37b9d0ba2d4effbccfd89c169649f7d0e8a3eb006846579ad0000000000000000000000000000000012be651a5fa620340d418834526d37a8c932652345400b4cd9d43c8f41c080f41a6d9558118ebeab9d4268bb73e850e10000000000000000000000000000000015f4b235c209d89ce833f8f296e4cfb748e8abce6990ce1a5a914b9416c08e0d3a26db89625915c821a5f152b7fa592e0000000000000000000000000000000006fcacb3ee6650a1044852d61c9c20bedc8ee90aad97de8e24670a9ef57483e678db11dd95428915088d76e30cb01a37"
+
+	blsG1Inf = strings.Repeat("0", 256)
+	blsG2Inf = strings.Repeat("0", 512)
+)
+
+func blsScalar(k string) string {
+	return strings.Repeat("0", 64-len(k)) + k
+}
+
+func blsFp(x string) string {
+	return strings.Repeat("0", 128-len(x)) + x
+}
+
+func TestBLSG1Add(t *testing.T) {
+	var tests = []precompiledTest{
+		{
+			Name:     "g1+2g1",
+			Input:    blsG1 + blsG1x2,
+			Expected: blsG1x3,
+		},
+		{
+			Name:     "g1+inf",
+			Input:    blsG1 + blsG1Inf,
+			Expected: blsG1,
+		},
+		{
+			Name:     "2g1+(-2g1)",
+			Input:    blsG1x2 + blsG1Neg2,
+			Expected: blsG1Inf,
+		},
+	}
+	testPrecompiled(t, &blsG1Add{}, tests)
+}
+
+func TestBLSG1Mul(t *testing.T) {
+	var tests = []precompiledTest{
+		{
+			Name:     "g1*8",
+			Input:    blsG1 + blsScalar("8"),
+			Expected: blsG1x8,
+		},
+		{
+			Name:     "g1*0",
+			Input:    blsG1 + blsScalar("0"),
+			Expected: blsG1Inf,
+		},
+		{
+			Name:     "g1*r",
+			Input:    blsG1 + blsScalar("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001"),
+			Expected: blsG1Inf,
+		},
+	}
+	testPrecompiled(t, &blsG1Mul{}, tests)
+}
+
+func TestBLSG1MultiExp(t *testing.T) {
+	var tests = []precompiledTest{
+		{
+			Name:     "g1*2+2g1*3",
+			Input:    blsG1 + blsScalar("2") + blsG1x2 + blsScalar("3"),
+			Expected: blsG1x8,
+		},
+		{
+			// the windows of the scalars are larger than one bit
+			Name:     "g1+2g1+3g1*0+g1*5",
+			Input:    blsG1 + blsScalar("1") + blsG1x2 + blsScalar("1") + blsG1x3 + blsScalar("0") + blsG1 + blsScalar("5"),
+			Expected: blsG1x8,
+		},
+	}
+	testPrecompiled(t, &blsG1MultiExp{}, tests)
+}
+
+func TestBLSG2Add(t *testing.T) {
+	var tests = []precompiledTest{
+		{
+			Name:     "g2+2g2",
+			Input:    blsG2 + blsG2x2,
+			Expected: blsG2x3,
+		},
+		{
+			Name:     "inf+g2",
+			Input:    blsG2Inf + blsG2,
+			Expected: blsG2,
+		},
+	}
+	testPrecompiled(t, &blsG2Add{}, tests)
+}
+
+func TestBLSG2Mul(t *testing.T) {
+	var tests = []precompiledTest{
+		{
+			Name:     "g2*8",
+			Input:    blsG2 + blsScalar("8"),
+			Expected: blsG2x8,
+		},
+	}
+	testPrecompiled(t, &blsG2Mul{}, tests)
+}
+
+func TestBLSG2MultiExp(t *testing.T) {
+	var tests = []precompiledTest{
+		{
+			Name:     "g2*2+2g2*3",
+			Input:    blsG2 + blsScalar("2") + blsG2x2 + blsScalar("3"),
+			Expected: blsG2x8,
+		},
+	}
+	testPrecompiled(t, &blsG2MultiExp{}, tests)
+}
+
+func TestBLSPairing(t *testing.T) {
+	var tests = []precompiledTest{
+		{
+			Name:     "e(g1,2g2)e(-2g1,g2)",
+			Input:    blsG1 + blsG2x2 + blsG1Neg2 + blsG2,
+			Expected: hex.EncodeToString(trueBytes),
+		},
+		{
+			Name:     "e(g1,g2)",
+			Input:    blsG1 + blsG2,
+			Expected: hex.EncodeToString(falseBytes),
+		},
+		{
+			Name:     "e(inf,g2)",
+			Input:    blsG1Inf + blsG2,
+			Expected: hex.EncodeToString(trueBytes),
+		},
+	}
+	testPrecompiled(t, &blsPairing{}, tests)
+}
+
+// blsMapVectors are the encode_to_curve vectors of the hash to curve
+// specification (RFC 9380, appendix J). The field elements u are mapped
+// to the points P by the map precompiles of EIP-2537.
+type blsMapVectors struct {
+	Vectors []struct {
+		P struct {
+			X string `json:"x"`
+			Y string `json:"y"`
+		}
+		U []string `json:"u"`
+	} `json:"vectors"`
+}
+
+// blsFpVector encodes the comma separated field elements of a vector
+func blsFpVector(str string) string {
+	res := ""
+	for _, x := range strings.Split(str, ",") {
+		res += blsFp(strings.TrimPrefix(x, "0x"))
+	}
+	return res
+}
+
+func readBLSMapVectors(t *testing.T, name string) []precompiledTest {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors blsMapVectors
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []precompiledTest{}
+	for _, v := range vectors.Vectors {
+		tests = append(tests, precompiledTest{
+			Input:    blsFpVector(v.U[0]),
+			Expected: blsFpVector(v.P.X) + blsFpVector(v.P.Y),
+		})
+	}
+	return tests
+}
+
+func TestBLSMapToCurve(t *testing.T) {
+	testPrecompiled(t, &blsMapG1{}, readBLSMapVectors(t, "BLS12381G1_XMD_SHA-256_SSWU_NU_.json"))
+	testPrecompiled(t, &blsMapG2{}, readBLSMapVectors(t, "BLS12381G2_XMD_SHA-256_SSWU_NU_.json"))
+}
+
+// blsVector is one of the test vectors of the EIP-2537 precompiles in
+// testdata/eip2537, the failure vectors have an expected error instead
+// of an output and a gas cost
+type blsVector struct {
+	Name          string
+	Input         string
+	Expected      string
+	ExpectedError string
+	Gas           uint64
+}
+
+func readBLSVectors(t *testing.T, name string) []blsVector {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "eip2537", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []blsVector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	return vectors
+}
+
+func TestBLSVectors(t *testing.T) {
+	cases := map[string]contract{
+		"blsG1Add":      &blsG1Add{},
+		"blsG1Mul":      &blsG1Mul{},
+		"blsG1MultiExp": &blsG1MultiExp{},
+		"blsG2Add":      &blsG2Add{},
+		"blsG2Mul":      &blsG2Mul{},
+		"blsG2MultiExp": &blsG2MultiExp{},
+		"blsPairing":    &blsPairing{},
+		"blsMapG1":      &blsMapG1{},
+		"blsMapG2":      &blsMapG2{},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			for _, v := range readBLSVectors(t, name+".json") {
+				input, _ := hex.DecodeString(v.Input)
+				assert.Equal(t, v.Gas, c.gas(input), v.Name)
+
+				found, err := c.run(input)
+				assert.NoError(t, err, v.Name)
+				assert.Equal(t, v.Expected, hex.EncodeToString(found), v.Name)
+			}
+			for _, v := range readBLSVectors(t, "fail-"+name+".json") {
+				input, _ := hex.DecodeString(v.Input)
+				_, err := c.run(input)
+				assert.Error(t, err, v.Name+": "+v.ExpectedError)
+			}
+		})
+	}
+}
+
+func TestBLSInvalidInput(t *testing.T) {
+	notOnCurve := blsG1[:255] + "0"
+
+	cases := []struct {
+		c     contract
+		input string
+	}{
+		{&blsG1Add{}, blsG1},
+		{&blsG1Add{}, blsG1 + notOnCurve},
+		{&blsG1Mul{}, blsG1},
+		{&blsG1MultiExp{}, ""},
+		{&blsG2Add{}, blsG2 + blsG2[:510]},
+		{&blsPairing{}, notOnCurve + blsG2},
+		{&blsMapG1{}, blsG1},
+		// the field element is larger than the modulus
+		{&blsMapG1{}, strings.Repeat("f", 128)},
+	}
+	for _, c := range cases {
+		input, _ := hex.DecodeString(c.input)
+		_, err := c.c.run(input)
+		assert.Error(t, err)
+	}
+}
+
+func TestBLSMultiExpGas(t *testing.T) {
+	assert.Equal(t, uint64(14400), (&blsG1MultiExp{}).gas(make([]byte, 160)))
+	assert.Equal(t, uint64(2*12000*888/1000), (&blsG1MultiExp{}).gas(make([]byte, 2*160)))
+	assert.Equal(t, uint64(200*55000*174/1000), (&blsG2MultiExp{}).gas(make([]byte, 200*288)))
+}
+
+func TestBLSForkGate(t *testing.T) {
+	p := NewPrecompiled()
+	host := &mockHost{}
+
+	for _, addr := range []string{"9", "a", "10", "12", "13"} {
+		c := &runtime.Contract{CodeAddress: types.StringToAddress(addr)}
+
+		expected := addr != "9" && addr != "13"
+		assert.Equal(t, expected, p.CanRun(c, host, &chain.ForksInTime{EIP2537: true}), addr)
+		assert.False(t, p.CanRun(c, host, &chain.ForksInTime{}), addr)
+	}
+}
@@ -1,7 +1,10 @@
 package precompiled
 
 import (
+	"bytes"
 	"encoding/binary"
+	"fmt"
+	"sync"
 
 	"github.com/umbracle/minimal/chain"
 	"github.com/umbracle/minimal/state/runtime"
@@ -15,10 +18,36 @@ type contract interface {
 	run(input []byte) ([]byte, error)
 }
 
+// Contract is a native contract that can be registered on a custom address
+type Contract interface {
+	// Gas returns the gas cost to execute the contract with the given input
+	Gas(input []byte, config *chain.ForksInTime) uint64
+
+	// Run executes the contract. The state of the chain can be accessed
+	// and modified through the host. The contract is responsible of
+	// rejecting writes if the call is static (c.Static). It can be
+	// called concurrently if the parallel execution is enabled.
+	Run(c *runtime.Contract, host runtime.Host, config *chain.ForksInTime) ([]byte, error)
+}
+
+// Factory is the factory function to create a custom precompiled contract
+type Factory func(config map[string]interface{}) (Contract, error)
+
+type customContract struct {
+	Contract
+
+	// block from which the contract is available
+	block uint64
+}
+
 // Precompiled is the runtime for the precompiled contracts
 type Precompiled struct {
+	// lock protects buf since the runtime can be used
+	// by several transactions at the same time
+	lock      sync.Mutex
 	buf       []byte
 	contracts map[types.Address]contract
+	custom    map[types.Address]*customContract
 }
 
 // NewPrecompiled creates a new runtime for the precompiled contracts
@@ -39,6 +68,17 @@ func (p *Precompiled) setupContracts() {
 	p.register("6", &bn256Add{p})
 	p.register("7", &bn256Mul{p})
 	p.register("8", &bn256Pairing{p})
+
+	// EIP-2537 fork
+	p.register("a", &blsG1Add{})
+	p.register("b", &blsG1Mul{})
+	p.register("c", &blsG1MultiExp{})
+	p.register("d", &blsG2Add{})
+	p.register("e", &blsG2Mul{})
+	p.register("f", &blsG2MultiExp{})
+	p.register("10", &blsPairing{})
+	p.register("11", &blsMapG1{})
+	p.register("12", &blsMapG2{})
 }
 
 func (p *Precompiled) register(addrStr string, b contract) {
@@ -48,11 +88,31 @@ func (p *Precompiled) register(addrStr string, b contract) {
 	p.contracts[types.StringToAddress(addrStr)] = b
 }
 
+// Register registers a custom precompiled contract on the given address.
+// The contract is only available from the given block number.
+func (p *Precompiled) Register(addr types.Address, c Contract, block uint64) error {
+	if _, ok := p.contracts[addr]; ok {
+		return fmt.Errorf("address %s is reserved for a precompiled contract", addr)
+	}
+	if _, ok := p.custom[addr]; ok {
+		return fmt.Errorf("precompiled contract already registered at %s", addr)
+	}
+	if len(p.custom) == 0 {
+		p.custom = map[types.Address]*customContract{}
+	}
+	p.custom[addr] = &customContract{c, block}
+	return nil
+}
+
 var (
 	five  = types.StringToAddress("5")
 	six   = types.StringToAddress("6")
 	seven = types.StringToAddress("7")
 	eight = types.StringToAddress("8")
+
+	// first and last address of the EIP-2537 precompiles
+	blsFirst = types.StringToAddress("a")
+	blsLast  = types.StringToAddress("12")
 )
 
 // CanRun implements the runtime interface
@@ -62,7 +122,11 @@ func (p *Precompiled) CanRun(c *runtime.Contract, host runtime.Host, config *cha
 	//fmt.Println(config.Byzantium)
 
 	if _, ok := p.contracts[c.CodeAddress]; !ok {
-		return false
+		custom, ok := p.custom[c.CodeAddress]
+		if !ok {
+			return false
+		}
+		return uint64(host.GetTxContext().Number) >= custom.block
 	}
 
 	// byzantium precompiles
@@ -77,6 +141,11 @@ func (p *Precompiled) CanRun(c *runtime.Contract, host runtime.Host, config *cha
 		return config.Byzantium
 	}
 
+	// EIP-2537 precompiles
+	if bytes.Compare(c.CodeAddress[:], blsFirst[:]) >= 0 && bytes.Compare(c.CodeAddress[:], blsLast[:]) <= 0 {
+		return config.EIP2537
+	}
+
 	return true
 }
 
@@ -87,6 +156,13 @@ func (p *Precompiled) Name() string {
 
 // Run runs an execution
 func (p *Precompiled) Run(c *runtime.Contract, host runtime.Host, config *chain.ForksInTime) ([]byte, uint64, error) {
+	if custom, ok := p.custom[c.CodeAddress]; ok {
+		return p.runCustom(custom, c, host, config)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
 	contract := p.contracts[c.CodeAddress]
 	gasCost := contract.gas(c.Input)
 
@@ -106,6 +182,20 @@ func (p *Precompiled) Run(c *runtime.Contract, host runtime.Host, config *chain.
 	return ret, c.Gas, err
 }
 
+func (p *Precompiled) runCustom(contract *customContract, c *runtime.Contract, host runtime.Host, config *chain.ForksInTime) ([]byte, uint64, error) {
+	gasCost := contract.Gas(c.Input, config)
+	if c.Gas < gasCost {
+		return nil, 0, runtime.ErrGasOverflow
+	}
+
+	c.Gas = c.Gas - gasCost
+	ret, err := contract.Run(c, host, config)
+	if err != nil {
+		return nil, 0, err
+	}
+	return ret, c.Gas, nil
+}
+
 var zeroPadding = make([]byte, 64)
 
 func (p *Precompiled) leftPad(buf []byte, n int) []byte {
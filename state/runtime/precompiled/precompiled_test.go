@@ -0,0 +1,71 @@
+package precompiled
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/minimal/chain"
+	"github.com/umbracle/minimal/state/runtime"
+	"github.com/umbracle/minimal/types"
+)
+
+type mockHost struct {
+	runtime.Host
+	number  int64
+	storage map[types.Hash]types.Hash
+}
+
+func (m *mockHost) GetTxContext() runtime.TxContext {
+	return runtime.TxContext{Number: m.number}
+}
+
+func (m *mockHost) SetStorage(addr types.Address, key types.Hash, value types.Hash, discount bool) runtime.StorageStatus {
+	m.storage[key] = value
+	return runtime.StorageModified
+}
+
+// storeContract stores the input under a fixed key
+type storeContract struct{}
+
+func (s *storeContract) Gas(input []byte, config *chain.ForksInTime) uint64 {
+	return 100
+}
+
+func (s *storeContract) Run(c *runtime.Contract, host runtime.Host, config *chain.ForksInTime) ([]byte, error) {
+	host.SetStorage(c.Address, types.Hash{}, types.BytesToHash(c.Input), false)
+	return c.Input, nil
+}
+
+func TestPrecompiledCustomContract(t *testing.T) {
+	addr := types.StringToAddress("100")
+
+	p := NewPrecompiled()
+	assert.NoError(t, p.Register(addr, &storeContract{}, 10))
+
+	// addresses cannot be registered twice or collide with the builtin contracts
+	assert.Error(t, p.Register(addr, &storeContract{}, 10))
+	assert.Error(t, p.Register(types.StringToAddress("1"), &storeContract{}, 0))
+
+	host := &mockHost{storage: map[types.Hash]types.Hash{}}
+	config := &chain.ForksInTime{}
+
+	c := &runtime.Contract{
+		Address:     addr,
+		CodeAddress: addr,
+		Input:       []byte{0x1},
+		Gas:         1000,
+	}
+
+	// not active before the block
+	host.number = 9
+	assert.False(t, p.CanRun(c, host, config))
+
+	host.number = 10
+	assert.True(t, p.CanRun(c, host, config))
+
+	ret, gas, err := p.Run(c, host, config)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x1}, ret)
+	assert.Equal(t, uint64(900), gas)
+	assert.Equal(t, types.BytesToHash([]byte{0x1}), host.storage[types.Hash{}])
+}
@@ -22,6 +22,12 @@ type Snapshot interface {
 	Commit(objs []*Object) (Snapshot, []byte)
 }
 
+// storageSnapshot is implemented by the snapshots that can read
+// the storage of an account without its trie
+type storageSnapshot interface {
+	StorageSnapshot(addrHash []byte, root types.Hash) (Snapshot, error)
+}
+
 // account trie
 type accountTrie interface {
 	Get(k []byte) ([]byte, bool)
@@ -6,6 +6,7 @@ import (
 	"hash"
 	"math/big"
 	"strconv"
+	"sync"
 
 	iradix "github.com/hashicorp/go-immutable-radix"
 	lru "github.com/hashicorp/golang-lru"
@@ -38,6 +39,15 @@ type Txn struct {
 	txn       *iradix.Txn
 	codeCache *lru.Cache
 	hash      *keccak.Keccak
+
+	// reads and writes record the accounts and slots accessed
+	// during the parallel execution of the transactions
+	reads  *accessSet
+	writes *accessSet
+
+	// lock serializes the access to the snapshot if it is shared
+	// among several transactions running concurrently
+	lock *sync.Mutex
 }
 
 func NewTxn(state State, snapshot Snapshot) *Txn {
@@ -101,6 +111,11 @@ func (txn *Txn) GetAccount(addr types.Address) (*Account, bool) {
 }
 
 func (txn *Txn) getStateObject(addr types.Address) (*StateObject, bool) {
+	txn.reads.addAccount(addr)
+	return txn.loadStateObject(addr)
+}
+
+func (txn *Txn) loadStateObject(addr types.Address) (*StateObject, bool) {
 	val, exists := txn.txn.Get(addr.Bytes())
 	if exists {
 		obj := val.(*StateObject)
@@ -110,6 +125,11 @@ func (txn *Txn) getStateObject(addr types.Address) (*StateObject, bool) {
 		return obj.Copy(), true
 	}
 
+	if txn.lock != nil {
+		txn.lock.Lock()
+		defer txn.lock.Unlock()
+	}
+
 	data, ok := txn.snapshot.Get(txn.hashit(addr.Bytes()))
 	if !ok {
 		return nil, false
@@ -124,6 +144,11 @@ func (txn *Txn) getStateObject(addr types.Address) (*StateObject, bool) {
 	// Load trie from memory if there is some state
 	if account.Root == emptyStateHash {
 		account.Trie = txn.state.NewSnapshot()
+	} else if snap, ok := txn.snapshot.(storageSnapshot); ok {
+		account.Trie, err = snap.StorageSnapshot(txn.hashit(addr.Bytes()), account.Root)
+		if err != nil {
+			return nil, false
+		}
 	} else {
 		account.Trie, err = txn.state.NewSnapshotAt(account.Root)
 		if err != nil {
@@ -155,12 +180,25 @@ func (txn *Txn) upsertAccount(addr types.Address, create bool, f func(object *St
 
 	if object != nil {
 		txn.txn.Insert(addr.Bytes(), object)
+		txn.writes.addAccount(addr)
+	}
+}
+
+// committedState returns the value of the slot in the trie of the account
+func (txn *Txn) committedState(object *StateObject, key types.Hash) types.Hash {
+	k := types.BytesToHash(txn.hashit(key.Bytes()))
+
+	if txn.lock != nil {
+		txn.lock.Lock()
+		defer txn.lock.Unlock()
 	}
+	return object.GetCommitedState(k)
 }
 
 func (txn *Txn) AddSealingReward(addr types.Address, balance *big.Int) {
 	txn.upsertAccount(addr, true, func(object *StateObject) {
 		if object.Suicide {
+			txn.writes.addReset(addr)
 			*object = *newStateObject(txn)
 			object.Account.Balance.SetBytes(balance.Bytes())
 		} else {
@@ -305,23 +343,35 @@ func (txn *Txn) SetStorage(addr types.Address, key types.Hash, value types.Hash,
 
 // SetState change the state of an address
 func (txn *Txn) SetState(addr types.Address, key, value types.Hash) {
-	txn.upsertAccount(addr, true, func(object *StateObject) {
-		if object.Txn == nil {
-			object.Txn = iradix.New().Txn()
-		}
+	// only the slot is accessed unless the account has to be created
+	object, exists := txn.loadStateObject(addr)
+	if !exists {
+		txn.reads.addAccount(addr)
+		txn.writes.addAccount(addr)
+		object = newStateObject(txn)
+	}
+	txn.reads.addSlot(addr, key)
+	txn.writes.addSlot(addr, key)
 
-		if value == zeroHash {
-			object.Txn.Insert(key.Bytes(), nil)
-		} else {
-			object.Txn.Insert(key.Bytes(), value.Bytes())
-		}
-	})
+	if object.Txn == nil {
+		object.Txn = iradix.New().Txn()
+	}
+
+	if value == zeroHash {
+		object.Txn.Insert(key.Bytes(), nil)
+	} else {
+		object.Txn.Insert(key.Bytes(), value.Bytes())
+	}
+	txn.txn.Insert(addr.Bytes(), object)
 }
 
 // GetState returns the state of the address at a given hash
 func (txn *Txn) GetState(addr types.Address, hash types.Hash) types.Hash {
-	object, exists := txn.getStateObject(addr)
+	txn.reads.addSlot(addr, hash)
+
+	object, exists := txn.loadStateObject(addr)
 	if !exists {
+		txn.reads.addAccount(addr)
 		return types.Hash{}
 	}
 
@@ -334,8 +384,7 @@ func (txn *Txn) GetState(addr types.Address, hash types.Hash) types.Hash {
 		}
 	}
 
-	k := txn.hashit(hash.Bytes())
-	return object.GetCommitedState(types.BytesToHash(k))
+	return txn.committedState(object, hash)
 }
 
 // Nonce
@@ -383,12 +432,15 @@ func (txn *Txn) GetCode(addr types.Address) []byte {
 		return object.Code
 	}
 	// TODO; Should we move this to state?
-	v, ok := txn.codeCache.Get(addr)
+	// the cache is indexed by the code hash since the account may be
+	// destroyed and created again without code during the transaction
+	codeHash := types.BytesToHash(object.Account.CodeHash)
+	v, ok := txn.codeCache.Get(codeHash)
 	if ok {
 		return v.([]byte)
 	}
-	code, _ := txn.state.GetCode(types.BytesToHash(object.Account.CodeHash))
-	txn.codeCache.Add(addr, code)
+	code, _ := txn.state.GetCode(codeHash)
+	txn.codeCache.Add(codeHash, code)
 	return code
 }
 
@@ -408,6 +460,9 @@ func (txn *Txn) GetCodeHash(addr types.Address) types.Hash {
 func (txn *Txn) Suicide(addr types.Address) bool {
 	var suicided bool
 	txn.upsertAccount(addr, false, func(object *StateObject) {
+		if object != nil {
+			txn.writes.addReset(addr)
+		}
 		if object == nil || object.Suicide {
 			suicided = false
 		} else {
@@ -457,17 +512,27 @@ func (txn *Txn) GetRefund() uint64 {
 
 // GetCommittedState returns the state of the address in the trie
 func (txn *Txn) GetCommittedState(addr types.Address, hash types.Hash) types.Hash {
-	obj, ok := txn.getStateObject(addr)
+	txn.reads.addSlot(addr, hash)
+
+	obj, ok := txn.loadStateObject(addr)
 	if !ok {
+		txn.reads.addAccount(addr)
 		return types.Hash{}
 	}
-	return obj.GetCommitedState(types.BytesToHash(txn.hashit(hash.Bytes())))
+	return txn.committedState(obj, hash)
 }
 
 func (txn *Txn) TouchAccount(addr types.Address) {
-	txn.upsertAccount(addr, true, func(obj *StateObject) {
-
-	})
+	// touching an existing account does not modify it, it only
+	// makes it a candidate to be removed if it is empty
+	object, exists := txn.getStateObject(addr)
+	if !exists {
+		txn.writes.addAccount(addr)
+		object = newStateObject(txn)
+	} else {
+		txn.writes.addTouch(addr)
+	}
+	txn.txn.Insert(addr.Bytes(), object)
 }
 
 // TODO, check panics with this ones
@@ -512,6 +577,7 @@ func (txn *Txn) CreateAccount(addr types.Address) {
 	}
 
 	txn.txn.Insert(addr.Bytes(), obj)
+	txn.writes.addReset(addr)
 }
 
 func (txn *Txn) CleanDeleteObjects(deleteEmptyObjects bool) {
@@ -540,6 +606,8 @@ func (txn *Txn) CleanDeleteObjects(deleteEmptyObjects bool) {
 		obj2 := obj.Copy()
 		obj2.Deleted = true
 		txn.txn.Insert(k, obj2)
+
+		txn.writes.addReset(types.BytesToAddress(k))
 	}
 
 	// delete refunds
@@ -598,14 +666,14 @@ func show(objs []*Object) {
 	fmt.Println("##################################################################################")
 }
 
-func (txn *Txn) Commit(deleteEmptyObjects bool) (Snapshot, []byte) {
-	txn.CleanDeleteObjects(deleteEmptyObjects)
-
-	x := txn.txn.Commit()
+// Objects returns the accounts modified in the transaction
+func (txn *Txn) Objects() []*Object {
+	return objectsOf(txn.txn.Root())
+}
 
-	// Do a more complex thing for now
+func objectsOf(root *iradix.Node) []*Object {
 	objs := []*Object{}
-	x.Root().Walk(func(k []byte, v interface{}) bool {
+	root.Walk(func(k []byte, v interface{}) bool {
 		a, ok := v.(*StateObject)
 		if !ok {
 			// We also have logs, avoid those
@@ -641,6 +709,16 @@ func (txn *Txn) Commit(deleteEmptyObjects bool) (Snapshot, []byte) {
 		objs = append(objs, obj)
 		return false
 	})
+	return objs
+}
+
+func (txn *Txn) Commit(deleteEmptyObjects bool) (Snapshot, []byte) {
+	txn.CleanDeleteObjects(deleteEmptyObjects)
+
+	x := txn.txn.Commit()
+
+	// Do a more complex thing for now
+	objs := objectsOf(x.Root())
 	// show(objs)
 
 	t, hash := txn.snapshot.Commit(objs)
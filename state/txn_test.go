@@ -16,6 +16,7 @@ import (
 
 type mockState struct {
 	snapshots map[types.Hash]Snapshot
+	code      map[types.Hash][]byte
 }
 
 func (m *mockState) NewSnapshotAt(root types.Hash) (Snapshot, error) {
@@ -31,7 +32,11 @@ func (m *mockState) NewSnapshot() Snapshot {
 }
 
 func (m *mockState) GetCode(hash types.Hash) ([]byte, bool) {
-	panic("Not implemented in tests")
+	if m.code == nil {
+		panic("Not implemented in tests")
+	}
+	code, ok := m.code[hash]
+	return code, ok
 }
 
 type mockSnapshot struct {
@@ -129,6 +134,36 @@ func TestSnapshotUpdateData(t *testing.T) {
 	assert.Equal(t, hash1, txn.GetState(addr1, hash1))
 }
 
+func TestTxnCodeCache(t *testing.T) {
+	code := []byte{0x1, 0x2}
+	codeHash := types.BytesToHash(hashit(code))
+
+	state := &mockState{
+		snapshots: map[types.Hash]Snapshot{},
+		code:      map[types.Hash][]byte{codeHash: code},
+	}
+	account := &Account{
+		Balance:  big.NewInt(1),
+		Root:     emptyStateHash,
+		CodeHash: codeHash.Bytes(),
+	}
+	snapshot := &mockSnapshot{
+		data: map[string][]byte{
+			hex.EncodeToHex(hashit(addr1.Bytes())): account.MarshalWith(&fastrlp.Arena{}).MarshalTo(nil),
+		},
+	}
+
+	txn := newTxn(state, snapshot)
+	assert.Equal(t, code, txn.GetCode(addr1))
+
+	// the account is destroyed and created again without code
+	assert.True(t, txn.Suicide(addr1))
+	txn.CleanDeleteObjects(true)
+	txn.AddBalance(addr1, big.NewInt(1))
+
+	assert.Empty(t, txn.GetCode(addr1))
+}
+
 func hashit(k []byte) []byte {
 	h := sha3.NewLegacyKeccak256()
 	h.Write(k)
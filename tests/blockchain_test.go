@@ -111,16 +111,24 @@ func testBlockChainCase(t *testing.T, c *BlockchainTest) {
 	executor.SetRuntime(precompiled.NewPrecompiled())
 	executor.SetRuntime(evm.NewEVM())
 
+	// the blocks are executed again in parallel at the end
+	parallel := state.NewExecutor(params, st)
+	parallel.SetRuntime(precompiled.NewPrecompiled())
+	parallel.SetRuntime(evm.NewEVM())
+	parallel.SetParallel(4)
+
 	b := blockchain.NewBlockchain(s, engine, executor)
 	if err := b.WriteGenesis(genesis); err != nil {
 		t.Fatal(err)
 	}
 
 	executor.GetHash = b.GetHashHelper
+	parallel.GetHash = b.GetHashHelper
 
 	// Change the dao block
 	if c.Network == "HomesteadToDaoAt5" {
 		b.Executor().SetDAOHardFork(5)
+		parallel.SetDAOHardFork(5)
 		// b.SetDAOBlock(5)
 		engine.(*ethash.Ethash).SetDAOBlock(5)
 	}
@@ -206,6 +214,40 @@ func testBlockChainCase(t *testing.T, c *BlockchainTest) {
 			t.Fatalf("Headers are not equal")
 		}
 	}
+
+	testParallelBlocks(t, b, parallel)
+}
+
+// testParallelBlocks executes the canonical blocks of the chain with the
+// sequential and the parallel execution and compares the results
+func testParallelBlocks(t *testing.T, b *blockchain.Blockchain, parallel *state.Executor) {
+	head, _ := b.Header()
+	for n := uint64(1); n <= head.Number; n++ {
+		block, ok := b.GetBlockByNumber(n, true)
+		if !ok {
+			t.Fatalf("block %d not found", n)
+		}
+		parent, ok := b.GetHeaderByHash(block.ParentHash())
+		if !ok {
+			t.Fatalf("parent of block %d not found", n)
+		}
+
+		seqTxn, seqRoot, err := b.Executor().ProcessBlock(parent.StateRoot, block)
+		if err != nil {
+			t.Fatalf("failed to execute block %d: %v", n, err)
+		}
+		parTxn, parRoot, err := parallel.ProcessBlock(parent.StateRoot, block)
+		if err != nil {
+			t.Fatalf("failed to execute block %d in parallel: %v", n, err)
+		}
+
+		if seqRoot != parRoot {
+			t.Fatalf("block %d: state root mismatch: sequential %s but parallel %s", n, seqRoot.String(), parRoot.String())
+		}
+		if !reflect.DeepEqual(seqTxn.Receipts(), parTxn.Receipts()) {
+			t.Fatalf("block %d: receipts mismatch", n)
+		}
+	}
 }
 
 func testBlockChainCases(t *testing.T, folder string, skip []string) {
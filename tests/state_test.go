@@ -1,83 +1,14 @@
 package tests
 
 import (
-	"bytes"
-	"encoding/json"
-	"io/ioutil"
-	"math/big"
 	"strings"
 	"testing"
 
-	"github.com/umbracle/minimal/chain"
-	"github.com/umbracle/minimal/helper/hex"
-	"github.com/umbracle/minimal/state"
-	"github.com/umbracle/minimal/state/runtime/evm"
-	"github.com/umbracle/minimal/state/runtime/precompiled"
-	"github.com/umbracle/minimal/types"
+	"github.com/umbracle/minimal/tests/statetest"
 )
 
 var stateTests = "GeneralStateTests"
 
-type stateCase struct {
-	Info        *info                `json:"_info"`
-	Env         *env                 `json:"env"`
-	Pre         chain.GenesisAlloc   `json:"pre"`
-	Post        map[string]postState `json:"post"`
-	Transaction *stTransaction       `json:"transaction"`
-}
-
-var ripemd = types.StringToAddress("0000000000000000000000000000000000000003")
-
-func RunSpecificTest(file string, t *testing.T, c stateCase, name, fork string, index int, p postEntry) {
-	config, ok := Forks[fork]
-	if !ok {
-		t.Fatalf("config %s not found", fork)
-	}
-
-	env := c.Env.ToEnv(t)
-
-	msg, err := c.Transaction.At(p.Indexes)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	s, _, pastRoot := buildState(t, c.Pre)
-	forks := config.At(uint64(env.Number))
-
-	xxx := state.NewExecutor(&chain.Params{Forks: config}, s)
-	xxx.SetRuntime(precompiled.NewPrecompiled())
-	xxx.SetRuntime(evm.NewEVM())
-
-	xxx.PostHook = func(t *state.Transition) {
-		if name == "failed_tx_xcf416c53" {
-			// create the account
-			t.Txn().TouchAccount(ripemd)
-			// now remove it
-			t.Txn().Suicide(ripemd)
-		}
-	}
-	xxx.GetHash = func(*types.Header) func(i uint64) types.Hash {
-		return vmTestBlockHash
-	}
-
-	executor, _ := xxx.BeginTxn(pastRoot, c.Env.ToHeader(t))
-	_, _, err = executor.Apply(msg)
-
-	txn := executor.Txn()
-
-	// mining rewards
-	txn.AddSealingReward(env.Coinbase, big.NewInt(0))
-
-	_, root := txn.Commit(forks.EIP158)
-	if !bytes.Equal(root, p.Root.Bytes()) {
-		t.Fatalf("root mismatch (%s %s %d): expected %s but found %s", name, fork, index, p.Root.String(), hex.EncodeToHex(root))
-	}
-
-	if logs := rlpHashLogs(txn.Logs()); logs != p.Logs {
-		t.Fatalf("logs mismatch (%s, %s %d): expected %s but found %s", name, fork, index, p.Logs.String(), logs.String())
-	}
-}
-
 func TestState(t *testing.T) {
 	long := []string{
 		"static_Call50000",
@@ -115,21 +46,19 @@ func TestState(t *testing.T) {
 					continue
 				}
 
-				data, err := ioutil.ReadFile(file)
+				results, err := statetest.RunFile(file, "", nil)
 				if err != nil {
 					t.Fatal(err)
 				}
-
-				var c map[string]stateCase
-				if err := json.Unmarshal(data, &c); err != nil {
-					t.Fatal(err)
-				}
-
-				for name, i := range c {
-					for fork, f := range i.Post {
-						for indx, e := range f {
-							RunSpecificTest(file, t, i, name, fork, indx, e)
-						}
+				for _, res := range results {
+					if res.Error != "" {
+						t.Fatalf("%s (%s %d): %s", res.Name, res.Fork, res.Index, res.Error)
+					}
+					if res.Root != res.ExpectedRoot {
+						t.Fatalf("root mismatch (%s %s %d): expected %s but found %s", res.Name, res.Fork, res.Index, res.ExpectedRoot.String(), res.Root.String())
+					}
+					if res.Logs != res.ExpectedLogs {
+						t.Fatalf("logs mismatch (%s, %s %d): expected %s but found %s", res.Name, res.Fork, res.Index, res.ExpectedLogs.String(), res.Logs.String())
 					}
 				}
 			}
@@ -0,0 +1,260 @@
+package statetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/umbracle/minimal/chain"
+	"github.com/umbracle/minimal/crypto"
+	"github.com/umbracle/minimal/helper/hex"
+	"github.com/umbracle/minimal/types"
+)
+
+// Case is a GeneralStateTests fixture
+type Case struct {
+	Env         *Env                    `json:"env"`
+	Pre         chain.GenesisAlloc      `json:"pre"`
+	Post        map[string][]*PostEntry `json:"post"`
+	Transaction *Transaction            `json:"transaction"`
+}
+
+// Env is the block environment of the fixture
+type Env struct {
+	Coinbase   string `json:"currentCoinbase"`
+	Difficulty string `json:"currentDifficulty"`
+	GasLimit   string `json:"currentGasLimit"`
+	Number     string `json:"currentNumber"`
+	Timestamp  string `json:"currentTimestamp"`
+}
+
+// ToHeader returns the header of the block the transaction is applied to
+func (e *Env) ToHeader() (*types.Header, error) {
+	if e.Coinbase == "" {
+		return nil, fmt.Errorf("currentCoinbase not found")
+	}
+
+	header := &types.Header{
+		Miner: types.StringToAddress(e.Coinbase),
+	}
+
+	var err error
+	if header.Difficulty, err = types.ParseUint64orHex(&e.Difficulty); err != nil {
+		return nil, fmt.Errorf("currentDifficulty: %v", err)
+	}
+	if header.GasLimit, err = types.ParseUint64orHex(&e.GasLimit); err != nil {
+		return nil, fmt.Errorf("currentGasLimit: %v", err)
+	}
+	if header.Number, err = types.ParseUint64orHex(&e.Number); err != nil {
+		return nil, fmt.Errorf("currentNumber: %v", err)
+	}
+	if header.Timestamp, err = types.ParseUint64orHex(&e.Timestamp); err != nil {
+		return nil, fmt.Errorf("currentTimestamp: %v", err)
+	}
+	return header, nil
+}
+
+// Indexes select the data, gas and value of the transaction
+type Indexes struct {
+	Data  int `json:"data"`
+	Gas   int `json:"gas"`
+	Value int `json:"value"`
+}
+
+// PostEntry is the expected result of one transaction of the fixture
+type PostEntry struct {
+	Root    types.Hash
+	Logs    types.Hash
+	Indexes Indexes
+}
+
+// UnmarshalJSON implements the json interface
+func (p *PostEntry) UnmarshalJSON(input []byte) error {
+	type stateUnmarshall struct {
+		Root    string  `json:"hash"`
+		Logs    string  `json:"logs"`
+		Indexes Indexes `json:"indexes"`
+	}
+
+	var dec stateUnmarshall
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+
+	p.Root = types.StringToHash(dec.Root)
+	p.Logs = types.StringToHash(dec.Logs)
+	p.Indexes = dec.Indexes
+
+	return nil
+}
+
+// Transaction is the set of transactions of the fixture. Each post
+// entry selects one of them with its indexes.
+type Transaction struct {
+	Data     []string
+	GasLimit []uint64
+	Value    []*big.Int
+	GasPrice *big.Int
+	Nonce    uint64
+	From     types.Address
+	To       *types.Address
+}
+
+// At returns the transaction for the given indexes
+func (t *Transaction) At(i Indexes) (*types.Transaction, error) {
+	if i.Data >= len(t.Data) {
+		return nil, fmt.Errorf("data index %d out of bounds (%d)", i.Data, len(t.Data))
+	}
+	if i.Gas >= len(t.GasLimit) {
+		return nil, fmt.Errorf("gas index %d out of bounds (%d)", i.Gas, len(t.GasLimit))
+	}
+	if i.Value >= len(t.Value) {
+		return nil, fmt.Errorf("value index %d out of bounds (%d)", i.Value, len(t.Value))
+	}
+
+	input, err := hex.DecodeHex(t.Data[i.Data])
+	if err != nil {
+		return nil, fmt.Errorf("data %d: %v", i.Data, err)
+	}
+
+	msg := &types.Transaction{
+		To:       t.To,
+		Nonce:    t.Nonce,
+		Value:    t.Value[i.Value].Bytes(),
+		Gas:      t.GasLimit[i.Gas],
+		GasPrice: t.GasPrice.Bytes(),
+		Input:    input,
+	}
+
+	msg.From = t.From
+	return msg, nil
+}
+
+// UnmarshalJSON implements the json interface
+func (t *Transaction) UnmarshalJSON(input []byte) error {
+	type txUnmarshall struct {
+		Data      []string `json:"data"`
+		GasLimit  []string `json:"gasLimit"`
+		Value     []string `json:"value"`
+		GasPrice  string   `json:"gasPrice"`
+		Nonce     string   `json:"nonce"`
+		SecretKey string   `json:"secretKey"`
+		To        string   `json:"to"`
+	}
+
+	var dec txUnmarshall
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+
+	t.Data = dec.Data
+	for _, i := range dec.GasLimit {
+		gas, err := types.ParseUint64orHex(&i)
+		if err != nil {
+			return fmt.Errorf("gasLimit: %v", err)
+		}
+		t.GasLimit = append(t.GasLimit, gas)
+	}
+
+	for _, i := range dec.Value {
+		value := new(big.Int)
+		if i != "0x" {
+			v, err := types.ParseUint256orHex(&i)
+			if err != nil {
+				return fmt.Errorf("value: %v", err)
+			}
+			value = v
+		}
+		t.Value = append(t.Value, value)
+	}
+
+	var err error
+	if t.GasPrice, err = types.ParseUint256orHex(&dec.GasPrice); err != nil {
+		return fmt.Errorf("gasPrice: %v", err)
+	}
+	if t.Nonce, err = types.ParseUint64orHex(&dec.Nonce); err != nil {
+		return fmt.Errorf("nonce: %v", err)
+	}
+
+	t.From = types.Address{}
+	if len(dec.SecretKey) > 0 {
+		secretKey, err := types.ParseBytes(&dec.SecretKey)
+		if err != nil {
+			return err
+		}
+		key, err := crypto.ParsePrivateKey(secretKey)
+		if err != nil {
+			return fmt.Errorf("invalid private key: %v", err)
+		}
+		t.From = crypto.PubKeyToAddress(&key.PublicKey)
+	}
+
+	if dec.To != "" {
+		address := types.StringToAddress(dec.To)
+		t.To = &address
+	}
+	return nil
+}
+
+// Forks are the fork configurations used in the fixtures
+var Forks = map[string]*chain.Forks{
+	"Frontier": {},
+	"Homestead": {
+		Homestead: chain.NewFork(0),
+	},
+	"EIP150": {
+		Homestead: chain.NewFork(0),
+		EIP150:    chain.NewFork(0),
+	},
+	"EIP158": {
+		Homestead: chain.NewFork(0),
+		EIP150:    chain.NewFork(0),
+		EIP155:    chain.NewFork(0),
+		EIP158:    chain.NewFork(0),
+	},
+	"Byzantium": {
+		Homestead: chain.NewFork(0),
+		EIP150:    chain.NewFork(0),
+		EIP155:    chain.NewFork(0),
+		EIP158:    chain.NewFork(0),
+		Byzantium: chain.NewFork(0),
+	},
+	"Constantinople": {
+		Homestead:      chain.NewFork(0),
+		EIP150:         chain.NewFork(0),
+		EIP155:         chain.NewFork(0),
+		EIP158:         chain.NewFork(0),
+		Byzantium:      chain.NewFork(0),
+		Constantinople: chain.NewFork(0),
+	},
+	"FrontierToHomesteadAt5": {
+		Homestead: chain.NewFork(5),
+	},
+	"HomesteadToEIP150At5": {
+		Homestead: chain.NewFork(0),
+		EIP150:    chain.NewFork(5),
+	},
+	"HomesteadToDaoAt5": {
+		Homestead: chain.NewFork(0),
+	},
+	"EIP158ToByzantiumAt5": {
+		Homestead: chain.NewFork(0),
+		EIP150:    chain.NewFork(0),
+		EIP155:    chain.NewFork(0),
+		EIP158:    chain.NewFork(0),
+		Byzantium: chain.NewFork(5),
+	},
+	"ByzantiumToConstantinopleAt5": {
+		Byzantium:      chain.NewFork(0),
+		Constantinople: chain.NewFork(5),
+	},
+	"ConstantinopleFix": {
+		Homestead:      chain.NewFork(0),
+		EIP150:         chain.NewFork(0),
+		EIP155:         chain.NewFork(0),
+		EIP158:         chain.NewFork(0),
+		Byzantium:      chain.NewFork(0),
+		Constantinople: chain.NewFork(0),
+		Petersburg:     chain.NewFork(0),
+	},
+}
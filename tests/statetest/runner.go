@@ -0,0 +1,268 @@
+package statetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/umbracle/fastrlp"
+	"github.com/umbracle/minimal/chain"
+	"github.com/umbracle/minimal/crypto"
+	"github.com/umbracle/minimal/helper/hex"
+	"github.com/umbracle/minimal/helper/keccak"
+	"github.com/umbracle/minimal/state"
+	itrie "github.com/umbracle/minimal/state/immutable-trie"
+	"github.com/umbracle/minimal/state/runtime/evm"
+	"github.com/umbracle/minimal/state/runtime/precompiled"
+	"github.com/umbracle/minimal/types"
+)
+
+var ripemd = types.StringToAddress("0000000000000000000000000000000000000003")
+
+// Result is the outcome of one post state of a fixture
+type Result struct {
+	File         string     `json:"file,omitempty"`
+	Name         string     `json:"name"`
+	Fork         string     `json:"fork"`
+	Index        int        `json:"index"`
+	Pass         bool       `json:"pass"`
+	Root         types.Hash `json:"stateRoot"`
+	ExpectedRoot types.Hash `json:"expectedStateRoot"`
+	Logs         types.Hash `json:"logsHash"`
+	ExpectedLogs types.Hash `json:"expectedLogsHash"`
+	Error        string     `json:"error,omitempty"`
+
+	// PostState are the accounts modified by the transaction. It is
+	// only set if the result does not match the expected one.
+	PostState []*Account `json:"postState,omitempty"`
+}
+
+// Account is an account modified by the transaction
+type Account struct {
+	Address  types.Address     `json:"address"`
+	Deleted  bool              `json:"deleted,omitempty"`
+	Balance  string            `json:"balance"`
+	Nonce    uint64            `json:"nonce"`
+	CodeHash types.Hash        `json:"codeHash"`
+	Storage  map[string]string `json:"storage,omitempty"`
+}
+
+// ReadFile reads the fixtures of a file
+func ReadFile(path string) (map[string]*Case, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cases map[string]*Case
+	if err := json.Unmarshal(data, &cases); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", path, err)
+	}
+	return cases, nil
+}
+
+// ListFiles returns the json fixtures in a path, which is
+// either a single file or a directory
+func ListFiles(path string) ([]string, error) {
+	files := []string{}
+	err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".json") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// RunFile runs all the post states of the fixtures in a file. If fork
+// is not empty only the post states for that fork are run.
+func RunFile(path string, fork string, tracer evm.Tracer) ([]*Result, error) {
+	cases, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(cases))
+	for name := range cases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := []*Result{}
+	for _, name := range names {
+		c := cases[name]
+
+		forks := make([]string, 0, len(c.Post))
+		for f := range c.Post {
+			if fork == "" || f == fork {
+				forks = append(forks, f)
+			}
+		}
+		sort.Strings(forks)
+
+		for _, f := range forks {
+			for index, entry := range c.Post[f] {
+				res := c.Run(name, f, index, entry, tracer)
+				res.File = path
+				results = append(results, res)
+			}
+		}
+	}
+	return results, nil
+}
+
+// Run applies the transaction of the post entry and compares
+// the resulting state root and logs with the expected ones
+func (c *Case) Run(name, fork string, index int, entry *PostEntry, tracer evm.Tracer) *Result {
+	res := &Result{
+		Name:         name,
+		Fork:         fork,
+		Index:        index,
+		ExpectedRoot: entry.Root,
+		ExpectedLogs: entry.Logs,
+	}
+
+	txn, eip158, err := c.apply(name, fork, entry, tracer)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	_, root := txn.Commit(eip158)
+	res.Root = types.BytesToHash(root)
+	res.Logs = rlpHashLogs(txn.Logs())
+
+	res.Pass = res.Root == res.ExpectedRoot && res.Logs == res.ExpectedLogs
+	if !res.Pass {
+		res.PostState = dumpObjects(txn.Objects())
+	}
+	return res
+}
+
+func (c *Case) apply(name, fork string, entry *PostEntry, tracer evm.Tracer) (*state.Txn, bool, error) {
+	config, ok := Forks[fork]
+	if !ok {
+		return nil, false, fmt.Errorf("fork %s not supported", fork)
+	}
+	if c.Env == nil || c.Transaction == nil {
+		return nil, false, fmt.Errorf("env or transaction not found")
+	}
+
+	header, err := c.Env.ToHeader()
+	if err != nil {
+		return nil, false, err
+	}
+	msg, err := c.Transaction.At(entry.Indexes)
+	if err != nil {
+		return nil, false, err
+	}
+
+	s, root := buildState(c.Pre)
+
+	executor := state.NewExecutor(&chain.Params{Forks: config}, s)
+	executor.SetRuntime(precompiled.NewPrecompiled())
+
+	evmConfig := evm.DefaultConfig()
+	evmConfig.Tracer = tracer
+	executor.SetRuntime(evm.NewEVMWithConfig(evmConfig))
+
+	executor.PostHook = func(t *state.Transition) {
+		if name == "failed_tx_xcf416c53" {
+			// create the account
+			t.Txn().TouchAccount(ripemd)
+			// now remove it
+			t.Txn().Suicide(ripemd)
+		}
+	}
+	executor.GetHash = func(*types.Header) state.GetHashByNumber {
+		return blockHash
+	}
+
+	transition, err := executor.BeginTxn(root, header)
+	if err != nil {
+		return nil, false, err
+	}
+	// an invalid transaction is not an error of the test, it leaves
+	// the state untouched (except for the mining reward)
+	transition.Apply(msg)
+
+	txn := transition.Txn()
+
+	// mining rewards
+	txn.AddSealingReward(header.Miner, big.NewInt(0))
+
+	return txn, config.At(header.Number).EIP158, nil
+}
+
+func buildState(allocs chain.GenesisAlloc) (state.State, types.Hash) {
+	s := itrie.NewState(itrie.NewMemoryStorage())
+	txn := state.NewTxn(s, s.NewSnapshot())
+
+	for addr, alloc := range allocs {
+		txn.CreateAccount(addr)
+		txn.SetNonce(addr, alloc.Nonce)
+		if alloc.Balance != nil {
+			txn.SetBalance(addr, alloc.Balance)
+		}
+		if len(alloc.Code) != 0 {
+			txn.SetCode(addr, alloc.Code)
+		}
+		for k, v := range alloc.Storage {
+			txn.SetState(addr, k, v)
+		}
+	}
+
+	_, root := txn.Commit(false)
+	return s, types.BytesToHash(root)
+}
+
+func dumpObjects(objs []*state.Object) []*Account {
+	accounts := make([]*Account, 0, len(objs))
+	for _, obj := range objs {
+		account := &Account{
+			Address:  obj.Address,
+			Deleted:  obj.Deleted,
+			Nonce:    obj.Nonce,
+			CodeHash: obj.CodeHash,
+		}
+		if obj.Balance != nil {
+			account.Balance = obj.Balance.String()
+		}
+		if len(obj.Storage) != 0 {
+			account.Storage = map[string]string{}
+			for _, entry := range obj.Storage {
+				val := "0x"
+				if !entry.Deleted {
+					val = hex.EncodeToHex(entry.Val)
+				}
+				account.Storage[hex.EncodeToHex(entry.Key)] = val
+			}
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts
+}
+
+// blockHash is the hash of the block used by the fixtures
+func blockHash(n uint64) types.Hash {
+	return types.BytesToHash(crypto.Keccak256([]byte(big.NewInt(int64(n)).String())))
+}
+
+func rlpHashLogs(logs []*types.Log) (res types.Hash) {
+	r := &types.Receipt{
+		Logs: logs,
+	}
+
+	ar := &fastrlp.Arena{}
+	v := r.MarshalLogsWith(ar)
+
+	keccak.Keccak256Rlp(res[:0], v)
+	return
+}
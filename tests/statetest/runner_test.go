@@ -0,0 +1,86 @@
+package statetest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/minimal/types"
+)
+
+// transfer sends 10 wei from the account of the test key to 0x1000
+var transfer = `{
+	"transfer": {
+		"env": {
+			"currentCoinbase": "0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba",
+			"currentDifficulty": "0x020000",
+			"currentGasLimit": "0x7fffffffffffffff",
+			"currentNumber": "0x01",
+			"currentTimestamp": "0x03e8"
+		},
+		"pre": {
+			"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b": {
+				"balance": "0x0de0b6b3a7640000",
+				"code": "0x",
+				"nonce": "0x00",
+				"storage": {}
+			}
+		},
+		"post": {
+			"Byzantium": [
+				{
+					"hash": "0x0000000000000000000000000000000000000000000000000000000000000001",
+					"indexes": {"data": 0, "gas": 0, "value": 0},
+					"logs": "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347"
+				}
+			]
+		},
+		"transaction": {
+			"data": ["0x"],
+			"gasLimit": ["0x5208"],
+			"gasPrice": "0x01",
+			"nonce": "0x00",
+			"secretKey": "0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8",
+			"to": "0x0000000000000000000000000000000000001000",
+			"value": ["0x0a"]
+		}
+	}
+}`
+
+func TestRunCase(t *testing.T) {
+	var cases map[string]*Case
+	assert.NoError(t, json.Unmarshal([]byte(transfer), &cases))
+
+	c := cases["transfer"]
+	entry := c.Post["Byzantium"][0]
+
+	res := c.Run("transfer", "Byzantium", 0, entry, nil)
+	assert.Empty(t, res.Error)
+	assert.Equal(t, entry.Logs, res.Logs)
+
+	// the expected root is wrong, the result includes the post state
+	assert.False(t, res.Pass)
+
+	balances := map[types.Address]string{}
+	for _, account := range res.PostState {
+		balances[account.Address] = account.Balance
+	}
+	assert.Equal(t, "999999999999978990", balances[types.StringToAddress("0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b")])
+	assert.Equal(t, "10", balances[types.StringToAddress("0x1000")])
+	assert.Equal(t, "21000", balances[types.StringToAddress("0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba")])
+
+	// with the correct root the case passes
+	entry.Root = res.Root
+	res = c.Run("transfer", "Byzantium", 0, entry, nil)
+	assert.True(t, res.Pass)
+	assert.Empty(t, res.PostState)
+}
+
+func TestRunCaseUnknownFork(t *testing.T) {
+	var cases map[string]*Case
+	assert.NoError(t, json.Unmarshal([]byte(transfer), &cases))
+
+	res := cases["transfer"].Run("transfer", "Unknown", 0, &PostEntry{}, nil)
+	assert.False(t, res.Pass)
+	assert.Equal(t, "fork Unknown not supported", res.Error)
+}
@@ -11,13 +11,12 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/umbracle/minimal/crypto"
-	"github.com/umbracle/minimal/helper/hex"
 	itrie "github.com/umbracle/minimal/state/immutable-trie"
 	"github.com/umbracle/minimal/state/runtime"
 
 	"github.com/umbracle/minimal/chain"
 	"github.com/umbracle/minimal/state"
+	"github.com/umbracle/minimal/tests/statetest"
 
 	"github.com/umbracle/minimal/types"
 )
@@ -227,206 +226,8 @@ func buildState(t *testing.T, allocs chain.GenesisAlloc) (state.State, state.Sna
 	return s, snap, types.BytesToHash(root)
 }
 
-type indexes struct {
-	Data  int `json:"data"`
-	Gas   int `json:"gas"`
-	Value int `json:"value"`
-}
-
-type postEntry struct {
-	Root    types.Hash
-	Logs    types.Hash
-	Indexes indexes
-}
-
-type postState []postEntry
-
-func (p *postEntry) UnmarshalJSON(input []byte) error {
-	type stateUnmarshall struct {
-		Root    string  `json:"hash"`
-		Logs    string  `json:"logs"`
-		Indexes indexes `json:"indexes"`
-	}
-
-	var dec stateUnmarshall
-	if err := json.Unmarshal(input, &dec); err != nil {
-		return err
-	}
-
-	p.Root = types.StringToHash(dec.Root)
-	p.Logs = types.StringToHash(dec.Logs)
-	p.Indexes = dec.Indexes
-
-	return nil
-}
-
-type stTransaction struct {
-	Data     []string       `json:"data"`
-	GasLimit []uint64       `json:"gasLimit"`
-	Value    []*big.Int     `json:"value"`
-	GasPrice *big.Int       `json:"gasPrice"`
-	Nonce    uint64         `json:"nonce"`
-	From     types.Address  `json:"secretKey"`
-	To       *types.Address `json:"to"`
-}
-
-func (t *stTransaction) At(i indexes) (*types.Transaction, error) {
-	if i.Data > len(t.Data) {
-		return nil, fmt.Errorf("data index %d out of bounds (%d)", i.Data, len(t.Data))
-	}
-	if i.Gas > len(t.GasLimit) {
-		return nil, fmt.Errorf("gas index %d out of bounds (%d)", i.Gas, len(t.GasLimit))
-	}
-	if i.Value > len(t.Value) {
-		return nil, fmt.Errorf("value index %d out of bounds (%d)", i.Value, len(t.Value))
-	}
-
-	msg := &types.Transaction{
-		To:       t.To,
-		Nonce:    t.Nonce,
-		Value:    t.Value[i.Value].Bytes(),
-		Gas:      t.GasLimit[i.Gas],
-		GasPrice: t.GasPrice.Bytes(),
-		Input:    hex.MustDecodeHex(t.Data[i.Data]),
-	}
-
-	msg.From = t.From
-	return msg, nil
-}
-
-func (t *stTransaction) UnmarshalJSON(input []byte) error {
-	type txUnmarshall struct {
-		Data      []string `json:"data"`
-		GasLimit  []string `json:"gasLimit"`
-		Value     []string `json:"value"`
-		GasPrice  string   `json:"gasPrice"`
-		Nonce     string   `json:"nonce"`
-		SecretKey string   `json:"secretKey"`
-		To        string   `json:"to"`
-	}
-
-	var dec txUnmarshall
-	err := json.Unmarshal(input, &dec)
-	if err != nil {
-		return err
-	}
-
-	t.Data = dec.Data
-	for _, i := range dec.GasLimit {
-		if j, err := stringToUint64(i); err != nil {
-			return err
-		} else {
-			t.GasLimit = append(t.GasLimit, j)
-		}
-	}
-
-	for _, i := range dec.Value {
-		value := new(big.Int)
-		if i != "0x" {
-			v, err := types.ParseUint256orHex(&i)
-			if err != nil {
-				return err
-			}
-			/*
-				v, ok := math.ParseBig256(i)
-				if !ok {
-					return fmt.Errorf("invalid tx value %q", i)
-				}
-			*/
-			value = v
-		}
-		t.Value = append(t.Value, value)
-	}
-
-	t.GasPrice, err = stringToBigInt(dec.GasPrice)
-	t.Nonce, err = stringToUint64(dec.Nonce)
-	if err != nil {
-		return err
-	}
-
-	t.From = types.Address{}
-	if len(dec.SecretKey) > 0 {
-		secretKey, err := types.ParseBytes(&dec.SecretKey)
-		if err != nil {
-			return err
-		}
-		key, err := crypto.ParsePrivateKey(secretKey)
-		if err != nil {
-			return fmt.Errorf("invalid private key: %v", err)
-		}
-		t.From = crypto.PubKeyToAddress(&key.PublicKey)
-	}
-
-	if dec.To != "" {
-		address := types.StringToAddress(dec.To)
-		t.To = &address
-	}
-	return nil
-}
-
-// forks
-
-var Forks = map[string]*chain.Forks{
-	"Frontier": {},
-	"Homestead": {
-		Homestead: chain.NewFork(0),
-	},
-	"EIP150": {
-		Homestead: chain.NewFork(0),
-		EIP150:    chain.NewFork(0),
-	},
-	"EIP158": {
-		Homestead: chain.NewFork(0),
-		EIP150:    chain.NewFork(0),
-		EIP155:    chain.NewFork(0),
-		EIP158:    chain.NewFork(0),
-	},
-	"Byzantium": {
-		Homestead: chain.NewFork(0),
-		EIP150:    chain.NewFork(0),
-		EIP155:    chain.NewFork(0),
-		EIP158:    chain.NewFork(0),
-		Byzantium: chain.NewFork(0),
-	},
-	"Constantinople": {
-		Homestead:      chain.NewFork(0),
-		EIP150:         chain.NewFork(0),
-		EIP155:         chain.NewFork(0),
-		EIP158:         chain.NewFork(0),
-		Byzantium:      chain.NewFork(0),
-		Constantinople: chain.NewFork(0),
-	},
-	"FrontierToHomesteadAt5": {
-		Homestead: chain.NewFork(5),
-	},
-	"HomesteadToEIP150At5": {
-		Homestead: chain.NewFork(0),
-		EIP150:    chain.NewFork(5),
-	},
-	"HomesteadToDaoAt5": {
-		Homestead: chain.NewFork(0),
-	},
-	"EIP158ToByzantiumAt5": {
-		Homestead: chain.NewFork(0),
-		EIP150:    chain.NewFork(0),
-		EIP155:    chain.NewFork(0),
-		EIP158:    chain.NewFork(0),
-		Byzantium: chain.NewFork(5),
-	},
-	"ByzantiumToConstantinopleAt5": {
-		Byzantium:      chain.NewFork(0),
-		Constantinople: chain.NewFork(5),
-	},
-	"ConstantinopleFix": {
-		Homestead:      chain.NewFork(0),
-		EIP150:         chain.NewFork(0),
-		EIP155:         chain.NewFork(0),
-		EIP158:         chain.NewFork(0),
-		Byzantium:      chain.NewFork(0),
-		Constantinople: chain.NewFork(0),
-		Petersburg:     chain.NewFork(0),
-	},
-}
+// Forks are the fork configurations used in the fixtures
+var Forks = statetest.Forks
 
 type header struct {
 	header *types.Header